@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"hardwareAnalyzer/hardwarecontrollerscommon"
+	"hardwareAnalyzer/smart"
 	"hardwareAnalyzer/utils"
 	"math"
 	"regexp"
@@ -52,6 +53,348 @@ var CheckAadaptecRaid = func() (bool, error) {
 	return false, nil
 }
 
+// Function only used from processHWAdaptecRaid
+// Function as variable in order to be possible to mock it from unitary tests
+var GetAdaptecControllerInfo = func(manufacturer, controllerIdArcconf string, controller *utils.ControllerStruct) error {
+	//fmt.Println("-- getAdaptecControllerInfo --")
+	controller.FirmwareVersion = "Unknown"
+	controller.BiosVersion = "Unknown"
+	controller.DriverVersion = "Unknown"
+	controller.PciAddress = "Unknown"
+	controller.SerialNumber = "Unknown"
+	controller.RocTemperature = "Unknown"
+
+	// Firmware, BIOS and driver versions
+	command := "GETVERSION"
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getAdaptecControllerInfo", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+
+	insideControllerData := false
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		//fmt.Println("Line: ", line)
+		// Controller #1
+		if strings.HasPrefix(line, "Controller #") {
+			insideControllerData = strings.TrimPrefix(line, "Controller #") == controllerIdArcconf
+			continue
+		}
+		if !insideControllerData || !strings.Contains(line, ":") {
+			continue
+		}
+		versionData := strings.SplitN(line, ":", 2)
+		versionKey := strings.TrimSpace(versionData[0])
+		versionValue := strings.TrimSpace(versionData[1])
+		switch versionKey {
+		case "Firmware":
+			controller.FirmwareVersion = versionValue
+		case "BIOS":
+			controller.BiosVersion = versionValue
+		// Driver : Linux 1.2-1 (50983)
+		case "Driver":
+			controller.DriverVersion = strings.TrimPrefix(versionValue, "Linux ")
+		}
+	}
+
+	// Serial number, PCI address and temperature
+	command = "GETCONFIG " + controllerIdArcconf + " AD"
+	outputStdout, outputStderr, err = utils.GetCommandOutput(manufacturer, "getAdaptecControllerInfo", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+
+	scanner = bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		// Keys can contain colons: PCI Address (Bus:Device:Function) : 0:3:0:0
+		if !strings.Contains(line, " : ") {
+			continue
+		}
+		//fmt.Println("Line: ", line)
+		configData := strings.SplitN(line, " : ", 2)
+		configKey := strings.TrimSpace(configData[0])
+		configValue := strings.TrimSpace(configData[1])
+		switch {
+		case configKey == "Controller Serial Number":
+			controller.SerialNumber = configValue
+		case strings.HasPrefix(configKey, "PCI Address"):
+			controller.PciAddress = configValue
+		// Temperature : 45 C/ 113 F (Normal)
+		case configKey == "Temperature" && controller.RocTemperature == "Unknown":
+			controller.RocTemperature = strings.TrimSpace(strings.Split(configValue, "/")[0])
+		}
+	}
+
+	// Series 6/7/8 controllers use aacraid driver, SmartRAID/SmartHBA ones smartpqi
+	// Both drivers can be loaded at the same time, driver bound to controller PCI device is used when available
+	driverName, driverVersion := utils.GetKernelModuleVersion("smartpqi", "aacraid")
+	pciDriver := utils.GetPciDriver(controller.PciAddress)
+	if pciDriver != "Unknown" {
+		driverName = pciDriver
+		_, driverVersion = utils.GetKernelModuleVersion(pciDriver)
+	}
+	controller.DriverName = driverName
+	if controller.DriverVersion == "Unknown" {
+		controller.DriverVersion = driverVersion
+	}
+	return nil
+}
+
+// Function only used from processHWAdaptecRaid
+// Function as variable in order to be possible to mock it from unitary tests
+var GetAdaptecEnclosures = func(manufacturer, controllerId, controllerIdArcconf string) ([]utils.EnclosureStruct, error) {
+	//fmt.Println("-- getAdaptecEnclosures --")
+	enclosures := []utils.EnclosureStruct{}
+	command := "GETCONFIG " + controllerIdArcconf + " EN"
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getAdaptecEnclosures", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return enclosures, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return enclosures, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+
+	// Fan 0 status, Power supply 0 status, Temperature Sensor 0 status, Slot 0
+	fanRegexp := regexp.MustCompile(`(?i)^Fan\s+\d+`)
+	powerSupplyRegexp := regexp.MustCompile(`(?i)^Power\s+supply\s+\d+`)
+	temperatureSensorRegexp := regexp.MustCompile(`(?i)^Temperature(\s+Sensor\s+\d+.*)?$`)
+	slotRegexp := regexp.MustCompile(`(?i)^Slot\s+\d+$`)
+	// 25 C/ 77 F (Normal)
+	temperatureStatusRegexp := regexp.MustCompile(`\(([^)]+)\)`)
+
+	var enclosure *utils.EnclosureStruct
+	slots := 0
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		if !strings.Contains(line, " : ") {
+			continue
+		}
+		//fmt.Println("Line: ", line)
+		enclosureData := strings.SplitN(line, " : ", 2)
+		enclosureKey := strings.TrimSpace(enclosureData[0])
+		enclosureValue := strings.TrimSpace(enclosureData[1])
+		if enclosureKey == "Enclosure ID" {
+			if enclosure != nil {
+				enclosure.Slots = strconv.Itoa(slots)
+			}
+			enclosures = append(enclosures, utils.EnclosureStruct{
+				ControllerId: manufacturer + "-" + controllerId,
+				Id:           enclosureValue,
+				State:        "Unknown",
+			})
+			enclosure = &enclosures[len(enclosures)-1]
+			slots = 0
+			continue
+		}
+		if enclosure == nil {
+			continue
+		}
+		switch {
+		case enclosureKey == "Vendor" || enclosureKey == "Vendor ID":
+			enclosure.Vendor = enclosureValue
+		case enclosureKey == "Model" || enclosureKey == "Product ID":
+			enclosure.Model = enclosureValue
+		case enclosureKey == "Status":
+			enclosure.State = enclosureValue
+		case fanRegexp.MatchString(enclosureKey):
+			enclosure.Fans = append(enclosure.Fans, enclosureValue)
+		case powerSupplyRegexp.MatchString(enclosureKey):
+			enclosure.PowerSupplies = append(enclosure.PowerSupplies, enclosureValue)
+		case temperatureSensorRegexp.MatchString(enclosureKey):
+			temperatureStatus := temperatureStatusRegexp.FindStringSubmatch(enclosureValue)
+			if len(temperatureStatus) == 2 {
+				enclosureValue = temperatureStatus[1]
+			}
+			enclosure.TemperatureSensors = append(enclosure.TemperatureSensors, enclosureValue)
+		case slotRegexp.MatchString(enclosureKey):
+			slots++
+		}
+	}
+	if enclosure != nil {
+		enclosure.Slots = strconv.Itoa(slots)
+	}
+	return enclosures, nil
+}
+
+// Get Channel,Device of each physical device indexed by EID:Slot: 0:1 -> 0,1
+// arcconf identifies disks by Channel,Device in some commands
+// Function as variable in order to be possible to mock it from unitary tests
+var GetAdaptecDeviceChannels = func(manufacturer, controllerIdArcconf string) (map[string]string, error) {
+	//fmt.Println("-- getAdaptecDeviceChannels --")
+	deviceChannels := make(map[string]string)
+	command := "GETCONFIG " + controllerIdArcconf + " PD"
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getAdaptecDeviceChannels", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return deviceChannels, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return deviceChannels, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+
+	channelDevice := ""
+	// Reported Channel,Device(T:L) : 0,1(1:0)
+	channelDeviceRegexp := regexp.MustCompile(`^(\d+),(\d+)`)
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		if !strings.Contains(line, " : ") {
+			continue
+		}
+		//fmt.Println("Line: ", line)
+		physicalDeviceData := strings.SplitN(line, " : ", 2)
+		physicalDeviceKey := strings.TrimSpace(physicalDeviceData[0])
+		physicalDeviceValue := strings.TrimSpace(physicalDeviceData[1])
+		if strings.HasPrefix(physicalDeviceKey, "Reported Channel,Device") {
+			channelDeviceData := channelDeviceRegexp.FindStringSubmatch(physicalDeviceValue)
+			if len(channelDeviceData) == 3 {
+				channelDevice = channelDeviceData[1] + "," + channelDeviceData[2]
+			}
+			continue
+		}
+		// Reported Location : Enclosure 0, Slot 1( Connector Unknown )
+		if physicalDeviceKey == "Reported Location" && len(channelDevice) > 0 {
+			physicalDeviceEsd := strings.ReplaceAll(physicalDeviceValue, "( Connector Unknown )", "")
+			physicalDeviceEsd = strings.ReplaceAll(physicalDeviceEsd, "Enclosure", "")
+			physicalDeviceEsd = strings.ReplaceAll(physicalDeviceEsd, "Slot", "")
+			physicalDeviceEsd = strings.ReplaceAll(physicalDeviceEsd, ",", ":")
+			physicalDeviceEsd = utils.ClearString(physicalDeviceEsd)
+			deviceChannels[physicalDeviceEsd] = channelDevice
+			channelDevice = ""
+		}
+	}
+	return deviceChannels, nil
+}
+
+// Turn on/off disk identify LED
+// arcconf IDENTIFY 1 DEVICE 0 1 TIME 3600, arcconf IDENTIFY 1 DEVICE 0 1 STOP
+// Function as variable in order to be possible to mock it from unitary tests
+var LocateAdaptecDisk = func(manufacturer, controllerIdArcconf, eidSlot string, on bool) error {
+	//fmt.Println("-- locateAdaptecDisk --")
+	deviceChannels, err := GetAdaptecDeviceChannels(manufacturer, controllerIdArcconf)
+	if err != nil {
+		return err
+	}
+	channelDevice, ok := deviceChannels[eidSlot]
+	if !ok {
+		color.Red("++ ERROR: Disk %s not found in controller %s.", eidSlot, controllerIdArcconf)
+		return fmt.Errorf("Error: Disk %s not found in controller %s.", eidSlot, controllerIdArcconf)
+	}
+	// Only selected disk LED is stopped, ALL STOP would turn off every identify LED of the controller
+	command := "IDENTIFY " + controllerIdArcconf + " DEVICE " + strings.ReplaceAll(channelDevice, ",", " ") + " STOP"
+	if on {
+		// Without TIME arcconf waits for a key press to stop blinking
+		command = "IDENTIFY " + controllerIdArcconf + " DEVICE " + strings.ReplaceAll(channelDevice, ",", " ") + " TIME 3600"
+		color.Yellow("> Adaptec identify LED turns off automatically after one hour.")
+	}
+
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "locateAdaptecDisk", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	if !strings.Contains(outputStdout.String(), "Command completed successfully") {
+		color.Red("++ ERROR: Command %s failed.", command)
+		return fmt.Errorf("Error: Command %s failed.", command)
+	}
+	return nil
+}
+
+// Get drives SMART data through controller indexed by EID:Slot: arcconf GETSMARTSTATS 1
+// Only SATA drives report ATA SMART attributes
+// Function as variable in order to be possible to mock it from unitary tests
+var GetAdaptecSmart = func(manufacturer, controllerIdArcconf string) (map[string]utils.SmartStruct, error) {
+	//fmt.Println("-- getAdaptecSmart --")
+	drivesSmart := make(map[string]utils.SmartStruct)
+	deviceChannels, err := GetAdaptecDeviceChannels(manufacturer, controllerIdArcconf)
+	if err != nil {
+		return drivesSmart, err
+	}
+	eidSlots := make(map[string]string)
+	for eidSlot, channelDevice := range deviceChannels {
+		eidSlots[channelDevice] = eidSlot
+	}
+
+	command := "GETSMARTSTATS " + controllerIdArcconf
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getAdaptecSmart", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return drivesSmart, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return drivesSmart, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+
+	// <Attribute id="0x05" name="Reallocated Sectors Count" normalizedCurrent="100" normalizedWorst="100" thresholdValue="36" rawValue="0" Status="OK" />
+	xmlAttributeRegexp := regexp.MustCompile(`(\w+)="([^"]*)"`)
+	eidSlot := ""
+	attributes := []smart.AtaAttribute{}
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		//fmt.Println("Line: ", line)
+		xmlAttributes := make(map[string]string)
+		for _, xmlAttribute := range xmlAttributeRegexp.FindAllStringSubmatch(line, -1) {
+			xmlAttributes[xmlAttribute[1]] = xmlAttribute[2]
+		}
+		switch {
+		case strings.HasPrefix(line, "<PhysicalDriveSmartStats "):
+			eidSlot = eidSlots[xmlAttributes["channel"]+","+xmlAttributes["id"]]
+			attributes = []smart.AtaAttribute{}
+		case strings.HasPrefix(line, "<Attribute ") && len(eidSlot) > 0:
+			id, err := strconv.ParseInt(xmlAttributes["id"], 0, 64)
+			if err != nil {
+				continue
+			}
+			value, _ := strconv.Atoi(xmlAttributes["normalizedCurrent"])
+			threshold, _ := strconv.Atoi(xmlAttributes["thresholdValue"])
+			raw, _ := strconv.ParseUint(xmlAttributes["rawValue"], 0, 64)
+			attributes = append(attributes, smart.AtaAttribute{
+				Id:        int(id),
+				Prefail:   threshold > 0,
+				Value:     value,
+				Threshold: threshold,
+				Raw:       raw,
+			})
+		case strings.HasPrefix(line, "</PhysicalDriveSmartStats>") && len(eidSlot) > 0:
+			if len(attributes) > 0 {
+				drivesSmart[eidSlot] = smart.SmartFromAtaAttributes("adaptec", attributes)
+			}
+			eidSlot = ""
+		}
+	}
+	return drivesSmart, nil
+}
+
 var ProcessHWAdaptecRaid = func(manufacturer string) ([]utils.ControllerStruct, []utils.RaidStruct, []utils.NoRaidDiskStruct, error) {
 	var controllers = []utils.ControllerStruct{}
 	var raids = []utils.RaidStruct{}
@@ -187,6 +530,10 @@ var ProcessHWAdaptecRaid = func(manufacturer string) ([]utils.ControllerStruct,
 					Model:        controllerModel,
 					Status:       controllerStatus,
 				}
+				err = GetAdaptecControllerInfo(manufacturer, controllerIdArcconfString, &controller)
+				if err != nil {
+					color.Red("++ ERROR Getting controller info: %s", err)
+				}
 				controllers = append(controllers, controller)
 				// Reset data except controllerId that is used after
 				controllerModel = ""
@@ -555,6 +902,19 @@ var ProcessHWAdaptecRaid = func(manufacturer string) ([]utils.ControllerStruct,
 				}
 			}
 		}
+
+		// Enclosures inventory and disks bay mapping
+		for j := range controllers {
+			if controllers[j].Id != manufacturer+"-"+controllerId {
+				continue
+			}
+			enclosures, err := GetAdaptecEnclosures(manufacturer, controllerId, controllerIdArcconfString)
+			if err != nil {
+				color.Red("++ ERROR Getting enclosures: %s", err)
+			}
+			controllers[j].Enclosures = enclosures
+			utils.SetControllerBays(&controllers[j], raids, noRaidDisks)
+		}
 	}
 	return controllers, raids, noRaidDisks, nil
 }
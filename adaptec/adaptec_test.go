@@ -2,9 +2,11 @@ package adaptec
 
 import (
 	"bytes"
+	"fmt"
 	"hardwareAnalyzer/hardwarecontrollerscommon"
 	"hardwareAnalyzer/utils"
 	"math"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -340,3 +342,281 @@ func TestProcessHWAdaptecRaid(t *testing.T) {
 		}
 	}
 }
+
+// Test GetAdaptecControllerInfo
+func TestGetAdaptecControllerInfo(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsLinkOri := utils.ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked functions, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command == "GETVERSION" {
+			outputStdout.WriteString(`
+				Controllers found: 2
+				Controller #1
+				==============
+				Firmware                               : 7.18-0 (33556)
+				Staged Firmware                        : 7.18-0 (33556)
+				BIOS                                   : 7.18-0 (33556)
+				Driver                                 : Linux 1.2-1 (50983)
+				Boot Flash                             : 7.18-0 (33556)
+
+				Controller #2
+				==============
+				Firmware                               : 7.5-0 (32033)
+				Staged Firmware                        : 7.5-0 (32033)
+				BIOS                                   : 7.5-0 (32033)
+				Driver                                 : Linux 1.2-1 (50983)
+				Boot Flash                             : 7.5-0 (32033)
+			`)
+		} else if command == "GETCONFIG 1 AD" {
+			outputStdout.WriteString(`
+				Controller Status                          : Optimal
+				Controller Model                           : Adaptec ASR8405
+				Controller Serial Number                   : 7A4612345AB
+				Physical Slot                              : 2
+				Temperature                                : 51 C/ 123 F (Normal)
+				PCI Address (Bus:Device:Function)          : 0:3:0:0
+			`)
+		} else {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		return &outputStdout, &outputStderr, nil
+	}
+
+	// Mocked functions, this way we can run unit tests in servers without hardware raid controller installed.
+	// smartpqi is loaded too for an HPE controller, Adaptec controller PCI device is bound to aacraid
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		switch path {
+		case "/sys/module/aacraid/version":
+			return "1.2.1[50983]-custom", nil
+		case "/sys/module/smartpqi/version":
+			return "2.1.22-040", nil
+		}
+		return "", fmt.Errorf("No such file or directory")
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		if path == "/sys/bus/pci/devices/0000:03:00.0/driver" {
+			return "../../../../bus/pci/drivers/aacraid", nil
+		}
+		return "", fmt.Errorf("No such file or directory")
+	}
+
+	controller := utils.ControllerStruct{}
+	err := GetAdaptecControllerInfo("adaptec", "1", &controller)
+	if err != nil {
+		t.Fatalf(`TestGetAdaptecControllerInfo: error: %s`, err)
+	}
+
+	controllerInfoWanted := map[string][2]string{
+		"FirmwareVersion": {controller.FirmwareVersion, "7.18-0 (33556)"},
+		"BiosVersion":     {controller.BiosVersion, "7.18-0 (33556)"},
+		"DriverName":      {controller.DriverName, "aacraid"},
+		"DriverVersion":   {controller.DriverVersion, "1.2-1 (50983)"},
+		"PciAddress":      {controller.PciAddress, "0:3:0:0"},
+		"SerialNumber":    {controller.SerialNumber, "7A4612345AB"},
+		"RocTemperature":  {controller.RocTemperature, "51 C"},
+	}
+	for field, values := range controllerInfoWanted {
+		if values[0] != values[1] {
+			t.Fatalf(`TestGetAdaptecControllerInfo: controller.%s: %v muts match %v`, field, values[0], values[1])
+		}
+	}
+}
+
+// Test GetAdaptecEnclosures
+func TestGetAdaptecEnclosures(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "GETCONFIG 1 EN" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		// arcconf GETCONFIG 1 EN
+		outputStdout.WriteString(`
+			Controllers found: 1
+			----------------------------------------------------------------------
+			Enclosure information
+			----------------------------------------------------------------------
+			      Device #2
+			         Device is an Enclosure Services Device
+			         Reported Channel,Device(T:L)       : 2,2(2:0)
+			         Enclosure ID                       : 0
+			         Type                               : SES2
+			         Vendor                             : ADAPTEC
+			         Model                              : Virtual SGPIO
+			         Firmware                           : 0001
+			         Status                             : Optimal
+			         Fan 0 status                       : Optimal
+			         Power supply 0 status              : Failed
+			         Temperature                        : 25 C/ 77 F (Normal)
+			         Slot 0                             : Insufficient Power
+			         Slot 1                             : Insufficient Power
+			         Slot 2                             : Insufficient Power
+			         Slot 3                             : Insufficient Power
+
+			Command completed successfully.
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	enclosures, err := GetAdaptecEnclosures("adaptec", "0", "1")
+	if err != nil {
+		t.Fatalf(`TestGetAdaptecEnclosures returned error: %s`, err)
+	}
+	enclosuresWanted := []utils.EnclosureStruct{
+		{
+			ControllerId:       "adaptec-0",
+			Id:                 "0",
+			Vendor:             "ADAPTEC",
+			Model:              "Virtual SGPIO",
+			State:              "Optimal",
+			Slots:              "4",
+			Fans:               []string{"Optimal"},
+			PowerSupplies:      []string{"Failed"},
+			TemperatureSensors: []string{"Normal"},
+		},
+	}
+	if !reflect.DeepEqual(enclosures, enclosuresWanted) {
+		t.Fatalf(`TestGetAdaptecEnclosures enclosures: %v should be: %v`, enclosures, enclosuresWanted)
+	}
+}
+
+// Test LocateAdaptecDisk
+func TestLocateAdaptecDisk(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	executedCommands := []string{}
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		executedCommands = append(executedCommands, command)
+		if command == "GETCONFIG 1 PD" {
+			// arcconf GETCONFIG 1 PD
+			outputStdout.WriteString(`
+			Controllers found: 1
+			----------------------------------------------------------------------
+			Physical Device information
+			----------------------------------------------------------------------
+			      Device #0
+			         Device is a Hard drive
+			         State                              : Online
+			         Reported Channel,Device(T:L)       : 0,0(0:0)
+			         Reported Location                  : Enclosure 0, Slot 0( Connector Unknown )
+			      Device #1
+			         Device is a Hard drive
+			         State                              : Online
+			         Reported Channel,Device(T:L)       : 0,1(1:0)
+			         Reported Location                  : Enclosure 0, Slot 1( Connector Unknown )
+
+			Command completed successfully.
+			`)
+			return &outputStdout, &outputStderr, nil
+		}
+		outputStdout.WriteString(`
+			Controllers found: 1
+
+			Command completed successfully.
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	err := LocateAdaptecDisk("adaptec", "1", "0:1", true)
+	if err != nil {
+		t.Fatalf(`TestLocateAdaptecDisk returned error: %s`, err)
+	}
+	err = LocateAdaptecDisk("adaptec", "1", "0:1", false)
+	if err != nil {
+		t.Fatalf(`TestLocateAdaptecDisk returned error: %s`, err)
+	}
+	executedCommandsWanted := []string{"GETCONFIG 1 PD", "IDENTIFY 1 DEVICE 0 1 TIME 3600", "GETCONFIG 1 PD", "IDENTIFY 1 DEVICE 0 1 STOP"}
+	if !reflect.DeepEqual(executedCommands, executedCommandsWanted) {
+		t.Fatalf(`TestLocateAdaptecDisk executedCommands: %v should be: %v`, executedCommands, executedCommandsWanted)
+	}
+
+	err = LocateAdaptecDisk("adaptec", "1", "0:7", true)
+	if err == nil {
+		t.Fatalf(`TestLocateAdaptecDisk should return error on unknown disk`)
+	}
+}
+
+// Test GetAdaptecSmart
+func TestGetAdaptecSmart(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	getAdaptecDeviceChannelsOri := GetAdaptecDeviceChannels
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		GetAdaptecDeviceChannels = getAdaptecDeviceChannelsOri
+	}()
+
+	// Mocked functions, this way we can run unit tests in servers without hardware raid controller installed.
+	GetAdaptecDeviceChannels = func(manufacturer, controllerIdArcconf string) (map[string]string, error) {
+		return map[string]string{"0:0": "0,0", "0:1": "0,1"}, nil
+	}
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "GETSMARTSTATS 1" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		// arcconf GETSMARTSTATS 1
+		outputStdout.WriteString(`
+			Controllers found: 1
+			<SmartStats controllerID="0" time="1660000000" deviceName="ASR8405" serialNumber="7A4113C3D5C" >
+			<PhysicalDriveSmartStats channel="0" id="1" nonSpinning="false" isDescriptionAvailable="true" >
+			<Attribute id="0x05" name="Reallocated Sectors Count" normalizedCurrent="100" normalizedWorst="100" thresholdValue="5" rawValue="0" Status="OK" />
+			<Attribute id="0x09" name="Power-On Hours" normalizedCurrent="92" normalizedWorst="92" thresholdValue="0" rawValue="35124" Status="OK" />
+			<Attribute id="0xC5" name="Current Pending Sector Count" normalizedCurrent="100" normalizedWorst="100" thresholdValue="0" rawValue="1" Status="OK" />
+			<Attribute id="0xC2" name="Temperature" normalizedCurrent="33" normalizedWorst="45" thresholdValue="0" rawValue="33" Status="OK" />
+			</PhysicalDriveSmartStats>
+			</SmartStats>
+
+			Command completed successfully.
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	drivesSmart, err := GetAdaptecSmart("adaptec", "1")
+	if err != nil {
+		t.Fatalf(`TestGetAdaptecSmart returned error: %s`, err)
+	}
+	drivesSmartWanted := map[string]utils.SmartStruct{
+		"0:1": {
+			Source:             "adaptec",
+			Verdict:            "PASSED",
+			ReallocatedSectors: 0,
+			PendingSectors:     1,
+			MediaErrors:        -1,
+			WearLevel:          -1,
+			PowerOnHours:       35124,
+			Temperature:        33,
+			AvailableSpare:     -1,
+			Status:             "Unknown",
+		},
+	}
+	if !reflect.DeepEqual(drivesSmart, drivesSmartWanted) {
+		t.Fatalf(`TestGetAdaptecSmart drivesSmart: %v should be: %v`, drivesSmart, drivesSmartWanted)
+	}
+}
@@ -2,14 +2,17 @@ package btrfs
 
 import (
 	"bufio"
+	"encoding/binary"
 	"fmt"
 	"hardwareAnalyzer/utils"
-	"math"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/Masterminds/semver"
 	human "github.com/dustin/go-humanize"
@@ -96,178 +99,596 @@ var CheckBtrfsRaid = func() (bool, error) {
 	return false, nil
 }
 
-var GetBtrfsRaidType = func(device string) (string, error) {
-	//fmt.Println("-- getBtrfsRaidType --")
-	device = "/dev/" + device
-	//fmt.Println("Device: ", device)
+// Block group profiles, data and metadata can use different profiles: data raid0, metadata raid1
+// Several profiles are listed while a balance conversion is running: raid1+raid5
+type BtrfsProfilesStruct struct {
+	Data     string
+	Metadata string
+	System   string
+}
+
+// Profile names as shown in /sys/fs/btrfs/<uuid>/allocation/<type>/
+var btrfsProfileNames = []string{"single", "dup", "raid0", "raid1", "raid10", "raid5", "raid6", "raid1c3", "raid1c4"}
+
+// On disk format constants
+const (
+	btrfsSuperblockOffset = 0x10000
+	btrfsSuperblockSize   = 4096
+	btrfsMagic            = "_BHRfS_M"
+	btrfsHeaderSize       = 101
+	btrfsItemSize         = 25
+	btrfsKeyPtrSize       = 33
+	btrfsChunkSize        = 48
+	btrfsStripeSize       = 32
+	btrfsKeySize          = 17
+	btrfsChunkItemKey     = 228
+	btrfsMaxTreeLevel     = 8
+)
 
-	// We DONT USE utils.GetCommandOutput because we are parsing command output in real time
-	raidBinary, exe, err := utils.GetBinaryExecutor("btrfs", "getBtrfsRaidType")
+// Chunk type flags
+const (
+	btrfsBlockGroupData     = 1 << 0
+	btrfsBlockGroupSystem   = 1 << 1
+	btrfsBlockGroupMetadata = 1 << 2
+	btrfsBlockGroupRaid0    = 1 << 3
+	btrfsBlockGroupRaid1    = 1 << 4
+	btrfsBlockGroupDup      = 1 << 5
+	btrfsBlockGroupRaid10   = 1 << 6
+	btrfsBlockGroupRaid5    = 1 << 7
+	btrfsBlockGroupRaid6    = 1 << 8
+	btrfsBlockGroupRaid1c3  = 1 << 9
+	btrfsBlockGroupRaid1c4  = 1 << 10
+)
+
+// Chunk mapping logical addresses to device stripes
+type btrfsChunk struct {
+	Logical   uint64
+	Length    uint64
+	StripeLen uint64
+	Type      uint64
+	Stripes   []btrfsStripe
+}
+
+type btrfsStripe struct {
+	DevId  uint64
+	Offset uint64
+}
+
+// Function as variable in order to be able to mock it from unit tests
+// Read size bytes from block device at offset
+var ReadDeviceBytes = func(device string, offset int64, size int) ([]byte, error) {
+	file, err := os.Open("/dev/" + device)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data := make([]byte, size)
+	_, err = file.ReadAt(data, offset)
 	if err != nil {
-		color.Red("++ ERROR utils.GetBinaryExecutor: %s", err)
-		return "ERROR utils.GetBinaryExecutor", err
+		return nil, err
 	}
-	if exe != nil {
-		// When exitting from hardwarecontrollerscommon.GetRaidOSDevice function, last instruction executed will be exe.Close()
-		defer exe.Close()
+	return data, nil
+}
+
+func getChunkProfile(flags uint64) string {
+	switch {
+	case flags&btrfsBlockGroupRaid0 != 0:
+		return "raid0"
+	case flags&btrfsBlockGroupRaid1 != 0:
+		return "raid1"
+	case flags&btrfsBlockGroupDup != 0:
+		return "dup"
+	case flags&btrfsBlockGroupRaid10 != 0:
+		return "raid10"
+	case flags&btrfsBlockGroupRaid5 != 0:
+		return "raid5"
+	case flags&btrfsBlockGroupRaid6 != 0:
+		return "raid6"
+	case flags&btrfsBlockGroupRaid1c3 != 0:
+		return "raid1c3"
+	case flags&btrfsBlockGroupRaid1c4 != 0:
+		return "raid1c4"
 	}
+	return "single"
+}
 
-	// Execute btrfs
-	// Kernel < 3.17 detected, we cant execute embeded binaries from RAM, we wirtted it to /tmp/hardwareAnalyzerBin instead.
-	var cmd *exec.Cmd
-	if raidBinary == "/tmp/hardwareAnalyzerBin" {
-		defer utils.RemoveFile("getBtrfsRaidType")
-		cmd = exec.Command(raidBinary, "inspect-internal", "dump-tree", device)
-	} else {
-		cmd = exe.Command("inspect-internal", "dump-tree", device)
+// Add profile once: raid1 + raid5 -> raid1+raid5
+func addProfile(profiles string, profile string) string {
+	if len(profiles) == 0 {
+		return profile
 	}
+	if slices.Contains(strings.Split(profiles, "+"), profile) {
+		return profiles
+	}
+	return profiles + "+" + profile
+}
+
+// Chunk item: length, owner, stripe_len, type, io_align, io_width, sector_size, num_stripes, sub_stripes, stripes
+func parseChunkItem(logical uint64, data []byte) (btrfsChunk, int, error) {
+	if len(data) < btrfsChunkSize {
+		return btrfsChunk{}, 0, fmt.Errorf("Error: chunk item too short.")
+	}
+	chunk := btrfsChunk{
+		Logical:   logical,
+		Length:    binary.LittleEndian.Uint64(data[0:8]),
+		StripeLen: binary.LittleEndian.Uint64(data[16:24]),
+		Type:      binary.LittleEndian.Uint64(data[24:32]),
+	}
+	numStripes := int(binary.LittleEndian.Uint16(data[44:46]))
+	chunkSize := btrfsChunkSize + numStripes*btrfsStripeSize
+	if len(data) < chunkSize {
+		return btrfsChunk{}, 0, fmt.Errorf("Error: chunk item stripes too short.")
+	}
+	for i := 0; i < numStripes; i++ {
+		stripeOffset := btrfsChunkSize + i*btrfsStripeSize
+		chunk.Stripes = append(chunk.Stripes, btrfsStripe{
+			DevId:  binary.LittleEndian.Uint64(data[stripeOffset : stripeOffset+8]),
+			Offset: binary.LittleEndian.Uint64(data[stripeOffset+8 : stripeOffset+16]),
+		})
+	}
+	return chunk, chunkSize, nil
+}
 
-	// In this case we use StdoutPipe not having to wait for command execution ;)
-	// Stdout
-	stdout, err := cmd.StdoutPipe()
+// Superblock sys_chunk_array: key + chunk item pairs describing system chunks, where chunk tree lives
+func parseSysChunkArray(superblock []byte) ([]btrfsChunk, error) {
+	chunks := []btrfsChunk{}
+	arraySize := int(binary.LittleEndian.Uint32(superblock[0xa0:0xa4]))
+	if 0x32b+arraySize > len(superblock) {
+		return chunks, fmt.Errorf("Error: incorrect sys_chunk_array size: %d.", arraySize)
+	}
+	sysChunkArray := superblock[0x32b : 0x32b+arraySize]
+	for offset := 0; offset+btrfsKeySize <= len(sysChunkArray); {
+		logical := binary.LittleEndian.Uint64(sysChunkArray[offset+9 : offset+17])
+		offset = offset + btrfsKeySize
+		chunk, chunkSize, err := parseChunkItem(logical, sysChunkArray[offset:])
+		if err != nil {
+			return chunks, err
+		}
+		chunks = append(chunks, chunk)
+		offset = offset + chunkSize
+	}
+	return chunks, nil
+}
+
+// Chunk tree blocks are stored in system chunks, striped profiles are not supported
+func mapLogicalAddress(chunks []btrfsChunk, devId uint64, logical uint64) (int64, error) {
+	for _, chunk := range chunks {
+		if logical < chunk.Logical || logical >= chunk.Logical+chunk.Length {
+			continue
+		}
+		if chunk.Type&(btrfsBlockGroupRaid0|btrfsBlockGroupRaid10|btrfsBlockGroupRaid5|btrfsBlockGroupRaid6) != 0 {
+			return 0, fmt.Errorf("Error: striped system chunk not supported.")
+		}
+		for _, stripe := range chunk.Stripes {
+			if stripe.DevId == devId {
+				return int64(stripe.Offset + logical - chunk.Logical), nil
+			}
+		}
+		return 0, fmt.Errorf("Error: logical address %d not stored in device %d.", logical, devId)
+	}
+	return 0, fmt.Errorf("Error: logical address %d not mapped.", logical)
+}
+
+// Walk chunk tree nodes collecting chunk items profiles
+func walkChunkTree(device string, devId uint64, sysChunks []btrfsChunk, logical uint64, nodeSize int, level int, profiles *BtrfsProfilesStruct) error {
+	physical, err := mapLogicalAddress(sysChunks, devId, logical)
+	if err != nil {
+		return err
+	}
+	node, err := ReadDeviceBytes(device, physical, nodeSize)
 	if err != nil {
-		color.Red("++ ERROR getBtrfsRaidType cmd.StdoutPipe: %s", err)
+		return err
+	}
+	if len(node) < btrfsHeaderSize || binary.LittleEndian.Uint64(node[0x30:0x38]) != logical {
+		return fmt.Errorf("Error: incorrect tree block at %d.", logical)
+	}
+	nodeLevel := int(node[0x64])
+	nrItems := int(binary.LittleEndian.Uint32(node[0x60:0x64]))
+	if nodeLevel != level {
+		return fmt.Errorf("Error: tree block level %d should be: %d.", nodeLevel, level)
 	}
-	defer stdout.Close()
-	stdoutReader := bufio.NewReader(stdout)
-	//fmt.Println("StdoutPipe linked with stdoutReader")
 
-	//Stderr
-	stderr, err := cmd.StderrPipe()
+	for i := 0; i < nrItems; i++ {
+		if nodeLevel > 0 {
+			keyPtrOffset := btrfsHeaderSize + i*btrfsKeyPtrSize
+			if keyPtrOffset+btrfsKeyPtrSize > len(node) {
+				return fmt.Errorf("Error: tree node too short.")
+			}
+			blockPtr := binary.LittleEndian.Uint64(node[keyPtrOffset+btrfsKeySize : keyPtrOffset+btrfsKeySize+8])
+			err = walkChunkTree(device, devId, sysChunks, blockPtr, nodeSize, nodeLevel-1, profiles)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		itemOffset := btrfsHeaderSize + i*btrfsItemSize
+		if itemOffset+btrfsItemSize > len(node) {
+			return fmt.Errorf("Error: tree leaf too short.")
+		}
+		if node[itemOffset+8] != btrfsChunkItemKey {
+			continue
+		}
+		dataOffset := btrfsHeaderSize + int(binary.LittleEndian.Uint32(node[itemOffset+17:itemOffset+21]))
+		dataSize := int(binary.LittleEndian.Uint32(node[itemOffset+21 : itemOffset+25]))
+		if dataOffset+dataSize > len(node) {
+			return fmt.Errorf("Error: chunk item out of tree leaf.")
+		}
+		chunk, _, err := parseChunkItem(binary.LittleEndian.Uint64(node[itemOffset+9:itemOffset+17]), node[dataOffset:dataOffset+dataSize])
+		if err != nil {
+			return err
+		}
+		profile := getChunkProfile(chunk.Type)
+		if chunk.Type&btrfsBlockGroupData != 0 {
+			profiles.Data = addProfile(profiles.Data, profile)
+		}
+		if chunk.Type&btrfsBlockGroupMetadata != 0 {
+			profiles.Metadata = addProfile(profiles.Metadata, profile)
+		}
+		if chunk.Type&btrfsBlockGroupSystem != 0 {
+			profiles.System = addProfile(profiles.System, profile)
+		}
+	}
+	return nil
+}
+
+// Read profiles from device superblock and chunk tree, used for unmounted filesystems
+func getChunkTreeProfiles(device string) (BtrfsProfilesStruct, error) {
+	profiles := BtrfsProfilesStruct{}
+	superblock, err := ReadDeviceBytes(device, btrfsSuperblockOffset, btrfsSuperblockSize)
 	if err != nil {
-		color.Red("++ ERROR getBtrfsRaidType cmd.StderrPipe: %s", err)
+		return profiles, err
+	}
+	if string(superblock[0x40:0x48]) != btrfsMagic {
+		return profiles, fmt.Errorf("Error: %s without Btrfs superblock.", device)
+	}
+	chunkRoot := binary.LittleEndian.Uint64(superblock[0x58:0x60])
+	nodeSize := int(binary.LittleEndian.Uint32(superblock[0x94:0x98]))
+	chunkRootLevel := int(superblock[0xc7])
+	// dev_item.devid
+	devId := binary.LittleEndian.Uint64(superblock[0xc9:0xd1])
+	if chunkRootLevel > btrfsMaxTreeLevel {
+		return profiles, fmt.Errorf("Error: incorrect chunk root level: %d.", chunkRootLevel)
 	}
-	defer stderr.Close()
-	stderrReader := bufio.NewReader(stderr)
-	//fmt.Println("StderrPipe linked with stderrReader")
 
-	// Start command
-	//fmt.Printf("> Getting Btrfs info: %s, it can take some time, please wait.\n", device)
-	err = cmd.Start()
+	sysChunks, err := parseSysChunkArray(superblock)
+	if err != nil {
+		return profiles, err
+	}
+	err = walkChunkTree(device, devId, sysChunks, chunkRoot, nodeSize, chunkRootLevel, &profiles)
 	if err != nil {
-		color.Red("++ ERROR getBtrfsRaidType cmd.Start: %s", err)
-		return "ERROR getBtrfsRaidType cmd.Start", nil
+		return profiles, err
 	}
+	return profiles, nil
+}
 
-	// Parse Stdout
-	for {
-		line, err := stdoutReader.ReadString('\n')
-		//fmt.Println("line: ", line)
+// Mounted filesystems show one directory per allocated profile: /sys/fs/btrfs/<uuid>/allocation/data/raid1
+func getSysfsProfiles(uuid string) (BtrfsProfilesStruct, bool) {
+	profiles := BtrfsProfilesStruct{}
+	for _, allocationType := range []string{"data", "metadata", "system"} {
+		entries, err := utils.ReadSysfsDir("/sys/fs/btrfs/" + uuid + "/allocation/" + allocationType)
 		if err != nil {
-			break
+			return profiles, false
+		}
+		allocationProfiles := ""
+		for _, entry := range entries {
+			if slices.Contains(btrfsProfileNames, entry) {
+				allocationProfiles = addProfile(allocationProfiles, entry)
+			}
+		}
+		switch allocationType {
+		case "data":
+			profiles.Data = allocationProfiles
+		case "metadata":
+			profiles.Metadata = allocationProfiles
+		case "system":
+			profiles.System = allocationProfiles
+		}
+	}
+	return profiles, true
+}
+
+// Function as variable in order to be able to mock it from unit tests
+// Mounted filesystems profiles are read from sysfs, unmounted ones from the first readable member chunk tree
+var GetBtrfsProfiles = func(uuid string, devices []string) (BtrfsProfilesStruct, error) {
+	profiles, mounted := getSysfsProfiles(uuid)
+	if mounted {
+		return profiles, nil
+	}
+	var err error
+	for _, device := range devices {
+		profiles, err = getChunkTreeProfiles(device)
+		if err == nil {
+			return profiles, nil
+		}
+		//fmt.Println("getChunkTreeProfiles: ", device, err)
+	}
+	return BtrfsProfilesStruct{}, fmt.Errorf("Error: Cant read Btrfs profiles of %s: %v.", uuid, err)
+}
+
+// Kernel name of device paths shown by btrfs: mapper/luks-data -> dm-0
+func getKernelDevice(device string) string {
+	link, err := utils.ReadSysfsLink("/dev/" + device)
+	if err != nil {
+		return device
+	}
+	return filepath.Base(link)
+}
+
+// Devices attached to a mounted filesystem: /sys/fs/btrfs/<uuid>/devices/sdb1
+func getSysfsDevices(uuid string) ([]string, bool) {
+	devices, err := utils.ReadSysfsDir("/sys/fs/btrfs/" + uuid + "/devices")
+	if err != nil {
+		return devices, false
+	}
+	return devices, true
+}
+
+// write_errs 0 (sysfs) or [/dev/sdb].write_io_errs 0 (btrfs device stats)
+func parseBtrfsDeviceStats(stats string) utils.IoErrorsStruct {
+	ioErrors := utils.IoErrorsStruct{Source: "btrfs"}
+	for _, line := range strings.Split(stats, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
 		}
-		// Data raid type can missmatch with metadata raid type, we rely on DATA to get raid type
-		matched, err := regexp.MatchString(`.*DATA\|.*`, line)
+		counter, err := strconv.ParseInt(fields[1], 10, 64)
 		if err != nil {
-			color.Red("++ ERROR: getBtrfsRaidType Regexp errror %s", err)
-		}
-		if matched {
-			//fmt.Println("DATA found")
-			//fmt.Println("line: ", line)
-			diskRaidTypeData := strings.Split(line, "|")
-			diskRaidType := diskRaidTypeData[1]
-			diskRaidType = utils.ClearString(diskRaidType)
-			diskRaidType = strings.TrimSuffix(diskRaidType, "\n")
-			//fmt.Println("diskRaidType: ", diskRaidType)
-			return diskRaidType, nil
-		}
-	}
-	// Parse Stderr
-	for {
-		line, err := stderrReader.ReadString('\n')
-		//fmt.Printf("line: |%s|\n", line)
+			continue
+		}
+		switch fields[0][strings.LastIndex(fields[0], ".")+1:] {
+		case "write_errs", "write_io_errs":
+			ioErrors.Write = counter
+		case "read_errs", "read_io_errs":
+			ioErrors.Read = counter
+		case "flush_errs", "flush_io_errs":
+			ioErrors.Flush = counter
+		case "corruption_errs":
+			ioErrors.Checksum = counter
+		case "generation_errs":
+			ioErrors.Generation = counter
+		}
+	}
+	return ioErrors
+}
+
+func hasBtrfsErrors(ioErrors utils.IoErrorsStruct) bool {
+	return ioErrors.Read > 0 || ioErrors.Write > 0 || ioErrors.Flush > 0 || ioErrors.Checksum > 0 || ioErrors.Generation > 0
+}
+
+// Function as variable in order to be able to mock it from unit tests
+// Persistent device error counters of mounted filesystems: /sys/fs/btrfs/<uuid>/devinfo/<devid>/error_stats (kernel >= 5.14) or btrfs device stats
+var GetBtrfsDeviceStats = func(uuid string, devId string, device string) (utils.IoErrorsStruct, error) {
+	stats, err := utils.ReadSysfsFile("/sys/fs/btrfs/" + uuid + "/devinfo/" + devId + "/error_stats")
+	if err != nil {
+		command := "device stats /dev/" + device
+		outputStdout, outputStderr, err := utils.GetCommandOutput("btrfs", "getBtrfsDeviceStats", command)
 		if err != nil {
-			break
+			color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+			return utils.IoErrorsStruct{}, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
 		}
-		if line == "ERROR: cannot read chunk root\n" {
-			return "Cant read chunk root", nil
+		if len(outputStderr.String()) != 0 {
+			color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+			return utils.IoErrorsStruct{}, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
 		}
+		stats = outputStdout.String()
 	}
-	return "Unknown", nil
+	return parseBtrfsDeviceStats(stats), nil
 }
 
-var GetBtrfsRaidSize = func(raid utils.RaidStruct) (string, error) {
-	//fmt.Println("-- getBtrfsRaidSize --")
-	//spew.Dump(raid)
-
-	// SINGLE: ZFS stripe
-	// MIXED: ZFS stripe but mixing data with metadata
-	// DUP: 2 copies of the data in the same disk
-	// RAID0
-	// RAID1: 2 copies of the data in diferent disks
-	// RAID1c3: 3 copies of the data in diferent disks
-	// RAID1c4: 4 copies of the data in diferent disks
-	// RAID10
-	// RAID5: Stripped data betwwen disks with 1 faulty disk fault tolerance
-	// RAID6: Stripped data betwwen disks with 2 faulty disk fault tolerance
-
-	var totalSumDisks float64
-	var raidSize float64
-	var diskSize float64
-	re := regexp.MustCompile(`^(\d+\.\d+)\s([KMGTPEZ]iB)$`)
-	for i, disk := range raid.Disks {
-		//fmt.Println("disk.Size", disk.Size)
-		multiplierFactor := 1
-		matches := re.FindStringSubmatch(disk.Size)
-		diskSizeString := matches[1]
-		diskSizeUnit := matches[2]
-		diskSize, _ = strconv.ParseFloat(diskSizeString, 64)
-
-		switch diskSizeUnit {
-		case "KiB":
-			multiplierFactor = 1
-		case "MiB":
-			multiplierFactor = 2
-		case "GiB":
-			multiplierFactor = 3
-		case "TiB":
-			multiplierFactor = 4
-		case "PiB":
-			multiplierFactor = 5
-		case "EiB":
-			multiplierFactor = 5
-		case "ZiB":
-			multiplierFactor = 6
-		}
-		diskSize = diskSize * math.Pow(float64(1024), float64(multiplierFactor))
-		//fmt.Println("diskSize bytes: ", diskSize)
-		if i == 0 {
-			totalSumDisks = diskSize
-		} else {
-			totalSumDisks = totalSumDisks + diskSize
-		}
-	}
-
-	//fmt.Printf("raid.raidType: |%s|\n", raid.raidType)
-	// Sometimes raidType is uppercase, sometimes lowercase
-	switch raid.RaidType {
-	case "SINGLE", "single":
-		raidSize = totalSumDisks
-	case "MIXED", "mixed":
-		raidSize = totalSumDisks
-	case "DUP", "dup":
-		raidSize = totalSumDisks / 2
-	case "RAID0", "raid0":
-		raidSize = totalSumDisks
-	case "RAID1", "raid1":
-		raidSize = totalSumDisks / 2
-	case "RAID1c3", "raid1c3":
-		raidSize = totalSumDisks / 3
-	case "RAID1c4", "raid1c4":
-		raidSize = totalSumDisks / 4
-	case "RAID10", "raid10":
-		raidSize = totalSumDisks / 2
-	case "RAID5", "raid5":
-		raidSize = totalSumDisks - diskSize
-	case "RAID6", "raid6":
-		raidSize = totalSumDisks - (diskSize * 2)
-	default:
-		return "Unknown", fmt.Errorf("Incorrect RAID type")
-	}
-	raidSizeFinal := human.Bytes(uint64(raidSize))
-	raidSizeFinal = raidSizeFinal + " Aprox"
-	//fmt.Println("raidSizeFinal: ", raidSizeFinal)
-	return raidSizeFinal, nil
+// Scrub status formats, btrfs-progs >= 5.x:
+//
+//	Scrub started:    Sun Oct 13 00:25:24 2024
+//	Status:           finished
+//	Error summary:    csum=3
+//
+// Older versions:
+//
+//	scrub started at Sun Oct 13 00:25:24 2024 and finished after 00:10:00
+//	total bytes scrubbed: 1.00TiB with 3 errors
+var scrubStartedRegexp = regexp.MustCompile(`(?:Scrub started:|scrub started at)\s+(\w{3} \w{3} +\d+ \d{2}:\d{2}:\d{2} \d{4})`)
+var scrubStatusRegexp = regexp.MustCompile(`Status:\s+(\w+)`)
+var scrubErrorSummaryRegexp = regexp.MustCompile(`Error summary:\s+(.+)`)
+var scrubErrorsRegexp = regexp.MustCompile(`with (\d+) errors`)
+
+func parseBtrfsScrubStatus(output string) ([]string, bool) {
+	if strings.Contains(output, "no stats available") {
+		return []string{"never scrubbed"}, false
+	}
+
+	scrubDate := "unknown date"
+	startedData := scrubStartedRegexp.FindStringSubmatch(output)
+	if len(startedData) == 2 {
+		scrubTime, err := time.Parse("Mon Jan _2 15:04:05 2006", startedData[1])
+		if err == nil {
+			scrubDate = scrubTime.Format("2006-01-02")
+		}
+	}
+
+	scrubStatus := "Unknown"
+	statusData := scrubStatusRegexp.FindStringSubmatch(output)
+	switch {
+	case len(statusData) == 2:
+		scrubStatus = statusData[1]
+	case strings.Contains(output, "finished after"):
+		scrubStatus = "finished"
+	case strings.Contains(output, "running for"):
+		scrubStatus = "running"
+	case strings.Contains(output, "aborted"):
+		scrubStatus = "aborted"
+	case strings.Contains(output, "interrupted"):
+		scrubStatus = "interrupted"
+	}
+
+	scrubErrors := "Unknown"
+	hasErrors := false
+	summaryData := scrubErrorSummaryRegexp.FindStringSubmatch(output)
+	errorsData := scrubErrorsRegexp.FindStringSubmatch(output)
+	switch {
+	case len(summaryData) == 2:
+		scrubErrors = strings.TrimSpace(summaryData[1])
+		hasErrors = scrubErrors != "no errors found"
+	case len(errorsData) == 2:
+		scrubErrors = errorsData[1] + " errors"
+		hasErrors = errorsData[1] != "0"
+	}
+	return []string{"last scrub " + scrubDate + " " + scrubStatus + ": " + scrubErrors}, hasErrors
+}
+
+// Function as variable in order to be able to mock it from unit tests
+// Last scrub of a mounted filesystem, returns scrub details and if errors were found
+// Scrub status is read through its mountpoint, a member device only reports its own scrub errors
+var GetBtrfsScrubStatus = func(devices []string) ([]string, bool, error) {
+	mountpoint, mounted := getBtrfsMountpoint(devices)
+	if !mounted {
+		return []string{}, false, fmt.Errorf("Error: Btrfs filesystem of %s is not mounted.", strings.Join(devices, ","))
+	}
+	command := "scrub status " + mountpoint
+	outputStdout, outputStderr, err := utils.GetCommandOutput("btrfs", "getBtrfsScrubStatus", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return []string{}, false, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return []string{}, false, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+	details, hasErrors := parseBtrfsScrubStatus(outputStdout.String())
+	return details, hasErrors, nil
+}
+
+// Chunk allocation of a filesystem in bytes, DataRatio: raw bytes allocated per data byte(raid1: 2)
+type BtrfsUsageStruct struct {
+	DataTotal     uint64
+	DataUsed      uint64
+	MetadataTotal uint64
+	MetadataUsed  uint64
+	DeviceSize    uint64
+	Unallocated   uint64
+	DataRatio     float64
+}
+
+// First mountpoint of any filesystem member: /dev/sdb1 /data btrfs rw,relatime 0 0
+func getBtrfsMountpoint(devices []string) (string, bool) {
+	mounts, err := utils.ReadSysfsFile("/proc/mounts")
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(mounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[2] != "btrfs" {
+			continue
+		}
+		for _, device := range devices {
+			if fields[0] == "/dev/"+device {
+				return fields[1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// btrfs filesystem usage -b output:
+//
+//	    Device size:                  2000398934016
+//	    Device unallocated:            117440512000
+//	    Data ratio:                            2.00
+//	Data,RAID1: Size:858993459200, Used:805306368000 (93.75%)
+//	Metadata,RAID1: Size:8589934592, Used:4294967296 (50.00%)
+var usageProfileRegexp = regexp.MustCompile(`^(Data|Metadata|System|Data\+Metadata),[^:]+:\s+Size:(\d+), Used:(\d+)`)
+
+func parseBtrfsFilesystemUsage(output string) BtrfsUsageStruct {
+	usage := BtrfsUsageStruct{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "Device size:") && len(fields) >= 3:
+			usage.DeviceSize, _ = strconv.ParseUint(fields[2], 10, 64)
+		case strings.HasPrefix(line, "Device unallocated:") && len(fields) >= 3:
+			usage.Unallocated, _ = strconv.ParseUint(fields[2], 10, 64)
+		case strings.HasPrefix(line, "Data ratio:") && len(fields) >= 3:
+			usage.DataRatio, _ = strconv.ParseFloat(fields[2], 64)
+		}
+
+		// Several profiles can coexist while converting, its allocation is added
+		matches := usageProfileRegexp.FindStringSubmatch(line)
+		if len(matches) != 4 {
+			continue
+		}
+		total, _ := strconv.ParseUint(matches[2], 10, 64)
+		used, _ := strconv.ParseUint(matches[3], 10, 64)
+		if matches[1] == "Data" || matches[1] == "Data+Metadata" {
+			usage.DataTotal = usage.DataTotal + total
+			usage.DataUsed = usage.DataUsed + used
+		}
+		if matches[1] == "Metadata" || matches[1] == "Data+Metadata" {
+			usage.MetadataTotal = usage.MetadataTotal + total
+			usage.MetadataUsed = usage.MetadataUsed + used
+		}
+	}
+	return usage
+}
+
+// Function as variable in order to be able to mock it from unit tests
+// Allocation is read from btrfs filesystem usage, it requires filesystem to be mounted
+// Device size is the size btrfs uses on each member, sysfs block devices size is higher after a filesystem resize
+var GetBtrfsUsage = func(uuid string, devices []string) (BtrfsUsageStruct, error) {
+	usage := BtrfsUsageStruct{}
+	mountpoint, mounted := getBtrfsMountpoint(devices)
+	if !mounted {
+		return usage, fmt.Errorf("Error: Btrfs filesystem %s is not mounted.", uuid)
+	}
+	command := "filesystem usage -b " + mountpoint
+	outputStdout, outputStderr, err := utils.GetCommandOutput("btrfs", "getBtrfsUsage", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return usage, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	// Non root users get a warning about missing per device info
+	if len(outputStdout.String()) == 0 && len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return usage, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+	return parseBtrfsFilesystemUsage(outputStdout.String()), nil
+}
+
+// Usable size: data chunks plus unallocated space once profile copies are discounted
+func getBtrfsUsableSize(usage BtrfsUsageStruct) uint64 {
+	dataRatio := usage.DataRatio
+	if dataRatio < 1 {
+		dataRatio = 1
+	}
+	return usage.DataTotal + uint64(float64(usage.Unallocated)/dataRatio)
+}
+
+func getUsagePercent(used uint64, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) * 100 / float64(total)
+}
+
+// Chunks allocation details and health flags
+// New chunks cant be allocated without unallocated space, writes fail with ENOSPC even when data chunks have free space
+func getUsageHealth(usage BtrfsUsageStruct) ([]string, []string) {
+	details := []string{}
+	flags := []string{}
+	dataPercent := getUsagePercent(usage.DataUsed, usage.DataTotal)
+	metadataPercent := getUsagePercent(usage.MetadataUsed, usage.MetadataTotal)
+	details = append(details, fmt.Sprintf("data %s/%s (%.0f%%)", human.Bytes(usage.DataUsed), human.Bytes(usage.DataTotal), dataPercent))
+	details = append(details, fmt.Sprintf("metadata %s/%s (%.0f%%)", human.Bytes(usage.MetadataUsed), human.Bytes(usage.MetadataTotal), metadataPercent))
+	details = append(details, "unallocated "+human.Bytes(usage.Unallocated))
+
+	if usage.Unallocated < uint64(utils.Thresholds.BtrfsUnallocatedGiB)*1024*1024*1024 {
+		flags = append(flags, "Unallocated-Low")
+	}
+	if metadataPercent >= float64(utils.Thresholds.BtrfsMetadata) {
+		flags = append(flags, "Metadata-Full")
+	}
+	return details, flags
 }
 
 var ProcessBtrfsRaid = func(manufacturer string) ([]utils.ControllerStruct, []utils.RaidStruct, error) {
@@ -342,6 +763,8 @@ var ProcessBtrfsRaid = func(manufacturer string) ([]utils.ControllerStruct, []ut
 	diskSerialNumber := "Unknown"
 	firstOutputLine := true
 	currentLine := 0
+	// Member devices devid, used to read its error counters
+	devIds := map[string]string{}
 	for scanner.Scan() {
 		line := scanner.Text()
 		// Dont skip blank lines, it are userful for controlling edge of each raid
@@ -357,39 +780,63 @@ var ProcessBtrfsRaid = func(manufacturer string) ([]utils.ControllerStruct, []ut
 		// End of raid detected, fill extra raid data and append raid to raids array
 		if currentLine == lastLine || (strings.Contains(line, "uuid:") && !firstOutputLine) {
 			//fmt.Println("Raid end detected")
-			// Get raid type
-			raidType := "Unknown"
-			previousRaidType := "Unknown"
-			allRaidTypesMatches := false
-			for i, btrfsDisk := range raid.Disks {
-				if i == 0 {
-					raidType, _ = GetBtrfsRaidType(btrfsDisk.OsDevice)
-					allRaidTypesMatches = true
-				} else {
-					raidType, _ = GetBtrfsRaidType(btrfsDisk.OsDevice)
-					if raidType == previousRaidType {
-						allRaidTypesMatches = true
-					} else {
-						color.Red("++ ERROR: raid type missmatch: %s != %s", raidType, previousRaidType)
-						allRaidTypesMatches = false
-					}
-				}
-				//fmt.Println("raidType: ", raidType)
-				previousRaidType = raidType
+			// Get raid profiles, data profile determines raid type
+			devices := []string{}
+			for _, btrfsDisk := range raid.Disks {
+				devices = append(devices, btrfsDisk.OsDevice)
 			}
-			if allRaidTypesMatches {
-				raid.RaidType = raidType
-			} else {
-				raid.RaidType = "Unknown"
+			raid.RaidType = "Unknown"
+			profiles, err := GetBtrfsProfiles(raid.Dg, devices)
+			if err != nil {
+				color.Red("++ ERROR: GetBtrfsProfiles: %s", err)
+			} else if len(profiles.Data) > 0 {
+				raid.RaidType = profiles.Data
+				raid.Details = []string{"metadata " + profiles.Metadata, "system " + profiles.System}
 			}
 			//fmt.Println("raid.raidType: ", raid.raidType)
 
-			// Calculate raid size knowing devices size and raid type
-			if raid.RaidType != "Unknown" {
-				raidSize, _ := GetBtrfsRaidSize(raid)
-				raid.Size = raidSize
-			} else {
-				raid.Size = "Unknown"
+			// Mounted filesystem members not attached to the kernel filesystem are missing
+			// Error counters and scrub status are only available for mounted filesystems
+			sysfsDevices, mounted := getSysfsDevices(raid.Dg)
+			if mounted {
+				for i := range raid.Disks {
+					disk := &raid.Disks[i]
+					if disk.State == "MISSING" || !slices.Contains(sysfsDevices, getKernelDevice(disk.OsDevice)) {
+						disk.State = "MISSING"
+						raid.State = "Missing devices"
+						controllers[0].Status = "Bad"
+						continue
+					}
+					ioErrors, err := GetBtrfsDeviceStats(raid.Dg, devIds[disk.OsDevice], disk.OsDevice)
+					if err != nil {
+						continue
+					}
+					disk.IoErrors = ioErrors
+					if hasBtrfsErrors(ioErrors) {
+						disk.State = "Warning"
+					}
+				}
+
+				scrubDetails, scrubErrors, err := GetBtrfsScrubStatus(devices)
+				if err == nil {
+					raid.Details = append(raid.Details, scrubDetails...)
+					if scrubErrors {
+						raid.State = raid.State + "/Scrub-Errors"
+						controllers[0].Status = "Bad"
+					}
+				}
+			}
+
+			// Raid size and allocation health are only known for mounted filesystems
+			raid.Size = "Unknown"
+			usage, err := GetBtrfsUsage(raid.Dg, devices)
+			if err == nil {
+				raid.Size = human.Bytes(getBtrfsUsableSize(usage))
+				usageDetails, usageFlags := getUsageHealth(usage)
+				raid.Details = append(raid.Details, usageDetails...)
+				for _, usageFlag := range usageFlags {
+					raid.State = raid.State + "/" + usageFlag
+				}
 			}
 
 			raids = append(raids, raid)
@@ -410,6 +857,7 @@ var ProcessBtrfsRaid = func(manufacturer string) ([]utils.ControllerStruct, []ut
 				State:        "ONLINE",
 				Dg:           uuid,
 			}
+			devIds = map[string]string{}
 			//fmt.Println("Raid object created")
 			continue
 		}
@@ -417,21 +865,46 @@ var ProcessBtrfsRaid = func(manufacturer string) ([]utils.ControllerStruct, []ut
 		if strings.Contains(line, "devid") {
 			//fmt.Println("devid detected")
 			//fmt.Println(strings.Fields(line))
-			diskSize = strings.Fields(line)[3]
+			// devid    1 size 3.49TiB used 2.04TiB path /dev/sdb1
+			// devid    2 size 0 used 0 path <missing disk> MISSING
+			lineData := strings.Fields(line)
+			diskSize = lineData[3]
 			//fmt.Println("diskSize: ", diskSize)
 			// All data is always shown with Num Unit syntax, adapt Btrfs to standar output
 			// Ex: 931.51GiB -> 931.51 GiB
 			re := regexp.MustCompile(`^(\d+\.\d+)([KMGTPEZ]iB)$`)
 			matches := re.FindStringSubmatch(diskSize)
-			diskSizeString := matches[1]
-			//fmt.Println("diskSizeString: ", diskSizeString)
-			diskSizeUnit := matches[2]
-			//fmt.Println("diskSizeUnit: ", diskSizeUnit)
-			diskSize = diskSizeString + " " + diskSizeUnit
+			if len(matches) == 3 {
+				diskSizeString := matches[1]
+				//fmt.Println("diskSizeString: ", diskSizeString)
+				diskSizeUnit := matches[2]
+				//fmt.Println("diskSizeUnit: ", diskSizeUnit)
+				diskSize = diskSizeString + " " + diskSizeUnit
+			}
 			//fmt.Println("diskSize: ", diskSize)
-			osDevice = strings.Fields(line)[7]
+
+			if strings.Contains(line, "<missing disk>") {
+				btrfsDisk = utils.DiskStruct{
+					ControllerId: "btrfs-0",
+					Dg:           uuid,
+					State:        "MISSING",
+					Size:         diskSize,
+					Intf:         "Unknown",
+					Medium:       "Unknown",
+					Model:        "Unknown",
+					SerialNumber: "Unknown",
+					OsDevice:     "missing-devid-" + lineData[1],
+				}
+				raid.AddDisk(btrfsDisk)
+				raid.State = "Missing devices"
+				controllers[0].Status = "Bad"
+				continue
+			}
+
+			osDevice = lineData[7]
 			osDevice = strings.ReplaceAll(osDevice, "/dev/", "")
 			//fmt.Println("osDevice: ", osDevice)
+			devIds[osDevice] = lineData[1]
 			btrfsDisk = utils.DiskStruct{
 				ControllerId: "btrfs-0",
 				Dg:           uuid,
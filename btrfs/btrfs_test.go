@@ -2,12 +2,12 @@ package btrfs
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"hardwareAnalyzer/utils"
-	"math"
+	"reflect"
 	"strconv"
 	"testing"
-
-	human "github.com/dustin/go-humanize"
 )
 
 // Test CheckBtrfsRaid
@@ -89,54 +89,135 @@ func TestCheckBtrfsRaidError(t *testing.T) {
 	}
 }
 
-// TODO GetBtrfsRaidType, gets binary executor directly
-
-// Test GetBtrfsRaidSize
-func TestGetBtrfsRaidSize(t *testing.T) {
-	// Generate btrfs raid with disks
-	raid := utils.RaidStruct{
-		ControllerId: "btrfs-0",
-		RaidLevel:    0,
-		RaidType:     "RAID10",
-		State:        "ONLINE",
-		Dg:           "XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXX",
-	}
-
-	var totalSumDisks float64
-	var raidSize float64
-	var diskSize float64
-	totalSumDisks = 0
-	// TiB: multiplierFactor -> 4
-	multiplierFactor := 4
-
-	for i := 1; i <= 4; i++ {
-		diskSize = float64(i) * math.Pow(float64(1024), float64(multiplierFactor))
-		totalSumDisks = totalSumDisks + diskSize
-
-		testSizeValue := strconv.Itoa(i) + ".0 TiB"
-		//fmt.Println("testSizeValue: ", testSizeValue)
-		btrfsDisk := utils.DiskStruct{
-			ControllerId: "btrfs-0",
-			Dg:           "XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXX",
-			State:        "ONLINE",
-			Size:         testSizeValue,
-			OsDevice:     "XYZ",
-		}
-		raid.AddDisk(btrfsDisk)
+// Build chunk item with its stripes: devid, physical offset
+func buildBtrfsChunk(length uint64, chunkType uint64, stripes [][2]uint64) []byte {
+	chunk := make([]byte, btrfsChunkSize+len(stripes)*btrfsStripeSize)
+	binary.LittleEndian.PutUint64(chunk[0:8], length)
+	binary.LittleEndian.PutUint64(chunk[16:24], 65536)
+	binary.LittleEndian.PutUint64(chunk[24:32], chunkType)
+	binary.LittleEndian.PutUint16(chunk[44:46], uint16(len(stripes)))
+	for i, stripe := range stripes {
+		binary.LittleEndian.PutUint64(chunk[btrfsChunkSize+i*btrfsStripeSize:], stripe[0])
+		binary.LittleEndian.PutUint64(chunk[btrfsChunkSize+i*btrfsStripeSize+8:], stripe[1])
+	}
+	return chunk
+}
 
+// Build tree block header
+func buildBtrfsNode(nodeSize int, logical uint64, level byte, nrItems int) []byte {
+	node := make([]byte, nodeSize)
+	binary.LittleEndian.PutUint64(node[0x30:0x38], logical)
+	binary.LittleEndian.PutUint32(node[0x60:0x64], uint32(nrItems))
+	node[0x64] = level
+	return node
+}
+
+// Test GetBtrfsProfiles reading unmounted filesystem superblock and a two levels chunk tree
+func TestGetBtrfsProfiles(t *testing.T) {
+	// Copy original functions content
+	readDeviceBytesOri := ReadDeviceBytes
+	readSysfsDirOri := utils.ReadSysfsDir
+	// unmock functions content
+	defer func() {
+		ReadDeviceBytes = readDeviceBytesOri
+		utils.ReadSysfsDir = readSysfsDirOri
+	}()
+
+	nodeSize := 16384
+	chunkRoot := uint64(0x1500000)
+	leafLogical := uint64(0x1504000)
+	systemChunk := buildBtrfsChunk(8*1024*1024, btrfsBlockGroupSystem|btrfsBlockGroupDup, [][2]uint64{{1, 0x2000000}, {1, 0x2800000}})
+
+	// Superblock: magic, chunk root, node size, chunk root level 1, devid 1 and system chunk
+	superblock := make([]byte, btrfsSuperblockSize)
+	copy(superblock[0x40:0x48], btrfsMagic)
+	binary.LittleEndian.PutUint64(superblock[0x58:0x60], chunkRoot)
+	binary.LittleEndian.PutUint32(superblock[0x94:0x98], uint32(nodeSize))
+	superblock[0xc7] = 1
+	binary.LittleEndian.PutUint64(superblock[0xc9:0xd1], 1)
+	sysChunkArray := make([]byte, btrfsKeySize)
+	binary.LittleEndian.PutUint64(sysChunkArray[0:8], 256)
+	sysChunkArray[8] = btrfsChunkItemKey
+	binary.LittleEndian.PutUint64(sysChunkArray[9:17], chunkRoot)
+	sysChunkArray = append(sysChunkArray, systemChunk...)
+	binary.LittleEndian.PutUint32(superblock[0xa0:0xa4], uint32(len(sysChunkArray)))
+	copy(superblock[0x32b:], sysChunkArray)
+
+	// Chunk root node pointing to one leaf
+	rootNode := buildBtrfsNode(nodeSize, chunkRoot, 1, 1)
+	binary.LittleEndian.PutUint64(rootNode[btrfsHeaderSize+btrfsKeySize:], leafLogical)
+
+	// Leaf: device item, system dup, metadata dup and data single + raid1 while converting
+	leafItems := []struct {
+		keyType byte
+		logical uint64
+		data    []byte
+	}{
+		{216, 1, make([]byte, 98)},
+		{btrfsChunkItemKey, chunkRoot, systemChunk},
+		{btrfsChunkItemKey, 0x1D00000, buildBtrfsChunk(256*1024*1024, btrfsBlockGroupMetadata|btrfsBlockGroupDup, [][2]uint64{{1, 0x3000000}, {1, 0x13000000}})},
+		{btrfsChunkItemKey, 0x11D00000, buildBtrfsChunk(1024*1024*1024, btrfsBlockGroupData, [][2]uint64{{1, 0x23000000}})},
+		{btrfsChunkItemKey, 0x51D00000, buildBtrfsChunk(1024*1024*1024, btrfsBlockGroupData|btrfsBlockGroupRaid1, [][2]uint64{{1, 0x63000000}, {2, 0x1000000}})},
+	}
+	leaf := buildBtrfsNode(nodeSize, leafLogical, 0, len(leafItems))
+	dataOffset := nodeSize - btrfsHeaderSize
+	for i, item := range leafItems {
+		itemOffset := btrfsHeaderSize + i*btrfsItemSize
+		dataOffset = dataOffset - len(item.data)
+		leaf[itemOffset+8] = item.keyType
+		binary.LittleEndian.PutUint64(leaf[itemOffset+9:itemOffset+17], item.logical)
+		binary.LittleEndian.PutUint32(leaf[itemOffset+17:itemOffset+21], uint32(dataOffset))
+		binary.LittleEndian.PutUint32(leaf[itemOffset+21:itemOffset+25], uint32(len(item.data)))
+		copy(leaf[btrfsHeaderSize+dataOffset:], item.data)
 	}
-	// RAID10
-	raidSize = totalSumDisks / 2
-	raidSizeFinal1 := human.Bytes(uint64(raidSize))
-	raidSizeFinal1 = raidSizeFinal1 + " Aprox"
-	//fmt.Println("raidSizeFinal1: ", raidSizeFinal1)
 
-	// Check raid size
-	raidSizeFinal2, _ := GetBtrfsRaidSize(raid)
-	//fmt.Println("raidSizeFinal2: ", raidSizeFinal2)
+	// Mocked functions: sdb is not a Btrfs device, sdc has the filesystem
+	ReadDeviceBytes = func(device string, offset int64, size int) ([]byte, error) {
+		if device != "sdc" {
+			return make([]byte, size), nil
+		}
+		switch offset {
+		case btrfsSuperblockOffset:
+			return superblock, nil
+		case 0x2000000:
+			return rootNode, nil
+		case 0x2004000:
+			return leaf, nil
+		}
+		return nil, errors.New("unexpected read")
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		return []string{}, errors.New("No such file or directory")
+	}
+
+	profiles, err := GetBtrfsProfiles("XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX", []string{"sdb", "sdc"})
+	if err != nil {
+		t.Fatalf(`TestGetBtrfsProfiles returned error: %s`, err)
+	}
+	profilesWanted := BtrfsProfilesStruct{Data: "single+raid1", Metadata: "dup", System: "dup"}
+	if profiles != profilesWanted {
+		t.Fatalf(`TestGetBtrfsProfiles profiles: %v should be: %v`, profiles, profilesWanted)
+	}
 
-	if raidSizeFinal1 != raidSizeFinal2 {
-		t.Fatalf(`TestGetBtrfsRaidSize: raidSizeFinal1: %v must be equal to raidSizeFinal2: %v`, raidSizeFinal1, raidSizeFinal2)
+	// Mounted filesystem: profiles from sysfs allocation directories
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		switch path {
+		case "/sys/fs/btrfs/XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX/allocation/data":
+			return []string{"bytes_used", "disk_total", "flags", "raid0", "total_bytes"}, nil
+		case "/sys/fs/btrfs/XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX/allocation/metadata":
+			return []string{"bytes_used", "raid1", "total_bytes"}, nil
+		case "/sys/fs/btrfs/XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX/allocation/system":
+			return []string{"raid1"}, nil
+		}
+		return []string{}, errors.New("No such file or directory")
+	}
+	profiles, err = GetBtrfsProfiles("XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX", []string{"sdb", "sdc"})
+	if err != nil {
+		t.Fatalf(`TestGetBtrfsProfiles mounted returned error: %s`, err)
+	}
+	profilesWanted = BtrfsProfilesStruct{Data: "raid0", Metadata: "raid1", System: "raid1"}
+	if !reflect.DeepEqual(profiles, profilesWanted) {
+		t.Fatalf(`TestGetBtrfsProfiles mounted profiles: %v should be: %v`, profiles, profilesWanted)
 	}
 }
 
@@ -144,14 +225,16 @@ func TestGetBtrfsRaidSize(t *testing.T) {
 func TestProcessBtrfsRaid(t *testing.T) {
 	// Copy original functions content
 	getCommandOutputOri := utils.GetCommandOutput
-	getBtrfsRaidTypeOri := GetBtrfsRaidType
-	getBtrfsRaidSizeOri := GetBtrfsRaidSize
+	getBtrfsProfilesOri := GetBtrfsProfiles
+	readSysfsDirOri := utils.ReadSysfsDir
+	getBtrfsUsageOri := GetBtrfsUsage
 	getDiskDataOri := utils.GetDiskData
 	// unmock functions content
 	defer func() {
 		utils.GetCommandOutput = getCommandOutputOri
-		GetBtrfsRaidType = getBtrfsRaidTypeOri
-		GetBtrfsRaidSize = getBtrfsRaidSizeOri
+		GetBtrfsProfiles = getBtrfsProfilesOri
+		utils.ReadSysfsDir = readSysfsDirOri
+		GetBtrfsUsage = getBtrfsUsageOri
 		utils.GetDiskData = getDiskDataOri
 	}()
 
@@ -171,13 +254,18 @@ func TestProcessBtrfsRaid(t *testing.T) {
 	}
 
 	// Mocked functions, this way we can run unit tests in servers without hardware raid controller installed.
-	GetBtrfsRaidType = func(device string) (string, error) {
-		return "RAID1", nil
+	GetBtrfsProfiles = func(uuid string, devices []string) (BtrfsProfilesStruct, error) {
+		return BtrfsProfilesStruct{Data: "RAID1", Metadata: "RAID1", System: "RAID1"}, nil
 	}
 
-	// Mocked functions, this way we can run unit tests in servers without hardware raid controller installed.
-	GetBtrfsRaidSize = func(raid utils.RaidStruct) (string, error) {
-		return "3.49TiB", nil
+	// Mocked functions, unmounted filesystem
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		return []string{}, errors.New("No such file or directory")
+	}
+
+	// Mocked functions, 1.5TB data chunks and 4TB raw unallocated space with raid1 copies
+	GetBtrfsUsage = func(uuid string, devices []string) (BtrfsUsageStruct, error) {
+		return BtrfsUsageStruct{DataTotal: 1500000000000, DataUsed: 1400000000000, MetadataTotal: 8000000000, MetadataUsed: 4000000000, DeviceSize: 7000000000000, Unallocated: 4000000000000, DataRatio: 2}, nil
 	}
 
 	// Mocked functions, this way we can run unit tests in servers without hardware raid controller installed.
@@ -285,7 +373,7 @@ func TestProcessBtrfsRaid(t *testing.T) {
 			t.Fatalf(`TestProcessHWAdaptecRaid: raidState: %s muts match %v`, raidState, raidStateWanted)
 		}
 
-		raidSizeWanted := "3.49TiB"
+		raidSizeWanted := "3.5 TB"
 		if raidSize != raidSizeWanted {
 			t.Fatalf(`TestProcessHWAdaptecRaid: raidSize: %s muts match %v`, raidSize, raidSizeWanted)
 		}
@@ -376,3 +464,236 @@ func TestProcessBtrfsRaid(t *testing.T) {
 		}
 	}
 }
+
+// Test parseBtrfsScrubStatus
+func TestParseBtrfsScrubStatus(t *testing.T) {
+	scrubs := []struct {
+		output    string
+		details   []string
+		hasErrors bool
+	}{
+		{`UUID:             XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX
+Scrub started:    Sun Oct 13 00:25:24 2024
+Status:           finished
+Duration:         0:10:00
+Total to scrub:   1.00TiB
+Rate:             1.70GiB/s
+Error summary:    no errors found`, []string{"last scrub 2024-10-13 finished: no errors found"}, false},
+		{`UUID:             XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX
+Scrub started:    Sun Oct 13 00:25:24 2024
+Status:           finished
+Duration:         0:10:00
+Error summary:    csum=3
+  Corrected:      3
+  Uncorrectable:  0
+  Unverified:     0`, []string{"last scrub 2024-10-13 finished: csum=3"}, true},
+		{`scrub status for XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX
+	scrub started at Sun Oct 13 00:25:24 2024 and finished after 00:10:00
+	total bytes scrubbed: 1.00TiB with 2 errors`, []string{"last scrub 2024-10-13 finished: 2 errors"}, true},
+		{`scrub status for XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX
+	no stats available`, []string{"never scrubbed"}, false},
+	}
+	for _, scrub := range scrubs {
+		details, hasErrors := parseBtrfsScrubStatus(scrub.output)
+		if !reflect.DeepEqual(details, scrub.details) || hasErrors != scrub.hasErrors {
+			t.Fatalf(`TestParseBtrfsScrubStatus: %v %v should be: %v %v`, details, hasErrors, scrub.details, scrub.hasErrors)
+		}
+	}
+}
+
+// Test ProcessBtrfsRaid mounted filesystem with device error counters, scrub status and a missing device
+func TestProcessBtrfsRaidMounted(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	getBtrfsProfilesOri := GetBtrfsProfiles
+	getBtrfsUsageOri := GetBtrfsUsage
+	getDiskDataOri := utils.GetDiskData
+	readSysfsDirOri := utils.ReadSysfsDir
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsLinkOri := utils.ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		GetBtrfsProfiles = getBtrfsProfilesOri
+		GetBtrfsUsage = getBtrfsUsageOri
+		utils.GetDiskData = getDiskDataOri
+		utils.ReadSysfsDir = readSysfsDirOri
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked functions: raid1c3 with sdb on sysfs error_stats, dm-0 through btrfs device stats and devid 3 missing
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		switch command {
+		case "filesystem show":
+			outputStdout.WriteString(`
+			Label: 'data'  uuid: XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX
+			Total devices 3 FS bytes used 1.50TiB
+			devid    1 size 3.49TiB used 2.04TiB path /dev/sdb
+			devid    2 size 3.49TiB used 2.04TiB path /dev/mapper/luks-data
+			devid    3 size 0 used 0 path <missing disk> MISSING
+		`)
+		case "device stats /dev/mapper/luks-data":
+			outputStdout.WriteString("[/dev/mapper/luks-data].write_io_errs    0\n[/dev/mapper/luks-data].read_io_errs     0\n[/dev/mapper/luks-data].flush_io_errs    0\n[/dev/mapper/luks-data].corruption_errs  0\n[/dev/mapper/luks-data].generation_errs  0\n")
+		case "scrub status /data":
+			outputStdout.WriteString("UUID:             XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX\nScrub started:    Sun Oct 13 00:25:24 2024\nStatus:           finished\nError summary:    no errors found\n")
+		default:
+			return &outputStdout, &outputStderr, errors.New("Unexpected command: " + command)
+		}
+		return &outputStdout, &outputStderr, nil
+	}
+	GetBtrfsProfiles = func(uuid string, devices []string) (BtrfsProfilesStruct, error) {
+		return BtrfsProfilesStruct{Data: "raid1c3", Metadata: "raid1c3", System: "raid1c3"}, nil
+	}
+	GetBtrfsUsage = func(uuid string, devices []string) (BtrfsUsageStruct, error) {
+		return BtrfsUsageStruct{DataTotal: 2000000000000, DataUsed: 1900000000000, MetadataTotal: 4000000000, MetadataUsed: 3800000000, DeviceSize: 7600000000000, Unallocated: 1000000000, DataRatio: 3}, nil
+	}
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "SERIALNUMBER-" + diskDrive, "MODEL", "SATA", "HDD", nil
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		if path == "/sys/fs/btrfs/XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX/devices" {
+			return []string{"dm-0", "sdb"}, nil
+		}
+		return []string{}, errors.New("No such file or directory")
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		if path == "/sys/fs/btrfs/XXXXXX-XXXX-XXXX-XXXX-XXXXXXXX/devinfo/1/error_stats" {
+			return "write_errs 0\nread_errs 4\nflush_errs 0\ncorruption_errs 1\ngeneration_errs 0", nil
+		}
+		// Filesystem mounted through its second member
+		if path == "/proc/mounts" {
+			return "/dev/sda2 / ext4 rw,relatime 0 0\n/dev/mapper/luks-data /data btrfs rw,relatime,space_cache=v2 0 0", nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		if path == "/dev/mapper/luks-data" {
+			return "../dm-0", nil
+		}
+		return "", errors.New("Invalid argument")
+	}
+
+	newControllers, newRaids, err := ProcessBtrfsRaid("btrfs")
+	if err != nil {
+		t.Fatalf(`TestProcessBtrfsRaidMounted: error: %s`, err)
+	}
+	if newControllers[0].Status != "Bad" {
+		t.Fatalf(`TestProcessBtrfsRaidMounted: controller.Status: %v should be: Bad`, newControllers[0].Status)
+	}
+	if len(newRaids) != 1 || len(newRaids[0].Disks) != 3 {
+		t.Fatalf(`TestProcessBtrfsRaidMounted: raids: %v should be one raid with 3 disks`, newRaids)
+	}
+	raid := newRaids[0]
+	if raid.State != "Missing devices/Unallocated-Low/Metadata-Full" || raid.RaidType != "raid1c3" || raid.Size != "2.0 TB" {
+		t.Fatalf(`TestProcessBtrfsRaidMounted: raid: %v %v %v should be: Missing devices/Unallocated-Low/Metadata-Full raid1c3 2.0 TB`, raid.State, raid.RaidType, raid.Size)
+	}
+	detailsWanted := []string{"metadata raid1c3", "system raid1c3", "last scrub 2024-10-13 finished: no errors found", "data 1.9 TB/2.0 TB (95%)", "metadata 3.8 GB/4.0 GB (95%)", "unallocated 1.0 GB"}
+	if !reflect.DeepEqual(raid.Details, detailsWanted) {
+		t.Fatalf(`TestProcessBtrfsRaidMounted: raid.Details: %v should be: %v`, raid.Details, detailsWanted)
+	}
+
+	ioErrorsWanted := utils.IoErrorsStruct{Source: "btrfs", Read: 4, Checksum: 1}
+	if raid.Disks[0].State != "Warning" || raid.Disks[0].IoErrors != ioErrorsWanted {
+		t.Fatalf(`TestProcessBtrfsRaidMounted: sdb: %v %v should be: Warning %v`, raid.Disks[0].State, raid.Disks[0].IoErrors, ioErrorsWanted)
+	}
+	if raid.Disks[1].State != "ONLINE" || raid.Disks[1].IoErrors.Source != "btrfs" {
+		t.Fatalf(`TestProcessBtrfsRaidMounted: mapper/luks-data: %v %v should be ONLINE without errors`, raid.Disks[1].State, raid.Disks[1].IoErrors)
+	}
+	if raid.Disks[2].State != "MISSING" || raid.Disks[2].OsDevice != "missing-devid-3" {
+		t.Fatalf(`TestProcessBtrfsRaidMounted: missing disk: %v %v should be: MISSING missing-devid-3`, raid.Disks[2].State, raid.Disks[2].OsDevice)
+	}
+}
+
+// Test GetBtrfsUsage
+func TestGetBtrfsUsage(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	readSysfsFileOri := utils.ReadSysfsFile
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		utils.ReadSysfsFile = readSysfsFileOri
+	}()
+
+	// Mocked functions: mounted raid1 filesystem, its members were shrunk to 1000000000000 bytes
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		if path == "/proc/mounts" {
+			return "/dev/sda2 / ext4 rw,relatime 0 0\n/dev/sdd /backup btrfs rw,relatime,space_cache=v2 0 0", nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "filesystem usage -b /backup" {
+			return &outputStdout, &outputStderr, errors.New("Unexpected command: " + command)
+		}
+		outputStdout.WriteString(`Overall:
+    Device size:                  2000000000000
+    Device allocated:             1900000000000
+    Device unallocated:            100000000000
+    Device missing:                           0
+    Used:                         1810000000000
+    Free (estimated):              95000000000	(min: 95000000000)
+    Data ratio:                            2.00
+    Metadata ratio:                        2.00
+    Global reserve:                   536870912	(used: 0)
+    Multiple profiles:                       no
+
+Data,RAID1: Size:900000000000, Used:890000000000 (98.89%)
+   /dev/sdd	900000000000
+   /dev/sde	900000000000
+
+Metadata,RAID1: Size:50000000000, Used:15000000000 (30.00%)
+   /dev/sdd	50000000000
+   /dev/sde	50000000000
+
+System,RAID1: Size:33554432, Used:180224 (0.54%)
+   /dev/sdd	33554432
+   /dev/sde	33554432
+
+Unallocated:
+   /dev/sdd	50000000000
+   /dev/sde	50000000000
+`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	// Filesystem usage is read through its mountpoint
+	usage, err := GetBtrfsUsage("YYYYYY-YYYY-YYYY-YYYY-YYYYYYYY", []string{"sdd", "sde"})
+	if err != nil {
+		t.Fatalf(`TestGetBtrfsUsage: error: %s`, err)
+	}
+	usageWanted := BtrfsUsageStruct{DataTotal: 900000000000, DataUsed: 890000000000, MetadataTotal: 50000000000, MetadataUsed: 15000000000, DeviceSize: 2000000000000, Unallocated: 100000000000, DataRatio: 2}
+	if !reflect.DeepEqual(usage, usageWanted) {
+		t.Fatalf(`TestGetBtrfsUsage filesystem usage: %v should be: %v`, usage, usageWanted)
+	}
+	if getBtrfsUsableSize(usage) != 950000000000 {
+		t.Fatalf(`TestGetBtrfsUsage usable size: %v should be: 950000000000`, getBtrfsUsableSize(usage))
+	}
+
+	// Unmounted filesystem
+	_, err = GetBtrfsUsage("ZZZZZZ-ZZZZ-ZZZZ-ZZZZ-ZZZZZZZZ", []string{"sdf"})
+	if err == nil {
+		t.Fatalf(`TestGetBtrfsUsage: unmounted filesystem should return an error`)
+	}
+}
+
+// Test getUsageHealth
+func TestGetUsageHealth(t *testing.T) {
+	usages := []struct {
+		usage BtrfsUsageStruct
+		flags []string
+	}{
+		{BtrfsUsageStruct{DataTotal: 100000000000, DataUsed: 90000000000, MetadataTotal: 2000000000, MetadataUsed: 1000000000, Unallocated: 500000000000, DataRatio: 1}, []string{}},
+		{BtrfsUsageStruct{DataTotal: 100000000000, DataUsed: 99000000000, MetadataTotal: 2000000000, MetadataUsed: 1000000000, Unallocated: 1000000000, DataRatio: 1}, []string{"Unallocated-Low"}},
+		{BtrfsUsageStruct{DataTotal: 100000000000, DataUsed: 90000000000, MetadataTotal: 2000000000, MetadataUsed: 1900000000, Unallocated: 500000000000, DataRatio: 1}, []string{"Metadata-Full"}},
+	}
+	for _, usage := range usages {
+		_, flags := getUsageHealth(usage.usage)
+		if !reflect.DeepEqual(flags, usage.flags) {
+			t.Fatalf(`TestGetUsageHealth: flags: %v should be: %v`, flags, usage.flags)
+		}
+	}
+}
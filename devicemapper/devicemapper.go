@@ -0,0 +1,400 @@
+package devicemapper
+
+// Device-mapper stack between disks and filesystems: crypt, integrity, cache, writecache, VDO and thin targets
+// Tables and status are read through DM_TABLE_STATUS ioctl on /dev/mapper/control, no dmsetup binary needed
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hardwareAnalyzer/utils"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	human "github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+)
+
+const (
+	dmTableStatus     = 0xC138FD0C
+	dmIoctlSize       = 312
+	dmTargetSpecSize  = 40
+	dmStatusTableFlag = 1 << 4
+	dmBufferFullFlag  = 1 << 8
+	dmSecureDataFlag  = 1 << 15
+)
+
+// Device-mapper target from DM_TABLE_STATUS: table or status params depending on request
+type DmTarget struct {
+	Start  uint64
+	Length uint64
+	Type   string
+	Params string
+}
+
+// Supported target types, other targets(linear, striped, raid, multipath) are handled by LVM/multipath code
+var supportedTargets = []string{"crypt", "integrity", "cache", "writecache", "vdo", "thin-pool", "thin"}
+
+// C string from fixed size buffer
+func cString(data []byte) string {
+	end := 0
+	for end < len(data) && data[end] != 0 {
+		end++
+	}
+	return string(data[:end])
+}
+
+// Parse dm_target_spec list following dm_ioctl header
+// Each spec next field is the offset from first spec to the following one
+func parseDmTargets(buffer []byte) []DmTarget {
+	targets := []DmTarget{}
+	if len(buffer) < dmIoctlSize {
+		return targets
+	}
+	dataStart := int(binary.NativeEndian.Uint32(buffer[16:20]))
+	targetCount := int(binary.NativeEndian.Uint32(buffer[20:24]))
+	offset := dataStart
+	for i := 0; i < targetCount; i++ {
+		if offset+dmTargetSpecSize > len(buffer) {
+			break
+		}
+		spec := buffer[offset:]
+		target := DmTarget{
+			Start:  binary.NativeEndian.Uint64(spec[0:8]),
+			Length: binary.NativeEndian.Uint64(spec[8:16]),
+			Type:   cString(spec[24:40]),
+			Params: cString(spec[dmTargetSpecSize:]),
+		}
+		// Never keep crypt keys: <cipher> <key> <iv_offset> <device> <offset>
+		if target.Type == "crypt" {
+			params := strings.Fields(target.Params)
+			if len(params) > 1 {
+				params[1] = "-"
+				target.Params = strings.Join(params, " ")
+			}
+		}
+		targets = append(targets, target)
+		offset = dataStart + int(binary.NativeEndian.Uint32(spec[20:24]))
+	}
+	return targets
+}
+
+// Read device-mapper device table(table=true) or status(table=false)
+// Function as variable in order to be possible to mock it from unitary tests
+var ReadDmTable = func(name string, table bool) ([]DmTarget, error) {
+	control, err := os.OpenFile("/dev/mapper/control", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer control.Close()
+
+	bufferSize := 16384
+	for {
+		buffer := make([]byte, bufferSize)
+		// dm_ioctl: version 4.0.0, data_size, data_start, flags, name
+		binary.NativeEndian.PutUint32(buffer[0:4], 4)
+		binary.NativeEndian.PutUint32(buffer[12:16], uint32(bufferSize))
+		binary.NativeEndian.PutUint32(buffer[16:20], dmIoctlSize)
+		flags := uint32(0)
+		if table {
+			flags = dmStatusTableFlag | dmSecureDataFlag
+		}
+		binary.NativeEndian.PutUint32(buffer[28:32], flags)
+		copy(buffer[48:48+127], name)
+
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, control.Fd(), dmTableStatus, uintptr(unsafe.Pointer(&buffer[0])))
+		runtime.KeepAlive(buffer)
+		if errno != 0 {
+			return nil, errno
+		}
+		if binary.NativeEndian.Uint32(buffer[28:32])&dmBufferFullFlag != 0 && bufferSize < 1<<20 {
+			bufferSize = bufferSize * 4
+			continue
+		}
+		return parseDmTargets(buffer), nil
+	}
+}
+
+// Used percentage from used/total fraction: 123/456, -1 when unknown
+func usagePercent(used, total string) int64 {
+	usedInt, err := strconv.ParseInt(used, 10, 64)
+	if err != nil {
+		return -1
+	}
+	totalInt, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || totalInt == 0 {
+		return -1
+	}
+	return usedInt * 100 / totalInt
+}
+
+func fractionPercent(fraction string) int64 {
+	used, total, found := strings.Cut(fraction, "/")
+	if !found {
+		return -1
+	}
+	return usagePercent(used, total)
+}
+
+// Describe device-mapper node from its table and status: crypt (aes-xts-plain64), thin pool 87% data used
+// Returned state: ONLINE, DEGRADED, FAILED
+func describeTarget(tableTarget, statusTarget DmTarget) (string, string) {
+	tableParams := strings.Fields(tableTarget.Params)
+	statusParams := strings.Fields(statusTarget.Params)
+	state := "ONLINE"
+	if statusTarget.Params == "Fail" {
+		return tableTarget.Type + " failed", "FAILED"
+	}
+
+	switch tableTarget.Type {
+	case "crypt":
+		cipher := "Unknown"
+		if len(tableParams) > 0 {
+			cipher = tableParams[0]
+		}
+		return "crypt (" + cipher + ")", state
+	case "integrity":
+		// <dev> <offset> <tag size> <mode>
+		description := "integrity"
+		if len(tableParams) > 3 {
+			modes := map[string]string{"J": "journal", "B": "bitmap", "D": "direct", "R": "recovery"}
+			mode, ok := modes[tableParams[3]]
+			if !ok {
+				mode = tableParams[3]
+			}
+			description = description + " (" + mode + ", " + tableParams[2] + " bytes tag)"
+		}
+		// <mismatches> <provided data sectors> <recalculate sector>
+		if len(statusParams) > 0 && statusParams[0] != "0" {
+			description = description + " " + statusParams[0] + " mismatches"
+			state = "DEGRADED"
+		}
+		return description, state
+	case "cache":
+		// <metadata block size> <used>/<total metadata> <cache block size> <used>/<total cache> <read hits> <read misses>
+		// <write hits> <write misses> <demotions> <promotions> <dirty> <#features> <features>* ... <metadata mode> <needs_check>
+		description := "cache"
+		if len(statusParams) > 13 {
+			featuresCount, _ := strconv.Atoi(statusParams[11])
+			for _, feature := range statusParams[12:min(12+featuresCount, len(statusParams))] {
+				if feature == "writeback" || feature == "writethrough" || feature == "passthrough" {
+					description = description + " (" + feature + ")"
+				}
+			}
+			description = description + " " + strconv.FormatInt(fractionPercent(statusParams[3]), 10) + "% used, " + statusParams[10] + " dirty blocks"
+			if statusParams[len(statusParams)-2] == "ro" || statusParams[len(statusParams)-1] == "needs_check" {
+				description = description + ", metadata " + statusParams[len(statusParams)-2] + " " + statusParams[len(statusParams)-1]
+				state = "DEGRADED"
+			}
+		}
+		return description, state
+	case "writecache":
+		// <p|s> <origin> <cache> <block size>
+		description := "writecache"
+		if len(tableParams) > 0 {
+			if tableParams[0] == "p" {
+				description = description + " (pmem)"
+			} else {
+				description = description + " (ssd)"
+			}
+		}
+		// <error> <total blocks> <free blocks> <blocks under writeback>
+		if len(statusParams) > 3 {
+			totalBlocks, _ := strconv.ParseInt(statusParams[1], 10, 64)
+			freeBlocks, _ := strconv.ParseInt(statusParams[2], 10, 64)
+			description = description + " " + strconv.FormatInt(usagePercent(strconv.FormatInt(totalBlocks-freeBlocks, 10), statusParams[1]), 10) + "% used, " + statusParams[3] + " blocks under writeback"
+			if statusParams[0] != "0" {
+				description = description + ", error " + statusParams[0]
+				state = "FAILED"
+			}
+		}
+		return description, state
+	case "vdo":
+		// <device> <operating mode> <in recovery> <index state> <compression state> <physical blocks used> <total physical blocks>
+		description := "vdo"
+		if len(statusParams) > 6 {
+			description = description + " (" + statusParams[1] + ") " + strconv.FormatInt(usagePercent(statusParams[5], statusParams[6]), 10) + "% used, compression " + statusParams[4]
+			if statusParams[1] != "normal" {
+				state = "DEGRADED"
+			}
+		}
+		return description, state
+	case "thin-pool":
+		// <transaction id> <used>/<total metadata blocks> <used>/<total data blocks> <held metadata root> ro|rw|out_of_data_space ...
+		description := "thin pool"
+		if len(statusParams) > 4 {
+			description = description + " " + strconv.FormatInt(fractionPercent(statusParams[2]), 10) + "% data used, " + strconv.FormatInt(fractionPercent(statusParams[1]), 10) + "% metadata used"
+			if statusParams[4] != "rw" {
+				description = description + ", " + statusParams[4]
+				state = "DEGRADED"
+			}
+			for _, statusParam := range statusParams[5:] {
+				if statusParam == "needs_check" {
+					description = description + ", needs_check"
+					state = "DEGRADED"
+				}
+			}
+		}
+		return description, state
+	case "thin":
+		// <pool dev> <dev id>, status: <nr mapped sectors> <highest mapped sector>
+		description := "thin volume"
+		if len(tableParams) > 1 {
+			description = description + " id " + tableParams[1]
+		}
+		if len(statusParams) > 0 {
+			mappedSectors, err := strconv.ParseUint(statusParams[0], 10, 64)
+			if err == nil {
+				description = description + " " + human.Bytes(mappedSectors*512) + " mapped"
+			}
+		}
+		return description, state
+	}
+	return tableTarget.Type, state
+}
+
+// Device-mapper device name: dm-3 -> vg0-pool_tdata, not device-mapper devices are returned unmodified
+func getDmName(device string) string {
+	name, err := utils.ReadSysfsFile("/sys/block/" + device + "/dm/name")
+	if err != nil || len(name) == 0 {
+		return device
+	}
+	return name
+}
+
+// Underlying device of a device-mapper node: disk, partition, md or another dm device
+func getSlaveDisk(controllerId, slave string) utils.DiskStruct {
+	diskSize, err := utils.GetDiskPartitionSize(slave)
+	if err != nil {
+		color.Red("++ ERROR: GetDiskPartitionSize: %s", err)
+	}
+	disk := utils.DiskStruct{
+		ControllerId: controllerId,
+		State:        "ONLINE",
+		Size:         diskSize,
+		Medium:       "N/A",
+		SerialNumber: "N/A",
+		OsDevice:     slave,
+	}
+	switch {
+	case strings.HasPrefix(slave, "dm-"):
+		disk.Intf = "dm"
+		disk.Model = getDmName(slave)
+	case strings.HasPrefix(slave, "md"):
+		disk.Intf = "md"
+		disk.Model = "SoftRaid"
+	default:
+		disk.SerialNumber, disk.Model, disk.Intf, disk.Medium, err = utils.GetDiskData(slave)
+		if err != nil {
+			color.Red("++ ERROR: utils.GetDiskData: %s", err)
+		}
+	}
+	return disk
+}
+
+// Multipath maps and LVM volumes(thin pools, cache and raid sub-LVs included) are shown by its own backends
+func isForeignDm(blockDevice string) bool {
+	uuid, _ := utils.ReadSysfsFile("/sys/block/" + blockDevice + "/dm/uuid")
+	return strings.HasPrefix(uuid, "mpath-") || strings.HasPrefix(uuid, "LVM-")
+}
+
+// Check for device-mapper devices other than multipath maps and LVM volumes
+var CheckDeviceMapper = func() (bool, error) {
+	fmt.Println("> Checking device-mapper devices.")
+	blockDevices, err := utils.ReadSysfsDir("/sys/block")
+	if err != nil {
+		return false, err
+	}
+	for _, blockDevice := range blockDevices {
+		if !strings.HasPrefix(blockDevice, "dm-") {
+			continue
+		}
+		if !isForeignDm(blockDevice) {
+			color.Magenta("> Device-mapper devices detected.")
+			return true, nil
+		}
+	}
+	fmt.Println("> No device-mapper devices detected.")
+	return false, nil
+}
+
+// Each supported device-mapper node is shown as a raid of devicemapper-0 controller with its slaves as disks
+var ProcessDeviceMapper = func(manufacturer string) ([]utils.ControllerStruct, []utils.RaidStruct, error) {
+	fmt.Println("> Getting current device-mapper configuration.")
+	controllers := []utils.ControllerStruct{}
+	raids := []utils.RaidStruct{}
+	controllerId := manufacturer + "-0"
+
+	blockDevices, err := utils.ReadSysfsDir("/sys/block")
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong reading /sys/block: %v", err)
+		return controllers, raids, fmt.Errorf("Error: Something went wrong reading /sys/block: %v.", err)
+	}
+	for _, blockDevice := range blockDevices {
+		if !strings.HasPrefix(blockDevice, "dm-") {
+			continue
+		}
+		if isForeignDm(blockDevice) {
+			continue
+		}
+		name := getDmName(blockDevice)
+
+		tableTargets, err := ReadDmTable(name, true)
+		if err != nil || len(tableTargets) == 0 {
+			color.Red("++ ERROR: Something went wrong reading %s table: %v", name, err)
+			continue
+		}
+		if !slices.Contains(supportedTargets, tableTargets[0].Type) {
+			//fmt.Printf("%s target %s discarded.\n", name, tableTargets[0].Type)
+			continue
+		}
+		statusTargets, err := ReadDmTable(name, false)
+		if err != nil || len(statusTargets) == 0 {
+			color.Red("++ ERROR: Something went wrong reading %s status: %v", name, err)
+			statusTargets = []DmTarget{{Type: tableTargets[0].Type}}
+		}
+
+		description, state := describeTarget(tableTargets[0], statusTargets[0])
+		sectors := uint64(0)
+		for _, tableTarget := range tableTargets {
+			sectors = sectors + tableTarget.Length
+		}
+
+		raid := utils.RaidStruct{
+			ControllerId: controllerId,
+			Dg:           name,
+			State:        state,
+			Size:         human.Bytes(sectors * 512),
+			OsDevice:     blockDevice,
+		}
+		slaves, err := utils.ReadSysfsDir("/sys/block/" + blockDevice + "/slaves")
+		if err != nil {
+			color.Red("++ ERROR: Something went wrong reading %s slaves: %v", blockDevice, err)
+		}
+		slaveNames := []string{}
+		for _, slave := range slaves {
+			raid.AddDisk(getSlaveDisk(controllerId, slave))
+			slaveNames = append(slaveNames, getDmName(slave))
+		}
+		// crypt (aes-xts-plain64) over md0
+		raid.RaidType = description
+		if len(slaveNames) > 0 {
+			raid.RaidType = description + " over " + strings.Join(slaveNames, ", ")
+		}
+		raids = append(raids, raid)
+	}
+
+	if len(raids) > 0 {
+		controllers = append(controllers, utils.ControllerStruct{
+			Id:           controllerId,
+			Manufacturer: manufacturer,
+			Model:        "DEVICE-MAPPER",
+			Status:       "Good",
+		})
+	}
+	return controllers, raids, nil
+}
@@ -0,0 +1,203 @@
+package devicemapper
+
+import (
+	"encoding/binary"
+	"errors"
+	"hardwareAnalyzer/utils"
+	"reflect"
+	"testing"
+)
+
+// Build DM_TABLE_STATUS answer buffer with given targets
+func buildDmBuffer(targets []DmTarget) []byte {
+	buffer := make([]byte, 4096)
+	binary.NativeEndian.PutUint32(buffer[16:20], dmIoctlSize)
+	binary.NativeEndian.PutUint32(buffer[20:24], uint32(len(targets)))
+	offset := dmIoctlSize
+	for _, target := range targets {
+		spec := buffer[offset:]
+		binary.NativeEndian.PutUint64(spec[0:8], target.Start)
+		binary.NativeEndian.PutUint64(spec[8:16], target.Length)
+		copy(spec[24:40], target.Type)
+		copy(spec[dmTargetSpecSize:], target.Params)
+		// Specs are 8 bytes aligned
+		specLength := (dmTargetSpecSize + len(target.Params) + 1 + 7) / 8 * 8
+		offset = offset + specLength
+		binary.NativeEndian.PutUint32(spec[20:24], uint32(offset-dmIoctlSize))
+	}
+	return buffer
+}
+
+// Test parseDmTargets
+func TestParseDmTargets(t *testing.T) {
+	buffer := buildDmBuffer([]DmTarget{
+		{Start: 0, Length: 2048, Type: "crypt", Params: "aes-xts-plain64 7a5c2f8e1b9d4c3a 0 9:0 32768"},
+		{Start: 2048, Length: 4096, Type: "linear", Params: "8:3 0"},
+	})
+	targets := parseDmTargets(buffer)
+	// Crypt key must be discarded
+	targetsWanted := []DmTarget{
+		{Start: 0, Length: 2048, Type: "crypt", Params: "aes-xts-plain64 - 0 9:0 32768"},
+		{Start: 2048, Length: 4096, Type: "linear", Params: "8:3 0"},
+	}
+	if !reflect.DeepEqual(targets, targetsWanted) {
+		t.Fatalf(`TestParseDmTargets targets: %v should be: %v`, targets, targetsWanted)
+	}
+}
+
+// Test describeTarget
+func TestDescribeTarget(t *testing.T) {
+	testCases := []struct {
+		tableTarget       DmTarget
+		statusTarget      DmTarget
+		descriptionWanted string
+		stateWanted       string
+	}{
+		{
+			DmTarget{Type: "crypt", Params: "aes-xts-plain64 - 0 9:0 32768 1 allow_discards"},
+			DmTarget{Type: "crypt"},
+			"crypt (aes-xts-plain64)", "ONLINE",
+		},
+		{
+			DmTarget{Type: "integrity", Params: "8:2 0 4 J 6 journal_sectors:130944 interleave_sectors:32768 buffer_sectors:128 journal_watermark:50 commit_time:10000 internal_hash:crc32c"},
+			DmTarget{Type: "integrity", Params: "3 1953253376 -"},
+			"integrity (journal, 4 bytes tag) 3 mismatches", "DEGRADED",
+		},
+		{
+			DmTarget{Type: "cache", Params: "253:4 253:3 253:5 128 1 writeback smq 0"},
+			DmTarget{Type: "cache", Params: "8 117/4096 128 7200/16000 2345 678 901 234 0 7200 12 1 writeback 2 migration_threshold 2048 smq 0 rw -"},
+			"cache (writeback) 45% used, 12 dirty blocks", "ONLINE",
+		},
+		{
+			DmTarget{Type: "vdo", Params: "V4 /dev/sdb 262144 4096 32768 16380"},
+			DmTarget{Type: "vdo", Params: "/dev/sdb normal - online online 420 1000"},
+			"vdo (normal) 42% used, compression online", "ONLINE",
+		},
+		{
+			DmTarget{Type: "thin-pool", Params: "253:1 253:2 128 0 1 skip_block_zeroing"},
+			DmTarget{Type: "thin-pool", Params: "0 123/1000 870/1000 - rw discard_passdown queue_if_no_space - 1024"},
+			"thin pool 87% data used, 12% metadata used", "ONLINE",
+		},
+		{
+			DmTarget{Type: "thin-pool", Params: "253:1 253:2 128 0 0"},
+			DmTarget{Type: "thin-pool", Params: "0 123/1000 1000/1000 - out_of_data_space discard_passdown queue_if_no_space - 1024"},
+			"thin pool 100% data used, 12% metadata used, out_of_data_space", "DEGRADED",
+		},
+		{
+			DmTarget{Type: "thin", Params: "253:3 1"},
+			DmTarget{Type: "thin", Params: "Fail"},
+			"thin failed", "FAILED",
+		},
+	}
+	for _, testCase := range testCases {
+		description, state := describeTarget(testCase.tableTarget, testCase.statusTarget)
+		if description != testCase.descriptionWanted || state != testCase.stateWanted {
+			t.Fatalf(`TestDescribeTarget %v: %v %v should be: %v %v`, testCase.tableTarget.Type, description, state, testCase.descriptionWanted, testCase.stateWanted)
+		}
+	}
+}
+
+// Test ProcessDeviceMapper
+func TestProcessDeviceMapper(t *testing.T) {
+	// Copy original functions content
+	readDmTableOri := ReadDmTable
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsDirOri := utils.ReadSysfsDir
+	getDiskPartitionSizeOri := utils.GetDiskPartitionSize
+	getDiskDataOri := utils.GetDiskData
+	// unmock functions content
+	defer func() {
+		ReadDmTable = readDmTableOri
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsDir = readSysfsDirOri
+		utils.GetDiskPartitionSize = getDiskPartitionSizeOri
+		utils.GetDiskData = getDiskDataOri
+	}()
+
+	// Mocked functions: dm-0 LUKS over md0, dm-1 LVM linear volume, dm-2 multipath map, dm-3 LVM thin pool
+	sysfsFiles := map[string]string{
+		"/sys/block/dm-3/dm/name": "vg0-pool-tpool",
+		"/sys/block/dm-3/dm/uuid": "LVM-Qx2Vd1cE0Ii3aLbfd9KczXsrRr5x2ZkIaB3cD4eF5gH6iJ7kL8mN9oP0qR1sT2uV-tpool",
+		"/sys/block/dm-0/dm/name": "luks-data",
+		"/sys/block/dm-0/dm/uuid": "CRYPT-LUKS2-5f0e3c2a9b8d4e6f-luks-data",
+		"/sys/block/dm-1/dm/name": "vg0-root",
+		"/sys/block/dm-1/dm/uuid": "LVM-Qx2Vd1cE0Ii3aLbfd9KczXsrRr5x2ZkI",
+		"/sys/block/dm-2/dm/name": "mpatha",
+		"/sys/block/dm-2/dm/uuid": "mpath-3600a098038303053453f463045727a46",
+	}
+	sysfsDirs := map[string][]string{
+		"/sys/block":             {"sda", "md0", "dm-0", "dm-1", "dm-2", "dm-3"},
+		"/sys/block/dm-0/slaves": {"md0"},
+		"/sys/block/dm-1/slaves": {"sda2"},
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		if value, ok := sysfsFiles[path]; ok {
+			return value, nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		if entries, ok := sysfsDirs[path]; ok {
+			return entries, nil
+		}
+		return nil, errors.New("No such file or directory")
+	}
+	ReadDmTable = func(name string, table bool) ([]DmTarget, error) {
+		switch name {
+		case "luks-data":
+			if table {
+				return []DmTarget{{Start: 0, Length: 1953253376, Type: "crypt", Params: "aes-xts-plain64 - 0 9:0 32768"}}, nil
+			}
+			return []DmTarget{{Start: 0, Length: 1953253376, Type: "crypt"}}, nil
+		case "vg0-root":
+			return []DmTarget{{Start: 0, Length: 41943040, Type: "linear", Params: "8:2 2048"}}, nil
+		case "vg0-pool-tpool":
+			return []DmTarget{{Start: 0, Length: 209715200, Type: "thin-pool", Params: "253:4 253:5 128 0 0"}}, nil
+		}
+		return nil, errors.New("No such device or address")
+	}
+	utils.GetDiskPartitionSize = func(diskDrive string) (string, error) {
+		return "1.0 TB", nil
+	}
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "Unknown", "Unknown", "Unknown", "Unknown", nil
+	}
+
+	check, err := CheckDeviceMapper()
+	if err != nil || !check {
+		t.Fatalf(`TestProcessDeviceMapper CheckDeviceMapper: %v %v should be: true nil`, check, err)
+	}
+
+	controllers, raids, err := ProcessDeviceMapper("devicemapper")
+	if err != nil {
+		t.Fatalf(`TestProcessDeviceMapper returned error: %s`, err)
+	}
+	controllersWanted := []utils.ControllerStruct{
+		{Id: "devicemapper-0", Manufacturer: "devicemapper", Model: "DEVICE-MAPPER", Status: "Good"},
+	}
+	if !reflect.DeepEqual(controllers, controllersWanted) {
+		t.Fatalf(`TestProcessDeviceMapper controllers: %v should be: %v`, controllers, controllersWanted)
+	}
+	raidsWanted := []utils.RaidStruct{
+		{
+			ControllerId: "devicemapper-0",
+			Dg:           "luks-data",
+			RaidType:     "crypt (aes-xts-plain64) over md0",
+			State:        "ONLINE",
+			Size:         "1.0 TB",
+			OsDevice:     "dm-0",
+			Disks: []utils.DiskStruct{
+				{ControllerId: "devicemapper-0", State: "ONLINE", Size: "1.0 TB", Intf: "md", Medium: "N/A", Model: "SoftRaid", SerialNumber: "N/A", OsDevice: "md0"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(raids, raidsWanted) {
+		t.Fatalf(`TestProcessDeviceMapper raids: %v should be: %v`, raids, raidsWanted)
+	}
+	// Only LVM and multipath devices: nothing to show
+	sysfsDirs["/sys/block"] = []string{"sda", "dm-1", "dm-2", "dm-3"}
+	check, err = CheckDeviceMapper()
+	if err != nil || check {
+		t.Fatalf(`TestProcessDeviceMapper CheckDeviceMapper: %v %v should be: false nil`, check, err)
+	}
+}
@@ -0,0 +1,215 @@
+package filesystems
+
+import (
+	"fmt"
+	"hardwareAnalyzer/utils"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/fatih/color"
+)
+
+// Mounted filesystems from /proc/self/mountinfo joined with statfs usage
+// Only local filesystems are checked, network mounts could hang statfs calls
+
+// Read-only image filesystems are always full and read-only, them are not reported
+var skippedTypes = []string{"squashfs", "iso9660", "udf", "erofs"}
+
+// Function as variable in order to be able to mock it from unit tests
+var Statfs = func(path string) (syscall.Statfs_t, error) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(path, &stat)
+	return stat, err
+}
+
+type mountInfo struct {
+	majorMinor   string
+	mountpoint   string
+	options      string
+	fsType       string
+	source       string
+	superOptions string
+}
+
+// 36 25 8:1 / /boot rw,relatime shared:7 - ext4 /dev/sda1 rw,errors=remount-ro
+// Optional fields are variable, fields after the - separator are filesystem type, source and superblock options
+func parseMountinfo(mountinfo string) []mountInfo {
+	mounts := []mountInfo{}
+	unescape := strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+	for _, line := range strings.Split(mountinfo, "\n") {
+		fields := strings.Fields(line)
+		separator := slices.Index(fields, "-")
+		if separator < 6 || separator+1 >= len(fields) {
+			continue
+		}
+		mount := mountInfo{
+			majorMinor: fields[2],
+			mountpoint: unescape.Replace(fields[4]),
+			options:    fields[5],
+			fsType:     fields[separator+1],
+		}
+		if separator+2 < len(fields) {
+			mount.source = unescape.Replace(fields[separator+2])
+		}
+		if separator+3 < len(fields) {
+			mount.superOptions = fields[separator+3]
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts
+}
+
+// LVM device-mapper names double its dashes: my--vg-my--lv -> my-vg/my-lv
+func getLvmName(dmName string) string {
+	for i := 0; i < len(dmName); i++ {
+		if dmName[i] != '-' {
+			continue
+		}
+		if i+1 < len(dmName) && dmName[i+1] == '-' {
+			i++
+			continue
+		}
+		return strings.ReplaceAll(dmName[:i], "--", "-") + "/" + strings.ReplaceAll(dmName[i+1:], "--", "-")
+	}
+	return dmName
+}
+
+// Kernel device under a mount: /sys/dev/block/253:0 -> dm-0
+// Btrfs mounts use an anonymous major:minor, its source device is resolved instead
+func getKernelDevice(mount mountInfo) string {
+	link, err := utils.ReadSysfsLink("/sys/dev/block/" + mount.majorMinor)
+	if err == nil {
+		return filepath.Base(link)
+	}
+	link, err = utils.ReadSysfsLink(mount.source)
+	if err == nil {
+		return filepath.Base(link)
+	}
+	return filepath.Base(mount.source)
+}
+
+// Topology layer a filesystem sits on and its device name
+func getBackingDevice(mount mountInfo, kernelDevice string) (string, string) {
+	switch {
+	case mount.fsType == "zfs":
+		return "zfs", mount.source
+	case mount.fsType == "btrfs":
+		return "btrfs", kernelDevice
+	case strings.HasPrefix(kernelDevice, "dm-"):
+		name, err := utils.ReadSysfsFile("/sys/block/" + kernelDevice + "/dm/name")
+		if err != nil {
+			name = kernelDevice
+		}
+		uuid, _ := utils.ReadSysfsFile("/sys/block/" + kernelDevice + "/dm/uuid")
+		switch {
+		case strings.HasPrefix(uuid, "LVM-"):
+			return "lvm", getLvmName(name)
+		case strings.HasPrefix(uuid, "CRYPT-"):
+			return "dm-crypt", name
+		case strings.HasPrefix(uuid, "mpath-"):
+			return "multipath", name
+		}
+		return "dm", name
+	case strings.HasPrefix(kernelDevice, "md"):
+		return "md", kernelDevice
+	case strings.HasPrefix(kernelDevice, "zd"):
+		return "zvol", kernelDevice
+	}
+	return "disk", kernelDevice
+}
+
+func getPercent(used uint64, total uint64) int64 {
+	if total == 0 {
+		return 0
+	}
+	return int64(used * 100 / total)
+}
+
+// Usage, inodes and read-only state flags
+// Filesystems remounted read-only after IO errors keep rw mount options but its superblock options become ro
+func getFilesystemState(filesystem utils.MountedFilesystemStruct, kernelDevice string) string {
+	flags := []string{}
+	if getPercent(filesystem.Used, filesystem.Used+filesystem.Available) >= utils.Thresholds.FilesystemUsage {
+		flags = append(flags, "Usage-High")
+	}
+	// Btrfs and some other filesystems allocate inodes dynamically and report 0 inodes
+	if filesystem.Inodes > 0 && getPercent(filesystem.Inodes-filesystem.InodesFree, filesystem.Inodes) >= utils.Thresholds.FilesystemInodes {
+		flags = append(flags, "Inodes-High")
+	}
+	// Intentional read-only mounts have ro mount options too
+	if filesystem.ReadOnly && slices.Contains(strings.Split(filesystem.Options, ","), "rw") {
+		flags = append(flags, "Read-only")
+	}
+	// ext4 keeps errors count since mkfs
+	if strings.HasPrefix(filesystem.Type, "ext") {
+		errorsCount, err := utils.ReadSysfsFile("/sys/fs/ext4/" + kernelDevice + "/errors_count")
+		if err == nil && errorsCount != "0" {
+			flags = append(flags, "FS-Errors")
+		}
+	}
+	if len(flags) == 0 {
+		return "OK"
+	}
+	return strings.Join(flags, "/")
+}
+
+// Local mounted filesystems, bind mounts and Btrfs subvolumes sharing the same source are reported once
+var GetMountedFilesystems = func() ([]utils.MountedFilesystemStruct, error) {
+	fmt.Println("> Checking mounted filesystems.")
+	filesystems := []utils.MountedFilesystemStruct{}
+	mountinfo, err := utils.ReadSysfsFile("/proc/self/mountinfo")
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong reading /proc/self/mountinfo: %v", err)
+		return filesystems, fmt.Errorf("Error: Something went wrong reading /proc/self/mountinfo: %v.", err)
+	}
+
+	for _, mount := range parseMountinfo(mountinfo) {
+		// Pseudo filesystems(proc, tmpfs, overlay, nfs) have no block device: 0:XX
+		if strings.HasPrefix(mount.majorMinor, "0:") && mount.fsType != "btrfs" && mount.fsType != "zfs" {
+			continue
+		}
+		if slices.Contains(skippedTypes, mount.fsType) {
+			continue
+		}
+		index := slices.IndexFunc(filesystems, func(filesystem utils.MountedFilesystemStruct) bool {
+			return filesystem.Source == mount.source && filesystem.Type == mount.fsType
+		})
+		if index >= 0 {
+			filesystems[index].Mountpoints = append(filesystems[index].Mountpoints, mount.mountpoint)
+			continue
+		}
+
+		kernelDevice := getKernelDevice(mount)
+		// Loop devices are images, not storage
+		if strings.HasPrefix(kernelDevice, "loop") {
+			continue
+		}
+		filesystem := utils.MountedFilesystemStruct{
+			Mountpoints: []string{mount.mountpoint},
+			Source:      mount.source,
+			Type:        mount.fsType,
+			Options:     mount.options,
+			ReadOnly:    slices.Contains(strings.Split(mount.superOptions, ","), "ro"),
+		}
+		filesystem.Layer, filesystem.Device = getBackingDevice(mount, kernelDevice)
+
+		stat, err := Statfs(mount.mountpoint)
+		if err != nil {
+			color.Red("++ ERROR: statfs %s: %v", mount.mountpoint, err)
+			filesystem.State = "Unknown"
+			filesystems = append(filesystems, filesystem)
+			continue
+		}
+		blockSize := uint64(stat.Bsize)
+		filesystem.Size = stat.Blocks * blockSize
+		filesystem.Used = (stat.Blocks - stat.Bfree) * blockSize
+		filesystem.Available = stat.Bavail * blockSize
+		filesystem.Inodes = stat.Files
+		filesystem.InodesFree = stat.Ffree
+		filesystem.State = getFilesystemState(filesystem, kernelDevice)
+		filesystems = append(filesystems, filesystem)
+	}
+	return filesystems, nil
+}
@@ -0,0 +1,274 @@
+package filesystems
+
+import (
+	"errors"
+	"hardwareAnalyzer/utils"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+// Test getLvmName
+func TestGetLvmName(t *testing.T) {
+	tests := map[string]string{
+		"vg0-root":         "vg0/root",
+		"my--vg-my--lv":    "my-vg/my-lv",
+		"data--vg-backups": "data-vg/backups",
+		"nodash":           "nodash",
+	}
+	for dmName, want := range tests {
+		got := getLvmName(dmName)
+		if got != want {
+			t.Errorf("getLvmName(%s): %v should be: %v", dmName, got, want)
+		}
+	}
+}
+
+// Test GetMountedFilesystems
+func TestGetMountedFilesystems(t *testing.T) {
+	// Copy original functions content
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsLinkOri := utils.ReadSysfsLink
+	statfsOri := Statfs
+	// unmock functions content
+	defer func() {
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+		Statfs = statfsOri
+	}()
+
+	mountinfo := `22 1 0:21 / /proc rw,nosuid,nodev,noexec,relatime shared:12 - proc proc rw
+25 1 253:0 / / rw,relatime shared:1 - ext4 /dev/mapper/vg0-root rw,errors=remount-ro
+26 25 9:0 / /srv rw,relatime shared:2 - xfs /dev/md0 rw,attr2,inode64
+27 25 0:45 /@data /data rw,relatime shared:3 - btrfs /dev/sdc rw,space_cache=v2,subvolid=256
+28 25 0:45 /@backup /backup rw,relatime shared:4 - btrfs /dev/sdc rw,space_cache=v2,subvolid=257
+29 25 0:50 / /tank rw,noatime shared:5 - zfs tank rw,xattr
+30 25 230:16 / /var/lib/vm\040images rw,relatime shared:6 - ext4 /dev/zd16 ro,errors=remount-ro
+31 25 7:0 / /snap/core rw,relatime shared:7 - squashfs /dev/loop0 ro
+32 25 7:1 / /mnt/image rw,relatime shared:8 - ext4 /dev/loop1 rw
+33 25 8:1 / /boot ro,relatime shared:9 - ext4 /dev/sda1 ro
+34 25 253:1 / /secure rw,relatime shared:10 - ext4 /dev/mapper/secure rw`
+
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		switch path {
+		case "/proc/self/mountinfo":
+			return mountinfo, nil
+		case "/sys/block/dm-0/dm/name":
+			return "vg0-root", nil
+		case "/sys/block/dm-0/dm/uuid":
+			return "LVM-abcdef", nil
+		case "/sys/block/dm-1/dm/name":
+			return "secure", nil
+		case "/sys/block/dm-1/dm/uuid":
+			return "CRYPT-LUKS2-0123-secure", nil
+		case "/sys/fs/ext4/dm-0/errors_count":
+			return "0", nil
+		case "/sys/fs/ext4/dm-1/errors_count":
+			return "3", nil
+		}
+		return "", errors.New("No such file")
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		links := map[string]string{
+			"/sys/dev/block/253:0":  "../../devices/virtual/block/dm-0",
+			"/sys/dev/block/253:1":  "../../devices/virtual/block/dm-1",
+			"/sys/dev/block/9:0":    "../../devices/virtual/block/md0",
+			"/sys/dev/block/230:16": "../../devices/virtual/block/zd16",
+			"/sys/dev/block/7:1":    "../../devices/virtual/block/loop1",
+			"/sys/dev/block/8:1":    "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda/sda1",
+			"/dev/sdc":              "sdc",
+		}
+		link, ok := links[path]
+		if !ok {
+			return "", errors.New("No such link")
+		}
+		return link, nil
+	}
+	Statfs = func(path string) (syscall.Statfs_t, error) {
+		stats := map[string]syscall.Statfs_t{
+			// 95% used
+			"/": {Bsize: 4096, Blocks: 1000, Bfree: 50, Bavail: 50, Files: 100, Ffree: 50},
+			// 95% inodes used
+			"/srv":               {Bsize: 4096, Blocks: 1000, Bfree: 500, Bavail: 500, Files: 100, Ffree: 5},
+			"/data":              {Bsize: 4096, Blocks: 1000, Bfree: 800, Bavail: 800, Files: 0, Ffree: 0},
+			"/tank":              {Bsize: 512, Blocks: 1000, Bfree: 900, Bavail: 900, Files: 1000, Ffree: 990},
+			"/var/lib/vm images": {Bsize: 4096, Blocks: 1000, Bfree: 900, Bavail: 900, Files: 100, Ffree: 90},
+			"/boot":              {Bsize: 4096, Blocks: 1000, Bfree: 900, Bavail: 900, Files: 100, Ffree: 90},
+		}
+		stat, ok := stats[path]
+		if !ok {
+			return stat, errors.New("Permission denied")
+		}
+		return stat, nil
+	}
+
+	want := []utils.MountedFilesystemStruct{
+		{
+			Mountpoints: []string{"/"},
+			Source:      "/dev/mapper/vg0-root",
+			Type:        "ext4",
+			Options:     "rw,relatime",
+			Layer:       "lvm",
+			Device:      "vg0/root",
+			Size:        4096000,
+			Used:        3891200,
+			Available:   204800,
+			Inodes:      100,
+			InodesFree:  50,
+			State:       "Usage-High",
+		},
+		{
+			Mountpoints: []string{"/srv"},
+			Source:      "/dev/md0",
+			Type:        "xfs",
+			Options:     "rw,relatime",
+			Layer:       "md",
+			Device:      "md0",
+			Size:        4096000,
+			Used:        2048000,
+			Available:   2048000,
+			Inodes:      100,
+			InodesFree:  5,
+			State:       "Inodes-High",
+		},
+		{
+			Mountpoints: []string{"/data", "/backup"},
+			Source:      "/dev/sdc",
+			Type:        "btrfs",
+			Options:     "rw,relatime",
+			Layer:       "btrfs",
+			Device:      "sdc",
+			Size:        4096000,
+			Used:        819200,
+			Available:   3276800,
+			State:       "OK",
+		},
+		{
+			Mountpoints: []string{"/tank"},
+			Source:      "tank",
+			Type:        "zfs",
+			Options:     "rw,noatime",
+			Layer:       "zfs",
+			Device:      "tank",
+			Size:        512000,
+			Used:        51200,
+			Available:   460800,
+			Inodes:      1000,
+			InodesFree:  990,
+			State:       "OK",
+		},
+		{
+			Mountpoints: []string{"/var/lib/vm images"},
+			Source:      "/dev/zd16",
+			Type:        "ext4",
+			Options:     "rw,relatime",
+			Layer:       "zvol",
+			Device:      "zd16",
+			Size:        4096000,
+			Used:        409600,
+			Available:   3686400,
+			Inodes:      100,
+			InodesFree:  90,
+			ReadOnly:    true,
+			State:       "Read-only",
+		},
+		{
+			Mountpoints: []string{"/boot"},
+			Source:      "/dev/sda1",
+			Type:        "ext4",
+			Options:     "ro,relatime",
+			Layer:       "disk",
+			Device:      "sda1",
+			Size:        4096000,
+			Used:        409600,
+			Available:   3686400,
+			Inodes:      100,
+			InodesFree:  90,
+			ReadOnly:    true,
+			State:       "OK",
+		},
+		{
+			Mountpoints: []string{"/secure"},
+			Source:      "/dev/mapper/secure",
+			Type:        "ext4",
+			Options:     "rw,relatime",
+			Layer:       "dm-crypt",
+			Device:      "secure",
+			State:       "Unknown",
+		},
+	}
+
+	filesystems, err := GetMountedFilesystems()
+	if err != nil {
+		t.Errorf("GetMountedFilesystems err: %v should be: nil", err)
+	}
+	if !reflect.DeepEqual(filesystems, want) {
+		t.Errorf("GetMountedFilesystems: %+v should be: %+v", filesystems, want)
+	}
+}
+
+// Test getFilesystemState ext4 errors and several flags
+func TestGetFilesystemState(t *testing.T) {
+	// Copy original functions content
+	readSysfsFileOri := utils.ReadSysfsFile
+	thresholdsOri := utils.Thresholds
+	// unmock functions content
+	defer func() {
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.Thresholds = thresholdsOri
+	}()
+
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		if path == "/sys/fs/ext4/sdb1/errors_count" {
+			return "2", nil
+		}
+		return "", errors.New("No such file")
+	}
+	utils.Thresholds.FilesystemUsage = 50
+	utils.Thresholds.FilesystemInodes = 50
+
+	filesystem := utils.MountedFilesystemStruct{
+		Type:       "ext4",
+		Options:    "rw,relatime",
+		Used:       60,
+		Available:  40,
+		Inodes:     10,
+		InodesFree: 4,
+		ReadOnly:   true,
+	}
+	state := getFilesystemState(filesystem, "sdb1")
+	want := "Usage-High/Inodes-High/Read-only/FS-Errors"
+	if state != want {
+		t.Errorf("getFilesystemState: %v should be: %v", state, want)
+	}
+
+	filesystem.Used = 40
+	filesystem.Available = 60
+	filesystem.InodesFree = 6
+	filesystem.ReadOnly = false
+	state = getFilesystemState(filesystem, "sdc1")
+	if state != "OK" {
+		t.Errorf("getFilesystemState: %v should be: OK", state)
+	}
+}
+
+// Test GetMountedFilesystems mountinfo read error
+func TestGetMountedFilesystemsError(t *testing.T) {
+	// Copy original functions content
+	readSysfsFileOri := utils.ReadSysfsFile
+	// unmock functions content
+	defer func() {
+		utils.ReadSysfsFile = readSysfsFileOri
+	}()
+
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		return "", errors.New("No such file")
+	}
+	filesystems, err := GetMountedFilesystems()
+	if err == nil {
+		t.Errorf("GetMountedFilesystems err: %v should be: not nil", err)
+	}
+	if len(filesystems) != 0 {
+		t.Errorf("GetMountedFilesystems: %v should be: []", filesystems)
+	}
+}
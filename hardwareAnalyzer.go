@@ -1,7 +1,7 @@
 package main
 
 // Linux Raid/disks configuration detection tool with auto contained disk tools:
-// MegaRaid/PERC/SAS2IRCU/ADAPTEC/SoftRAID/ZFS/Btrfs/LVM/Disks Linux support.
+// MegaRaid/PERC/SAS2IRCU/SAS3IRCU/mpt3sas/ADAPTEC/HPE/NVMe/SoftRAID/ZFS/Btrfs/LVM/Device-mapper/Disks Linux support.
 
 // When net and user functions are involved Go compiles dynamically linked binaries
 // go-memexec module uses osusergo functionalities, so force to compile statically.
@@ -24,13 +24,23 @@ import (
 	"fmt"
 	"hardwareAnalyzer/adaptec"
 	"hardwareAnalyzer/btrfs"
+	"hardwareAnalyzer/devicemapper"
+	"hardwareAnalyzer/filesystems"
 	"hardwareAnalyzer/hardwarecontrollerscommon"
+	"hardwareAnalyzer/hpe"
+	"hardwareAnalyzer/kernellog"
 	"hardwareAnalyzer/lvm"
 	"hardwareAnalyzer/megaraidpercsas2ircu"
+	"hardwareAnalyzer/nvme"
+	"hardwareAnalyzer/partitions"
 	"hardwareAnalyzer/regulardisks"
+	"hardwareAnalyzer/smart"
 	"hardwareAnalyzer/softraid"
 	"hardwareAnalyzer/utils"
 	"hardwareAnalyzer/zfs"
+	"os"
+	"strconv"
+	"strings"
 
 	//"github.com/davecgh/go-spew/spew"
 
@@ -41,9 +51,23 @@ import (
 
 // We use init to initializar flags in order to not get the error: flag redefined when unit testing code
 var showInfo *bool
+var kernelLog *string
 
 func init() {
 	showInfo = flag.Bool("showInfo", false, "Show binary information.")
+	kernelLog = flag.String("kernelLog", "/dev/kmsg", "Kernel log to scan for storage errors: /dev/kmsg or a dmesg capture file.")
+	flag.Int64Var(&utils.Thresholds.SmartReallocatedSectors, "smartReallocatedMax", utils.Thresholds.SmartReallocatedSectors, "SMART reallocated sectors warning threshold.")
+	flag.Int64Var(&utils.Thresholds.SmartPendingSectors, "smartPendingMax", utils.Thresholds.SmartPendingSectors, "SMART pending sectors warning threshold.")
+	flag.Int64Var(&utils.Thresholds.SmartMediaErrors, "smartMediaErrorsMax", utils.Thresholds.SmartMediaErrors, "SMART media errors warning threshold.")
+	flag.Int64Var(&utils.Thresholds.SmartWearLevel, "smartWearMax", utils.Thresholds.SmartWearLevel, "SMART wear level(percentage used) warning threshold.")
+	flag.Int64Var(&utils.Thresholds.SmartTemperature, "smartTemperatureMax", utils.Thresholds.SmartTemperature, "SMART temperature(Celsius) warning threshold.")
+	flag.Int64Var(&utils.Thresholds.ThinPoolData, "thinDataMax", utils.Thresholds.ThinPoolData, "LVM thin pool data usage(percentage) warning threshold.")
+	flag.Int64Var(&utils.Thresholds.ThinPoolMetadata, "thinMetadataMax", utils.Thresholds.ThinPoolMetadata, "LVM thin pool metadata usage(percentage) warning threshold.")
+	flag.Int64Var(&utils.Thresholds.ZfsScrubDays, "zfsScrubMaxDays", utils.Thresholds.ZfsScrubDays, "ZFS pools days without scrub warning threshold.")
+	flag.Int64Var(&utils.Thresholds.BtrfsUnallocatedGiB, "btrfsUnallocatedMinGiB", utils.Thresholds.BtrfsUnallocatedGiB, "Btrfs filesystems unallocated space(GiB) warning threshold.")
+	flag.Int64Var(&utils.Thresholds.BtrfsMetadata, "btrfsMetadataMax", utils.Thresholds.BtrfsMetadata, "Btrfs filesystems metadata usage(percentage) warning threshold.")
+	flag.Int64Var(&utils.Thresholds.FilesystemUsage, "fsUsageMax", utils.Thresholds.FilesystemUsage, "Mounted filesystems usage(percentage) warning threshold.")
+	flag.Int64Var(&utils.Thresholds.FilesystemInodes, "fsInodesMax", utils.Thresholds.FilesystemInodes, "Mounted filesystems inodes usage(percentage) warning threshold.")
 }
 
 func checkHardware() (bool, bool, bool, bool, bool, bool, bool, bool) {
@@ -220,6 +244,68 @@ func inquireHardwareConfiguration(megaRaidCheck, percRaidCheck, sas2ircuRaidChec
 		}
 	}
 
+	// SAS3 HBAs, sas3ircu is not embedded so it is only used when installed in the system
+	color.Set(color.FgCyan)
+	fmt.Println("")
+	sas3ircuRaidCheck, err := megaraidpercsas2ircu.CheckSas3ircuRaid()
+	if err != nil {
+		color.Red("++ ERROR: %s", err)
+	}
+	if sas3ircuRaidCheck {
+		newControllers, newRaids, newNoRaidDisks, err := megaraidpercsas2ircu.ProcessHWSas2ircuRaid("sas3ircu")
+		if err != nil {
+			color.Red("++ ERROR: %s", err)
+		}
+
+		// Append controllers, raids and noraiddisks to already existent
+		if len(newControllers) > 0 {
+			for _, newController := range newControllers {
+				controllers = append(controllers, newController)
+			}
+		}
+		if len(newRaids) > 0 {
+			for _, newRaid := range newRaids {
+				raids = append(raids, newRaid)
+			}
+		}
+		if len(newNoRaidDisks) > 0 {
+			for _, newNoRaidDisk := range newNoRaidDisks {
+				noRaidDisks = append(noRaidDisks, newNoRaidDisk)
+			}
+		}
+	}
+
+	// mpt3sas HBAs not managed by storcli, sas2ircu or sas3ircu are read from driver sysfs
+	color.Set(color.FgCyan)
+	fmt.Println("")
+	sysfsSasHbaCheck, err := megaraidpercsas2ircu.CheckSysfsSasHba()
+	if err != nil {
+		color.Red("++ ERROR: %s", err)
+	}
+	if sysfsSasHbaCheck {
+		newControllers, newRaids, newNoRaidDisks, err := megaraidpercsas2ircu.ProcessSysfsSasHba("mpt3sas", controllers)
+		if err != nil {
+			color.Red("++ ERROR: %s", err)
+		}
+
+		// Append controllers, raids and noraiddisks to already existent
+		if len(newControllers) > 0 {
+			for _, newController := range newControllers {
+				controllers = append(controllers, newController)
+			}
+		}
+		if len(newRaids) > 0 {
+			for _, newRaid := range newRaids {
+				raids = append(raids, newRaid)
+			}
+		}
+		if len(newNoRaidDisks) > 0 {
+			for _, newNoRaidDisk := range newNoRaidDisks {
+				noRaidDisks = append(noRaidDisks, newNoRaidDisk)
+			}
+		}
+	}
+
 	color.Set(color.FgCyan)
 	if adaptecRaidCheck {
 		newControllers, newRaids, newNoRaidDisks, err := adaptec.ProcessHWAdaptecRaid("adaptec")
@@ -245,6 +331,37 @@ func inquireHardwareConfiguration(megaRaidCheck, percRaidCheck, sas2ircuRaidChec
 		}
 	}
 
+	// HPE Smart Array, ssacli is not embedded so it is only used when installed in the system
+	color.Set(color.FgCyan)
+	fmt.Println("")
+	ssacliRaidCheck, err := hpe.CheckSsacliRaid()
+	if err != nil {
+		color.Red("++ ERROR: %s", err)
+	}
+	if ssacliRaidCheck {
+		newControllers, newRaids, newNoRaidDisks, err := hpe.ProcessHWSsacliRaid("hpe")
+		if err != nil {
+			color.Red("++ ERROR: %s", err)
+		}
+
+		// Append controllers, raids and noraiddisks to already existent
+		if len(newControllers) > 0 {
+			for _, newController := range newControllers {
+				controllers = append(controllers, newController)
+			}
+		}
+		if len(newRaids) > 0 {
+			for _, newRaid := range newRaids {
+				raids = append(raids, newRaid)
+			}
+		}
+		if len(newNoRaidDisks) > 0 {
+			for _, newNoRaidDisk := range newNoRaidDisks {
+				noRaidDisks = append(noRaidDisks, newNoRaidDisk)
+			}
+		}
+	}
+
 	// Softraid requires extra steps like hardwarecontrollerscommon.CheckJbodDisks and hardwarecontrollerscommon.CheckHardRaidDisks in order to rename disks if required and fill model, medium disk info
 	color.Set(color.FgCyan)
 	if softRaidCheck {
@@ -394,9 +511,61 @@ func inquireHardwareConfiguration(megaRaidCheck, percRaidCheck, sas2ircuRaidChec
 		}
 	}
 
+	// NVMe disks, must be processed before regular disks in order to not be detected as motherboard disks
+	color.Set(color.FgCyan)
+	fmt.Println("")
+	nvmeCheck, err := nvme.CheckNvme()
+	if err != nil {
+		color.Red("++ ERROR: %s", err)
+	}
+	if nvmeCheck {
+		newControllers, newRaids, err := nvme.ProcessNvme("nvme")
+		if err != nil {
+			color.Red("++ ERROR: %s", err)
+		}
+
+		// Append controllers and raids to already existent
+		if len(newControllers) > 0 {
+			for _, newController := range newControllers {
+				controllers = append(controllers, newController)
+			}
+		}
+		if len(newRaids) > 0 {
+			for _, newRaid := range newRaids {
+				raids = append(raids, newRaid)
+			}
+		}
+	}
+
+	// Device-mapper stack: crypt, integrity, cache, VDO, thin
+	color.Set(color.FgCyan)
+	fmt.Println("")
+	deviceMapperCheck, err := devicemapper.CheckDeviceMapper()
+	if err != nil {
+		color.Red("++ ERROR: %s", err)
+	}
+	if deviceMapperCheck {
+		newControllers, newRaids, err := devicemapper.ProcessDeviceMapper("devicemapper")
+		if err != nil {
+			color.Red("++ ERROR: %s", err)
+		}
+
+		// Append controllers and raids to already existent
+		if len(newControllers) > 0 {
+			for _, newController := range newControllers {
+				controllers = append(controllers, newController)
+			}
+		}
+		if len(newRaids) > 0 {
+			for _, newRaid := range newRaids {
+				raids = append(raids, newRaid)
+			}
+		}
+	}
+
 	// Regular disks
 	color.Set(color.FgCyan)
-	newControllers, newRaids, err := regulardisks.ProcessRegularDisks(raids, noRaidDisks)
+	newControllers, newRaids, err = regulardisks.ProcessRegularDisks(raids, noRaidDisks)
 	if err != nil {
 		color.Red("++ ERROR: %s", err)
 	}
@@ -422,6 +591,141 @@ func inquireHardwareConfiguration(megaRaidCheck, percRaidCheck, sas2ircuRaidChec
 	return controllers, pools, volumeGroups, raids, noRaidDisks
 }
 
+// Collect SMART data of all physical disks: hardware raid disks through its controller, other disks natively
+func collectSmartData(controllers []utils.ControllerStruct, raids []utils.RaidStruct, noRaidDisks []utils.NoRaidDiskStruct) {
+	fmt.Println("> Getting disks SMART data.")
+	// Get SMART data of a hardware controller disk
+	adaptecSmart := make(map[string]map[string]utils.SmartStruct)
+	getControllerSmart := func(controllerId, eidSlot string) (utils.SmartStruct, bool) {
+		if len(eidSlot) == 0 {
+			return utils.SmartStruct{}, false
+		}
+		manufacturer := strings.Split(controllerId, "-")[0]
+		controllerIdData := strings.Split(controllerId, "-")
+		controllerNumber := controllerIdData[len(controllerIdData)-1]
+		switch manufacturer {
+		case "mega", "perc":
+			driveSmart, err := megaraidpercsas2ircu.GetMegaraidPercDriveSmart(manufacturer, controllerNumber, eidSlot)
+			if err != nil {
+				color.Red("++ ERROR Getting SMART data: %s", err)
+				return utils.SmartStruct{}, false
+			}
+			return driveSmart, true
+		case "adaptec":
+			if _, ok := adaptecSmart[controllerId]; !ok {
+				// Adaptec controllers starts with ID 1, but all other controllers with 0
+				controllerIdArcconf, _ := strconv.Atoi(controllerNumber)
+				drivesSmart, err := adaptec.GetAdaptecSmart(manufacturer, strconv.Itoa(controllerIdArcconf+1))
+				if err != nil {
+					color.Red("++ ERROR Getting SMART data: %s", err)
+				}
+				adaptecSmart[controllerId] = drivesSmart
+			}
+			driveSmart, ok := adaptecSmart[controllerId][eidSlot]
+			return driveSmart, ok
+		}
+		return utils.SmartStruct{}, false
+	}
+
+	for i := range raids {
+		for j := range raids[i].Disks {
+			disk := &raids[i].Disks[j]
+			if driveSmart, ok := getControllerSmart(disk.ControllerId, disk.EidSlot); ok {
+				utils.EvaluateSmart(&driveSmart)
+				disk.Smart = driveSmart
+				disk.State = smart.SmartState(disk.State, driveSmart)
+			}
+		}
+	}
+	for i := range noRaidDisks {
+		noRaidDisk := &noRaidDisks[i]
+		if driveSmart, ok := getControllerSmart(noRaidDisk.ControllerId, noRaidDisk.EidSlot); ok {
+			utils.EvaluateSmart(&driveSmart)
+			noRaidDisk.Smart = driveSmart
+			noRaidDisk.State = smart.SmartState(noRaidDisk.State, driveSmart)
+		}
+	}
+
+	// Motherboard, HBA/JBOD and software raid disks
+	smart.CollectDeviceSmart(raids, noRaidDisks)
+}
+
+// Turn on/off identify LED of selected disk using its controller tool or sysfs for HBA/JBOD/software raid disks
+func locateDisk(disk utils.LocateDiskStruct, controllers []utils.ControllerStruct, on bool) error {
+	manufacturer := strings.Split(disk.ControllerId, "-")[0]
+	for _, controller := range controllers {
+		if controller.Id == disk.ControllerId {
+			manufacturer = controller.Manufacturer
+			break
+		}
+	}
+	controllerIdData := strings.Split(disk.ControllerId, "-")
+	controllerId := controllerIdData[len(controllerIdData)-1]
+
+	switch {
+	case (manufacturer == "mega" || manufacturer == "perc") && len(disk.EidSlot) > 0:
+		return megaraidpercsas2ircu.LocateMegaraidPercDisk(manufacturer, controllerId, disk.EidSlot, on)
+	case manufacturer == "sas2ircu" && len(disk.EidSlot) > 0:
+		return megaraidpercsas2ircu.LocateSas2ircuDisk(controllerId, disk.EidSlot, on)
+	case manufacturer == "sas3ircu" && len(disk.EidSlot) > 0:
+		return megaraidpercsas2ircu.LocateSas3ircuDisk(controllerId, disk.EidSlot, on)
+	case manufacturer == "hpe" && len(disk.EidSlot) > 0:
+		return hpe.LocateSsacliDisk(controllerId, disk.EidSlot, on)
+	case manufacturer == "adaptec" && len(disk.EidSlot) > 0:
+		// Adaptec controllers starts with ID 1, but all other controllers with 0
+		controllerIdArcconf, err := strconv.Atoi(controllerId)
+		if err != nil {
+			return fmt.Errorf("Error: Incorrect controller id: %s.", disk.ControllerId)
+		}
+		return adaptec.LocateAdaptecDisk(manufacturer, strconv.Itoa(controllerIdArcconf+1), disk.EidSlot, on)
+	case len(disk.OsDevice) > 0:
+		return utils.LocateSysfsDisk(disk.OsDevice, on)
+	}
+	return fmt.Errorf("Error: Disk cant be located, no EID:Slot or OS device known.")
+}
+
+// locate command: hardwareAnalyzer locate -disk S3Z8NB0K123456|sdb|mega-0/252:1 -action on|off -confirm
+func runLocate(args []string) {
+	locateFlags := flag.NewFlagSet("locate", flag.ContinueOnError)
+	locateFlags.SetOutput(color.Output)
+	diskQuery := locateFlags.String("disk", "", "Disk serial number, OS device or controllerId/EID:Slot: S3Z8NB0K123456, sdb, mega-0/252:1")
+	action := locateFlags.String("action", "on", "Identify LED action: on|off")
+	confirm := locateFlags.Bool("confirm", false, "Confirm LED action, nothing is done without it.")
+	if err := locateFlags.Parse(args); err != nil {
+		return
+	}
+
+	if len(*diskQuery) == 0 || (*action != "on" && *action != "off") {
+		color.Red("++ ERROR: Usage: locate -disk S3Z8NB0K123456|sdb|mega-0/252:1 -action on|off -confirm")
+		fmt.Println("")
+		return
+	}
+	if !*confirm {
+		color.Red("++ ERROR: Refusing to turn %s identify LED of disk %s without -confirm flag.", *action, *diskQuery)
+		fmt.Println("")
+		return
+	}
+
+	megaRaidCheck, percRaidCheck, sas2ircuRaidCheck, adaptecRaidCheck, softRaidCheck, zfsRaidCheck, btrfsRaidCheck, lvmRaidCheck := checkHardware()
+	controllers, _, _, raids, noRaidDisks := inquireHardwareConfiguration(megaRaidCheck, percRaidCheck, sas2ircuRaidCheck, adaptecRaidCheck, softRaidCheck, zfsRaidCheck, btrfsRaidCheck, lvmRaidCheck)
+
+	fmt.Println("")
+	disk, err := utils.FindLocateDisk(*diskQuery, controllers, raids, noRaidDisks)
+	if err != nil {
+		color.Red("++ ERROR: %s", err)
+		fmt.Println("")
+		return
+	}
+	err = locateDisk(disk, controllers, *action == "on")
+	if err != nil {
+		color.Red("++ ERROR: %s", err)
+		fmt.Println("")
+		return
+	}
+	color.Green("> Identify LED turned %s: %s %s SN: %s => %s", *action, disk.ControllerId, disk.EidSlot, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+	fmt.Println("")
+}
+
 func main() {
 	version := "2.8"
 	codename := "Sistine Chapel"
@@ -431,10 +735,10 @@ func main() {
 
 	screen.MoveTopLeft()
 	screen.Clear()
-	fmt.Println("########################################################################################")
-	fmt.Printf("| HardwareAnalyzer v%v - CodeName: %v %v                                   |\n", version, codename, emoji.LatinCross)
-	fmt.Println("| Coded by kr0m - MegaRaid/PERC/SAS2IRCU/ADAPTEC/SoftRAID/ZFS/Btrfs/LVM/Disks support. |")
-	fmt.Println("########################################################################################")
+	fmt.Println("################################################################################################################################")
+	fmt.Printf("| HardwareAnalyzer v%v - CodeName: %v %v                                                                           |\n", version, codename, emoji.LatinCross)
+	fmt.Println("| Coded by kr0m - MegaRaid/PERC/SAS2IRCU/SAS3IRCU/mpt3sas/ADAPTEC/HPE/NVMe/SoftRAID/ZFS/Btrfs/LVM/Device-mapper/Disks support. |")
+	fmt.Println("################################################################################################################################")
 	fmt.Println("")
 
 	if !utils.IsRoot() {
@@ -452,6 +756,12 @@ func main() {
 		return
 	}
 
+	// locate command:
+	if len(os.Args) > 1 && os.Args[1] == "locate" {
+		runLocate(os.Args[2:])
+		return
+	}
+
 	// -info command:
 	flag.Parse()
 	if *showInfo {
@@ -459,17 +769,17 @@ func main() {
 
 		screen.MoveTopLeft()
 		screen.Clear()
-		fmt.Println("#################################################################################################################")
-		fmt.Printf("| HardwareAnalyzer v%v - CodeName: %v %v                                                            |\n", version, codename, emoji.LatinCross)
-		fmt.Println("| Coded by kr0m(https://alfaexploit.com) - MegaRaid/PERC/SAS2IRCU/ADAPTEC/SoftRAID/ZFS/Btrfs/LVM/Disks support. |")
-		fmt.Println("|   - storcli: Linux/x86-64-static v007.1408.0000.0000 Apr 16, 2020                                             |")
-		fmt.Println("|   - percCLI: Linux/x86-64-static Ver 007.0127.0000.0000 July 13, 2017                                         |")
-		fmt.Println("|   - sas2ircu: Linux/x86-64-static Version 20.00.00.00 (2014.09.18)                                            |")
-		fmt.Println("|   - arcconf: Linux/x86-64 Version 2.05 (B22932) - Statically repacked: packelf.sh                             |")
-		fmt.Println("|   - zpool: Linux/x86-64 Version zfs-2.1.5-1 - Statically repacked: packelf.sh                                 |")
-		fmt.Println("|   - btrfs: Linux/x86-64 Version 5.16.2-1 - Statically repacked: packelf.sh                                    |")
-		fmt.Println("|   - lvm: Linux/x86-64 Version 2.03.11(2) - Statically repacked: packelf.sh                                    |")
-		fmt.Println("#################################################################################################################")
+		fmt.Println("#########################################################################################################################################################")
+		fmt.Printf("| HardwareAnalyzer v%v - CodeName: %v %v                                                                                                    |\n", version, codename, emoji.LatinCross)
+		fmt.Println("| Coded by kr0m(https://alfaexploit.com) - MegaRaid/PERC/SAS2IRCU/SAS3IRCU/mpt3sas/ADAPTEC/HPE/NVMe/SoftRAID/ZFS/Btrfs/LVM/Device-mapper/Disks support. |")
+		fmt.Println("|   - storcli: Linux/x86-64-static v007.1408.0000.0000 Apr 16, 2020                                                                                     |")
+		fmt.Println("|   - percCLI: Linux/x86-64-static Ver 007.0127.0000.0000 July 13, 2017                                                                                 |")
+		fmt.Println("|   - sas2ircu: Linux/x86-64-static Version 20.00.00.00 (2014.09.18)                                                                                    |")
+		fmt.Println("|   - arcconf: Linux/x86-64 Version 2.05 (B22932) - Statically repacked: packelf.sh                                                                     |")
+		fmt.Println("|   - zpool: Linux/x86-64 Version zfs-2.1.5-1 - Statically repacked: packelf.sh                                                                         |")
+		fmt.Println("|   - btrfs: Linux/x86-64 Version 5.16.2-1 - Statically repacked: packelf.sh                                                                            |")
+		fmt.Println("|   - lvm: Linux/x86-64 Version 2.03.11(2) - Statically repacked: packelf.sh                                                                            |")
+		fmt.Println("#########################################################################################################################################################")
 		fmt.Println("")
 
 		return
@@ -479,7 +789,43 @@ func main() {
 
 	controllers, pools, volumeGroups, raids, noRaidDisks := inquireHardwareConfiguration(megaRaidCheck, percRaidCheck, sas2ircuRaidCheck, adaptecRaidCheck, softRaidCheck, zfsRaidCheck, btrfsRaidCheck, lvmRaidCheck)
 
+	// Disks identity sources
+	utils.SetDiskIdentities(raids, noRaidDisks)
+
+	// Disks transport and link speed
+	utils.CollectDiskTransports(raids, noRaidDisks)
+
+	// Disks health
+	collectSmartData(controllers, raids, noRaidDisks)
+
+	// Kernel log IO errors, link and controller resets
+	unattributedEvents := []utils.KernelEventStruct{}
+	kernelEvents, err := kernellog.ScanKernelLog(*kernelLog)
+	if err == nil {
+		unattributedEvents = kernellog.AttributeKernelEvents(kernelEvents, controllers, raids, noRaidDisks)
+	}
+
+	// OS visible enclosures, also maps OS disks to its bay
+	enclosures := utils.GetSysfsEnclosures(raids, noRaidDisks)
+
+	// OS disks partition tables and filesystems
+	layouts := []utils.DiskLayoutStruct{}
+	systemDisks, err := regulardisks.GetSystemDisks()
+	if err == nil {
+		layouts = partitions.GetDiskLayouts(systemDisks)
+	}
+
+	// Mounted filesystems usage
+	mountedFilesystems, err := filesystems.GetMountedFilesystems()
+	if err != nil {
+		color.Red("++ ERROR: %s", err)
+	}
+
 	// Show gathered raid info:
 	utils.ShowGatheredData(controllers, pools, volumeGroups, raids, noRaidDisks)
+	utils.ShowEnclosures(enclosures)
+	utils.ShowDiskLayouts(layouts)
+	utils.ShowMountedFilesystems(mountedFilesystems)
+	utils.ShowKernelEvents(unattributedEvents)
 	fmt.Println("")
 }
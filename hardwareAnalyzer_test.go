@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"hardwareAnalyzer/adaptec"
 	"hardwareAnalyzer/btrfs"
+	"hardwareAnalyzer/devicemapper"
+	"hardwareAnalyzer/hpe"
 	"hardwareAnalyzer/lvm"
 	"hardwareAnalyzer/megaraidpercsas2ircu"
+	"hardwareAnalyzer/nvme"
 	"hardwareAnalyzer/regulardisks"
 	"hardwareAnalyzer/softraid"
 	"hardwareAnalyzer/utils"
@@ -251,9 +254,19 @@ func TestInquireHardwareConfiguration(t *testing.T) {
 	processBtrfsRaidOri := btrfs.ProcessBtrfsRaid
 	processLVMRaidOri := lvm.ProcessLVMRaid
 	processRegularDisksOri := regulardisks.ProcessRegularDisks
+	checkSas3ircuRaidOri := megaraidpercsas2ircu.CheckSas3ircuRaid
+	checkSysfsSasHbaOri := megaraidpercsas2ircu.CheckSysfsSasHba
+	checkSsacliRaidOri := hpe.CheckSsacliRaid
+	checkNvmeOri := nvme.CheckNvme
+	checkDeviceMapperOri := devicemapper.CheckDeviceMapper
 
 	// unmock functions content
 	defer func() {
+		megaraidpercsas2ircu.CheckSas3ircuRaid = checkSas3ircuRaidOri
+		megaraidpercsas2ircu.CheckSysfsSasHba = checkSysfsSasHbaOri
+		hpe.CheckSsacliRaid = checkSsacliRaidOri
+		nvme.CheckNvme = checkNvmeOri
+		devicemapper.CheckDeviceMapper = checkDeviceMapperOri
 		megaraidpercsas2ircu.ProcessHWMegaraidPercRaid = processHWMegaraidPercRaidOri
 		megaraidpercsas2ircu.ProcessHWSas2ircuRaid = processHWSas2ircuRaidOri
 		adaptec.ProcessHWAdaptecRaid = processHWAdaptecRaidOri
@@ -361,6 +374,21 @@ func TestInquireHardwareConfiguration(t *testing.T) {
 		return controllers, volumeGroups, raids, nil
 	}
 
+	megaraidpercsas2ircu.CheckSas3ircuRaid = func() (bool, error) {
+		return false, nil
+	}
+	megaraidpercsas2ircu.CheckSysfsSasHba = func() (bool, error) {
+		return false, nil
+	}
+	hpe.CheckSsacliRaid = func() (bool, error) {
+		return false, nil
+	}
+	nvme.CheckNvme = func() (bool, error) {
+		return false, nil
+	}
+	devicemapper.CheckDeviceMapper = func() (bool, error) {
+		return false, nil
+	}
 	regulardisks.ProcessRegularDisks = func(raids []utils.RaidStruct, noRaidDisks []utils.NoRaidDiskStruct) ([]utils.ControllerStruct, []utils.RaidStruct, error) {
 		regularDiskControllers := []utils.ControllerStruct{}
 		regularDiskRaids := []utils.RaidStruct{}
@@ -515,3 +543,128 @@ func TestInquireHardwareConfiguration(t *testing.T) {
 		}
 	}
 }
+
+// Test main locate command without -confirm flag
+func TestMainLocateNoConfirm(t *testing.T) {
+	// Save original Args and restore on exit function
+	oldArgs := os.Args
+	defer func() {
+		os.Args = oldArgs
+	}()
+
+	// Configure new Args
+	os.Args = []string{"cmd", "locate", "-disk", "sdb", "-action", "on"}
+
+	// Copy original functions content
+	osStdoutOri := os.Stdout
+	osStderrOri := os.Stderr
+	colorOutputOri := color.Output
+	colorErrorOri := color.Error
+	locateMegaraidPercDiskOri := megaraidpercsas2ircu.LocateMegaraidPercDisk
+	// unmock functions content
+	defer func() {
+		megaraidpercsas2ircu.LocateMegaraidPercDisk = locateMegaraidPercDiskOri
+	}()
+
+	// Nothing must be executed without confirmation
+	megaraidpercsas2ircu.LocateMegaraidPercDisk = func(manufacturer, controllerId, eidSlot string, on bool) error {
+		t.Fatalf(`TestMainLocateNoConfirm: locate executed without confirmation`)
+		return nil
+	}
+
+	// All content written to w pipe, will be copied automatically to r pipe
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	os.Stderr = w
+	color.Output = w
+	color.Error = w
+
+	main()
+
+	// Close w pipe
+	w.Close()
+
+	// Restore Stdout/Stderr to normal output
+	os.Stdout = osStdoutOri
+	os.Stderr = osStderrOri
+	color.Output = colorOutputOri
+	color.Error = colorErrorOri
+
+	// Read all r pipe content
+	out, _ := io.ReadAll(r)
+
+	if !strings.Contains(string(out), "without -confirm flag") {
+		t.Fatalf(`TestMainLocateNoConfirm: refusal message not found`)
+	}
+}
+
+// Test locateDisk
+func TestLocateDisk(t *testing.T) {
+	// Copy original functions content
+	locateMegaraidPercDiskOri := megaraidpercsas2ircu.LocateMegaraidPercDisk
+	locateSas2ircuDiskOri := megaraidpercsas2ircu.LocateSas2ircuDisk
+	locateSas3ircuDiskOri := megaraidpercsas2ircu.LocateSas3ircuDisk
+	locateAdaptecDiskOri := adaptec.LocateAdaptecDisk
+	locateSsacliDiskOri := hpe.LocateSsacliDisk
+	// unmock functions content
+	defer func() {
+		megaraidpercsas2ircu.LocateMegaraidPercDisk = locateMegaraidPercDiskOri
+		megaraidpercsas2ircu.LocateSas2ircuDisk = locateSas2ircuDiskOri
+		megaraidpercsas2ircu.LocateSas3ircuDisk = locateSas3ircuDiskOri
+		adaptec.LocateAdaptecDisk = locateAdaptecDiskOri
+		hpe.LocateSsacliDisk = locateSsacliDiskOri
+	}()
+
+	// Mocked functions
+	calls := []string{}
+	megaraidpercsas2ircu.LocateMegaraidPercDisk = func(manufacturer, controllerId, eidSlot string, on bool) error {
+		calls = append(calls, fmt.Sprintf("%s %s %s %v", manufacturer, controllerId, eidSlot, on))
+		return nil
+	}
+	megaraidpercsas2ircu.LocateSas2ircuDisk = func(controllerId, eidSlot string, on bool) error {
+		calls = append(calls, fmt.Sprintf("sas2ircu %s %s %v", controllerId, eidSlot, on))
+		return nil
+	}
+	megaraidpercsas2ircu.LocateSas3ircuDisk = func(controllerId, eidSlot string, on bool) error {
+		calls = append(calls, fmt.Sprintf("sas3ircu %s %s %v", controllerId, eidSlot, on))
+		return nil
+	}
+	adaptec.LocateAdaptecDisk = func(manufacturer, controllerIdArcconf, eidSlot string, on bool) error {
+		calls = append(calls, fmt.Sprintf("%s %s %s %v", manufacturer, controllerIdArcconf, eidSlot, on))
+		return nil
+	}
+	hpe.LocateSsacliDisk = func(controllerId, eidSlot string, on bool) error {
+		calls = append(calls, fmt.Sprintf("hpe %s %s %v", controllerId, eidSlot, on))
+		return nil
+	}
+
+	controllers := []utils.ControllerStruct{
+		{Id: "perc-1", Manufacturer: "perc"},
+		{Id: "sas2ircu-0", Manufacturer: "sas2ircu"},
+		{Id: "sas3ircu-1", Manufacturer: "sas3ircu"},
+		{Id: "adaptec-0", Manufacturer: "adaptec"},
+		{Id: "hpe-0", Manufacturer: "hpe"},
+	}
+	disks := []utils.LocateDiskStruct{
+		{ControllerId: "perc-1", EidSlot: "32:4"},
+		{ControllerId: "sas2ircu-0", EidSlot: "1:2"},
+		{ControllerId: "sas3ircu-1", EidSlot: "2:5"},
+		{ControllerId: "adaptec-0", EidSlot: "0:1"},
+		{ControllerId: "hpe-0", EidSlot: "1I:1:3"},
+	}
+	for _, disk := range disks {
+		err := locateDisk(disk, controllers, true)
+		if err != nil {
+			t.Fatalf(`TestLocateDisk returned error: %s`, err)
+		}
+	}
+	callsWanted := []string{"perc 1 32:4 true", "sas2ircu 0 1:2 true", "sas3ircu 1 2:5 true", "adaptec 1 0:1 true", "hpe 0 1I:1:3 true"}
+	if strings.Join(calls, "|") != strings.Join(callsWanted, "|") {
+		t.Fatalf(`TestLocateDisk calls: %v should be: %v`, calls, callsWanted)
+	}
+
+	err := locateDisk(utils.LocateDiskStruct{ControllerId: "zfs-0"}, controllers, true)
+	if err == nil {
+		t.Fatalf(`TestLocateDisk should return error without EID:Slot and OS device`)
+	}
+}
@@ -85,7 +85,7 @@ var GetJbodOsDevice = func(manufacturer, controllerId, eidslot string) (string,
 			}
 		}
 		return "Unknown", nil
-	case "sas2ircu":
+	case "sas2ircu", "sas3ircu":
 		command := controllerId + " DISPLAY"
 		outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getJbodOsDevice", command)
 		if err != nil {
@@ -189,7 +189,7 @@ var GetRaidOSDevice = func(manufacturer, controllerId, dg string) (string, error
 			}
 		}
 		return "Unknown", nil
-	case "sas2ircu":
+	case "sas2ircu", "sas3ircu":
 		command := controllerId + " DISPLAY"
 		outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getRaidOSDevice", command)
 		if err != nil {
@@ -0,0 +1,554 @@
+package hpe
+
+import (
+	"bufio"
+	"fmt"
+	"hardwareAnalyzer/utils"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	human "github.com/dustin/go-humanize"
+
+	"github.com/fatih/color"
+)
+
+// HPE Smart Array/SmartRAID controllers through ssacli, it is not embedded so it is only executed when installed
+// Physical drives are identified by port:box:bay: 1I:1:3
+
+var CheckSsacliRaid = func() (bool, error) {
+	fmt.Println("> Checking HPE Smart Array RAID controller.")
+	command := "ctrl all show"
+	outputStdout, outputStderr, err := utils.GetCommandOutput("hpe", "checkSsacliRaid", command)
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+	if err != nil {
+		// System only tool could be not installed, its not an error
+		if strings.HasPrefix(err.Error(), "Cant find") {
+			fmt.Println("> No SSACLI tool installed.")
+			return false, nil
+		}
+		// When theres no Smart Array controller in the system, "exit status 1" is returned, its not an error
+		if err.Error() == "exit status 1" {
+			fmt.Println("> No HPE Smart Array RAID controller detected.")
+			return false, nil
+		}
+		//color.Red("++ ERROR: Something went wrong executing command %s: %v", command, err)
+		return false, fmt.Errorf("Something went wrong executing command %s: %v", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		//color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return false, fmt.Errorf("Something went wrong executing command: %s.", command)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		//fmt.Println("line: ", line)
+		// Smart Array P440ar in Slot 0 (Embedded)    (sn: PDNLH0BRH7V7YK)
+		if controllerRegexp.MatchString(line) {
+			color.Magenta("> HPE Smart Array RAID controller detected.")
+			return true, nil
+		}
+	}
+	fmt.Println("> No HPE Smart Array RAID controller detected.")
+	return false, nil
+}
+
+// Smart Array P440ar in Slot 0 (Embedded), HPE Smart Array P408i-a SR Gen10 in Slot 0 (Embedded), Smart HBA H240 in Slot 1
+var controllerRegexp = regexp.MustCompile(`^(.+?) in Slot (\S+)`)
+
+// Internal Drive Cage at Port 1I, Box 1, OK - Storage Enclosure at Port 1E, Box 1, OK
+var enclosureRegexp = regexp.MustCompile(`(?:Drive Cage|Storage Enclosure) at Port (\S+), Box (\d+), (.+)$`)
+
+// Logical drive members: physicaldrive 1I:1:1 (port 1I:box 1:bay 1, SATA SSD, 240 GB, OK)
+var physicalDriveRegexp = regexp.MustCompile(`^physicaldrive (\S+)(\s+\(.*\))?$`)
+
+// Physical drive data as shown in its physicaldrive section
+type physicalDrive struct {
+	eidSlot      string
+	array        string
+	status       string
+	driveType    string
+	intf         string
+	medium       string
+	size         string
+	model        string
+	serialNumber string
+	wwid         string
+	osDevice     string
+	exposed      bool
+	bay          string
+}
+
+// ssacli sizes: logical drives are reported in binary units, physical drives in decimal ones
+func getSsacliSize(size string, binary bool) string {
+	size = strings.TrimSpace(size)
+	if binary {
+		size = strings.TrimSuffix(size, "B") + "iB"
+	}
+	sizeBytes, err := human.ParseBytes(size)
+	if err != nil {
+		return "Unknown"
+	}
+	return human.Bytes(sizeBytes)
+}
+
+// Interface Type: SAS, SATA, Solid State SAS, Solid State SATA, NVMe
+func getSsacliInterface(interfaceType string) (string, string) {
+	medium := "HDD"
+	if strings.HasPrefix(interfaceType, "Solid State") || interfaceType == "NVMe" {
+		medium = "SSD"
+	}
+	intfFields := strings.Fields(interfaceType)
+	if len(intfFields) == 0 {
+		return "Unknown", "Unknown"
+	}
+	return intfFields[len(intfFields)-1], medium
+}
+
+// Fault Tolerance: 0, 1, 1+0, 5, 6 (ADG), 1 (ADM), 50, 60
+func getSsacliRaidType(faultTolerance string) string {
+	raidType := strings.TrimSpace(strings.Split(faultTolerance, "(")[0])
+	if strings.Contains(faultTolerance, "ADM") {
+		// Triple mirror
+		raidType = raidType + "ADM"
+	}
+	return "RAID" + strings.ReplaceAll(raidType, "+", "")
+}
+
+// OS device from WWN: /dev/disk/by-id/wwn-0x600508b1001c5f1a8d5b8c3d2e1f0a9b -> ../../sda
+func getWwnOsDevice(wwn string) string {
+	if len(wwn) == 0 {
+		return "Unknown"
+	}
+	link, err := utils.ReadSysfsLink("/dev/disk/by-id/wwn-0x" + strings.ToLower(wwn))
+	if err != nil {
+		return "Unknown"
+	}
+	return filepath.Base(link)
+}
+
+// Disk Name: /dev/sda -> sda
+func getDiskName(diskName string) string {
+	diskNameFields := strings.Fields(diskName)
+	if len(diskNameFields) == 0 {
+		return "Unknown"
+	}
+	return strings.TrimPrefix(diskNameFields[0], "/dev/")
+}
+
+// 1I:1:3 -> Port 1I Box 1 Bay 3
+func getSsacliBay(eidSlot string) string {
+	eidSlotData := strings.Split(eidSlot, ":")
+	if len(eidSlotData) != 3 {
+		return ""
+	}
+	return "Port " + eidSlotData[0] + " Box " + eidSlotData[1] + " Bay " + eidSlotData[2]
+}
+
+// Model: ATA     INTEL SSDSC2BB24 -> INTEL SSDSC2BB24, HP      EG0600FBDSR -> HP EG0600FBDSR
+func getSsacliModel(model string) string {
+	modelFields := strings.Fields(model)
+	if len(modelFields) > 1 && modelFields[0] == "ATA" {
+		modelFields = modelFields[1:]
+	}
+	if len(modelFields) == 0 {
+		return "Unknown"
+	}
+	return strings.Join(modelFields, " ")
+}
+
+// Turn on/off disk identify LED: ssacli ctrl slot=0 pd 1I:1:3 modify led=on|off
+// Function as variable in order to be possible to mock it from unitary tests
+var LocateSsacliDisk = func(controllerId, eidSlot string, on bool) error {
+	//fmt.Println("-- locateSsacliDisk --")
+	action := "off"
+	if on {
+		action = "on"
+	}
+	command := "ctrl slot=" + controllerId + " pd " + eidSlot + " modify led=" + action
+	outputStdout, outputStderr, err := utils.GetCommandOutput("hpe", "locateSsacliDisk", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	// ssacli is silent on success
+	if strings.Contains(outputStdout.String(), "Error:") {
+		color.Red("++ ERROR: Command %s failed.", command)
+		return fmt.Errorf("Error: Command %s failed.", command)
+	}
+	return nil
+}
+
+// Controller, raids and disks from parsed controller data
+// Logical drives members are taken from its member list, drives of the same array are shared by all its logical drives
+// RAID5/6 logical drives have no member list, its members are the data drives of its array
+// Controller is Bad when any logical or physical drive is not OK
+func buildSsacliController(controller utils.ControllerStruct, logicalDrives []utils.RaidStruct, logicalDriveMembers map[string][]string, logicalDriveArrays map[string]string, physicalDrives []physicalDrive) (utils.ControllerStruct, []utils.RaidStruct, []utils.NoRaidDiskStruct) {
+	noRaidDisks := []utils.NoRaidDiskStruct{}
+	physicalDrivesByEidSlot := make(map[string]physicalDrive)
+	for _, drive := range physicalDrives {
+		physicalDrivesByEidSlot[drive.eidSlot] = drive
+	}
+
+	for i := range logicalDrives {
+		raid := &logicalDrives[i]
+		members := logicalDriveMembers[raid.Dg]
+		if len(members) == 0 {
+			for _, drive := range physicalDrives {
+				if len(drive.array) > 0 && drive.array == logicalDriveArrays[raid.Dg] && drive.driveType != "Spare Drive" {
+					members = append(members, drive.eidSlot)
+				}
+			}
+		}
+		for _, eidSlot := range members {
+			drive, ok := physicalDrivesByEidSlot[eidSlot]
+			if !ok {
+				drive = physicalDrive{eidSlot: eidSlot, status: "Unknown", intf: "Unknown", medium: "Unknown", size: "Unknown", model: "Unknown", serialNumber: "Unknown"}
+			}
+			diskState := drive.status
+			if diskState == "OK" {
+				diskState = "Online"
+			}
+			raid.AddDisk(utils.DiskStruct{
+				ControllerId: controller.Id,
+				Dg:           raid.Dg,
+				EidSlot:      drive.eidSlot,
+				State:        diskState,
+				Size:         drive.size,
+				Intf:         drive.intf,
+				Medium:       drive.medium,
+				Model:        drive.model,
+				SerialNumber: drive.serialNumber,
+				Bay:          drive.bay,
+			})
+		}
+	}
+
+	for _, drive := range physicalDrives {
+		switch {
+		// Array dedicated spares, an array can be covered by several spares and a spare can cover several arrays
+		case drive.driveType == "Spare Drive":
+			spareState := drive.status
+			if spareState == "OK" {
+				spareState = "Available"
+			}
+			spare := utils.SpareStruct{
+				ControllerId: controller.Id,
+				Type:         "Dedicated",
+				State:        spareState,
+				EidSlot:      drive.eidSlot,
+				Size:         drive.size,
+				Intf:         drive.intf,
+				Medium:       drive.medium,
+				Model:        drive.model,
+				SerialNumber: drive.serialNumber,
+			}
+			for _, raid := range logicalDrives {
+				if logicalDriveArrays[raid.Dg] == drive.array {
+					spare.Arrays = append(spare.Arrays, raid.Dg)
+				}
+			}
+			controller.AddSpare(spare)
+		// Unassigned drives in RAID mode and HBA mode drives
+		case len(drive.array) == 0:
+			noRaidDiskState := drive.status
+			osDevice := ""
+			if drive.exposed {
+				osDevice = drive.osDevice
+				if len(osDevice) == 0 {
+					osDevice = getWwnOsDevice(drive.wwid)
+				}
+				osDevice = "JBOD-" + osDevice
+				if noRaidDiskState == "OK" {
+					noRaidDiskState = "JBOD"
+				}
+			} else if noRaidDiskState == "OK" {
+				noRaidDiskState = "UGood"
+			}
+			noRaidDisks = append(noRaidDisks, utils.NoRaidDiskStruct{
+				ControllerId: controller.Id,
+				EidSlot:      drive.eidSlot,
+				State:        noRaidDiskState,
+				Size:         drive.size,
+				Intf:         drive.intf,
+				Medium:       drive.medium,
+				Model:        drive.model,
+				SerialNumber: drive.serialNumber,
+				OsDevice:     osDevice,
+				Bay:          drive.bay,
+			})
+		}
+	}
+
+	// Unknown status means ssacli didnt report it
+	badLogicalDrives := 0
+	for _, raid := range logicalDrives {
+		if raid.State != "Optimal" && raid.State != "Unknown" {
+			badLogicalDrives++
+		}
+	}
+	badPhysicalDrives := 0
+	for _, drive := range physicalDrives {
+		if drive.status != "OK" && drive.status != "Unknown" {
+			badPhysicalDrives++
+		}
+	}
+	if controller.Status == "OK" && (badLogicalDrives > 0 || badPhysicalDrives > 0) {
+		controller.Status = fmt.Sprintf("Bad: %d logical drives and %d physical drives not OK.", badLogicalDrives, badPhysicalDrives)
+	}
+
+	// Enclosures occupied bays: 1I:1:3 -> enclosure 1I:1 bay 3
+	for i := range controller.Enclosures {
+		enclosure := &controller.Enclosures[i]
+		for _, drive := range physicalDrives {
+			eidSlotData := strings.Split(drive.eidSlot, ":")
+			if len(eidSlotData) == 3 && eidSlotData[0]+":"+eidSlotData[1] == enclosure.Id && !slices.Contains(enclosure.OccupiedSlots, eidSlotData[2]) {
+				enclosure.OccupiedSlots = append(enclosure.OccupiedSlots, eidSlotData[2])
+			}
+		}
+	}
+	return controller, logicalDrives, noRaidDisks
+}
+
+// Parse ssacli ctrl all show config detail
+var ProcessHWSsacliRaid = func(manufacturer string) ([]utils.ControllerStruct, []utils.RaidStruct, []utils.NoRaidDiskStruct, error) {
+	var controllers = []utils.ControllerStruct{}
+	var raids = []utils.RaidStruct{}
+	var noRaidDisks = []utils.NoRaidDiskStruct{}
+
+	fmt.Println("> Getting current HPE Smart Array configuration.")
+	command := "ctrl all show config detail"
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "processHWSsacliRaid", command)
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return controllers, raids, noRaidDisks, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return controllers, raids, noRaidDisks, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+
+	fmt.Println("> Parsing HPE Smart Array data.")
+	// Current controller data
+	var controller *utils.ControllerStruct
+	logicalDrives := []utils.RaidStruct{}
+	logicalDriveMembers := make(map[string][]string)
+	logicalDriveArrays := make(map[string]string)
+	physicalDrives := []physicalDrive{}
+	// Sections: controller, enclosure, array, logicaldrive, physicaldrive, other
+	section := ""
+	array := ""
+	var logicalDrive *utils.RaidStruct
+	var drive *physicalDrive
+
+	saveController := func() {
+		if controller == nil {
+			return
+		}
+		newController, newRaids, newNoRaidDisks := buildSsacliController(*controller, logicalDrives, logicalDriveMembers, logicalDriveArrays, physicalDrives)
+		controllers = append(controllers, newController)
+		raids = append(raids, newRaids...)
+		noRaidDisks = append(noRaidDisks, newNoRaidDisks...)
+		controller = nil
+		logicalDrives = []utils.RaidStruct{}
+		logicalDriveMembers = make(map[string][]string)
+		logicalDriveArrays = make(map[string]string)
+		physicalDrives = []physicalDrive{}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		//fmt.Println("line: ", line)
+
+		// New controller
+		if controllerData := controllerRegexp.FindStringSubmatch(line); controllerData != nil && !strings.Contains(line, ":") {
+			saveController()
+			// hpsa driver for Smart Array up to Gen9, smartpqi for SmartRAID/Smart Array Gen10
+			driverName, driverVersion := utils.GetKernelModuleVersion("hpsa", "smartpqi")
+			controller = &utils.ControllerStruct{
+				Id:              manufacturer + "-" + controllerData[2],
+				Manufacturer:    manufacturer,
+				Model:           strings.TrimSpace(controllerData[1]),
+				Status:          "Unknown",
+				FirmwareVersion: "Unknown",
+				BiosVersion:     "Unknown",
+				DriverName:      driverName,
+				DriverVersion:   driverVersion,
+				PciAddress:      "Unknown",
+				SerialNumber:    "Unknown",
+				RocTemperature:  "Unknown",
+			}
+			section = "controller"
+			array = ""
+			continue
+		}
+		if controller == nil {
+			continue
+		}
+
+		// Sections headers
+		if enclosureData := enclosureRegexp.FindStringSubmatch(line); enclosureData != nil {
+			controller.AddEnclosure(utils.EnclosureStruct{
+				ControllerId: controller.Id,
+				Id:           enclosureData[1] + ":" + enclosureData[2],
+				State:        enclosureData[3],
+				Slots:        "Unknown",
+			})
+			section = "enclosure"
+			continue
+		}
+		if strings.HasPrefix(line, "Array: ") {
+			array = strings.TrimSpace(strings.TrimPrefix(line, "Array: "))
+			section = "array"
+			continue
+		}
+		if strings.HasPrefix(line, "Logical Drive: ") {
+			logicalDrives = append(logicalDrives, utils.RaidStruct{
+				ControllerId: controller.Id,
+				RaidLevel:    0,
+				Dg:           strings.TrimSpace(strings.TrimPrefix(line, "Logical Drive: ")),
+				RaidType:     "Unknown",
+				State:        "Unknown",
+				Size:         "Unknown",
+				OsDevice:     "Unknown",
+				Details:      []string{"array " + array},
+			})
+			logicalDrive = &logicalDrives[len(logicalDrives)-1]
+			logicalDriveArrays[logicalDrive.Dg] = array
+			section = "logicaldrive"
+			continue
+		}
+		if line == "Unassigned" || line == "HBA Drives" {
+			array = ""
+			section = "unassigned"
+			continue
+		}
+		if physicalDriveData := physicalDriveRegexp.FindStringSubmatch(line); physicalDriveData != nil {
+			// Logical drive member list
+			if section == "logicaldrive" && len(physicalDriveData[2]) > 0 {
+				logicalDriveMembers[logicalDrive.Dg] = append(logicalDriveMembers[logicalDrive.Dg], physicalDriveData[1])
+				continue
+			}
+			physicalDrives = append(physicalDrives, physicalDrive{
+				eidSlot:      physicalDriveData[1],
+				array:        array,
+				status:       "Unknown",
+				intf:         "Unknown",
+				medium:       "Unknown",
+				size:         "Unknown",
+				model:        "Unknown",
+				serialNumber: "Unknown",
+				bay:          getSsacliBay(physicalDriveData[1]),
+			})
+			drive = &physicalDrives[len(physicalDrives)-1]
+			section = "physicaldrive"
+			continue
+		}
+		// Enclosure processors and expanders data is not used
+		if strings.HasPrefix(line, "SEP ") || strings.HasPrefix(line, "Expander ") || strings.HasPrefix(line, "Port Name: ") {
+			section = "other"
+			continue
+		}
+
+		if !strings.Contains(line, ": ") {
+			continue
+		}
+		lineData := strings.SplitN(line, ": ", 2)
+		key := strings.TrimSpace(lineData[0])
+		value := strings.TrimSpace(lineData[1])
+		switch section {
+		case "controller":
+			switch key {
+			case "Controller Status":
+				controller.Status = value
+			case "Serial Number":
+				controller.SerialNumber = value
+			case "Firmware Version":
+				controller.FirmwareVersion = value
+			case "Driver Name":
+				controller.DriverName = value
+			case "Driver Version":
+				controller.DriverVersion = value
+			case "PCI Address (Domain:Bus:Device.Function)":
+				controller.PciAddress = value
+			case "Controller Temperature (C)":
+				controller.RocTemperature = value + " C"
+			// Cache Status: OK, Not Configured, Temporarily Disabled, Permanently Disabled
+			case "Cache Status":
+				controller.CacheStatus = value
+			case "Battery/Capacitor Status":
+				controller.BatteryStatus = value
+			}
+		case "enclosure":
+			if key == "Drive Bays" {
+				enclosures := controller.Enclosures
+				enclosures[len(enclosures)-1].Slots = value
+			}
+		case "logicaldrive":
+			switch key {
+			case "Size":
+				logicalDrive.Size = getSsacliSize(value, true)
+			case "Fault Tolerance":
+				logicalDrive.RaidType = getSsacliRaidType(value)
+			// Status: OK, Interim Recovery Mode, Ready for Rebuild, Recovering, Failed
+			case "Status":
+				logicalDrive.State = value
+				if value == "OK" {
+					logicalDrive.State = "Optimal"
+				}
+			// Disk Name: /dev/sda
+			case "Disk Name":
+				logicalDrive.OsDevice = getDiskName(value)
+			case "Unique Identifier":
+				if logicalDrive.OsDevice == "Unknown" {
+					logicalDrive.OsDevice = getWwnOsDevice(value)
+				}
+			case "Parity Initialization Status", "Rebuild Status":
+				if value != "Initialization Completed" {
+					logicalDrive.Details = append(logicalDrive.Details, strings.ToLower(key)+": "+value)
+				}
+			}
+		case "physicaldrive":
+			switch key {
+			// Status: OK, Failed, Predictive Failure, Rebuilding
+			case "Status":
+				drive.status = value
+			// Drive Type: Data Drive, Spare Drive, Unassigned Drive, HBA Mode Drive
+			case "Drive Type":
+				drive.driveType = value
+			case "Interface Type":
+				drive.intf, drive.medium = getSsacliInterface(value)
+			case "Size":
+				drive.size = getSsacliSize(value, false)
+			case "Model":
+				drive.model = getSsacliModel(value)
+			case "Serial Number":
+				drive.serialNumber = value
+			case "WWID":
+				drive.wwid = value
+			case "Drive exposed to OS":
+				drive.exposed = value == "True"
+			case "Disk Name":
+				drive.osDevice = getDiskName(value)
+			}
+		}
+	}
+	saveController()
+	return controllers, raids, noRaidDisks, nil
+}
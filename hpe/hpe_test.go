@@ -0,0 +1,497 @@
+package hpe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hardwareAnalyzer/utils"
+	"testing"
+)
+
+// Test CheckSsacliRaid
+func TestCheckSsacliRaid(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		outputStdout.WriteString(`
+			Smart Array P440ar in Slot 0 (Embedded)   (sn: PDNLH0BRH7V7YK)
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	ssacliRaidCheck, err := CheckSsacliRaid()
+	if err != nil {
+		t.Fatalf(`TestCheckSsacliRaid returned error: %s`, err)
+	}
+	if !ssacliRaidCheck {
+		t.Fatalf(`TestCheckSsacliRaid ssacliRaidCheck: %v should match TRUE`, ssacliRaidCheck)
+	}
+}
+
+// Test CheckSsacliRaid without controllers and without ssacli installed
+func TestCheckSsacliRaidNoController(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		outputStdout.WriteString("Error: No controllers detected. Possible causes:")
+		return &outputStdout, &outputStderr, fmt.Errorf("exit status 1")
+	}
+	ssacliRaidCheck, err := CheckSsacliRaid()
+	if err != nil || ssacliRaidCheck {
+		t.Fatalf(`TestCheckSsacliRaidNoController ssacliRaidCheck: %v err: %v should match FALSE nil`, ssacliRaidCheck, err)
+	}
+
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		return &outputStdout, &outputStderr, fmt.Errorf("Cant find ssacli system binary.")
+	}
+	ssacliRaidCheck, err = CheckSsacliRaid()
+	if err != nil || ssacliRaidCheck {
+		t.Fatalf(`TestCheckSsacliRaidNoController ssacliRaidCheck: %v err: %v should match FALSE nil`, ssacliRaidCheck, err)
+	}
+}
+
+// Test ProcessHWSsacliRaid
+// Array A: RAID1 logical drive with a failed disk and a dedicated spare, array B: RAID6 logical drive
+// Array C: RAID5 logical drive without member list, one unassigned drive
+func TestProcessHWSsacliRaid(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	readSysfsLinkOri := utils.ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "ctrl all show config detail" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command")
+		}
+		outputStdout.WriteString(`
+Smart Array P440ar in Slot 0 (Embedded)
+   Bus Interface: PCI
+   Slot: 0
+   Serial Number: PDNLH0BRH7V7YK
+   Cache Serial Number: PDNLH0BRH7V7YK
+   RAID 6 (ADG) Status: Enabled
+   Controller Status: OK
+   Hardware Revision: B
+   Firmware Version: 6.60
+   Controller Temperature (C): 52
+   Cache Module Temperature (C): 43
+   Number of Ports: 2 Internal only
+   Driver Name: hpsa
+   Driver Version: 3.4.20
+   PCI Address (Domain:Bus:Device.Function): 0000:03:00.0
+   Cache Status: OK
+   Battery/Capacitor Count: 1
+   Battery/Capacitor Status: Failed (Replace Batteries/Capacitors)
+   Controller Mode: RAID
+   Port Name: 1I
+         Port ID: 0
+         Port Connection Number: 0
+         SAS Address: 5001438035D5A3A0
+         Port Location: Internal
+
+   Internal Drive Cage at Port 1I, Box 1, OK
+      Power Supply Status: Not Redundant
+      Drive Bays: 4
+      Port: 1I
+      Box: 1
+      Location: Internal
+
+   Array: A
+      Interface Type: Solid State SATA
+      Unused Space: 0  MB (0.00%)
+      Used Space: 447.07 GB (100.00%)
+      Status: Failed Physical Drive
+      Array Type: Data
+
+      Logical Drive: 1
+         Size: 223.54 GB
+         Fault Tolerance: 1
+         Heads: 255
+         Status: Interim Recovery Mode
+         Unrecoverable Media Errors: None
+         Caching:  Enabled
+         Unique Identifier: 600508B1001C5F1A8D5B8C3D2E1F0A9B
+         Disk Name: /dev/sda
+         Mount Points: /boot 512 MB Partition Number 2
+         Logical Drive Label: 0123ABCD
+         Mirror Group 1:
+            physicaldrive 1I:1:1 (port 1I:box 1:bay 1, SATA SSD, 240 GB, OK)
+         Mirror Group 2:
+            physicaldrive 1I:1:2 (port 1I:box 1:bay 2, SATA SSD, 240 GB, Failed)
+         Drive Type: Data
+         LD Acceleration Method: Controller Cache
+
+      physicaldrive 1I:1:1
+         Port: 1I
+         Box: 1
+         Bay: 1
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: Solid State SATA
+         Size: 240 GB
+         Drive exposed to OS: False
+         Firmware Revision: XCV10110
+         Serial Number: BTYS8123456789
+         WWID: 55CD2E414E123456
+         Model: ATA     INTEL SSDSC2BB24
+         Current Temperature (C): 22
+
+      physicaldrive 1I:1:2
+         Port: 1I
+         Box: 1
+         Bay: 2
+         Status: Failed
+         Drive Type: Data Drive
+         Interface Type: Solid State SATA
+         Size: 240 GB
+         Drive exposed to OS: False
+         Serial Number: BTYS8987654321
+         Model: ATA     INTEL SSDSC2BB24
+
+      physicaldrive 1I:1:4
+         Port: 1I
+         Box: 1
+         Bay: 4
+         Status: OK
+         Drive Type: Spare Drive
+         Interface Type: Solid State SATA
+         Size: 240 GB
+         Drive exposed to OS: False
+         Serial Number: BTYS8000000004
+         Model: ATA     INTEL SSDSC2BB24
+
+   Array: B
+      Interface Type: SAS
+      Status: OK
+
+      Logical Drive: 2
+         Size: 1.1 TB
+         Fault Tolerance: 6 (ADG)
+         Status: OK
+         Parity Initialization Status: In Progress
+         Unique Identifier: 600508B1001C0E6A3B0C6A7F2E1D4C5B
+         physicaldrive 2I:1:5 (port 2I:box 1:bay 5, SAS HDD, 300 GB, OK)
+         physicaldrive 2I:1:6 (port 2I:box 1:bay 6, SAS HDD, 300 GB, OK)
+
+      physicaldrive 2I:1:5
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 300 GB
+         Serial Number: S0K1AAAA
+         Model: HP      EG0300FCVBF
+
+      physicaldrive 2I:1:6
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 300 GB
+         Serial Number: S0K1BBBB
+         Model: HP      EG0300FCVBF
+
+   Array: C
+      Interface Type: SAS
+      Status: Failed Physical Drive
+
+      Logical Drive: 3
+         Size: 1.1 TB
+         Fault Tolerance: 5
+         Status: Interim Recovery Mode
+         Disk Name: /dev/sdc
+
+      physicaldrive 2I:2:1
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: S0K3AAAA
+         Model: HP      EG0600FBDSR
+
+      physicaldrive 2I:2:2
+         Status: Failed
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: S0K3BBBB
+         Model: HP      EG0600FBDSR
+
+      physicaldrive 2I:2:3
+         Status: OK
+         Drive Type: Data Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Serial Number: S0K3CCCC
+         Model: HP      EG0600FBDSR
+
+   Unassigned
+
+      physicaldrive 1I:1:3
+         Port: 1I
+         Box: 1
+         Bay: 3
+         Status: OK
+         Drive Type: Unassigned Drive
+         Interface Type: SAS
+         Size: 600 GB
+         Drive exposed to OS: False
+         Serial Number: S0K2CCCC
+         Model: HP      EG0600FBDSR
+
+   SEP (Vendor ID PMCSIERA, Model SRCv8x6G) 380
+      Device Number: 380
+      Firmware Version: RevB
+      WWID: 5001438035D5A3AF
+      Vendor ID: PMCSIERA
+      Model: SRCv8x6G
+`)
+		return &outputStdout, &outputStderr, nil
+	}
+	// Logical drive 2 has no Disk Name, it is found by its unique identifier
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		if path == "/dev/disk/by-id/wwn-0x600508b1001c0e6a3b0c6a7f2e1d4c5b" {
+			return "../../sdb", nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+
+	controllers, raids, noRaidDisks, err := ProcessHWSsacliRaid("hpe")
+	if err != nil {
+		t.Fatalf(`TestProcessHWSsacliRaid returned error: %s`, err)
+	}
+
+	if len(controllers) != 1 {
+		t.Fatalf(`TestProcessHWSsacliRaid controllers: %v should match: 1`, len(controllers))
+	}
+	controller := controllers[0]
+	// Degraded logical drives and failed disks
+	controllerStatusWanted := "Bad: 2 logical drives and 2 physical drives not OK."
+	if controller.Id != "hpe-0" || controller.Model != "Smart Array P440ar" || controller.Status != controllerStatusWanted {
+		t.Fatalf(`TestProcessHWSsacliRaid controller: %v/%v/%v should match: hpe-0/Smart Array P440ar/%v`, controller.Id, controller.Model, controller.Status, controllerStatusWanted)
+	}
+	if controller.FirmwareVersion != "6.60" || controller.DriverName != "hpsa" || controller.DriverVersion != "3.4.20" || controller.PciAddress != "0000:03:00.0" || controller.SerialNumber != "PDNLH0BRH7V7YK" || controller.RocTemperature != "52 C" {
+		t.Fatalf(`TestProcessHWSsacliRaid controller inventory: %+v`, controller)
+	}
+	if controller.CacheStatus != "OK" || controller.BatteryStatus != "Failed (Replace Batteries/Capacitors)" {
+		t.Fatalf(`TestProcessHWSsacliRaid controller cache/battery: %v/%v should match: OK/Failed (Replace Batteries/Capacitors)`, controller.CacheStatus, controller.BatteryStatus)
+	}
+	if len(controller.Enclosures) != 1 || controller.Enclosures[0].Id != "1I:1" || controller.Enclosures[0].Slots != "4" || len(controller.Enclosures[0].OccupiedSlots) != 4 {
+		t.Fatalf(`TestProcessHWSsacliRaid controller.Enclosures: %+v`, controller.Enclosures)
+	}
+	if len(controller.Spares) != 1 || controller.Spares[0].EidSlot != "1I:1:4" || controller.Spares[0].State != "Available" || len(controller.Spares[0].Arrays) != 1 || controller.Spares[0].Arrays[0] != "1" {
+		t.Fatalf(`TestProcessHWSsacliRaid controller.Spares: %+v`, controller.Spares)
+	}
+
+	if len(raids) != 3 {
+		t.Fatalf(`TestProcessHWSsacliRaid raids: %v should match: 3`, len(raids))
+	}
+	raidsWanted := []utils.RaidStruct{
+		{ControllerId: "hpe-0", Dg: "1", RaidType: "RAID1", State: "Interim Recovery Mode", Size: "240 GB", OsDevice: "sda"},
+		{ControllerId: "hpe-0", Dg: "2", RaidType: "RAID6", State: "Optimal", Size: "1.2 TB", OsDevice: "sdb"},
+	}
+	for i, raidWanted := range raidsWanted {
+		raid := raids[i]
+		if raid.ControllerId != raidWanted.ControllerId || raid.Dg != raidWanted.Dg || raid.RaidType != raidWanted.RaidType || raid.State != raidWanted.State || raid.Size != raidWanted.Size || raid.OsDevice != raidWanted.OsDevice {
+			t.Fatalf(`TestProcessHWSsacliRaid raid: %+v should match: %+v`, raid, raidWanted)
+		}
+		if len(raid.Disks) != 2 {
+			t.Fatalf(`TestProcessHWSsacliRaid raid.Disks: %v should match: 2`, len(raid.Disks))
+		}
+	}
+	// RAID5 members are its array data drives, failed drive included
+	raid5 := raids[2]
+	if raid5.RaidType != "RAID5" || raid5.State != "Interim Recovery Mode" || raid5.OsDevice != "sdc" || len(raid5.Disks) != 3 {
+		t.Fatalf(`TestProcessHWSsacliRaid RAID5 raid: %+v should match: RAID5 Interim Recovery Mode sdc with 3 disks`, raid5)
+	}
+	if raid5.Disks[1].EidSlot != "2I:2:2" || raid5.Disks[1].State != "Failed" || raid5.Disks[1].Dg != "3" {
+		t.Fatalf(`TestProcessHWSsacliRaid RAID5 disk: %+v should match: 2I:2:2 Failed`, raid5.Disks[1])
+	}
+	if len(raids[1].Details) != 2 || raids[1].Details[1] != "parity initialization status: In Progress" {
+		t.Fatalf(`TestProcessHWSsacliRaid raid.Details: %v should match: [array B parity initialization status: In Progress]`, raids[1].Details)
+	}
+
+	disksWanted := []utils.DiskStruct{
+		{ControllerId: "hpe-0", Dg: "1", EidSlot: "1I:1:1", State: "Online", Size: "240 GB", Intf: "SATA", Medium: "SSD", Model: "INTEL SSDSC2BB24", SerialNumber: "BTYS8123456789", Bay: "Port 1I Box 1 Bay 1"},
+		{ControllerId: "hpe-0", Dg: "1", EidSlot: "1I:1:2", State: "Failed", Size: "240 GB", Intf: "SATA", Medium: "SSD", Model: "INTEL SSDSC2BB24", SerialNumber: "BTYS8987654321", Bay: "Port 1I Box 1 Bay 2"},
+	}
+	for i, diskWanted := range disksWanted {
+		disk := raids[0].Disks[i]
+		if disk.ControllerId != diskWanted.ControllerId || disk.Dg != diskWanted.Dg || disk.EidSlot != diskWanted.EidSlot || disk.State != diskWanted.State || disk.Size != diskWanted.Size || disk.Intf != diskWanted.Intf || disk.Medium != diskWanted.Medium || disk.Model != diskWanted.Model || disk.SerialNumber != diskWanted.SerialNumber || disk.Bay != diskWanted.Bay {
+			t.Fatalf(`TestProcessHWSsacliRaid disk: %+v should match: %+v`, disk, diskWanted)
+		}
+	}
+	if raids[1].Disks[0].Model != "HP EG0300FCVBF" || raids[1].Disks[0].Intf != "SAS" || raids[1].Disks[0].Medium != "HDD" {
+		t.Fatalf(`TestProcessHWSsacliRaid disk: %+v should match: HP EG0300FCVBF SAS/HDD`, raids[1].Disks[0])
+	}
+
+	if len(noRaidDisks) != 1 {
+		t.Fatalf(`TestProcessHWSsacliRaid noRaidDisks: %v should match: 1`, len(noRaidDisks))
+	}
+	noRaidDisk := noRaidDisks[0]
+	if noRaidDisk.EidSlot != "1I:1:3" || noRaidDisk.State != "UGood" || noRaidDisk.Size != "600 GB" || noRaidDisk.SerialNumber != "S0K2CCCC" || noRaidDisk.OsDevice != "" {
+		t.Fatalf(`TestProcessHWSsacliRaid noRaidDisk: %+v`, noRaidDisk)
+	}
+}
+
+// Test ProcessHWSsacliRaid HBA mode drives exposed to OS
+func TestProcessHWSsacliRaidHbaMode(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	readSysfsLinkOri := utils.ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		outputStdout.WriteString(`
+Smart HBA H240 in Slot 1
+   Controller Status: OK
+   Firmware Version: 7.00
+   Controller Mode: HBA
+
+   HBA Drives
+
+      physicaldrive 1I:1:1
+         Status: OK
+         Drive Type: HBA Mode Drive
+         Interface Type: SATA
+         Size: 4 TB
+         Drive exposed to OS: True
+         Serial Number: ZC1ABCDE
+         WWID: 5000C500A1B2C3D4
+         Model: ATA     ST4000NM0035
+         Disk Name: /dev/sdc
+
+      physicaldrive 1I:1:2
+         Status: Predictive Failure
+         Drive Type: HBA Mode Drive
+         Interface Type: SATA
+         Size: 4 TB
+         Drive exposed to OS: True
+         Serial Number: ZC1FGHIJ
+         WWID: 5000C500A1B2C3D5
+         Model: ATA     ST4000NM0035
+`)
+		return &outputStdout, &outputStderr, nil
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		if path == "/dev/disk/by-id/wwn-0x5000c500a1b2c3d5" {
+			return "../../sdd", nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+
+	controllers, raids, noRaidDisks, err := ProcessHWSsacliRaid("hpe")
+	if err != nil {
+		t.Fatalf(`TestProcessHWSsacliRaidHbaMode returned error: %s`, err)
+	}
+	if len(controllers) != 1 || controllers[0].Id != "hpe-1" || len(controllers[0].CacheStatus) != 0 || controllers[0].Status != "Bad: 0 logical drives and 1 physical drives not OK." {
+		t.Fatalf(`TestProcessHWSsacliRaidHbaMode controllers: %+v`, controllers)
+	}
+	if len(raids) != 0 || len(noRaidDisks) != 2 {
+		t.Fatalf(`TestProcessHWSsacliRaidHbaMode raids/noRaidDisks: %v/%v should match: 0/2`, len(raids), len(noRaidDisks))
+	}
+	if noRaidDisks[0].State != "JBOD" || noRaidDisks[0].OsDevice != "JBOD-sdc" || noRaidDisks[0].Model != "ST4000NM0035" {
+		t.Fatalf(`TestProcessHWSsacliRaidHbaMode noRaidDisk: %+v should match: JBOD JBOD-sdc ST4000NM0035`, noRaidDisks[0])
+	}
+	if noRaidDisks[1].State != "Predictive Failure" || noRaidDisks[1].OsDevice != "JBOD-sdd" {
+		t.Fatalf(`TestProcessHWSsacliRaidHbaMode noRaidDisk: %+v should match: Predictive Failure JBOD-sdd`, noRaidDisks[1])
+	}
+}
+
+// Test ProcessHWSsacliRaid outputStderr
+func TestProcessHWSsacliRaidOutputStderr(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		outputStderr.WriteString("RANDOM ERROR")
+		return &outputStdout, &outputStderr, nil
+	}
+
+	_, _, _, err := ProcessHWSsacliRaid("hpe")
+	if err == nil {
+		t.Fatalf(`TestProcessHWSsacliRaidOutputStderr should return err != nil`)
+	}
+}
+
+// Test getSsacliRaidType
+func TestGetSsacliRaidType(t *testing.T) {
+	raidTypes := map[string]string{
+		"0":       "RAID0",
+		"1":       "RAID1",
+		"1+0":     "RAID10",
+		"5":       "RAID5",
+		"6 (ADG)": "RAID6",
+		"1 (ADM)": "RAID1ADM",
+		"60":      "RAID60",
+	}
+	for faultTolerance, raidTypeWanted := range raidTypes {
+		raidType := getSsacliRaidType(faultTolerance)
+		if raidType != raidTypeWanted {
+			t.Fatalf(`TestGetSsacliRaidType %s: %v should be: %v`, faultTolerance, raidType, raidTypeWanted)
+		}
+	}
+}
+
+// Test LocateSsacliDisk
+func TestLocateSsacliDisk(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "ctrl slot=0 pd 1I:1:3 modify led=on" && command != "ctrl slot=0 pd 1I:1:3 modify led=off" {
+			outputStdout.WriteString("Error: The specified device does not have any physical drives with ID 9I:9:9.")
+		}
+		return &outputStdout, &outputStderr, nil
+	}
+
+	err := LocateSsacliDisk("0", "1I:1:3", true)
+	if err != nil {
+		t.Fatalf(`TestLocateSsacliDisk returned error: %s`, err)
+	}
+	err = LocateSsacliDisk("0", "1I:1:3", false)
+	if err != nil {
+		t.Fatalf(`TestLocateSsacliDisk returned error: %s`, err)
+	}
+	err = LocateSsacliDisk("0", "9I:9:9", true)
+	if err == nil {
+		t.Fatalf(`TestLocateSsacliDisk should return err != nil`)
+	}
+}
@@ -0,0 +1,428 @@
+package kernellog
+
+import (
+	"errors"
+	"fmt"
+	"hardwareAnalyzer/utils"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Storage events scanned from the kernel ring buffer(/dev/kmsg) or a dmesg capture
+// Disks can be ONLINE for the controller while the kernel keeps logging IO errors and link resets
+
+const lastSeenLayout = "2006-01-02 15:04:05"
+
+// Kernel drivers of every hardware controller manufacturer
+var driverManufacturers = map[string][]string{
+	"megaraid_sas": {"mega", "perc"},
+	"mpt2sas":      {"sas2ircu", "mpt3sas"},
+	"mpt3sas":      {"sas3ircu", "mpt3sas"},
+	"aacraid":      {"adaptec"},
+	"hpsa":         {"hpe"},
+	"smartpqi":     {"adaptec", "hpe"},
+}
+
+// Hardware raid logical drives IO errors are reported on its controller
+var hardwareManufacturers = []string{"mega", "perc", "sas2ircu", "sas3ircu", "mpt3sas", "adaptec", "hpe"}
+
+// Read kernel log lines, /dev/kmsg records are read without blocking until the end of the ring buffer
+// Function as variable in order to be able to mock it from unit tests
+var ReadKernelLog = func(path string) ([]string, error) {
+	if path != "/dev/kmsg" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Split(string(content), "\n"), nil
+	}
+
+	// os.File reads would be parked by the runtime poller when no more records are available
+	fd, err := syscall.Open(path, syscall.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+	lines := []string{}
+	buffer := make([]byte, 8192)
+	for {
+		n, err := syscall.Read(fd, buffer)
+		if errors.Is(err, syscall.EAGAIN) {
+			break
+		}
+		// Oldest records were overwritten while reading, next read returns the next available one
+		if errors.Is(err, syscall.EPIPE) {
+			continue
+		}
+		if err != nil {
+			return lines, err
+		}
+		if n == 0 {
+			break
+		}
+		// Every read returns one record, continuation lines are device properties: " SUBSYSTEM=scsi"
+		record, _, _ := strings.Cut(string(buffer[:n]), "\n")
+		lines = append(lines, record)
+	}
+	return lines, nil
+}
+
+// Boot time from /proc/stat, kernel log timestamps are relative to it
+var getBootTime = func() (time.Time, error) {
+	stat, err := utils.ReadSysfsFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range strings.Split(stat, "\n") {
+		value, found := strings.CutPrefix(line, "btime ")
+		if !found {
+			continue
+		}
+		seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("btime not found in /proc/stat")
+}
+
+// /dev/kmsg record: 6,1234,5678901234,-;message
+var kmsgRegexp = regexp.MustCompile(`^\d+,\d+,(\d+),[^;]*;(.*)$`)
+
+// dmesg capture: [ 5678.901234] message
+var dmesgRegexp = regexp.MustCompile(`^\[\s*(\d+)\.(\d+)\] (.*)$`)
+
+// dmesg -T capture: [Mon Oct 19 10:00:00 2026] message
+var dmesgHumanRegexp = regexp.MustCompile(`^\[(\w{3} \w{3} [ \d]\d \d\d:\d\d:\d\d \d{4})\] (.*)$`)
+
+// Split a kernel log line in its timestamp and message, LastSeen is Unknown when it cant be calculated
+func parseLine(line string, bootTime time.Time) (string, string) {
+	sinceBoot := func(microseconds int64) string {
+		if bootTime.IsZero() {
+			return "Unknown"
+		}
+		return bootTime.Add(time.Duration(microseconds) * time.Microsecond).Format(lastSeenLayout)
+	}
+	if match := kmsgRegexp.FindStringSubmatch(line); match != nil {
+		microseconds, _ := strconv.ParseInt(match[1], 10, 64)
+		return sinceBoot(microseconds), match[2]
+	}
+	if match := dmesgRegexp.FindStringSubmatch(line); match != nil {
+		seconds, _ := strconv.ParseInt(match[1], 10, 64)
+		fraction, _ := strconv.ParseInt((match[2] + "000000")[:6], 10, 64)
+		return sinceBoot(seconds*1000000 + fraction), match[3]
+	}
+	if match := dmesgHumanRegexp.FindStringSubmatch(line); match != nil {
+		timestamp, err := time.ParseInLocation("Mon Jan _2 15:04:05 2006", match[1], time.Local)
+		if err != nil {
+			return "Unknown", match[2]
+		}
+		return timestamp.Format(lastSeenLayout), match[2]
+	}
+	return "Unknown", line
+}
+
+// blk_update_request: I/O error, dev sdb, sector 1234 op 0x0:(READ)
+// critical medium error, dev sdb, sector 1234, device-mapper names are hyphenated: I/O error, dev dm-3, sector 1234
+var blockErrorRegexp = regexp.MustCompile(`(I/O|critical medium|critical target|critical nexus|critical space allocation) error, dev ([\w-]+),`)
+
+// Buffer I/O error on dev sdb1, logical block 0, async page read
+var bufferErrorRegexp = regexp.MustCompile(`Buffer I/O error on dev ([\w-]+),`)
+
+// sd 2:0:0:0: [sdc] tag#9 Sense Key : Medium Error [current]
+// Add. Sense: Unrecovered read error line of the same failure is not counted
+var mediumErrorRegexp = regexp.MustCompile(`\[(\w+)\] .*Sense Key : Medium Error`)
+
+// ata3: hard resetting link, ata3.00: exception Emask 0x0 SAct 0x0 SErr 0x0 action 0x6 frozen
+// SATA link down is not counted, it is logged at boot for every empty port
+var ataResetRegexp = regexp.MustCompile(`^(ata\d+)(?:\.\d+)?: (hard resetting link|COMRESET failed|exception Emask|link is slow to respond)`)
+
+// sd 2:0:0:0: attempting task abort!, scsi 2:0:0:0: Device offlined - not ready after error recovery
+var scsiResetRegexp = regexp.MustCompile(`^(?:sd|scsi) (\d+:\d+:\d+:\d+): (?:\[(\w+)\] )?.*(attempting task abort|device reset|Device offlined|rejecting I/O to offline device)`)
+
+// md/raid1:md0: Disk failure on sdb1, disabling device.
+var mdFailureRegexp = regexp.MustCompile(`md/raid\d*:\w+: Disk failure on ([\w-]+)`)
+
+// zio pool=tank vdev=/dev/disk/by-id/ata-ST4000-part1 error=5 type=1 offset=270336 size=8192 flags=b08c1
+var zioErrorRegexp = regexp.MustCompile(`zio pool=\S+ vdev=(\S+) error=\d+`)
+
+// BTRFS error (device sdb): bdev /dev/sdc errs: wr 0, rd 1, flush 0, corrupt 0, gen 0
+var btrfsErrorRegexp = regexp.MustCompile(`BTRFS .*bdev (\S+) errs: wr \d+, rd \d+, flush \d+, corrupt \d+, gen \d+`)
+
+// nvme nvme0: I/O 123 QID 4 timeout, reset controller
+var nvmeResetRegexp = regexp.MustCompile(`^nvme (nvme\d+): .*(timeout|reset controller|controller is down|Removing after probe failure)`)
+
+// megaraid_sas 0000:03:00.0: resetting fusion adapter scsi0, aacraid 0000:04:00.0: Host adapter reset request
+// hpsa 0000:03:00.0: Controller lockup detected: 0x00130000 after 30
+var adapterResetRegexp = regexp.MustCompile(`(?i)^(megaraid_sas|aacraid|hpsa|smartpqi)(?: ([0-9a-f]{4}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-9a-f]))?: .*(reset|ocr|fault|abort|lockup)`)
+
+// mpt3sas_cm0: sending diag reset !!
+var mptResetRegexp = regexp.MustCompile(`^(mpt[23]sas)_(cm\d+): .*(diag reset|fault_state|FAULT)`)
+
+// Storage event of a kernel log message: source, device and event type
+func parseMessage(message string) (utils.KernelEventStruct, bool) {
+	if match := blockErrorRegexp.FindStringSubmatch(message); match != nil {
+		eventType := "IO-Error"
+		if match[1] == "critical medium" {
+			eventType = "Medium-Error"
+		}
+		return utils.KernelEventStruct{Source: "block", Device: match[2], Type: eventType}, true
+	}
+	if match := bufferErrorRegexp.FindStringSubmatch(message); match != nil {
+		return utils.KernelEventStruct{Source: "block", Device: match[1], Type: "IO-Error"}, true
+	}
+	if match := mediumErrorRegexp.FindStringSubmatch(message); match != nil {
+		return utils.KernelEventStruct{Source: "scsi", Device: match[1], Type: "Medium-Error"}, true
+	}
+	if match := ataResetRegexp.FindStringSubmatch(message); match != nil {
+		eventType := "Link-Reset"
+		if match[2] == "exception Emask" {
+			eventType = "ATA-Exception"
+		}
+		return utils.KernelEventStruct{Source: "ata", Device: match[1], Type: eventType}, true
+	}
+	if match := scsiResetRegexp.FindStringSubmatch(message); match != nil {
+		device := match[1]
+		if len(match[2]) > 0 {
+			device = match[2]
+		}
+		eventType := "Reset"
+		switch match[3] {
+		case "attempting task abort":
+			eventType = "Task-Abort"
+		case "Device offlined", "rejecting I/O to offline device":
+			eventType = "Offline"
+		}
+		return utils.KernelEventStruct{Source: "scsi", Device: device, Type: eventType}, true
+	}
+	if match := mdFailureRegexp.FindStringSubmatch(message); match != nil {
+		return utils.KernelEventStruct{Source: "md", Device: match[1], Type: "MD-Disk-Failure"}, true
+	}
+	if match := zioErrorRegexp.FindStringSubmatch(message); match != nil {
+		return utils.KernelEventStruct{Source: "zfs", Device: match[1], Type: "ZFS-IO-Error"}, true
+	}
+	if match := btrfsErrorRegexp.FindStringSubmatch(message); match != nil {
+		return utils.KernelEventStruct{Source: "btrfs", Device: match[1], Type: "Btrfs-Errors"}, true
+	}
+	if match := nvmeResetRegexp.FindStringSubmatch(message); match != nil {
+		return utils.KernelEventStruct{Source: "nvme", Device: match[1], Type: "Controller-Reset"}, true
+	}
+	if match := adapterResetRegexp.FindStringSubmatch(message); match != nil {
+		return utils.KernelEventStruct{Source: strings.ToLower(match[1]), Device: match[2], Type: "Controller-Reset"}, true
+	}
+	if match := mptResetRegexp.FindStringSubmatch(message); match != nil {
+		return utils.KernelEventStruct{Source: match[1], Device: match[2], Type: "Controller-Reset"}, true
+	}
+	return utils.KernelEventStruct{}, false
+}
+
+// Kernel block device name of a path: /dev/disk/by-id/ata-ST4000-part1 -> sdb, sdb1 -> sdb
+func getKernelDisk(device string) string {
+	if strings.HasPrefix(device, "/") {
+		if link, err := utils.ReadSysfsLink(device); err == nil {
+			device = link
+		}
+		device = device[strings.LastIndex(device, "/")+1:]
+	}
+	return utils.GetParentBlockDevice(device)
+}
+
+// Scan kernel log storage events, occurrences of the same source/device/type are grouped
+func ScanKernelLog(path string) ([]utils.KernelEventStruct, error) {
+	fmt.Println("> Scanning kernel log storage events.")
+	events := []utils.KernelEventStruct{}
+	lines, err := ReadKernelLog(path)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong reading %s: %v", path, err)
+		return events, fmt.Errorf("Error: Something went wrong reading %s: %v.", path, err)
+	}
+	bootTime, err := getBootTime()
+	if err != nil {
+		// Only relative timestamps are affected
+		bootTime = time.Time{}
+	}
+
+	for _, line := range lines {
+		lastSeen, message := parseLine(strings.TrimRight(line, "\r"), bootTime)
+		event, ok := parseMessage(message)
+		if !ok {
+			continue
+		}
+		switch event.Source {
+		case "block", "scsi", "md", "zfs", "btrfs":
+			if !strings.Contains(event.Device, ":") {
+				event.Device = getKernelDisk(event.Device)
+			}
+		}
+		index := slices.IndexFunc(events, func(savedEvent utils.KernelEventStruct) bool {
+			return savedEvent.Source == event.Source && savedEvent.Device == event.Device && savedEvent.Type == event.Type
+		})
+		if index < 0 {
+			event.Count = 1
+			event.LastSeen = lastSeen
+			events = append(events, event)
+			continue
+		}
+		events[index].Count++
+		events[index].LastSeen = latestSeen(events[index].LastSeen, lastSeen)
+	}
+
+	// Block layer critical medium error repeats the SCSI sense of the same failure, it is merged into its scsi event
+	// Devices without SCSI sense data(NVMe) keep its block event
+	for i, event := range events {
+		if event.Source != "block" || event.Type != "Medium-Error" {
+			continue
+		}
+		index := slices.IndexFunc(events, func(scsiEvent utils.KernelEventStruct) bool {
+			return scsiEvent.Source == "scsi" && scsiEvent.Device == event.Device && scsiEvent.Type == "Medium-Error"
+		})
+		if index >= 0 {
+			events[index].LastSeen = latestSeen(events[index].LastSeen, event.LastSeen)
+			events[i].Count = 0
+		}
+	}
+	events = slices.DeleteFunc(events, func(event utils.KernelEventStruct) bool { return event.Count == 0 })
+	return events, nil
+}
+
+// Most recent of two LastSeen values, Unknown is greater than any date, so dated events take precedence
+func latestSeen(lastSeen, newLastSeen string) string {
+	if newLastSeen != "Unknown" && (lastSeen == "Unknown" || newLastSeen > lastSeen) {
+		return newLastSeen
+	}
+	return lastSeen
+}
+
+// Controller of an adapter event: nvme0 -> nvme-0, mpt3sas cm0 -> sas3ircu-0/mpt3sas-0, megaraid_sas by PCI address
+// When the event address is unknown, the only controller using its driver is selected
+func findEventController(event utils.KernelEventStruct, controllers []utils.ControllerStruct) int {
+	if event.Source == "nvme" {
+		return slices.IndexFunc(controllers, func(controller utils.ControllerStruct) bool {
+			return controller.Id == "nvme-"+strings.TrimPrefix(event.Device, "nvme")
+		})
+	}
+	candidates := []int{}
+	for i, controller := range controllers {
+		if slices.Contains(driverManufacturers[event.Source], controller.Manufacturer) {
+			candidates = append(candidates, i)
+		}
+	}
+	for _, i := range candidates {
+		controllerIdData := strings.Split(controllers[i].Id, "-")
+		if strings.HasPrefix(event.Device, "cm") && controllerIdData[len(controllerIdData)-1] == strings.TrimPrefix(event.Device, "cm") {
+			return i
+		}
+		if len(utils.PciFunction(event.Device)) > 0 && utils.PciFunction(event.Device) == utils.PciFunction(controllers[i].PciAddress) {
+			return i
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return -1
+}
+
+// Kernel disk name of a raid member or disk OS device: JBOD-sdb -> sdb, /dev/sdb1 -> sdb, hardware raid disks have none
+func getDiskKernelDevice(eidSlot, osDevice string) string {
+	if len(eidSlot) > 0 && !strings.HasPrefix(osDevice, "JBOD-") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(osDevice, "JBOD-"))
+	if len(fields) == 0 {
+		return ""
+	}
+	device := strings.ToLower(strings.TrimPrefix(fields[0], "/dev/"))
+	if strings.Contains(device, "/") {
+		return ""
+	}
+	return utils.GetParentBlockDevice(device)
+}
+
+// Disk event matches disk name, or its sysfs path for ata ports and SCSI H:C:T:L addresses
+func eventMatchesDisk(event utils.KernelEventStruct, device string) bool {
+	if len(device) == 0 {
+		return false
+	}
+	if event.Source == "ata" || strings.Contains(event.Device, ":") {
+		link, err := utils.ReadSysfsLink("/sys/class/block/" + device)
+		return err == nil && strings.Contains(link, "/"+event.Device+"/")
+	}
+	return event.Device == device
+}
+
+// Attach events to its disks and controllers, disks with events are flagged: ONLINE/Kernel-Errors
+// Hardware raid logical drives events are attached to its controller
+// Events not matching any disk or controller are returned
+func AttributeKernelEvents(events []utils.KernelEventStruct, controllers []utils.ControllerStruct, raids []utils.RaidStruct, noRaidDisks []utils.NoRaidDiskStruct) []utils.KernelEventStruct {
+	unattributed := []utils.KernelEventStruct{}
+	diskDevices := make(map[string]string)
+	diskDevice := func(eidSlot, osDevice string) string {
+		key := eidSlot + "|" + osDevice
+		if _, ok := diskDevices[key]; !ok {
+			diskDevices[key] = getDiskKernelDevice(eidSlot, osDevice)
+		}
+		return diskDevices[key]
+	}
+
+	for _, event := range events {
+		attributed := false
+		switch event.Source {
+		case "nvme", "megaraid_sas", "mpt2sas", "mpt3sas", "aacraid", "hpsa", "smartpqi":
+			if index := findEventController(event, controllers); index >= 0 {
+				controllers[index].KernelEvents = append(controllers[index].KernelEvents, event)
+				attributed = true
+			}
+		default:
+			for i := range raids {
+				for j := range raids[i].Disks {
+					disk := &raids[i].Disks[j]
+					if eventMatchesDisk(event, diskDevice(disk.EidSlot, disk.OsDevice)) {
+						disk.KernelEvents = append(disk.KernelEvents, event)
+						if !strings.Contains(disk.State, "/Kernel-Errors") {
+							disk.State = disk.State + "/Kernel-Errors"
+						}
+						attributed = true
+					}
+				}
+			}
+			for i := range noRaidDisks {
+				noRaidDisk := &noRaidDisks[i]
+				if eventMatchesDisk(event, diskDevice(noRaidDisk.EidSlot, noRaidDisk.OsDevice)) {
+					noRaidDisk.KernelEvents = append(noRaidDisk.KernelEvents, event)
+					if !strings.Contains(noRaidDisk.State, "/Kernel-Errors") {
+						noRaidDisk.State = noRaidDisk.State + "/Kernel-Errors"
+					}
+					attributed = true
+				}
+			}
+			if attributed {
+				break
+			}
+			// Hardware raid logical drives
+			for _, raid := range raids {
+				manufacturer := strings.Split(raid.ControllerId, "-")[0]
+				if !slices.Contains(hardwareManufacturers, manufacturer) || !eventMatchesDisk(event, getDiskKernelDevice("", raid.OsDevice)) {
+					continue
+				}
+				index := slices.IndexFunc(controllers, func(controller utils.ControllerStruct) bool { return controller.Id == raid.ControllerId })
+				if index >= 0 {
+					controllers[index].KernelEvents = append(controllers[index].KernelEvents, event)
+					attributed = true
+					break
+				}
+			}
+		}
+		if !attributed {
+			unattributed = append(unattributed, event)
+		}
+	}
+	return unattributed
+}
@@ -0,0 +1,217 @@
+package kernellog
+
+import (
+	"errors"
+	"hardwareAnalyzer/utils"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Test parseLine timestamps formats
+func TestParseLine(t *testing.T) {
+	bootTime := time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		line     string
+		lastSeen string
+		message  string
+	}{
+		{"3,1234,61500000,-;ata3: hard resetting link", "2026-10-19 10:01:01", "ata3: hard resetting link"},
+		{"[  125.123456] md/raid1:md0: Disk failure on sdb1, disabling device.", "2026-10-19 10:02:05", "md/raid1:md0: Disk failure on sdb1, disabling device."},
+		{"[Mon Oct 19 11:30:00 2026] nvme nvme0: I/O 12 QID 3 timeout, reset controller", "2026-10-19 11:30:00", "nvme nvme0: I/O 12 QID 3 timeout, reset controller"},
+		{"kernel message without timestamp", "Unknown", "kernel message without timestamp"},
+	}
+	for _, test := range tests {
+		lastSeen, message := parseLine(test.line, bootTime)
+		if lastSeen != test.lastSeen {
+			t.Errorf("parseLine(%s) lastSeen: %v should be: %v", test.line, lastSeen, test.lastSeen)
+		}
+		if message != test.message {
+			t.Errorf("parseLine(%s) message: %v should be: %v", test.line, message, test.message)
+		}
+	}
+
+	lastSeen, _ := parseLine("[  125.123456] ata3: hard resetting link", time.Time{})
+	if lastSeen != "Unknown" {
+		t.Errorf("parseLine without boot time lastSeen: %v should be: Unknown", lastSeen)
+	}
+}
+
+// Test ScanKernelLog events parsing and grouping
+func TestScanKernelLog(t *testing.T) {
+	// Copy original functions content
+	readKernelLogOri := ReadKernelLog
+	getBootTimeOri := getBootTime
+	readSysfsLinkOri := utils.ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		ReadKernelLog = readKernelLogOri
+		getBootTime = getBootTimeOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	ReadKernelLog = func(path string) ([]string, error) {
+		return []string{
+			"[   10.000000] ata3: SATA link up 6.0 Gbps (SStatus 133 SControl 300)",
+			"[   10.100000] ata4: SATA link down (SStatus 0 SControl 300)",
+			"[  100.000000] blk_update_request: I/O error, dev sdb, sector 2048 op 0x0:(READ) flags 0x0 phys_seg 1 prio class 0",
+			"[  160.000000] I/O error, dev sdb, sector 4096 op 0x0:(READ) flags 0x0 phys_seg 1 prio class 0",
+			"[  170.000000] Buffer I/O error on dev sdb1, logical block 0, async page read",
+			"[  180.000000] sd 2:0:0:0: [sdc] tag#9 Sense Key : Medium Error [current]",
+			"[  180.000001] sd 2:0:0:0: [sdc] tag#9 Add. Sense: Unrecovered read error",
+			"[  190.000000] critical medium error, dev sdc, sector 8192 op 0x0:(READ)",
+			"[  195.000000] critical medium error, dev nvme0n1, sector 4096 op 0x0:(READ)",
+			"[  200.000000] ata3.00: exception Emask 0x0 SAct 0x0 SErr 0x0 action 0x6 frozen",
+			"[  201.000000] ata3: hard resetting link",
+			"[  210.000000] sd 4:0:1:0: attempting task abort!scmd(0x0000000012345678), outstanding for 30000 ms & timeout 30000 ms",
+			"[  220.000000] md/raid1:md0: Disk failure on sdb1, disabling device.",
+			"[  230.000000] zio pool=tank vdev=/dev/disk/by-id/ata-ST4000-part1 error=5 type=1 offset=270336 size=8192 flags=b08c1",
+			"[  240.000000] BTRFS error (device sde): bdev /dev/sdf errs: wr 0, rd 1, flush 0, corrupt 0, gen 0",
+			"[  250.000000] nvme nvme0: I/O 123 QID 4 timeout, reset controller",
+			"[  260.000000] megaraid_sas 0000:03:00.0: resetting fusion adapter scsi0.",
+			"[  270.000000] mpt3sas_cm1: sending diag reset !!",
+			"[  280.000000] aacraid: Host adapter abort request.",
+			"[  290.000000] hpsa 0000:05:00.0: Controller lockup detected: 0x00130000 after 30",
+			"[  300.000000] Buffer I/O error on dev dm-0, logical block 0, async page read",
+			"[  310.000000] blk_update_request: I/O error, dev dm-3, sector 2048 op 0x1:(WRITE) flags 0x0 phys_seg 1 prio class 0",
+			"[  320.000000] md/raid1:md1: Disk failure on dm-4, disabling device.",
+		}, nil
+	}
+	getBootTime = func() (time.Time, error) {
+		return time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local), nil
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		links := map[string]string{
+			"/sys/class/block/sdb1":            "../../devices/pci0000:00/0000:00:17.0/ata2/host1/target1:0:0/1:0:0:0/block/sdb/sdb1",
+			"/dev/disk/by-id/ata-ST4000-part1": "../../sdd1",
+			"/sys/class/block/sdd1":            "../../devices/pci0000:00/0000:00:17.0/ata4/host3/target3:0:0/3:0:0:0/block/sdd/sdd1",
+		}
+		link, ok := links[path]
+		if !ok {
+			return "", errors.New("No such link")
+		}
+		return link, nil
+	}
+
+	want := []utils.KernelEventStruct{
+		{Source: "block", Device: "sdb", Type: "IO-Error", Count: 3, LastSeen: "2026-10-19 10:02:50"},
+		{Source: "scsi", Device: "sdc", Type: "Medium-Error", Count: 1, LastSeen: "2026-10-19 10:03:10"},
+		{Source: "block", Device: "nvme0n1", Type: "Medium-Error", Count: 1, LastSeen: "2026-10-19 10:03:15"},
+		{Source: "ata", Device: "ata3", Type: "ATA-Exception", Count: 1, LastSeen: "2026-10-19 10:03:20"},
+		{Source: "ata", Device: "ata3", Type: "Link-Reset", Count: 1, LastSeen: "2026-10-19 10:03:21"},
+		{Source: "scsi", Device: "4:0:1:0", Type: "Task-Abort", Count: 1, LastSeen: "2026-10-19 10:03:30"},
+		{Source: "md", Device: "sdb", Type: "MD-Disk-Failure", Count: 1, LastSeen: "2026-10-19 10:03:40"},
+		{Source: "zfs", Device: "sdd", Type: "ZFS-IO-Error", Count: 1, LastSeen: "2026-10-19 10:03:50"},
+		{Source: "btrfs", Device: "sdf", Type: "Btrfs-Errors", Count: 1, LastSeen: "2026-10-19 10:04:00"},
+		{Source: "nvme", Device: "nvme0", Type: "Controller-Reset", Count: 1, LastSeen: "2026-10-19 10:04:10"},
+		{Source: "megaraid_sas", Device: "0000:03:00.0", Type: "Controller-Reset", Count: 1, LastSeen: "2026-10-19 10:04:20"},
+		{Source: "mpt3sas", Device: "cm1", Type: "Controller-Reset", Count: 1, LastSeen: "2026-10-19 10:04:30"},
+		{Source: "aacraid", Device: "", Type: "Controller-Reset", Count: 1, LastSeen: "2026-10-19 10:04:40"},
+		{Source: "hpsa", Device: "0000:05:00.0", Type: "Controller-Reset", Count: 1, LastSeen: "2026-10-19 10:04:50"},
+		{Source: "block", Device: "dm-0", Type: "IO-Error", Count: 1, LastSeen: "2026-10-19 10:05:00"},
+		{Source: "block", Device: "dm-3", Type: "IO-Error", Count: 1, LastSeen: "2026-10-19 10:05:10"},
+		{Source: "md", Device: "dm-4", Type: "MD-Disk-Failure", Count: 1, LastSeen: "2026-10-19 10:05:20"},
+	}
+
+	events, err := ScanKernelLog("/var/log/dmesg")
+	if err != nil {
+		t.Errorf("ScanKernelLog err: %v should be: nil", err)
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("ScanKernelLog: %+v should be: %+v", events, want)
+	}
+
+	// Unreadable kernel log
+	ReadKernelLog = func(path string) ([]string, error) {
+		return nil, errors.New("Permission denied")
+	}
+	events, err = ScanKernelLog("/dev/kmsg")
+	if err == nil {
+		t.Errorf("ScanKernelLog err: %v should be: not nil", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("ScanKernelLog: %v should be: []", events)
+	}
+}
+
+// Test AttributeKernelEvents disks, hardware raid logical drives and controllers matching
+func TestAttributeKernelEvents(t *testing.T) {
+	// Copy original functions content
+	readSysfsLinkOri := utils.ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		utils.ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		links := map[string]string{
+			"/sys/class/block/sdb1": "../../devices/pci0000:00/0000:00:17.0/ata2/host1/target1:0:0/1:0:0:0/block/sdb/sdb1",
+			"/sys/class/block/sdb":  "../../devices/pci0000:00/0000:00:17.0/ata2/host1/target1:0:0/1:0:0:0/block/sdb",
+			"/sys/class/block/sdc1": "../../devices/pci0000:00/0000:00:17.0/ata3/host2/target2:0:0/2:0:0:0/block/sdc/sdc1",
+			"/sys/class/block/sdc":  "../../devices/pci0000:00/0000:00:17.0/ata3/host2/target2:0:0/2:0:0:0/block/sdc",
+			"/sys/class/block/sdg":  "../../devices/pci0000:00/0000:00:02.0/0000:04:00.0/host4/target4:0:1/4:0:1:0/block/sdg",
+		}
+		link, ok := links[path]
+		if !ok {
+			return "", errors.New("No such link")
+		}
+		return link, nil
+	}
+
+	controllers := []utils.ControllerStruct{
+		{Id: "softraid-0", Manufacturer: "mdadm"},
+		{Id: "mega-0", Manufacturer: "mega", PciAddress: "00:03:00:00"},
+		{Id: "mega-1", Manufacturer: "mega", PciAddress: "00:05:00:00"},
+		{Id: "nvme-0", Manufacturer: "nvme"},
+	}
+	raids := []utils.RaidStruct{
+		{ControllerId: "softraid-0", OsDevice: "md0", Disks: []utils.DiskStruct{
+			{ControllerId: "softraid-0", State: "ONLINE", OsDevice: "sdb1"},
+			{ControllerId: "softraid-0", State: "ONLINE", OsDevice: "sdc1"},
+		}},
+		{ControllerId: "mega-0", OsDevice: "SDA", Disks: []utils.DiskStruct{
+			{ControllerId: "mega-0", EidSlot: "252:0", State: "Onln", OsDevice: "SDA"},
+		}},
+	}
+	noRaidDisks := []utils.NoRaidDiskStruct{
+		{ControllerId: "mega-1", EidSlot: "252:1", State: "JBOD", OsDevice: "JBOD-sdg"},
+	}
+	events := []utils.KernelEventStruct{
+		{Source: "block", Device: "sdb", Type: "IO-Error", Count: 3, LastSeen: "2026-10-19 10:02:50"},
+		{Source: "ata", Device: "ata3", Type: "Link-Reset", Count: 1, LastSeen: "2026-10-19 10:03:21"},
+		{Source: "scsi", Device: "4:0:1:0", Type: "Task-Abort", Count: 1, LastSeen: "2026-10-19 10:03:30"},
+		{Source: "block", Device: "sda", Type: "IO-Error", Count: 1, LastSeen: "2026-10-19 10:03:40"},
+		{Source: "nvme", Device: "nvme0", Type: "Controller-Reset", Count: 1, LastSeen: "2026-10-19 10:04:10"},
+		{Source: "megaraid_sas", Device: "0000:05:00.0", Type: "Controller-Reset", Count: 1, LastSeen: "2026-10-19 10:04:20"},
+		{Source: "block", Device: "sdz", Type: "IO-Error", Count: 1, LastSeen: "2026-10-19 10:04:30"},
+		{Source: "mpt3sas", Device: "cm0", Type: "Controller-Reset", Count: 1, LastSeen: "2026-10-19 10:04:40"},
+	}
+
+	unattributed := AttributeKernelEvents(events, controllers, raids, noRaidDisks)
+
+	wantUnattributed := []utils.KernelEventStruct{events[6], events[7]}
+	if !reflect.DeepEqual(unattributed, wantUnattributed) {
+		t.Errorf("AttributeKernelEvents unattributed: %+v should be: %+v", unattributed, wantUnattributed)
+	}
+	if !reflect.DeepEqual(raids[0].Disks[0].KernelEvents, []utils.KernelEventStruct{events[0]}) || raids[0].Disks[0].State != "ONLINE/Kernel-Errors" {
+		t.Errorf("AttributeKernelEvents sdb1: %+v %v should be: %+v ONLINE/Kernel-Errors", raids[0].Disks[0].KernelEvents, raids[0].Disks[0].State, events[0])
+	}
+	if !reflect.DeepEqual(raids[0].Disks[1].KernelEvents, []utils.KernelEventStruct{events[1]}) || raids[0].Disks[1].State != "ONLINE/Kernel-Errors" {
+		t.Errorf("AttributeKernelEvents sdc1: %+v %v should be: %+v ONLINE/Kernel-Errors", raids[0].Disks[1].KernelEvents, raids[0].Disks[1].State, events[1])
+	}
+	if len(raids[1].Disks[0].KernelEvents) != 0 || raids[1].Disks[0].State != "Onln" {
+		t.Errorf("AttributeKernelEvents hardware raid disk: %+v %v should be: [] Onln", raids[1].Disks[0].KernelEvents, raids[1].Disks[0].State)
+	}
+	if !reflect.DeepEqual(noRaidDisks[0].KernelEvents, []utils.KernelEventStruct{events[2]}) || noRaidDisks[0].State != "JBOD/Kernel-Errors" {
+		t.Errorf("AttributeKernelEvents JBOD-sdg: %+v %v should be: %+v JBOD/Kernel-Errors", noRaidDisks[0].KernelEvents, noRaidDisks[0].State, events[2])
+	}
+	if !reflect.DeepEqual(controllers[1].KernelEvents, []utils.KernelEventStruct{events[3]}) {
+		t.Errorf("AttributeKernelEvents mega-0: %+v should be: %+v", controllers[1].KernelEvents, events[3])
+	}
+	if !reflect.DeepEqual(controllers[2].KernelEvents, []utils.KernelEventStruct{events[5]}) {
+		t.Errorf("AttributeKernelEvents mega-1: %+v should be: %+v", controllers[2].KernelEvents, events[5])
+	}
+	if !reflect.DeepEqual(controllers[3].KernelEvents, []utils.KernelEventStruct{events[4]}) {
+		t.Errorf("AttributeKernelEvents nvme-0: %+v should be: %+v", controllers[3].KernelEvents, events[4])
+	}
+}
@@ -2,9 +2,11 @@ package lvm
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"hardwareAnalyzer/utils"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -63,7 +65,154 @@ var CheckLVMRaid = func() (bool, error) {
 	return false, nil
 }
 
+// lvm fullreport JSON output, one report per VG, all values are strings
+type lvmFullReport struct {
+	Report []lvmReport `json:"report"`
+}
+
+type lvmReport struct {
+	Vg  []map[string]string `json:"vg"`
+	Pv  []map[string]string `json:"pv"`
+	Lv  []map[string]string `json:"lv"`
+	Seg []map[string]string `json:"seg"`
+}
+
+// Convert LVM size in bytes to human: 478482006016B -> 478 GB
+func lvmSize(size string) string {
+	// Remove alphas
+	re := regexp.MustCompile(`\D`)
+	sizeValue := re.ReplaceAllString(size, "")
+	sizeValueInt, _ := strconv.ParseUint(sizeValue, 10, 64)
+	return human.Bytes(sizeValueInt)
+}
+
+// Hidden LVs are reported between brackets: [lv_rimage_0]
+func lvmLvName(lvName string) string {
+	return strings.Trim(lvName, "[]")
+}
+
+// Get PVs where LV really lives following its segments devices: /dev/sda3(0),lv_rimage_0(0)
+// Devices that are LVs (raid images, thin pool data, cache pools) are resolved recursively
+func getLvPvs(report lvmReport, lvUuid string, visited map[string]bool) []string {
+	pvs := []string{}
+	if visited[lvUuid] {
+		return pvs
+	}
+	visited[lvUuid] = true
+
+	for _, seg := range report.Seg {
+		if seg["lv_uuid"] != lvUuid {
+			continue
+		}
+		devices := []string{}
+		for _, field := range []string{"devices", "metadata_devices"} {
+			if len(seg[field]) > 0 {
+				devices = append(devices, strings.Split(seg[field], ",")...)
+			}
+		}
+		// Segments without devices field: /dev/sda3:0-12799 /dev/sdb:0-99
+		if len(devices) == 0 {
+			devices = append(devices, strings.Fields(seg["seg_pe_ranges"])...)
+		}
+		// Thin volumes and cached LVs reference its pool
+		for _, field := range []string{"pool_lv", "data_lv", "metadata_lv"} {
+			if len(seg[field]) > 0 {
+				devices = append(devices, seg[field])
+			}
+		}
+		for _, device := range devices {
+			// Remove extents info: /dev/sda3(0), /dev/sda3:0-12799
+			device = strings.Split(strings.Split(strings.TrimSpace(device), "(")[0], ":")[0]
+			if len(device) == 0 {
+				continue
+			}
+			if strings.HasPrefix(device, "/dev/") || device == "[unknown]" {
+				if !slices.Contains(pvs, device) {
+					pvs = append(pvs, device)
+				}
+				continue
+			}
+			for _, lv := range report.Lv {
+				if lvmLvName(lv["lv_name"]) == lvmLvName(device) {
+					for _, pv := range getLvPvs(report, lv["lv_uuid"], visited) {
+						if !slices.Contains(pvs, pv) {
+							pvs = append(pvs, pv)
+						}
+					}
+					break
+				}
+			}
+		}
+	}
+	return pvs
+}
+
+// Get LV health from lvm status fields, returned state: ONLINE, DEGRADED, FAILED, WARNING
+// raid/mirror: health status, sync action and progress, mismatches, check/repair scrubs dont degrade the LV
+// cache/writecache: cache mode and dirty blocks
+// thin-pool: data and metadata usage against thresholds
+func getLvHealth(lv map[string]string, lvType string, cacheMode string) (string, []string) {
+	state := "ONLINE"
+	var details []string
+
+	switch {
+	case lvType == "mirror" || (strings.HasPrefix(lvType, "raid") && lvType != "raid0" && lvType != "raid0_meta"):
+		if len(lv["raid_sync_action"]) > 0 {
+			details = append(details, "sync "+lv["raid_sync_action"])
+		}
+		if len(lv["sync_percent"]) > 0 && lv["sync_percent"] != "100.00" {
+			details = append(details, lv["sync_percent"]+"% synced")
+			if lv["raid_sync_action"] == "recover" || lv["raid_sync_action"] == "resync" {
+				state = "DEGRADED"
+			}
+		}
+		if len(lv["raid_mismatch_count"]) > 0 && lv["raid_mismatch_count"] != "0" {
+			details = append(details, lv["raid_mismatch_count"]+" mismatches")
+		}
+	case lvType == "cache" || lvType == "writecache":
+		if len(cacheMode) > 0 {
+			details = append(details, "mode "+cacheMode)
+		}
+		// writecache dirty data is reported as blocks pending writeback
+		dirtyBlocks := lv["cache_dirty_blocks"]
+		if lvType == "writecache" {
+			dirtyBlocks = lv["writecache_writeback_blocks"]
+		}
+		if len(dirtyBlocks) > 0 {
+			details = append(details, dirtyBlocks+" dirty blocks")
+		}
+	case lvType == "thin-pool":
+		dataPercent, err := strconv.ParseFloat(lv["data_percent"], 64)
+		if err == nil {
+			details = append(details, "data "+lv["data_percent"]+"%")
+			if dataPercent >= float64(utils.Thresholds.ThinPoolData) {
+				state = "WARNING"
+			}
+		}
+		metadataPercent, err := strconv.ParseFloat(lv["metadata_percent"], 64)
+		if err == nil {
+			details = append(details, "metadata "+lv["metadata_percent"]+"%")
+			if metadataPercent >= float64(utils.Thresholds.ThinPoolMetadata) {
+				state = "WARNING"
+			}
+		}
+	}
+
+	// partial, refresh needed, mismatches exist, out_of_data, metadata_read_only, failed
+	switch lv["lv_health_status"] {
+	case "":
+	case "failed":
+		state = "FAILED"
+		details = append(details, "health "+lv["lv_health_status"])
+	default:
+		state = "DEGRADED"
+		details = append(details, "health "+lv["lv_health_status"])
+	}
+	return state, details
+}
+
 // LVM: Drives are in VG, yet LVs determine the RAID level.
+// All LVM data is read with one fullreport command, each LV only lists PVs where its segments are allocated
 var ProcessLVMRaid = func(manufacturer string) ([]utils.ControllerStruct, []utils.VolumeGroupStruct, []utils.RaidStruct, error) {
 	//fmt.Println("-- processLVMRaid --")
 	var controllers = []utils.ControllerStruct{}
@@ -80,8 +229,7 @@ var ProcessLVMRaid = func(manufacturer string) ([]utils.ControllerStruct, []util
 
 	fmt.Println("> Getting current LVM configuration.")
 
-	// VGs:
-	command := "vgs --noheadings --units b -o vg_name,vg_size,vg_missing_pv_count"
+	command := "fullreport --reportformat json --units b"
 	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "processLVMRaid", command)
 	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
 	if err != nil {
@@ -116,204 +264,97 @@ var ProcessLVMRaid = func(manufacturer string) ([]utils.ControllerStruct, []util
 	}
 
 	fmt.Println("> Parsing LVM data.")
-	vgsScanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
-	vgName := "Unknown"
-	vgSize := "Unknown"
-	vgMissingPvCount := 0
-	vgHealth := "Unknown"
-	for vgsScanner.Scan() {
-		line := vgsScanner.Text()
-		line = strings.TrimSpace(line)
-		if len(line) == 0 {
+	var fullReport lvmFullReport
+	if err := json.Unmarshal(outputStdout.Bytes(), &fullReport); err != nil {
+		color.Red("++ ERROR: Something went wrong parsing command %s output: %v.", command, err)
+		return controllers, volumeGroups, raids, fmt.Errorf("Error: Something went wrong parsing command %s output: %v.", command, err)
+	}
+
+	for _, report := range fullReport.Report {
+		// Orphan PVs report has no VG
+		if len(report.Vg) == 0 {
 			continue
 		}
-		vgName = strings.Fields(line)[0]
-
-		// Size in human
-		vgSize = strings.Fields(line)[1]
-		// Remove alphas
-		re := regexp.MustCompile(`\D`)
-		vgSizeValue := re.ReplaceAllString(vgSize, "")
-		vgSizeInt, _ := strconv.Atoi(vgSizeValue)
-		vgSize = human.Bytes(uint64(vgSizeInt))
-
-		vgMissingPvCount, _ = strconv.Atoi(strings.Fields(line)[2])
-		if vgMissingPvCount == 0 {
-			vgHealth = "ONLINE"
-		} else {
+		vg := report.Vg[0]
+		vgName := vg["vg_name"]
+		vgHealth := "ONLINE"
+		vgMissingPvCount, _ := strconv.Atoi(vg["vg_missing_pv_count"])
+		if vgMissingPvCount != 0 {
 			vgHealth = "Bad: " + strconv.Itoa(vgMissingPvCount) + " missing device."
 		}
 		volumeGroup := utils.VolumeGroupStruct{
 			ControllerId: "lvm-0",
 			Name:         vgName,
 			State:        vgHealth,
-			Size:         vgSize,
+			Size:         lvmSize(vg["vg_size"]),
 		}
 		volumeGroups = append(volumeGroups, volumeGroup)
-	}
-
-	// LVs:
-	command = "vgs --noheadings --units b -o lv_size,segtype,vg_name,lv_path"
-	outputStdout, outputStderr, err = utils.GetCommandOutput(manufacturer, "processLVMRaid", command)
-	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
-	if err != nil {
-		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
-		return controllers, volumeGroups, raids, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
-	}
-	if len(outputStderr.String()) != 0 {
-		// Check if its a missing device error or a real error
-		scanner := bufio.NewScanner(strings.NewReader(outputStderr.String()))
-		missingDeviceError := false
-		for scanner.Scan() {
-			line := scanner.Text()
-			line = strings.TrimSpace(line)
-			if len(line) == 0 {
-				continue
-			}
-			//fmt.Println("line: ", line)
-			if strings.Contains(line, "is missing PV") {
-				missingDeviceError = true
-				break
-			}
-			// VG using old PV header
-			if strings.Contains(line, "is using an old PV header, modify the VG to update") {
-				missingDeviceError = true
-				break
-			}
-		}
-		if !missingDeviceError {
-			color.Red("++ ERROR: Something went wrong executing command: %s.", command)
-			return controllers, volumeGroups, raids, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
-		}
-	}
 
-	lvScanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
-	lvSize := "Unknown"
-	lvType := "Unknown"
-	lvVg := "Unknown"
-	lvPath := "Unknown"
-	lvStatus := "Unknown"
-	for lvScanner.Scan() {
-		line := lvScanner.Text()
-		line = strings.TrimSpace(line)
-		if len(line) == 0 {
-			continue
+		// VG health determines LV health
+		lvStatus := "ONLINE"
+		if vgHealth != "ONLINE" {
+			lvStatus = "Bad"
 		}
 
-		// Size in human
-		lvSize = strings.Fields(line)[0]
-		// Remove alphas
-		re := regexp.MustCompile(`\D`)
-		lvSizeValue := re.ReplaceAllString(lvSize, "")
-		lvSizeValueInt, _ := strconv.Atoi(lvSizeValue)
-		lvSize = human.Bytes(uint64(lvSizeValueInt))
-
-		lvType = strings.Fields(line)[1]
-		lvVg = strings.Fields(line)[2]
-		if len(strings.Fields(line)) == 4 {
-			lvPath = strings.Fields(line)[3]
-			lvPath = strings.ReplaceAll(lvPath, "/dev/", "")
-		} else {
-			lvPath = "NONE"
-		}
-		// Search VG health to determine LV health
-		for _, volumeGroup := range volumeGroups {
-			if lvVg == volumeGroup.Name {
-				if volumeGroup.State == "ONLINE" {
-					lvStatus = volumeGroup.State
-				} else {
-					lvStatus = "Bad"
-				}
-				break
+		for _, lv := range report.Lv {
+			// Hidden LVs: raid images, thin pool data/metadata, cache pools
+			if strings.HasPrefix(lv["lv_name"], "[") {
+				continue
 			}
-		}
-
-		raid := utils.RaidStruct{
-			ControllerId: "lvm-0",
-			RaidLevel:    0,
-			Dg:           lvVg,
-			RaidType:     lvType,
-			State:        lvStatus,
-			Size:         lvSize,
-			OsDevice:     lvPath,
-		}
-
-		// Add PVs to raid(LV)
-		command := "pvs --noheadings --units b -o pv_name,vg_name,pv_size"
-		outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "processLVMRaid", command)
-		//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
-		if err != nil {
-			color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
-			return controllers, volumeGroups, raids, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
-		}
-		if len(outputStderr.String()) != 0 {
-			// Check if its a missing device error or a real error
-			scanner := bufio.NewScanner(strings.NewReader(outputStderr.String()))
-			missingDeviceError := false
-			for scanner.Scan() {
-				line := scanner.Text()
-				line = strings.TrimSpace(line)
-				if len(line) == 0 {
-					continue
-				}
-				//fmt.Println("line: ", line)
-				if strings.Contains(line, "is missing PV") {
-					missingDeviceError = true
-					break
-				}
-				// VG using old PV header
-				if strings.Contains(line, "is using an old PV header, modify the VG to update") {
-					missingDeviceError = true
+			lvType := "Unknown"
+			cacheMode := ""
+			for _, seg := range report.Seg {
+				if seg["lv_uuid"] == lv["lv_uuid"] {
+					lvType = seg["segtype"]
+					cacheMode = seg["cache_mode"]
 					break
 				}
 			}
-			if !missingDeviceError {
-				color.Red("++ ERROR: Something went wrong executing command: %s.", command)
-				return controllers, volumeGroups, raids, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+			lvPath := strings.ReplaceAll(lv["lv_path"], "/dev/", "")
+			if len(lvPath) == 0 {
+				lvPath = "NONE"
 			}
-		}
 
-		//fmt.Println("> Parsing PVS list.")
-		pvScanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
-		diskSerialNumber := "Unknown"
-		diskMedium := "Unknown"
-		diskModel := "Unknown"
-		diskIntf := "Unknown"
-		for pvScanner.Scan() {
-			line := pvScanner.Text()
-			line = strings.TrimSpace(line)
-			if len(line) == 0 {
-				continue
+			// VG missing PVs health takes precedence over LV health
+			lvHealth, lvDetails := getLvHealth(lv, lvType, cacheMode)
+			if lvStatus != "ONLINE" {
+				lvHealth = lvStatus
+			}
+
+			raid := utils.RaidStruct{
+				ControllerId: "lvm-0",
+				RaidLevel:    0,
+				Dg:           vgName,
+				RaidType:     lvType,
+				State:        lvHealth,
+				Size:         lvmSize(lv["lv_size"]),
+				OsDevice:     lvPath,
+				Details:      lvDetails,
 			}
-			//fmt.Printf("--- LINE: |%v|\n", line)
-			//fmt.Println("len(line): ", len(line))
-
-			diskPv := strings.Fields(line)[0]
-			diskPv = strings.ReplaceAll(diskPv, "/dev/", "")
-			//fmt.Println("diskPv: ", diskPv)
-			diskVg := strings.Fields(line)[1]
-			//fmt.Println("diskVg: ", diskVg)
-
-			if diskVg == lvVg {
-				// Size in human
-				diskSize := strings.Fields(line)[2]
-				// Remove alphas
-				re := regexp.MustCompile(`\D`)
-				diskSizeValue := re.ReplaceAllString(diskSize, "")
-				diskSizeValueInt, _ := strconv.Atoi(diskSizeValue)
-				diskSize = human.Bytes(uint64(diskSizeValueInt))
-
-				//fmt.Println("Disk size: ", diskSize)
-				diskSerialNumber, diskModel, diskIntf, diskMedium, err = utils.GetDiskData(diskPv)
+
+			// Add PVs to raid(LV)
+			for _, lvPv := range getLvPvs(report, lv["lv_uuid"], make(map[string]bool)) {
+				diskSize := "Unknown"
+				diskState := "ONLINE"
+				for _, pv := range report.Pv {
+					if pv["pv_name"] == lvPv {
+						diskSize = lvmSize(pv["pv_size"])
+						if len(pv["pv_missing"]) > 0 {
+							diskState = "MISSING"
+						}
+						break
+					}
+				}
+				diskPv := strings.ReplaceAll(lvPv, "/dev/", "")
+				diskSerialNumber, diskModel, diskIntf, diskMedium, err := utils.GetDiskData(diskPv)
 				if err != nil {
 					color.Red("++ ERROR: utils.GetDiskData: %s", err)
 				}
 
-				// We assume that if disk appears listed, its online
 				physicalDisk := utils.DiskStruct{
 					ControllerId: "lvm-0",
-					Dg:           diskVg,
-					State:        "ONLINE",
+					Dg:           vgName,
+					State:        diskState,
 					Size:         diskSize,
 					Intf:         diskIntf,
 					Medium:       diskMedium,
@@ -322,11 +363,108 @@ var ProcessLVMRaid = func(manufacturer string) ([]utils.ControllerStruct, []util
 					OsDevice:     diskPv,
 				}
 				raid.AddDisk(physicalDisk)
-				continue
 			}
+			//spew.Dump(raid)
+			raids = append(raids, raid)
 		}
-		//spew.Dump(raid)
-		raids = append(raids, raid)
 	}
+
+	// Hot spares
+	spares := GetLVMSpares(fullReport, raids)
+	for _, spare := range spares {
+		controllers[0].AddSpare(spare)
+	}
+
 	return controllers, volumeGroups, raids, nil
 }
+
+// Get activation/raid_fault_policy, default value is included when it isnt configured: raid_fault_policy="warn"
+// Function as variable in order to be able to mock it from unit tests
+var GetLVMRaidFaultPolicy = func() (string, error) {
+	command := "lvmconfig --typeconfig full activation/raid_fault_policy"
+	outputStdout, _, err := utils.GetCommandOutput("lvm", "getLVMRaidFaultPolicy", command)
+	//fmt.Println("out:", outputStdout.String())
+	if err != nil {
+		return "", fmt.Errorf("Something went wrong executing command %s: %v", command, err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "raid_fault_policy=") {
+			continue
+		}
+		return strings.Trim(strings.TrimPrefix(line, "raid_fault_policy="), `"`), nil
+	}
+	return "", fmt.Errorf("raid_fault_policy not found in %s output", command)
+}
+
+// LVM has no hot spare concept, but raid LVs with raid_fault_policy=allocate rebuild using VG free PVs, so completely unused PVs in VGs with redundant LVs are considered dedicated spares of that VG.
+// With any other policy(default warn) nothing is rebuilt automatically, so no spares are reported.
+// PVs are taken from already parsed fullreport pv section
+// Function as variable in order to be able to mock it from unit tests
+var GetLVMSpares = func(fullReport lvmFullReport, raids []utils.RaidStruct) []utils.SpareStruct {
+	var spares = []utils.SpareStruct{}
+
+	// Get VGs with redundant LVs
+	redundantVgs := []string{}
+	for _, raid := range raids {
+		if utils.IsRedundantRaid(raid.RaidType) && !slices.Contains(redundantVgs, raid.Dg) {
+			redundantVgs = append(redundantVgs, raid.Dg)
+		}
+	}
+	if len(redundantVgs) == 0 {
+		return spares
+	}
+
+	raidFaultPolicy, err := GetLVMRaidFaultPolicy()
+	if err != nil {
+		color.Red("++ ERROR: GetLVMRaidFaultPolicy: %s", err)
+		return spares
+	}
+	//fmt.Println("raidFaultPolicy: ", raidFaultPolicy)
+	if raidFaultPolicy != "allocate" {
+		return spares
+	}
+
+	// Remove alphas
+	re := regexp.MustCompile(`\D`)
+	for _, report := range fullReport.Report {
+		// Orphan PVs report has no VG
+		if len(report.Vg) == 0 {
+			continue
+		}
+		diskVg := report.Vg[0]["vg_name"]
+		if !slices.Contains(redundantVgs, diskVg) {
+			continue
+		}
+		for _, pv := range report.Pv {
+			diskUsedValue := re.ReplaceAllString(pv["pv_used"], "")
+			diskUsedValueInt, err := strconv.ParseUint(diskUsedValue, 10, 64)
+			if err != nil || diskUsedValueInt != 0 || len(pv["pv_missing"]) > 0 {
+				continue
+			}
+
+			diskPv := strings.ReplaceAll(pv["pv_name"], "/dev/", "")
+			diskSerialNumber, diskModel, diskIntf, diskMedium, err := utils.GetDiskData(diskPv)
+			if err != nil {
+				color.Red("++ ERROR: utils.GetDiskData: %s", err)
+			}
+
+			spare := utils.SpareStruct{
+				ControllerId: "lvm-0",
+				Type:         "Dedicated",
+				Arrays:       []string{diskVg},
+				State:        "Available",
+				Size:         lvmSize(pv["pv_size"]),
+				Intf:         diskIntf,
+				Medium:       diskMedium,
+				Model:        diskModel,
+				SerialNumber: diskSerialNumber,
+				OsDevice:     diskPv,
+			}
+			spares = append(spares, spare)
+		}
+	}
+	return spares
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"hardwareAnalyzer/utils"
+	"reflect"
 	"testing"
 )
 
@@ -103,18 +104,38 @@ func TestProcessLVMRaid(t *testing.T) {
 		var outputStdout, outputStderr bytes.Buffer
 
 		switch command {
-		case "vgs --noheadings --units b -o vg_name,vg_size,vg_missing_pv_count":
-			outputStdout.WriteString("  test-vg 478482006016B           0")
-		case "vgs --noheadings --units b -o lv_size,segtype,vg_name,lv_path":
+		case "fullreport --reportformat json --units b":
 			outputStdout.WriteString(`
-					53687091200B linear test-vg /dev/test-vg/root-lv
-  					214748364800B linear test-vg /dev/test-vg/lv-0
-  					210046550016B linear test-vg /dev/test-vg/lv-1
-				`)
-		case "pvs --noheadings --units b -o pv_name,vg_name,pv_size":
-			outputStdout.WriteString(`
-					/dev/sda3  test-vg 478482006016B
-				`)
+  {
+      "report": [
+          {
+              "vg": [
+                  {"vg_fmt":"lvm2", "vg_uuid":"Qx2Vd1-cE0I-i3aL-bfd9-KczX-srRr-5x2ZkI", "vg_name":"test-vg", "vg_attr":"wz--n-", "vg_size":"478482006016B", "vg_free":"0B", "vg_missing_pv_count":"0"}
+              ]
+              ,
+              "pv": [
+                  {"pv_fmt":"lvm2", "pv_uuid":"b1Xz0e-8pQm-3kLw-Yd2c-Ux9R-tV4n-HsE7aP", "pv_name":"/dev/sda3", "pv_size":"478482006016B", "pv_used":"478482006016B", "pv_missing":""}
+              ]
+              ,
+              "lv": [
+                  {"lv_uuid":"aaaaaa-0000-0000-0000-0000-0000-000001", "lv_name":"root-lv", "lv_full_name":"test-vg/root-lv", "lv_path":"/dev/test-vg/root-lv", "lv_size":"53687091200B", "lv_attr":"-wi-ao----"},
+                  {"lv_uuid":"aaaaaa-0000-0000-0000-0000-0000-000002", "lv_name":"lv-0", "lv_full_name":"test-vg/lv-0", "lv_path":"/dev/test-vg/lv-0", "lv_size":"214748364800B", "lv_attr":"-wi-ao----"},
+                  {"lv_uuid":"aaaaaa-0000-0000-0000-0000-0000-000003", "lv_name":"lv-1", "lv_full_name":"test-vg/lv-1", "lv_path":"/dev/test-vg/lv-1", "lv_size":"210046550016B", "lv_attr":"-wi-ao----"}
+              ]
+              ,
+              "pvseg": [
+                  {"pvseg_start":"0", "pvseg_size":"12800", "pv_uuid":"b1Xz0e-8pQm-3kLw-Yd2c-Ux9R-tV4n-HsE7aP", "lv_uuid":"aaaaaa-0000-0000-0000-0000-0000-000001"}
+              ]
+              ,
+              "seg": [
+                  {"segtype":"linear", "stripes":"1", "seg_start":"0B", "seg_size":"53687091200B", "seg_pe_ranges":"/dev/sda3:0-12799", "devices":"/dev/sda3(0)", "lv_uuid":"aaaaaa-0000-0000-0000-0000-0000-000001"},
+                  {"segtype":"linear", "stripes":"1", "seg_start":"0B", "seg_size":"214748364800B", "seg_pe_ranges":"/dev/sda3:12800-63999", "devices":"/dev/sda3(12800)", "lv_uuid":"aaaaaa-0000-0000-0000-0000-0000-000002"},
+                  {"segtype":"linear", "stripes":"1", "seg_start":"0B", "seg_size":"210046550016B", "seg_pe_ranges":"/dev/sda3:64000-114078", "devices":"/dev/sda3(64000)", "lv_uuid":"aaaaaa-0000-0000-0000-0000-0000-000003"}
+              ]
+          }
+      ]
+  }
+`)
 		default:
 			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
 		}
@@ -304,3 +325,297 @@ func TestProcessLVMRaidGetCommandOutputError(t *testing.T) {
 		t.Fatalf(`TestProcessLVMRaidGetCommandOutputError err should be != nil`)
 	}
 }
+
+// Test GetLVMSpares
+func TestGetLVMSpares(t *testing.T) {
+	// Copy original functions content
+	getDiskDataOri := utils.GetDiskData
+	getLVMRaidFaultPolicyOri := GetLVMRaidFaultPolicy
+	// unmock functions content
+	defer func() {
+		utils.GetDiskData = getDiskDataOri
+		GetLVMRaidFaultPolicy = getLVMRaidFaultPolicyOri
+	}()
+
+	// Mocked function
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "SERIALNUMBER-" + diskDrive, "MODEL-" + diskDrive, "SATA", "HDD", nil
+	}
+	GetLVMRaidFaultPolicy = func() (string, error) {
+		return "allocate", nil
+	}
+
+	// raid-vg: sdd unused, linear-vg: sde unused but VG without redundant LVs, sdf orphan PV
+	fullReport := lvmFullReport{
+		Report: []lvmReport{
+			{
+				Vg: []map[string]string{{"vg_name": "raid-vg"}},
+				Pv: []map[string]string{
+					{"pv_name": "/dev/sdb", "pv_size": "1000204886016B", "pv_used": "500107862016B", "pv_missing": ""},
+					{"pv_name": "/dev/sdc", "pv_size": "1000204886016B", "pv_used": "500107862016B", "pv_missing": ""},
+					{"pv_name": "/dev/sdd", "pv_size": "1000204886016B", "pv_used": "0B", "pv_missing": ""},
+				},
+			},
+			{
+				Vg: []map[string]string{{"vg_name": "linear-vg"}},
+				Pv: []map[string]string{
+					{"pv_name": "/dev/sde", "pv_size": "1000204886016B", "pv_used": "0B", "pv_missing": ""},
+				},
+			},
+			{
+				Pv: []map[string]string{
+					{"pv_name": "/dev/sdf", "pv_size": "1000204886016B", "pv_used": "0B", "pv_missing": ""},
+				},
+			},
+		},
+	}
+	raids := []utils.RaidStruct{
+		{ControllerId: "lvm-0", Dg: "raid-vg", RaidType: "raid1"},
+		{ControllerId: "lvm-0", Dg: "linear-vg", RaidType: "linear"},
+	}
+	spares := GetLVMSpares(fullReport, raids)
+
+	if len(spares) != 1 {
+		t.Fatalf(`TestGetLVMSpares: len(spares): %v must match 1`, len(spares))
+	}
+	if spares[0].OsDevice != "sdd" {
+		t.Fatalf(`TestGetLVMSpares: spares[0].OsDevice: %v must match sdd`, spares[0].OsDevice)
+	}
+	if len(spares[0].Arrays) != 1 || spares[0].Arrays[0] != "raid-vg" {
+		t.Fatalf(`TestGetLVMSpares: spares[0].Arrays: %v must match [raid-vg]`, spares[0].Arrays)
+	}
+	if spares[0].Size != "1.0 TB" {
+		t.Fatalf(`TestGetLVMSpares: spares[0].Size: %v must match 1.0 TB`, spares[0].Size)
+	}
+
+	// Default warn policy doesnt rebuild automatically
+	GetLVMRaidFaultPolicy = func() (string, error) {
+		return "warn", nil
+	}
+	spares = GetLVMSpares(fullReport, raids)
+	if len(spares) != 0 {
+		t.Fatalf(`TestGetLVMSpares: warn policy len(spares): %v must match 0`, len(spares))
+	}
+
+	// Unknown policy
+	GetLVMRaidFaultPolicy = func() (string, error) {
+		return "", fmt.Errorf("Unknown command")
+	}
+	spares = GetLVMSpares(fullReport, raids)
+	if len(spares) != 0 {
+		t.Fatalf(`TestGetLVMSpares: unknown policy len(spares): %v must match 0`, len(spares))
+	}
+}
+
+// Test GetLVMRaidFaultPolicy
+func TestGetLVMRaidFaultPolicy(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "lvmconfig --typeconfig full activation/raid_fault_policy" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		outputStdout.WriteString(`raid_fault_policy="allocate"
+`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	raidFaultPolicy, err := GetLVMRaidFaultPolicy()
+	if err != nil || raidFaultPolicy != "allocate" {
+		t.Fatalf(`TestGetLVMRaidFaultPolicy: raidFaultPolicy: %v err: %v should be: allocate nil`, raidFaultPolicy, err)
+	}
+
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		return &outputStdout, &outputStderr, fmt.Errorf("exit status 5")
+	}
+	_, err = GetLVMRaidFaultPolicy()
+	if err == nil {
+		t.Fatalf(`TestGetLVMRaidFaultPolicy err should be != nil`)
+	}
+}
+
+// Test ProcessLVMRaid LVs segments mapping to its PVs
+func TestProcessLVMRaidSegments(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	getDiskDataOri := utils.GetDiskData
+	getLVMSparesOri := GetLVMSpares
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		utils.GetDiskData = getDiskDataOri
+		GetLVMSpares = getLVMSparesOri
+	}()
+
+	// Mocked function: linear LV on sdb, raid1 LV on sdc/sdd through its images, thin LV on sdb through its pool
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		switch command {
+		case "fullreport --reportformat json --units b":
+			outputStdout.WriteString(`
+  {
+      "report": [
+          {
+              "vg": [
+                  {"vg_name":"data-vg", "vg_size":"3000592982016B", "vg_missing_pv_count":"0"}
+              ]
+              ,
+              "pv": [
+                  {"pv_name":"/dev/sdb", "pv_size":"1000204886016B", "pv_missing":""},
+                  {"pv_name":"/dev/sdc", "pv_size":"1000204886016B", "pv_missing":""},
+                  {"pv_name":"/dev/sdd", "pv_size":"1000204886016B", "pv_missing":""}
+              ]
+              ,
+              "lv": [
+                  {"lv_uuid":"lv-linear", "lv_name":"linear-lv", "lv_path":"/dev/data-vg/linear-lv", "lv_size":"107374182400B"},
+                  {"lv_uuid":"lv-raid", "lv_name":"raid-lv", "lv_path":"/dev/data-vg/raid-lv", "lv_size":"107374182400B"},
+                  {"lv_uuid":"lv-rimage-0", "lv_name":"[raid-lv_rimage_0]", "lv_path":"", "lv_size":"107374182400B"},
+                  {"lv_uuid":"lv-rimage-1", "lv_name":"[raid-lv_rimage_1]", "lv_path":"", "lv_size":"107374182400B"},
+                  {"lv_uuid":"lv-rmeta-0", "lv_name":"[raid-lv_rmeta_0]", "lv_path":"", "lv_size":"4194304B"},
+                  {"lv_uuid":"lv-rmeta-1", "lv_name":"[raid-lv_rmeta_1]", "lv_path":"", "lv_size":"4194304B"},
+                  {"lv_uuid":"lv-pool", "lv_name":"pool", "lv_path":"", "lv_size":"214748364800B"},
+                  {"lv_uuid":"lv-tdata", "lv_name":"[pool_tdata]", "lv_path":"", "lv_size":"214748364800B"},
+                  {"lv_uuid":"lv-tmeta", "lv_name":"[pool_tmeta]", "lv_path":"", "lv_size":"109051904B"},
+                  {"lv_uuid":"lv-thin", "lv_name":"thin-lv", "lv_path":"/dev/data-vg/thin-lv", "lv_size":"536870912000B"}
+              ]
+              ,
+              "seg": [
+                  {"segtype":"linear", "seg_pe_ranges":"/dev/sdb:0-25599", "devices":"/dev/sdb(0)", "lv_uuid":"lv-linear"},
+                  {"segtype":"raid1", "seg_pe_ranges":"", "devices":"raid-lv_rimage_0(0),raid-lv_rimage_1(0)", "metadata_devices":"raid-lv_rmeta_0(0),raid-lv_rmeta_1(0)", "lv_uuid":"lv-raid"},
+                  {"segtype":"linear", "seg_pe_ranges":"/dev/sdc:1-25600", "devices":"/dev/sdc(1)", "lv_uuid":"lv-rimage-0"},
+                  {"segtype":"linear", "seg_pe_ranges":"/dev/sdd:1-25600", "devices":"/dev/sdd(1)", "lv_uuid":"lv-rimage-1"},
+                  {"segtype":"linear", "seg_pe_ranges":"/dev/sdc:0-0", "devices":"/dev/sdc(0)", "lv_uuid":"lv-rmeta-0"},
+                  {"segtype":"linear", "seg_pe_ranges":"/dev/sdd:0-0", "devices":"/dev/sdd(0)", "lv_uuid":"lv-rmeta-1"},
+                  {"segtype":"thin-pool", "seg_pe_ranges":"", "devices":"pool_tdata(0)", "metadata_devices":"pool_tmeta(0)", "lv_uuid":"lv-pool"},
+                  {"segtype":"linear", "seg_pe_ranges":"", "devices":"/dev/sdb(25600)", "lv_uuid":"lv-tdata"},
+                  {"segtype":"linear", "seg_pe_ranges":"", "devices":"/dev/sdb(76800)", "lv_uuid":"lv-tmeta"},
+                  {"segtype":"thin", "seg_pe_ranges":"", "devices":"", "pool_lv":"pool", "lv_uuid":"lv-thin"}
+              ]
+          }
+          ,
+          {
+              "vg": [
+              ]
+              ,
+              "pv": [
+                  {"pv_name":"/dev/sde", "pv_size":"1000204886016B", "pv_missing":""}
+              ]
+          }
+      ]
+  }
+`)
+		default:
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		return &outputStdout, &outputStderr, nil
+	}
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "SERIALNUMBER-" + diskDrive, "MODEL-" + diskDrive, "SATA", "HDD", nil
+	}
+	GetLVMSpares = func(fullReport lvmFullReport, raids []utils.RaidStruct) []utils.SpareStruct {
+		return []utils.SpareStruct{}
+	}
+
+	_, newVolumeGroups, newRaids, err := ProcessLVMRaid("lvm")
+	if err != nil {
+		t.Fatalf(`TestProcessLVMRaidSegments Error: %v`, err)
+	}
+	if len(newVolumeGroups) != 1 || newVolumeGroups[0].Name != "data-vg" || newVolumeGroups[0].Size != "3.0 TB" {
+		t.Fatalf(`TestProcessLVMRaidSegments: newVolumeGroups: %v muts match data-vg 3.0 TB`, newVolumeGroups)
+	}
+
+	lvsWanted := map[string][]string{
+		"data-vg/linear-lv": {"linear", "sdb"},
+		"data-vg/raid-lv":   {"raid1", "sdc", "sdd"},
+		"NONE":              {"thin-pool", "sdb"},
+		"data-vg/thin-lv":   {"thin", "sdb"},
+	}
+	if len(newRaids) != len(lvsWanted) {
+		t.Fatalf(`TestProcessLVMRaidSegments: len(newRaids): %v muts match %v`, len(newRaids), len(lvsWanted))
+	}
+	for _, newRaid := range newRaids {
+		lv := []string{newRaid.RaidType}
+		for _, disk := range newRaid.Disks {
+			lv = append(lv, disk.OsDevice)
+		}
+		if !reflect.DeepEqual(lv, lvsWanted[newRaid.OsDevice]) {
+			t.Fatalf(`TestProcessLVMRaidSegments: %v type and PVs: %v should be: %v`, newRaid.OsDevice, lv, lvsWanted[newRaid.OsDevice])
+		}
+	}
+}
+
+// Test getLvHealth
+func TestGetLvHealth(t *testing.T) {
+	testCases := []struct {
+		lv            map[string]string
+		lvType        string
+		cacheMode     string
+		stateWanted   string
+		detailsWanted []string
+	}{
+		{
+			map[string]string{"lv_health_status": "", "raid_sync_action": "idle", "sync_percent": "100.00", "raid_mismatch_count": "0"},
+			"raid1", "",
+			"ONLINE", []string{"sync idle"},
+		},
+		{
+			map[string]string{"lv_health_status": "", "raid_sync_action": "recover", "sync_percent": "45.20", "raid_mismatch_count": "0"},
+			"raid5", "",
+			"DEGRADED", []string{"sync recover", "45.20% synced"},
+		},
+		{
+			map[string]string{"lv_health_status": "", "raid_sync_action": "check", "sync_percent": "30.00", "raid_mismatch_count": "0"},
+			"raid1", "",
+			"ONLINE", []string{"sync check", "30.00% synced"},
+		},
+		{
+			map[string]string{"lv_health_status": "mismatches exist", "raid_sync_action": "idle", "sync_percent": "100.00", "raid_mismatch_count": "128"},
+			"raid10", "",
+			"DEGRADED", []string{"sync idle", "128 mismatches", "health mismatches exist"},
+		},
+		{
+			map[string]string{"lv_health_status": "partial", "raid_sync_action": "idle", "sync_percent": "100.00", "raid_mismatch_count": "0"},
+			"raid6", "",
+			"DEGRADED", []string{"sync idle", "health partial"},
+		},
+		{
+			map[string]string{"lv_health_status": "", "cache_dirty_blocks": "12"},
+			"cache", "writeback",
+			"ONLINE", []string{"mode writeback", "12 dirty blocks"},
+		},
+		{
+			map[string]string{"lv_health_status": "", "cache_dirty_blocks": "", "writecache_writeback_blocks": "34"},
+			"writecache", "",
+			"ONLINE", []string{"34 dirty blocks"},
+		},
+		{
+			map[string]string{"lv_health_status": "", "data_percent": "87.00", "metadata_percent": "12.50"},
+			"thin-pool", "",
+			"WARNING", []string{"data 87.00%", "metadata 12.50%"},
+		},
+		{
+			map[string]string{"lv_health_status": "", "data_percent": "20.00", "metadata_percent": "10.00"},
+			"thin-pool", "",
+			"ONLINE", []string{"data 20.00%", "metadata 10.00%"},
+		},
+		{
+			map[string]string{"lv_health_status": "failed"},
+			"linear", "",
+			"FAILED", []string{"health failed"},
+		},
+	}
+	for _, testCase := range testCases {
+		state, details := getLvHealth(testCase.lv, testCase.lvType, testCase.cacheMode)
+		if state != testCase.stateWanted || !reflect.DeepEqual(details, testCase.detailsWanted) {
+			t.Fatalf(`TestGetLvHealth %v: %v %v should be: %v %v`, testCase.lvType, state, details, testCase.stateWanted, testCase.detailsWanted)
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"fmt"
 	"hardwareAnalyzer/hardwarecontrollerscommon"
+	"hardwareAnalyzer/smart"
 	"hardwareAnalyzer/utils"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
@@ -56,6 +58,357 @@ var GetMegaraidPercDriveSerialNumber = func(manufacturer, controllerId, eidSlot
 	return "Unknown", nil
 }
 
+// Get drive SMART data through controller: storcli /c0/e252/s1 show all, storcli /c0/e252/s1 show smart
+// show smart only returns ATA SMART data for SATA drives, SAS drives use controller error counters
+// Function as variable in order to be possible to mock it from unitary tests
+var GetMegaraidPercDriveSmart = func(manufacturer, controllerId, eidSlot string) (utils.SmartStruct, error) {
+	//fmt.Println("-- getMegaraidPercDriveSmart --")
+	driveSmart := smart.NewSmart("megaraid")
+	eidSlotData := strings.Split(eidSlot, ":")
+	if len(eidSlotData) != 2 {
+		color.Red("++ ERROR: Incorrect EID:Slot: %s", eidSlot)
+		return driveSmart, fmt.Errorf("Error: Incorrect EID:Slot: %s.", eidSlot)
+	}
+	drive := "/c" + controllerId + "/e" + eidSlotData[0] + "/s" + eidSlotData[1]
+
+	command := drive + " show smart"
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getMegaraidPercDriveSmart", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v", command, err)
+		return driveSmart, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return driveSmart, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+
+	// Smart Data Info /c0/e252/s1 =
+	// 0a 00 05 33 00 64 64 00 00 00 00 00 00 00 09 32
+	smartData := []byte{}
+	insideSmartData := false
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Smart Data Info ") {
+			insideSmartData = true
+			continue
+		}
+		if !insideSmartData {
+			continue
+		}
+		if len(line) == 0 {
+			if len(smartData) > 0 {
+				break
+			}
+			continue
+		}
+		for _, hexField := range strings.Fields(line) {
+			// Old versions group bytes by pairs: 0a00 0533
+			for len(hexField) >= 2 {
+				hexByte, err := strconv.ParseUint(hexField[:2], 16, 8)
+				if err != nil {
+					break
+				}
+				smartData = append(smartData, byte(hexByte))
+				hexField = hexField[2:]
+			}
+		}
+	}
+	if len(smartData) >= 362 {
+		driveSmart = smart.SmartFromAtaAttributes("megaraid", smart.ParseAtaSmartData(smartData, nil))
+	}
+
+	command = drive + " show all"
+	outputStdout, outputStderr, err = utils.GetCommandOutput(manufacturer, "getMegaraidPercDriveSmart", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v", command, err)
+		return driveSmart, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return driveSmart, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+
+	scanner = bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		if !strings.Contains(line, " = ") {
+			continue
+		}
+		lineData := strings.SplitN(line, " = ", 2)
+		key := strings.TrimSpace(lineData[0])
+		value := strings.TrimSpace(lineData[1])
+		switch key {
+		case "Media Error Count":
+			mediaErrors, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				driveSmart.MediaErrors = mediaErrors
+			}
+		// Drive Temperature =  32C (89.60 F)
+		case "Drive Temperature":
+			temperature, err := strconv.ParseInt(strings.TrimSuffix(strings.Fields(value + " ")[0], "C"), 10, 64)
+			if err == nil && driveSmart.Temperature < 0 {
+				driveSmart.Temperature = temperature
+			}
+		case "S.M.A.R.T alert flagged by drive":
+			if value == "Yes" {
+				driveSmart.Verdict = "FAILED"
+			} else if driveSmart.Verdict == "Unknown" {
+				driveSmart.Verdict = "PASSED"
+			}
+		}
+	}
+	return driveSmart, nil
+}
+
+// Function only used from processHWMegaraidPercRaid
+// Function as variable in order to be possible to mock it from unitary tests
+var GetMegaraidPercForeignConfigs = func(manufacturer, controllerId string) (int, error) {
+	//fmt.Println("-- getMegaraidPercForeignConfigs --")
+	command := "/c" + controllerId + "/fall show"
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getMegaraidPercForeignConfigs", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v", command, err)
+		return 0, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return 0, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		//fmt.Println("LINE: ", line)
+		if len(line) == 0 {
+			continue
+		}
+		// No foreign configuration: Description = Couldn't find any foreign Configuration
+		if strings.Contains(strings.ToLower(line), "couldn't find any foreign configuration") {
+			return 0, nil
+		}
+		// Total foreign drive groups = 1
+		if strings.Contains(strings.ToLower(line), "total foreign drive groups = ") {
+			foreignConfigsData := strings.Split(line, " = ")
+			foreignConfigs, err := strconv.Atoi(utils.ClearString(foreignConfigsData[1]))
+			if err != nil {
+				color.Red("++ ERROR: Incorrect foreign drive groups value: %s", foreignConfigsData[1])
+				return 0, fmt.Errorf("Error: Incorrect foreign drive groups value: %s.", foreignConfigsData[1])
+			}
+			return foreignConfigs, nil
+		}
+	}
+	return 0, nil
+}
+
+// Function only used from processHWMegaraidPercRaid
+// Function as variable in order to be possible to mock it from unitary tests
+var GetMegaraidPercControllerInfo = func(manufacturer, controllerId string, controller *utils.ControllerStruct) error {
+	//fmt.Println("-- getMegaraidPercControllerInfo --")
+	controller.FirmwareVersion = "Unknown"
+	controller.BiosVersion = "Unknown"
+	controller.DriverName = "Unknown"
+	controller.DriverVersion = "Unknown"
+	controller.PciAddress = "Unknown"
+	controller.SerialNumber = "Unknown"
+	controller.RocTemperature = "Unknown"
+
+	command := "/c" + controllerId + " show all"
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getMegaraidPercControllerInfo", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v", command, err)
+		return fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+
+	firmwarePackageFound := false
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		//fmt.Println("LINE: ", line)
+		if !strings.Contains(line, " = ") {
+			continue
+		}
+		lineData := strings.SplitN(line, " = ", 2)
+		key := strings.TrimSpace(lineData[0])
+		value := strings.TrimSpace(lineData[1])
+		if len(value) == 0 {
+			continue
+		}
+		switch key {
+		case "Serial Number":
+			controller.SerialNumber = value
+		case "PCI Address":
+			controller.PciAddress = value
+		// Firmware package version is preferred over firmware version when present
+		case "FW Package Build", "Firmware Package Build":
+			controller.FirmwareVersion = value
+			firmwarePackageFound = true
+		case "FW Version", "Firmware Version":
+			if !firmwarePackageFound {
+				controller.FirmwareVersion = value
+			}
+		case "BIOS Version":
+			controller.BiosVersion = value
+		case "Driver Name":
+			controller.DriverName = value
+		case "Driver Version":
+			controller.DriverVersion = value
+		case "ROC temperature(Degree Celsius)", "ROC temperature(Degree Celcius)":
+			controller.RocTemperature = value + " C"
+		}
+	}
+
+	// Old storcli/perccli versions dont report driver info
+	if controller.DriverName == "Unknown" || controller.DriverVersion == "Unknown" {
+		driverName, driverVersion := utils.GetKernelModuleVersion("megaraid_sas")
+		if controller.DriverName == "Unknown" {
+			controller.DriverName = driverName
+		}
+		if controller.DriverVersion == "Unknown" && controller.DriverName == driverName {
+			controller.DriverVersion = driverVersion
+		}
+	}
+	return nil
+}
+
+// Function only used from processHWMegaraidPercRaid
+// Function as variable in order to be possible to mock it from unitary tests
+var GetMegaraidPercEnclosures = func(manufacturer, controllerId string) ([]utils.EnclosureStruct, error) {
+	//fmt.Println("-- getMegaraidPercEnclosures --")
+	enclosures := []utils.EnclosureStruct{}
+	command := "/c" + controllerId + "/eall show all"
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "getMegaraidPercEnclosures", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v", command, err)
+		return enclosures, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return enclosures, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+
+	var enclosure *utils.EnclosureStruct
+	elementsSection := ""
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		//fmt.Println("LINE: ", line)
+		if len(line) == 0 || strings.HasPrefix(line, "---") || strings.HasPrefix(line, "===") {
+			continue
+		}
+		// Enclosure /c0/e252  :
+		if strings.HasPrefix(line, "Enclosure /c") {
+			enclosureIdData := strings.Split(strings.Fields(line)[1], "/e")
+			enclosures = append(enclosures, utils.EnclosureStruct{
+				ControllerId: manufacturer + "-" + controllerId,
+				Id:           enclosureIdData[len(enclosureIdData)-1],
+				State:        "Unknown",
+				Slots:        "Unknown",
+			})
+			enclosure = &enclosures[len(enclosures)-1]
+			elementsSection = ""
+			continue
+		}
+		if enclosure == nil {
+			continue
+		}
+		// Section headers: Fan Status :, Power Supply Status :, Temperature Sensor Status :
+		if strings.HasSuffix(line, " :") {
+			switch {
+			case strings.Contains(line, "Fan"):
+				elementsSection = "fan"
+			case strings.Contains(line, "Power Supply"):
+				elementsSection = "powerSupply"
+			case strings.Contains(line, "Temperature Sensor"):
+				elementsSection = "temperatureSensor"
+			default:
+				elementsSection = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "Vendor Identification = ") {
+			enclosure.Vendor = strings.TrimSpace(strings.TrimPrefix(line, "Vendor Identification = "))
+			continue
+		}
+		if strings.HasPrefix(line, "Product Identification = ") {
+			enclosure.Model = strings.TrimSpace(strings.TrimPrefix(line, "Product Identification = "))
+			continue
+		}
+		lineFields := strings.Fields(line)
+		// EID State Slots PD PS Fans TSs Alms SIM Port# ProdID VendorSpecific
+		// 252 OK        8  4  0    0   0    0   1 -         SGPIO
+		if len(elementsSection) == 0 && len(lineFields) > 2 && lineFields[0] == enclosure.Id {
+			enclosure.State = lineFields[1]
+			enclosure.Slots = lineFields[2]
+			continue
+		}
+		// ID Status Speed(rpm)
+		// 0  OK     5280
+		if len(elementsSection) > 0 && len(lineFields) > 1 && lineFields[0][0] >= '0' && lineFields[0][0] <= '9' {
+			elementStatus := lineFields[1]
+			switch elementsSection {
+			case "fan":
+				enclosure.Fans = append(enclosure.Fans, elementStatus)
+			case "powerSupply":
+				enclosure.PowerSupplies = append(enclosure.PowerSupplies, elementStatus)
+			case "temperatureSensor":
+				enclosure.TemperatureSensors = append(enclosure.TemperatureSensors, elementStatus)
+			}
+		}
+	}
+	return enclosures, nil
+}
+
+// Turn on/off disk identify LED: storcli /c0/e252/s1 start|stop locate
+// Function as variable in order to be possible to mock it from unitary tests
+var LocateMegaraidPercDisk = func(manufacturer, controllerId, eidSlot string, on bool) error {
+	//fmt.Println("-- locateMegaraidPercDisk --")
+	eidSlotData := strings.Split(eidSlot, ":")
+	if len(eidSlotData) != 2 {
+		color.Red("++ ERROR: Incorrect EID:Slot: %s", eidSlot)
+		return fmt.Errorf("Error: Incorrect EID:Slot: %s.", eidSlot)
+	}
+	locateAction := "stop"
+	if on {
+		locateAction = "start"
+	}
+	command := "/c" + controllerId + "/e" + eidSlotData[0] + "/s" + eidSlotData[1] + " " + locateAction + " locate"
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "locateMegaraidPercDisk", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v", command, err)
+		return fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Status = ") && line != "Status = Success" {
+			color.Red("++ ERROR: Command %s failed: %s", command, line)
+			return fmt.Errorf("Error: Command %s failed: %s.", command, line)
+		}
+	}
+	return nil
+}
+
 var CheckMegaraidPerc = func(manufacturer string) (bool, error) {
 	//fmt.Println("--- CheckMegaraidPerc ---")
 	// Execute storcli/perccli
@@ -144,6 +497,8 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 
 	insidePhysicalList := false
 	physicalDataLine := false
+	// HBA firmware PD LIST lacks Type column
+	physicalTypeColumn := true
 
 	tableBarSeparator := ""
 	tableBarSeparatorCounter := 0
@@ -152,6 +507,8 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 	var controllerId string
 	var controllerModel string
 	var controllerStatus string
+	// HBA firmware(IT/HBA personality) controllers have no TOPOLOGY/VD LIST sections, its drives are only listed in PD LIST section
+	controllerHbaMode := false
 
 	// Initialize raid variables
 	raidLevel := 0
@@ -195,6 +552,13 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 			continue
 		}
 
+		// Driver Name = mpt3sas: HBA firmware, Driver Name = megaraid_sas: RAID firmware
+		if strings.Contains(line, "Driver Name = ") {
+			controllerHbaMode = strings.Contains(line, "mpt3sas")
+			//fmt.Println("Controller HBA mode: ", controllerHbaMode)
+			continue
+		}
+
 		// Status:
 		if strings.Contains(line, "Controller Status = ") {
 			controllerStatusData := strings.Split(line, "Controller Status = ")
@@ -205,17 +569,35 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 
 		// Save controller data only when all fields have been already parsed
 		if len(controllerId) > 0 && len(controllerModel) > 0 && len(controllerStatus) > 0 {
+			// Pending foreign configurations, usually after a drive swap, must be imported or cleared
+			// HBA firmware has no drive groups, so it has no foreign configurations
+			foreignConfigs := 0
+			if !controllerHbaMode {
+				foreignConfigs, err = GetMegaraidPercForeignConfigs(manufacturer, controllerId)
+				if err != nil {
+					color.Red("++ ERROR Getting foreign configurations: %s", err)
+				}
+			}
+			if foreignConfigs > 0 {
+				controllerStatus = "Bad: " + strconv.Itoa(foreignConfigs) + " foreign config pending import."
+			}
 			controller := utils.ControllerStruct{
-				Id:           manufacturer + "-" + controllerId,
-				Manufacturer: manufacturer,
-				Model:        controllerModel,
-				Status:       controllerStatus,
+				Id:             manufacturer + "-" + controllerId,
+				Manufacturer:   manufacturer,
+				Model:          controllerModel,
+				Status:         controllerStatus,
+				ForeignConfigs: foreignConfigs,
+			}
+			err = GetMegaraidPercControllerInfo(manufacturer, controllerId, &controller)
+			if err != nil {
+				color.Red("++ ERROR Getting controller info: %s", err)
 			}
 			controllers = append(controllers, controller)
 			// Reset all controller variables since controller is already appended to controllers array
 			// Except controllerId variable that is used in other code parts
 			controllerModel = ""
 			controllerStatus = ""
+			controllerHbaMode = false
 		}
 
 		// TOPOLOGY
@@ -383,6 +765,55 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 				continue
 			}
 
+			// Hot spare drives, dedicated ones are listed under its DG, global ones usually are only shown in PD LIST section
+			if len(controllerId) > 0 && len(topologyDG) > 0 && len(topologyEIDSlot) > 0 && (topologyType == "DHS" || topologyType == "GHS") {
+				//fmt.Println("Hot spare drive detected")
+				finalTopologySize := strings.Join([]string{topologySize, topologySizeUnit}, " ")
+				for i := range controllers {
+					if controllers[i].Id != manufacturer+"-"+controllerId {
+						continue
+					}
+					// Dedicated spares can be assigned to more than one DG, in that case the drive is listed once per DG
+					spareFound := false
+					for j := range controllers[i].Spares {
+						spare := &controllers[i].Spares[j]
+						if spare.EidSlot == topologyEIDSlot {
+							if spare.Type == "Dedicated" {
+								spare.Arrays = append(spare.Arrays, topologyDG)
+							}
+							spareFound = true
+							break
+						}
+					}
+					if spareFound {
+						break
+					}
+
+					// Get serial number
+					serialNumber, err := GetMegaraidPercDriveSerialNumber(manufacturer, controllerId, topologyEIDSlot)
+					if err != nil {
+						color.Red("++ ERROR Getting drive serial number: %s", err)
+						return controllers, raids, noRaidDisks, err
+					}
+					spare := utils.SpareStruct{
+						ControllerId: manufacturer + "-" + controllerId,
+						Type:         "Dedicated",
+						Arrays:       []string{topologyDG},
+						State:        "Available",
+						EidSlot:      topologyEIDSlot,
+						Size:         finalTopologySize,
+						SerialNumber: serialNumber,
+					}
+					if topologyType == "GHS" {
+						spare.Type = "Global"
+						spare.Arrays = nil
+					}
+					controllers[i].AddSpare(spare)
+					break
+				}
+				continue
+			}
+
 			// CacheCade drives
 			if len(controllerId) > 0 && len(topologyDG) > 0 && len(topologyEIDSlot) > 0 && len(topologyType) > 0 && len(topologyState) > 0 && len(topologySize) > 0 && len(topologySizeUnit) > 0 && strings.Contains(topologyType, "Cac") {
 				//fmt.Println("CAC drive detected")
@@ -423,6 +854,11 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 			//fmt.Println("tableBarSeparatorCounter: ", tableBarSeparatorCounter)
 		}
 
+		// EID:Slt DID State DG Size Intf Med SED PI SeSz Model Sp Type
+		if insidePhysicalList && strings.HasPrefix(line, "EID:Slt") {
+			physicalTypeColumn = strings.HasSuffix(line, "Type")
+		}
+
 		if insidePhysicalList && tableBarSeparatorCounter == 2 && physicalDataLine == false {
 			physicalDataLine = true
 			//fmt.Println("physicalDataLine: ", physicalDataLine)
@@ -434,6 +870,7 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 			//fmt.Println("physicalDataLine: ", line)
 			physicalEidSlot := strings.Fields(line)[0]
 			physicalState := strings.Fields(line)[2]
+			physicalDG := strings.Fields(line)[3]
 			physicalSize := strings.Fields(line)[4]
 			physicalSizeUnit := strings.Fields(line)[5]
 			finalphysicalSize := strings.Join([]string{physicalSize, physicalSizeUnit}, " ")
@@ -448,7 +885,7 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 			//fmt.Println("physicalModelEndField: ", physicalModelEndField)
 
 			// Some models lacks Type column
-			if physicalModelEndField <= physicalModelStartField {
+			if !physicalTypeColumn || physicalModelEndField <= physicalModelStartField {
 				physicalModelEndField = len(strings.Fields(line)) - 1
 			}
 
@@ -470,6 +907,85 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 			//fmt.Println("physicalMedium: ", physicalMedium)
 			//fmt.Println("physicalModel: ", physicalModel)
 
+			// Hot spare drives: complete drive information if it was previously seen on TOPOLOGY section, add it otherwise
+			if physicalState == "DHS" || physicalState == "GHS" {
+				for i := range controllers {
+					if controllers[i].Id != manufacturer+"-"+controllerId {
+						continue
+					}
+					spareFound := false
+					for j := range controllers[i].Spares {
+						spare := &controllers[i].Spares[j]
+						if spare.EidSlot == physicalEidSlot {
+							spare.Size = finalphysicalSize
+							spare.Intf = physicalIntf
+							spare.Medium = physicalMedium
+							spare.Model = physicalModel
+							spareFound = true
+							break
+						}
+					}
+					if spareFound {
+						break
+					}
+
+					// Get serial number
+					serialNumber, err := GetMegaraidPercDriveSerialNumber(manufacturer, controllerId, physicalEidSlot)
+					if err != nil {
+						color.Red("++ ERROR Getting drive serial number: %s", err)
+						return controllers, raids, noRaidDisks, err
+					}
+					spare := utils.SpareStruct{
+						ControllerId: manufacturer + "-" + controllerId,
+						Type:         "Global",
+						State:        "Available",
+						EidSlot:      physicalEidSlot,
+						Size:         finalphysicalSize,
+						Intf:         physicalIntf,
+						Medium:       physicalMedium,
+						Model:        physicalModel,
+						SerialNumber: serialNumber,
+					}
+					if physicalState == "DHS" {
+						spare.Type = "Dedicated"
+						spare.Arrays = []string{physicalDG}
+					}
+					controllers[i].AddSpare(spare)
+					break
+				}
+				continue
+			}
+
+			// Foreign and unconfigured bad drives, foreign drives are shown with F as DG
+			if physicalState == "UBad" || physicalState == "UBUnsp" || physicalState == "Unconfigured(bad)" || physicalDG == "F" {
+				// Get serial number
+				serialNumber, err := GetMegaraidPercDriveSerialNumber(manufacturer, controllerId, physicalEidSlot)
+				if err != nil {
+					color.Red("++ ERROR Getting drive serial number: %s", err)
+					return controllers, raids, noRaidDisks, err
+				}
+				if physicalDG == "F" {
+					physicalState = "Foreign-" + physicalState
+				}
+				foreignBadDisk := utils.NoRaidDiskStruct{
+					ControllerId: manufacturer + "-" + controllerId,
+					EidSlot:      physicalEidSlot,
+					State:        physicalState,
+					Size:         finalphysicalSize,
+					Intf:         physicalIntf,
+					Medium:       physicalMedium,
+					Model:        physicalModel,
+					SerialNumber: serialNumber,
+				}
+				for i := range controllers {
+					if controllers[i].Id == manufacturer+"-"+controllerId {
+						controllers[i].AddForeignBadDisk(foreignBadDisk)
+						break
+					}
+				}
+				continue
+			}
+
 			// Add extra drive information and check if physical drive was previously seen on VolumeGroup section
 			eidSlotFound := false
 			for _, raid := range raids {
@@ -550,6 +1066,17 @@ var ProcessHWMegaraidPercRaid = func(manufacturer string) ([]utils.ControllerStr
 		lineNumber++
 	}
 
+	// Enclosures inventory and disks bay mapping
+	for i := range controllers {
+		controllerIdData := strings.Split(controllers[i].Id, "-")
+		enclosures, err := GetMegaraidPercEnclosures(manufacturer, controllerIdData[len(controllerIdData)-1])
+		if err != nil {
+			color.Red("++ ERROR Getting enclosures: %s", err)
+		}
+		controllers[i].Enclosures = enclosures
+		utils.SetControllerBays(&controllers[i], raids, noRaidDisks)
+	}
+
 	//fmt.Println("> Done.")
 
 	//fmt.Println("len controllers", len(controllers))
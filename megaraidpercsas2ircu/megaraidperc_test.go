@@ -2,8 +2,10 @@ package megaraidpercsas2ircu
 
 import (
 	"bytes"
+	"fmt"
 	"hardwareAnalyzer/hardwarecontrollerscommon"
 	"hardwareAnalyzer/utils"
+	"reflect"
 	"strconv"
 	"testing"
 )
@@ -521,3 +523,568 @@ func TestProcessHWMegaraidPercRaidOutputStderr(t *testing.T) {
 		t.Fatalf(`TestProcessHWMegaraidPercRaidOutputStderr returned nil error, it should be != nil`)
 	}
 }
+
+// Test GetMegaraidPercForeignConfigs
+func TestGetMegaraidPercForeignConfigs(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "/c0/fall show" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		// storcli /c0/fall show
+		outputStdout.WriteString(`
+			Controller = 0
+			Status = Success
+			Description = Operation on foreign configuration Succeeded
+
+			FOREIGN CONFIGURATION :
+			=====================
+
+			---------------------------------------
+			DG EID:Slot Type  State       Size NoVDs
+			---------------------------------------
+			 0 -        RAID1 Frgn  1.089 TB     1
+			---------------------------------------
+
+			NoVDs - Number of VDs in disk group|DG - Diskgroup
+			Total foreign drive groups = 1
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	foreignConfigs, err := GetMegaraidPercForeignConfigs("mega", "0")
+	if err != nil {
+		t.Fatalf(`TestGetMegaraidPercForeignConfigs returned error: %s`, err)
+	}
+	foreignConfigsWanted := 1
+	if foreignConfigs != foreignConfigsWanted {
+		t.Fatalf(`TestGetMegaraidPercForeignConfigs foreignConfigs: %v should be: %v`, foreignConfigs, foreignConfigsWanted)
+	}
+}
+
+// Test GetMegaraidPercForeignConfigs without foreign configuration
+func TestGetMegaraidPercForeignConfigsNone(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		outputStdout.WriteString(`
+			Controller = 0
+			Status = Success
+			Description = Couldn't find any foreign Configuration
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	foreignConfigs, err := GetMegaraidPercForeignConfigs("mega", "0")
+	if err != nil {
+		t.Fatalf(`TestGetMegaraidPercForeignConfigsNone returned error: %s`, err)
+	}
+	if foreignConfigs != 0 {
+		t.Fatalf(`TestGetMegaraidPercForeignConfigsNone foreignConfigs: %v should be: 0`, foreignConfigs)
+	}
+}
+
+// Test ProcessHWMegaraidPercRaid foreign and unconfigured bad drives
+func TestProcessHWMegaraidPercRaidForeignBadDisks(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	getMegaraidPercDriveSerialNumberOri := GetMegaraidPercDriveSerialNumber
+	getMegaraidPercForeignConfigsOri := GetMegaraidPercForeignConfigs
+	getRaidOSDeviceOri := hardwarecontrollerscommon.GetRaidOSDevice
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		GetMegaraidPercDriveSerialNumber = getMegaraidPercDriveSerialNumberOri
+		GetMegaraidPercForeignConfigs = getMegaraidPercForeignConfigsOri
+		hardwarecontrollerscommon.GetRaidOSDevice = getRaidOSDeviceOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		// storcli /call show all
+		outputStdout.WriteString(`
+			Controller = 0
+			Model = LSI MegaRAID AlfaExploit Model
+			Controller Status = Optimal
+			TOPOLOGY :
+			------------------------------------------------------------------------------
+			DG Arr Row EID:Slot DID Type   State BT       Size PDC  PI SED DS3  FSpace TR
+			------------------------------------------------------------------------------
+			0 -   -   -        -   RAID1  Optl  N  744.687 GB enbl N  N   dflt N      N
+			0 0   -   -        -   RAID1  Optl  N  744.687 GB enbl N  N   dflt N      N
+			0 0   0   252:0    5   DRIVE  Onln  N  744.687 GB enbl N  N   dflt -      N
+			0 0   1   252:1    7   DRIVE  Onln  N  744.687 GB enbl N  N   dflt -      N
+			------------------------------------------------------------------------------
+
+			PD LIST :
+			---------------------------------------------------------------------------------
+			EID:Slt DID State DG       Size Intf Med SED PI SeSz Model               Sp Type
+			---------------------------------------------------------------------------------
+			252:0     5 Onln   0 744.687 GB SATA SSD N   N  512B INTEL SSDSC2BB800H4 U  -
+			252:1     7 Onln   0 744.687 GB SATA SSD N   N  512B INTEL SSDSC2BB800H4 U  -
+			252:2     6 UGood  F 744.687 GB SATA SSD N   N  512B INTEL SSDSC2BB800H4 U  -
+			252:3     4 UBad   - 744.687 GB SATA SSD N   N  512B INTEL SSDSC2BB800H4 U  -
+			---------------------------------------------------------------------------------
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	GetMegaraidPercDriveSerialNumber = func(manufacturer, controllerId, eidSlot string) (string, error) {
+		return "TESTSERIALNUMBER-" + eidSlot, nil
+	}
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	GetMegaraidPercForeignConfigs = func(manufacturer, controllerId string) (int, error) {
+		return 1, nil
+	}
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	hardwarecontrollerscommon.GetRaidOSDevice = func(manufacturer, controllerId, dg string) (string, error) {
+		return "TESTOSRAIDDEVICE", nil
+	}
+
+	newControllers, _, newNoRaidDisks, err := ProcessHWMegaraidPercRaid("mega")
+	if err != nil {
+		t.Fatalf(`TestProcessHWMegaraidPercRaidForeignBadDisks returned error: %s`, err)
+	}
+
+	if len(newNoRaidDisks) != 0 {
+		t.Fatalf(`TestProcessHWMegaraidPercRaidForeignBadDisks len(newNoRaidDisks): %v should be: 0`, len(newNoRaidDisks))
+	}
+
+	newControllerStatusWanted := "Bad: 1 foreign config pending import."
+	if newControllers[0].Status != newControllerStatusWanted {
+		t.Fatalf(`TestProcessHWMegaraidPercRaidForeignBadDisks newController.Status: %v should be: %v`, newControllers[0].Status, newControllerStatusWanted)
+	}
+
+	foreignBadDisksWanted := map[string]string{"252:2": "Foreign-UGood", "252:3": "UBad"}
+	if len(newControllers[0].ForeignBadDisks) != len(foreignBadDisksWanted) {
+		t.Fatalf(`TestProcessHWMegaraidPercRaidForeignBadDisks len(ForeignBadDisks): %v should be: %v`, len(newControllers[0].ForeignBadDisks), len(foreignBadDisksWanted))
+	}
+	for _, foreignBadDisk := range newControllers[0].ForeignBadDisks {
+		if foreignBadDisk.State != foreignBadDisksWanted[foreignBadDisk.EidSlot] {
+			t.Fatalf(`TestProcessHWMegaraidPercRaidForeignBadDisks foreignBadDisk.State: %v should be: %v`, foreignBadDisk.State, foreignBadDisksWanted[foreignBadDisk.EidSlot])
+		}
+		if foreignBadDisk.SerialNumber != "TESTSERIALNUMBER-"+foreignBadDisk.EidSlot {
+			t.Fatalf(`TestProcessHWMegaraidPercRaidForeignBadDisks foreignBadDisk.SerialNumber: %v should be: TESTSERIALNUMBER-%v`, foreignBadDisk.SerialNumber, foreignBadDisk.EidSlot)
+		}
+	}
+}
+
+// Test ProcessHWMegaraidPercRaid with a HBA firmware controller: no TOPOLOGY section, drives only in PD LIST without Type column
+func TestProcessHWMegaraidPercRaidHbaMode(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	getMegaraidPercDriveSerialNumberOri := GetMegaraidPercDriveSerialNumber
+	getMegaraidPercForeignConfigsOri := GetMegaraidPercForeignConfigs
+	getMegaraidPercEnclosuresOri := GetMegaraidPercEnclosures
+	getJbodOsDeviceOri := hardwarecontrollerscommon.GetJbodOsDevice
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		GetMegaraidPercDriveSerialNumber = getMegaraidPercDriveSerialNumberOri
+		GetMegaraidPercForeignConfigs = getMegaraidPercForeignConfigsOri
+		GetMegaraidPercEnclosures = getMegaraidPercEnclosuresOri
+		hardwarecontrollerscommon.GetJbodOsDevice = getJbodOsDeviceOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "/call show all" && command != "/c0 show all" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		// storcli /call show all, /c0 show all
+		outputStdout.WriteString(`
+			Generating detailed summary of the adapter, it may take a while to complete.
+
+			CLI Version = 007.2408.0000.0000 Nov 15, 2022
+			Operating system = Linux 6.1.0-13-amd64
+			Controller = 0
+			Status = Success
+			Description = None
+
+			Basics :
+			======
+			Controller = 0
+			Adapter Type =   SAS3408(B0)
+			Model = HBA 9400-8i
+			Serial Number = SP81234567
+			SAS Address =  500605b00e0a1b20
+			PCI Address = 00:5e:00:00
+
+			Version :
+			=======
+			Firmware Package Build = 24.00.00.00
+			Firmware Version = 24.00.00.00
+			Bios Version = 09.47.00.00_24.00.00.00
+			NVDATA Version = 24.00.00.15
+			Driver Name = mpt3sas
+			Driver Version = 43.100.00.00
+
+			Status :
+			======
+			Controller Status = OK
+			Memory Correctable Errors = 0
+			Memory Uncorrectable Errors = 0
+
+			PD LIST :
+			=======
+
+			--------------------------------------------------------------------------------------
+			EID:Slt DID State DG       Size Intf Med SED PI SeSz Model                          Sp
+			--------------------------------------------------------------------------------------
+			2:0       9 JBOD  -    3.637 TB SATA HDD N   N  512B ST4000NM0035-1V4107            U
+			2:1      10 JBOD  -  893.750 GB SATA SSD N   N  512B SAMSUNG MZ7LH960HAJR-00005     U
+			--------------------------------------------------------------------------------------
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	// Mocked functions, this way we can run unit tests in servers without hardware raid controller installed.
+	GetMegaraidPercDriveSerialNumber = func(manufacturer, controllerId, eidSlot string) (string, error) {
+		return "TESTSERIALNUMBER-" + eidSlot, nil
+	}
+	foreignConfigsQueried := false
+	GetMegaraidPercForeignConfigs = func(manufacturer, controllerId string) (int, error) {
+		foreignConfigsQueried = true
+		return 0, fmt.Errorf("Un-supported command")
+	}
+	GetMegaraidPercEnclosures = func(manufacturer, controllerId string) ([]utils.EnclosureStruct, error) {
+		return []utils.EnclosureStruct{}, nil
+	}
+	hardwarecontrollerscommon.GetJbodOsDevice = func(manufacturer, controllerId, eidslot string) (string, error) {
+		return map[string]string{"2:0": "sdb", "2:1": "sdc"}[eidslot], nil
+	}
+
+	newControllers, newRaids, newNoRaidDisks, err := ProcessHWMegaraidPercRaid("mega")
+	if err != nil {
+		t.Fatalf(`TestProcessHWMegaraidPercRaidHbaMode returned error: %s`, err)
+	}
+	if foreignConfigsQueried {
+		t.Fatalf(`TestProcessHWMegaraidPercRaidHbaMode foreign configurations should not be queried in HBA mode`)
+	}
+	if len(newControllers) != 1 || len(newRaids) != 0 {
+		t.Fatalf(`TestProcessHWMegaraidPercRaidHbaMode len(newControllers): %v len(newRaids): %v should be: 1 0`, len(newControllers), len(newRaids))
+	}
+	controllerWanted := map[string][2]string{
+		"Id":              {newControllers[0].Id, "mega-0"},
+		"Model":           {newControllers[0].Model, "HBA 9400-8i"},
+		"Status":          {newControllers[0].Status, "OK"},
+		"FirmwareVersion": {newControllers[0].FirmwareVersion, "24.00.00.00"},
+		"DriverName":      {newControllers[0].DriverName, "mpt3sas"},
+		"PciAddress":      {newControllers[0].PciAddress, "00:5e:00:00"},
+	}
+	for field, values := range controllerWanted {
+		if values[0] != values[1] {
+			t.Fatalf(`TestProcessHWMegaraidPercRaidHbaMode controller.%s: %v should be: %v`, field, values[0], values[1])
+		}
+	}
+
+	noRaidDisksWanted := []utils.NoRaidDiskStruct{
+		{ControllerId: "mega-0", EidSlot: "2:0", State: "JBOD", Size: "3.637 TB", Intf: "SATA", Medium: "HDD", Model: "ST4000NM0035-1V4107", SerialNumber: "TESTSERIALNUMBER-2:0", OsDevice: "JBOD-sdb", Bay: "Enclosure 2 Slot 0"},
+		{ControllerId: "mega-0", EidSlot: "2:1", State: "JBOD", Size: "893.750 GB", Intf: "SATA", Medium: "SSD", Model: "SAMSUNG MZ7LH960HAJR-00005", SerialNumber: "TESTSERIALNUMBER-2:1", OsDevice: "JBOD-sdc", Bay: "Enclosure 2 Slot 1"},
+	}
+	if !reflect.DeepEqual(newNoRaidDisks, noRaidDisksWanted) {
+		t.Fatalf(`TestProcessHWMegaraidPercRaidHbaMode newNoRaidDisks: %+v should be: %+v`, newNoRaidDisks, noRaidDisksWanted)
+	}
+}
+
+// Test GetMegaraidPercControllerInfo
+func TestGetMegaraidPercControllerInfo(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "/c0 show all" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		// storcli /c0 show all
+		outputStdout.WriteString(`
+			Basics :
+			======
+			Controller = 0
+			Model = PERC H730P Mini
+			Serial Number = 59M00FS
+			PCI Address = 00:02:00:00
+
+			Version :
+			=======
+			Firmware Package Build = 25.5.9.0001
+			Firmware Version = 4.300.00-8366
+			BIOS Version = 6.33.01.0_4.19.08.00_0x06120304
+			Driver Name = megaraid_sas
+			Driver Version = 07.714.04.00-rc1
+
+			HwCfg :
+			=====
+			ROC temperature(Degree Celsius) = 62
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	controller := utils.ControllerStruct{}
+	err := GetMegaraidPercControllerInfo("perc", "0", &controller)
+	if err != nil {
+		t.Fatalf(`TestGetMegaraidPercControllerInfo returned error: %s`, err)
+	}
+
+	controllerInfoWanted := map[string][2]string{
+		"FirmwareVersion": {controller.FirmwareVersion, "25.5.9.0001"},
+		"BiosVersion":     {controller.BiosVersion, "6.33.01.0_4.19.08.00_0x06120304"},
+		"DriverName":      {controller.DriverName, "megaraid_sas"},
+		"DriverVersion":   {controller.DriverVersion, "07.714.04.00-rc1"},
+		"PciAddress":      {controller.PciAddress, "00:02:00:00"},
+		"SerialNumber":    {controller.SerialNumber, "59M00FS"},
+		"RocTemperature":  {controller.RocTemperature, "62 C"},
+	}
+	for field, values := range controllerInfoWanted {
+		if values[0] != values[1] {
+			t.Fatalf(`TestGetMegaraidPercControllerInfo controller.%s: %v should be: %v`, field, values[0], values[1])
+		}
+	}
+}
+
+// Test GetMegaraidPercEnclosures
+func TestGetMegaraidPercEnclosures(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "/c0/eall show all" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		// storcli /c0/eall show all
+		outputStdout.WriteString(`
+			Controller = 0
+			Status = Success
+			Description = None
+
+
+			Enclosure /c0/e32  :
+			==================
+
+			Information :
+			===========
+
+			--------------------------------------------------------------------------
+			EID State Slots PD PS Fans TSs Alms SIM Port#          ProdID    VendorSpecific
+			--------------------------------------------------------------------------
+			 32 OK       12  4  2    3   1    0   0 00 & 00 x8     BP14G+    x36-254.15.0.0
+			--------------------------------------------------------------------------
+
+			Inquiry Data :
+			============
+
+			Vendor Identification = DP
+			Product Identification = BP14G+
+			Product Revision Level = 3.35
+
+			Fan Status :
+			==========
+
+			------------------------
+			ID Status   Speed(rpm)
+			------------------------
+			 0 OK       4800
+			 1 Critical 0
+			 2 OK       4800
+			------------------------
+
+			Power Supply Status :
+			===================
+
+			------------
+			ID Status
+			------------
+			 0 OK
+			 1 OK
+			------------
+
+			Temperature Sensor Status :
+			=========================
+
+			---------------------------------
+			ID Status   Temperature(Degree Celsius)
+			---------------------------------
+			 0 OK       28
+			---------------------------------
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	enclosures, err := GetMegaraidPercEnclosures("perc", "0")
+	if err != nil {
+		t.Fatalf(`TestGetMegaraidPercEnclosures returned error: %s`, err)
+	}
+	enclosuresWanted := []utils.EnclosureStruct{
+		{
+			ControllerId:       "perc-0",
+			Id:                 "32",
+			Vendor:             "DP",
+			Model:              "BP14G+",
+			State:              "OK",
+			Slots:              "12",
+			Fans:               []string{"OK", "Critical", "OK"},
+			PowerSupplies:      []string{"OK", "OK"},
+			TemperatureSensors: []string{"OK"},
+		},
+	}
+	if !reflect.DeepEqual(enclosures, enclosuresWanted) {
+		t.Fatalf(`TestGetMegaraidPercEnclosures enclosures: %v should be: %v`, enclosures, enclosuresWanted)
+	}
+}
+
+// Test LocateMegaraidPercDisk
+func TestLocateMegaraidPercDisk(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	executedCommands := []string{}
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		executedCommands = append(executedCommands, command)
+		if command == "/c0/e252/s9 start locate" {
+			outputStdout.WriteString(`
+			Controller = 0
+			Status = Failure
+			Description = Show Drive Information Failed.
+			`)
+			return &outputStdout, &outputStderr, nil
+		}
+		// storcli /c0/e252/s1 start locate
+		outputStdout.WriteString(`
+			Controller = 0
+			Status = Success
+			Description = Start Drive Locate Succeeded.
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	err := LocateMegaraidPercDisk("mega", "0", "252:1", true)
+	if err != nil {
+		t.Fatalf(`TestLocateMegaraidPercDisk returned error: %s`, err)
+	}
+	err = LocateMegaraidPercDisk("mega", "0", "252:1", false)
+	if err != nil {
+		t.Fatalf(`TestLocateMegaraidPercDisk returned error: %s`, err)
+	}
+	executedCommandsWanted := []string{"/c0/e252/s1 start locate", "/c0/e252/s1 stop locate"}
+	if !reflect.DeepEqual(executedCommands, executedCommandsWanted) {
+		t.Fatalf(`TestLocateMegaraidPercDisk executedCommands: %v should be: %v`, executedCommands, executedCommandsWanted)
+	}
+
+	err = LocateMegaraidPercDisk("mega", "0", "252:9", true)
+	if err == nil {
+		t.Fatalf(`TestLocateMegaraidPercDisk should return error on command failure`)
+	}
+}
+
+// Test GetMegaraidPercDriveSmart
+func TestGetMegaraidPercDriveSmart(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// SMART READ DATA: Reallocated_Sector_Ct raw 3, Power_On_Hours raw 1000, Temperature_Celsius raw 31
+	smartData := make([]byte, 512)
+	copy(smartData[2:], []byte{0x05, 0x33, 0x00, 0x64, 0x64, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	copy(smartData[14:], []byte{0x09, 0x32, 0x00, 0x5F, 0x5F, 0xE8, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00})
+	copy(smartData[26:], []byte{0xC2, 0x22, 0x00, 0x1F, 0x2D, 0x1F, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	smartDataHex := ""
+	for i, smartByte := range smartData {
+		smartDataHex = smartDataHex + fmt.Sprintf("%02x ", smartByte)
+		if i%16 == 15 {
+			smartDataHex = smartDataHex + "\n"
+		}
+	}
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		switch command {
+		case "/c0/e252/s1 show smart":
+			outputStdout.WriteString(`
+			Controller = 0
+			Status = Success
+			Description = Show Drive Smart Info Succeeded.
+
+
+			Smart Data Info /c0/e252/s1 = 
+			` + smartDataHex + `
+
+			`)
+		case "/c0/e252/s1 show all":
+			outputStdout.WriteString(`
+			Drive /c0/e252/s1 State :
+			=======================
+			Shield Counter = 0
+			Media Error Count = 2
+			Other Error Count = 0
+			Drive Temperature =  31C (87.80 F)
+			Predictive Failure Count = 0
+			S.M.A.R.T alert flagged by drive = No
+			`)
+		default:
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown command: %v.", command)
+		}
+		return &outputStdout, &outputStderr, nil
+	}
+
+	driveSmart, err := GetMegaraidPercDriveSmart("mega", "0", "252:1")
+	if err != nil {
+		t.Fatalf(`TestGetMegaraidPercDriveSmart returned error: %s`, err)
+	}
+	driveSmartWanted := utils.SmartStruct{
+		Source:             "megaraid",
+		Verdict:            "PASSED",
+		ReallocatedSectors: 3,
+		PendingSectors:     -1,
+		MediaErrors:        2,
+		WearLevel:          -1,
+		PowerOnHours:       1000,
+		Temperature:        31,
+		AvailableSpare:     -1,
+		Status:             "Unknown",
+	}
+	if !reflect.DeepEqual(driveSmart, driveSmartWanted) {
+		t.Fatalf(`TestGetMegaraidPercDriveSmart driveSmart: %v should be: %v`, driveSmart, driveSmartWanted)
+	}
+}
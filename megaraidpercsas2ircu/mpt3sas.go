@@ -0,0 +1,311 @@
+package megaraidpercsas2ircu
+
+import (
+	"fmt"
+	"hardwareAnalyzer/utils"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	human "github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+)
+
+// SAS3/SAS3.5 HBAs not managed by any tool(sas3ircu, storcli) are read from mpt3sas driver sysfs
+// scsi_host: controller inventory, sas_end_device/sas_device: disks and its bays, raid_devices: IR volumes
+
+// Controllers managed by mpt3sas driver, SAS2 controllers are shown as mpt2sas
+func getMpt3sasHosts() []string {
+	hosts := []string{}
+	scsiHosts, err := utils.ReadSysfsDir("/sys/class/scsi_host")
+	if err != nil {
+		return hosts
+	}
+	for _, scsiHost := range scsiHosts {
+		procName, err := utils.ReadSysfsFile("/sys/class/scsi_host/" + scsiHost + "/proc_name")
+		if err != nil {
+			continue
+		}
+		if procName == "mpt3sas" || procName == "mpt2sas" {
+			hosts = append(hosts, scsiHost)
+		}
+	}
+	return hosts
+}
+
+var CheckSysfsSasHba = func() (bool, error) {
+	fmt.Println("> Checking mpt3sas HBA controllers.")
+	if len(getMpt3sasHosts()) == 0 {
+		fmt.Println("> No mpt3sas HBA controller detected.")
+		return false, nil
+	}
+	color.Magenta("> mpt3sas HBA controller detected.")
+	return true, nil
+}
+
+// Host PCI address from its sysfs path: ../../devices/pci0000:00/0000:00:01.0/0000:03:00.0/host0/scsi_host/host0
+func getHostPciAddress(host string) string {
+	link, err := utils.ReadSysfsLink("/sys/class/scsi_host/" + host)
+	if err != nil {
+		return "Unknown"
+	}
+	linkData := strings.Split(link, "/")
+	index := slices.Index(linkData, host)
+	if index < 1 {
+		return "Unknown"
+	}
+	return linkData[index-1]
+}
+
+// SCSI device of a SAS end device: /sys/class/sas_device/end_device-0:1:0/device/target0:0:4/0:0:4:0
+func getEndDeviceScsiDevice(endDevice string) string {
+	devicePath := "/sys/class/sas_device/" + endDevice + "/device"
+	targets, err := utils.ReadSysfsDir(devicePath)
+	if err != nil {
+		return ""
+	}
+	for _, target := range targets {
+		if !strings.HasPrefix(target, "target") {
+			continue
+		}
+		scsiDevices, err := utils.ReadSysfsDir(devicePath + "/" + target)
+		if err != nil {
+			continue
+		}
+		for _, scsiDevice := range scsiDevices {
+			if strings.HasPrefix(scsiDevice, strings.TrimPrefix(target, "target")+":") {
+				return devicePath + "/" + target + "/" + scsiDevice
+			}
+		}
+	}
+	return ""
+}
+
+// Unit serial number VPD page 0x80: 4 bytes header followed by serial number
+func getVpdSerialNumber(scsiDevicePath string) string {
+	vpdPage, err := utils.ReadSysfsFile(scsiDevicePath + "/vpd_pg80")
+	if err != nil || len(vpdPage) <= 4 {
+		return "Unknown"
+	}
+	serialNumber := strings.TrimSpace(strings.Trim(vpdPage[4:], "\x00"))
+	if len(serialNumber) == 0 {
+		return "Unknown"
+	}
+	return serialNumber
+}
+
+// Disk data from its SCSI device and block device
+// Disks hidden by IR firmware because they are IR volume members have no block device
+func getSysfsSasDisk(endDevice string) (utils.NoRaidDiskStruct, bool) {
+	noRaidDisk := utils.NoRaidDiskStruct{
+		State:        "Unknown",
+		Size:         "Unknown",
+		Intf:         "Unknown",
+		Medium:       "Unknown",
+		Model:        "Unknown",
+		SerialNumber: "Unknown",
+	}
+	sasDevicePath := "/sys/class/sas_device/" + endDevice
+	// ssp: SAS disk, stp: SATA disk behind SAS controller
+	protocols, err := utils.ReadSysfsFile(sasDevicePath + "/target_port_protocols")
+	if err == nil {
+		switch {
+		case strings.Contains(protocols, "ssp"):
+			noRaidDisk.Intf = "SAS"
+		case strings.Contains(protocols, "stp"):
+			noRaidDisk.Intf = "SATA"
+		}
+	}
+	enclosureId, errEnclosure := utils.ReadSysfsFile(sasDevicePath + "/enclosure_identifier")
+	bayId, errBay := utils.ReadSysfsFile(sasDevicePath + "/bay_identifier")
+	if errEnclosure == nil && errBay == nil {
+		noRaidDisk.Bay = "Enclosure " + enclosureId + " Slot " + bayId
+	}
+
+	scsiDevicePath := getEndDeviceScsiDevice(endDevice)
+	if len(scsiDevicePath) == 0 {
+		return noRaidDisk, false
+	}
+	vendor, _ := utils.ReadSysfsFile(scsiDevicePath + "/vendor")
+	model, err := utils.ReadSysfsFile(scsiDevicePath + "/model")
+	if err == nil {
+		// SATA disks are reported with ATA vendor
+		if len(vendor) > 0 && vendor != "ATA" {
+			model = vendor + " " + model
+		}
+		noRaidDisk.Model = model
+	}
+	noRaidDisk.SerialNumber = getVpdSerialNumber(scsiDevicePath)
+	// running, offline, blocked
+	state, err := utils.ReadSysfsFile(scsiDevicePath + "/state")
+	if err == nil && len(state) > 0 {
+		noRaidDisk.State = strings.ToUpper(state[:1]) + state[1:]
+		if state == "running" {
+			noRaidDisk.State = "JBOD"
+		}
+	}
+
+	blockDevices, err := utils.ReadSysfsDir(scsiDevicePath + "/block")
+	if err != nil || len(blockDevices) == 0 {
+		return noRaidDisk, false
+	}
+	blockDevice := blockDevices[0]
+	noRaidDisk.OsDevice = "JBOD-" + blockDevice
+	sectors, err := utils.ReadSysfsFile("/sys/block/" + blockDevice + "/size")
+	if err == nil {
+		sectorsInt, err := strconv.ParseUint(sectors, 10, 64)
+		if err == nil {
+			noRaidDisk.Size = human.Bytes(sectorsInt * 512)
+		}
+	}
+	rotational, err := utils.ReadSysfsFile("/sys/block/" + blockDevice + "/queue/rotational")
+	if err == nil {
+		noRaidDisk.Medium = "HDD"
+		if rotational == "0" {
+			noRaidDisk.Medium = "SSD"
+		}
+	}
+	return noRaidDisk, true
+}
+
+// IR volume state from raid_class: active, degraded, resyncing, offline
+func getIrVolumeState(state string) string {
+	switch state {
+	case "active":
+		return "Optimal"
+	case "":
+		return "Unknown"
+	}
+	return strings.ToUpper(state[:1]) + state[1:]
+}
+
+// HBAs already managed by sas2ircu, sas3ircu or storcli are skipped comparing its PCI address
+var ProcessSysfsSasHba = func(manufacturer string, managedControllers []utils.ControllerStruct) ([]utils.ControllerStruct, []utils.RaidStruct, []utils.NoRaidDiskStruct, error) {
+	fmt.Println("> Getting current mpt3sas HBA configuration.")
+	controllers := []utils.ControllerStruct{}
+	raids := []utils.RaidStruct{}
+	noRaidDisks := []utils.NoRaidDiskStruct{}
+
+	managedPciAddresses := []string{}
+	for _, managedController := range managedControllers {
+		if pciFunction := utils.PciFunction(managedController.PciAddress); len(pciFunction) > 0 {
+			managedPciAddresses = append(managedPciAddresses, pciFunction)
+		}
+	}
+	driverName, driverVersion := utils.GetKernelModuleVersion("mpt3sas")
+
+	endDevices, err := utils.ReadSysfsDir("/sys/class/sas_end_device")
+	if err != nil {
+		endDevices = []string{}
+	}
+	raidDevices, err := utils.ReadSysfsDir("/sys/class/raid_devices")
+	if err != nil {
+		raidDevices = []string{}
+	}
+
+	for _, host := range getMpt3sasHosts() {
+		hostNumber := strings.TrimPrefix(host, "host")
+		hostPath := "/sys/class/scsi_host/" + host
+		pciAddress := getHostPciAddress(host)
+		if slices.Contains(managedPciAddresses, utils.PciFunction(pciAddress)) {
+			//fmt.Printf("HBA %s already managed by its tool\n", pciAddress)
+			continue
+		}
+		readField := func(field string) string {
+			value, err := utils.ReadSysfsFile(hostPath + "/" + field)
+			if err != nil || len(value) == 0 {
+				return "Unknown"
+			}
+			return value
+		}
+
+		// unique_id is the ioc number used in kernel messages: mpt3sas_cm0
+		controllerId := manufacturer + "-" + readField("unique_id")
+		model := readField("board_name")
+		if model == "Unknown" {
+			model = readField("version_product")
+		}
+		controller := utils.ControllerStruct{
+			Id:              controllerId,
+			Manufacturer:    manufacturer,
+			Model:           model,
+			Status:          "Good",
+			FirmwareVersion: readField("version_fw"),
+			BiosVersion:     readField("version_bios"),
+			DriverName:      driverName,
+			DriverVersion:   driverVersion,
+			PciAddress:      pciAddress,
+			SerialNumber:    "Unknown",
+			RocTemperature:  "Unknown",
+		}
+		controllers = append(controllers, controller)
+
+		// IR volumes: H:C:T:L
+		hostRaids := []utils.RaidStruct{}
+		for _, raidDevice := range raidDevices {
+			if !strings.HasPrefix(raidDevice, hostNumber+":") {
+				continue
+			}
+			raidPath := "/sys/class/raid_devices/" + raidDevice
+			level, _ := utils.ReadSysfsFile(raidPath + "/level")
+			state, _ := utils.ReadSysfsFile(raidPath + "/state")
+			raid := utils.RaidStruct{
+				ControllerId: controllerId,
+				RaidLevel:    0,
+				Dg:           raidDevice,
+				RaidType:     strings.ToUpper(level),
+				State:        getIrVolumeState(state),
+				Size:         "Unknown",
+				OsDevice:     "Unknown",
+			}
+			resync, err := utils.ReadSysfsFile(raidPath + "/resync")
+			if err == nil && len(resync) > 0 && resync != "0%" {
+				raid.Details = append(raid.Details, "resync "+resync)
+			}
+			blockDevices, err := utils.ReadSysfsDir(raidPath + "/device/block")
+			if err == nil && len(blockDevices) > 0 {
+				raid.OsDevice = blockDevices[0]
+				raid.Size, _ = utils.GetDiskPartitionSize(blockDevices[0])
+			}
+			hostRaids = append(hostRaids, raid)
+		}
+
+		// end_device-0:0 directly attached, end_device-0:1:3 behind an expander
+		for _, endDevice := range endDevices {
+			if !strings.HasPrefix(endDevice, "end_device-"+hostNumber+":") {
+				continue
+			}
+			noRaidDisk, visible := getSysfsSasDisk(endDevice)
+			noRaidDisk.ControllerId = controllerId
+			if visible {
+				noRaidDisks = append(noRaidDisks, noRaidDisk)
+				continue
+			}
+			// Hidden disks are IR volumes members, its membership is only known with a single volume
+			if len(hostRaids) == 1 {
+				memberState := noRaidDisk.State
+				if memberState == "JBOD" {
+					memberState = "ONLINE"
+				}
+				hostRaids[0].AddDisk(utils.DiskStruct{
+					ControllerId: controllerId,
+					Dg:           hostRaids[0].Dg,
+					State:        memberState,
+					Size:         noRaidDisk.Size,
+					Intf:         noRaidDisk.Intf,
+					Medium:       noRaidDisk.Medium,
+					Model:        noRaidDisk.Model,
+					SerialNumber: noRaidDisk.SerialNumber,
+					Bay:          noRaidDisk.Bay,
+				})
+				continue
+			}
+			noRaidDisk.State = "IR-Member"
+			noRaidDisk.OsDevice = filepath.Base(endDevice)
+			noRaidDisks = append(noRaidDisks, noRaidDisk)
+		}
+		raids = append(raids, hostRaids...)
+	}
+	return controllers, raids, noRaidDisks, nil
+}
@@ -0,0 +1,200 @@
+package megaraidpercsas2ircu
+
+import (
+	"errors"
+	"hardwareAnalyzer/utils"
+	"testing"
+)
+
+// Mocked mpt3sas sysfs:
+// host0: SAS3008 IR firmware, RAID1 volume sdb with two hidden members and one JBOD disk sdc
+// host1: SAS3108 already managed by storcli
+// host2: AHCI controller
+func mockMpt3sasSysfs() {
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		dirs := map[string][]string{
+			"/sys/class/scsi_host":                                                  {"host0", "host1", "host2"},
+			"/sys/class/sas_end_device":                                             {"end_device-0:0", "end_device-0:1", "end_device-0:2", "end_device-1:0"},
+			"/sys/class/raid_devices":                                               {"0:1:0:0"},
+			"/sys/class/raid_devices/0:1:0:0/device/block":                          {"sdb"},
+			"/sys/class/sas_device/end_device-0:0/device":                           {"power", "target0:0:0"},
+			"/sys/class/sas_device/end_device-0:0/device/target0:0:0":               {"0:0:0:0", "power"},
+			"/sys/class/sas_device/end_device-0:1/device":                           {"target0:0:1"},
+			"/sys/class/sas_device/end_device-0:1/device/target0:0:1":               {"0:0:1:0"},
+			"/sys/class/sas_device/end_device-0:2/device":                           {"target0:0:2"},
+			"/sys/class/sas_device/end_device-0:2/device/target0:0:2":               {"0:0:2:0"},
+			"/sys/class/sas_device/end_device-0:2/device/target0:0:2/0:0:2:0/block": {"sdc"},
+		}
+		dir, ok := dirs[path]
+		if !ok {
+			return []string{}, errors.New("No such file or directory")
+		}
+		return dir, nil
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		files := map[string]string{
+			"/sys/class/scsi_host/host0/proc_name":       "mpt3sas",
+			"/sys/class/scsi_host/host0/unique_id":       "0",
+			"/sys/class/scsi_host/host0/board_name":      "SAS9340-8i",
+			"/sys/class/scsi_host/host0/version_product": "SAS3008",
+			"/sys/class/scsi_host/host0/version_fw":      "16.00.10.00",
+			"/sys/class/scsi_host/host0/version_bios":    "08.37.00.00",
+			"/sys/class/scsi_host/host1/proc_name":       "mpt3sas",
+			"/sys/class/scsi_host/host1/unique_id":       "1",
+			"/sys/class/scsi_host/host2/proc_name":       "ahci",
+			"/sys/module/mpt3sas/version":                "43.100.00.00",
+			"/sys/class/raid_devices/0:1:0:0/level":      "raid1",
+			"/sys/class/raid_devices/0:1:0:0/state":      "degraded",
+			"/sys/class/raid_devices/0:1:0:0/resync":     "0%",
+			// Hidden IR members
+			"/sys/class/sas_device/end_device-0:0/target_port_protocols":               "stp",
+			"/sys/class/sas_device/end_device-0:0/enclosure_identifier":                "0x500605b00a1b2c3d",
+			"/sys/class/sas_device/end_device-0:0/bay_identifier":                      "0",
+			"/sys/class/sas_device/end_device-0:0/device/target0:0:0/0:0:0:0/vendor":   "ATA",
+			"/sys/class/sas_device/end_device-0:0/device/target0:0:0/0:0:0:0/model":    "INTEL SSDSC2KB48",
+			"/sys/class/sas_device/end_device-0:0/device/target0:0:0/0:0:0:0/state":    "running",
+			"/sys/class/sas_device/end_device-0:0/device/target0:0:0/0:0:0:0/vpd_pg80": "\x00\x80\x00\x14PHYS812345XY480BGN  ",
+			"/sys/class/sas_device/end_device-0:1/target_port_protocols":               "stp",
+			"/sys/class/sas_device/end_device-0:1/device/target0:0:1/0:0:1:0/vendor":   "ATA",
+			"/sys/class/sas_device/end_device-0:1/device/target0:0:1/0:0:1:0/model":    "INTEL SSDSC2KB48",
+			"/sys/class/sas_device/end_device-0:1/device/target0:0:1/0:0:1:0/state":    "offline",
+			// JBOD disk
+			"/sys/class/sas_device/end_device-0:2/target_port_protocols":               "ssp",
+			"/sys/class/sas_device/end_device-0:2/enclosure_identifier":                "0x500605b00a1b2c3d",
+			"/sys/class/sas_device/end_device-0:2/bay_identifier":                      "2",
+			"/sys/class/sas_device/end_device-0:2/device/target0:0:2/0:0:2:0/vendor":   "SEAGATE",
+			"/sys/class/sas_device/end_device-0:2/device/target0:0:2/0:0:2:0/model":    "ST4000NM0025",
+			"/sys/class/sas_device/end_device-0:2/device/target0:0:2/0:0:2:0/state":    "running",
+			"/sys/class/sas_device/end_device-0:2/device/target0:0:2/0:0:2:0/vpd_pg80": "\x00\x80\x00\x08ZC1ABCDE",
+			"/sys/block/sdc/size":             "7814037168",
+			"/sys/block/sdc/queue/rotational": "1",
+		}
+		file, ok := files[path]
+		if !ok {
+			return "", errors.New("No such file or directory")
+		}
+		return file, nil
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		links := map[string]string{
+			"/sys/class/scsi_host/host0": "../../devices/pci0000:3a/0000:3a:00.0/0000:3b:00.0/host0/scsi_host/host0",
+			"/sys/class/scsi_host/host1": "../../devices/pci0000:5d/0000:5d:00.0/0000:5e:00.0/host1/scsi_host/host1",
+		}
+		link, ok := links[path]
+		if !ok {
+			return "", errors.New("No such file or directory")
+		}
+		return link, nil
+	}
+	utils.GetDiskPartitionSize = func(diskDrive string) (string, error) {
+		return "480 GB", nil
+	}
+}
+
+// Test CheckSysfsSasHba
+func TestCheckSysfsSasHba(t *testing.T) {
+	// Copy original functions content
+	readSysfsDirOri := utils.ReadSysfsDir
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsLinkOri := utils.ReadSysfsLink
+	getDiskPartitionSizeOri := utils.GetDiskPartitionSize
+	// unmock functions content
+	defer func() {
+		utils.ReadSysfsDir = readSysfsDirOri
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+		utils.GetDiskPartitionSize = getDiskPartitionSizeOri
+	}()
+
+	mockMpt3sasSysfs()
+	sysfsSasHbaCheck, err := CheckSysfsSasHba()
+	if err != nil {
+		t.Fatalf(`TestCheckSysfsSasHba returned error: %s`, err)
+	}
+	if !sysfsSasHbaCheck {
+		t.Fatalf(`TestCheckSysfsSasHba sysfsSasHbaCheck: %v should match TRUE`, sysfsSasHbaCheck)
+	}
+
+	// No mpt3sas hosts
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		return []string{"host2"}, nil
+	}
+	sysfsSasHbaCheck, err = CheckSysfsSasHba()
+	if err != nil || sysfsSasHbaCheck {
+		t.Fatalf(`TestCheckSysfsSasHba sysfsSasHbaCheck: %v should match FALSE`, sysfsSasHbaCheck)
+	}
+}
+
+// Test ProcessSysfsSasHba
+func TestProcessSysfsSasHba(t *testing.T) {
+	// Copy original functions content
+	readSysfsDirOri := utils.ReadSysfsDir
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsLinkOri := utils.ReadSysfsLink
+	getDiskPartitionSizeOri := utils.GetDiskPartitionSize
+	// unmock functions content
+	defer func() {
+		utils.ReadSysfsDir = readSysfsDirOri
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+		utils.GetDiskPartitionSize = getDiskPartitionSizeOri
+	}()
+
+	mockMpt3sasSysfs()
+	// host1 is managed by storcli, its PCI address is reported as 00:5e:00:00
+	managedControllers := []utils.ControllerStruct{
+		{Id: "mega-0", Manufacturer: "mega", PciAddress: "00:5e:00:00"},
+	}
+	controllers, raids, noRaidDisks, err := ProcessSysfsSasHba("mpt3sas", managedControllers)
+	if err != nil {
+		t.Fatalf(`TestProcessSysfsSasHba returned error: %s`, err)
+	}
+
+	if len(controllers) != 1 {
+		t.Fatalf(`TestProcessSysfsSasHba controllers: %v should match: 1`, len(controllers))
+	}
+	controller := controllers[0]
+	if controller.Id != "mpt3sas-0" || controller.Model != "SAS9340-8i" || controller.PciAddress != "0000:3b:00.0" {
+		t.Fatalf(`TestProcessSysfsSasHba controller: %v/%v/%v should match: mpt3sas-0/SAS9340-8i/0000:3b:00.0`, controller.Id, controller.Model, controller.PciAddress)
+	}
+	if controller.FirmwareVersion != "16.00.10.00" || controller.BiosVersion != "08.37.00.00" {
+		t.Fatalf(`TestProcessSysfsSasHba controller versions: %v/%v should match: 16.00.10.00/08.37.00.00`, controller.FirmwareVersion, controller.BiosVersion)
+	}
+	if controller.DriverName != "mpt3sas" || controller.DriverVersion != "43.100.00.00" {
+		t.Fatalf(`TestProcessSysfsSasHba controller driver: %v %v should match: mpt3sas 43.100.00.00`, controller.DriverName, controller.DriverVersion)
+	}
+
+	if len(raids) != 1 {
+		t.Fatalf(`TestProcessSysfsSasHba raids: %v should match: 1`, len(raids))
+	}
+	raid := raids[0]
+	if raid.ControllerId != "mpt3sas-0" || raid.RaidType != "RAID1" || raid.State != "Degraded" || raid.OsDevice != "sdb" || raid.Size != "480 GB" {
+		t.Fatalf(`TestProcessSysfsSasHba raid: %+v should match: mpt3sas-0 RAID1 Degraded sdb 480 GB`, raid)
+	}
+	if len(raid.Disks) != 2 {
+		t.Fatalf(`TestProcessSysfsSasHba raid.Disks: %v should match: 2`, len(raid.Disks))
+	}
+	member := raid.Disks[0]
+	if member.State != "ONLINE" || member.Intf != "SATA" || member.Model != "INTEL SSDSC2KB48" || member.SerialNumber != "PHYS812345XY480BGN" || member.Bay != "Enclosure 0x500605b00a1b2c3d Slot 0" {
+		t.Fatalf(`TestProcessSysfsSasHba raid.Disks[0]: %+v`, member)
+	}
+
+	if len(noRaidDisks) != 1 {
+		t.Fatalf(`TestProcessSysfsSasHba noRaidDisks: %v should match: 1`, len(noRaidDisks))
+	}
+	noRaidDisk := noRaidDisks[0]
+	noRaidDiskWanted := utils.NoRaidDiskStruct{
+		ControllerId: "mpt3sas-0",
+		State:        "JBOD",
+		Size:         "4.0 TB",
+		Intf:         "SAS",
+		Medium:       "HDD",
+		Model:        "SEAGATE ST4000NM0025",
+		SerialNumber: "ZC1ABCDE",
+		OsDevice:     "JBOD-sdc",
+		Bay:          "Enclosure 0x500605b00a1b2c3d Slot 2",
+	}
+	if noRaidDisk.ControllerId != noRaidDiskWanted.ControllerId || noRaidDisk.State != noRaidDiskWanted.State || noRaidDisk.Size != noRaidDiskWanted.Size || noRaidDisk.Intf != noRaidDiskWanted.Intf || noRaidDisk.Medium != noRaidDiskWanted.Medium || noRaidDisk.Model != noRaidDiskWanted.Model || noRaidDisk.SerialNumber != noRaidDiskWanted.SerialNumber || noRaidDisk.OsDevice != noRaidDiskWanted.OsDevice || noRaidDisk.Bay != noRaidDiskWanted.Bay {
+		t.Fatalf(`TestProcessSysfsSasHba noRaidDisk: %+v should be: %+v`, noRaidDisk, noRaidDiskWanted)
+	}
+}
@@ -19,17 +19,33 @@ import (
 
 var CheckSas2ircuRaid = func() (bool, error) {
 	//fmt.Println("-- checkSas2ircuRaid --")
+	return checkSasircuRaid("sas2ircu")
+}
+
+// sas3ircu is not embedded, it is executed from system when installed
+var CheckSas3ircuRaid = func() (bool, error) {
+	//fmt.Println("-- checkSas3ircuRaid --")
+	return checkSasircuRaid("sas3ircu")
+}
 
-	fmt.Println("> Checking SAS2IRCU RAID controller.")
+// sas2ircu and sas3ircu share the same commands and output format, only its output prefix differs: SAS2IRCU/SAS3IRCU
+func checkSasircuRaid(manufacturer string) (bool, error) {
+	toolName := strings.ToUpper(manufacturer)
+	fmt.Printf("> Checking %s RAID controller.\n", toolName)
 	command := "LIST"
-	outputStdout, outputStderr, err := utils.GetCommandOutput("sas2ircu", "checkSas2ircuRaid", command)
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "checkSasircuRaid", command)
 	if err != nil {
+		// System only tools could be not installed, its not an error
+		if strings.HasPrefix(err.Error(), "Cant find") {
+			fmt.Printf("> No %s tool installed.\n", toolName)
+			return false, nil
+		}
 		// When theres no SAS2IRCU controller in the system, "exit status 1" is returned, its not an error
 		if err.Error() != "exit status 1" {
 			//color.Red("++ ERROR: Something went wrong executing command %s: %v", command, err)
 			return false, fmt.Errorf("Something went wrong executing command %s: %v", command, err)
 		} else {
-			fmt.Println("> No SAS2IRCU RAID controller detected.")
+			fmt.Printf("> No %s RAID controller detected.\n", toolName)
 			return false, nil
 		}
 	}
@@ -37,7 +53,7 @@ var CheckSas2ircuRaid = func() (bool, error) {
 		//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
 		// When theres no sas2ircu controller installed in the system, tool returns: SAS2IRCU: MPTLib2 Error 1
 		if strings.Contains(outputStdout.String(), "MPTLib2 Error 1") {
-			fmt.Println("> No SAS2IRCU RAID controller detected.")
+			fmt.Printf("> No %s RAID controller detected.\n", toolName)
 			return false, nil
 		} else {
 			//color.Red("++ ERROR: Something went wrong executing command: %s.", command)
@@ -54,15 +70,15 @@ var CheckSas2ircuRaid = func() (bool, error) {
 		}
 		//fmt.Println(line)
 		// Check first Status output line
-		if strings.Contains(line, "SAS2IRCU: ") {
+		if strings.Contains(line, toolName+": ") {
 			statusData := strings.Split(line, ": ")
 			status := statusData[1]
 			//fmt.Println("status: ", status)
 			if status == "Utility Completed Successfully." {
-				color.Magenta("> SAS2IRCU RAID controller detected.")
+				color.Magenta("> %s RAID controller detected.", toolName)
 				return true, nil
 			} else {
-				fmt.Println("> No SAS2IRCU RAID controller detected.")
+				fmt.Printf("> No %s RAID controller detected.\n", toolName)
 				return false, nil
 			}
 		}
@@ -70,14 +86,55 @@ var CheckSas2ircuRaid = func() (bool, error) {
 	return false, nil
 }
 
+// Turn on/off disk identify LED: sas2ircu 0 LOCATE 1:2 ON|OFF
+// Function as variable in order to be possible to mock it from unitary tests
+var LocateSas2ircuDisk = func(controllerId, eidSlot string, on bool) error {
+	//fmt.Println("-- locateSas2ircuDisk --")
+	return locateSasircuDisk("sas2ircu", controllerId, eidSlot, on)
+}
+
+// Turn on/off disk identify LED: sas3ircu 0 LOCATE 1:2 ON|OFF
+// Function as variable in order to be possible to mock it from unitary tests
+var LocateSas3ircuDisk = func(controllerId, eidSlot string, on bool) error {
+	//fmt.Println("-- locateSas3ircuDisk --")
+	return locateSasircuDisk("sas3ircu", controllerId, eidSlot, on)
+}
+
+func locateSasircuDisk(manufacturer, controllerId, eidSlot string, on bool) error {
+	locateAction := "OFF"
+	if on {
+		locateAction = "ON"
+	}
+	command := controllerId + " LOCATE " + eidSlot + " " + locateAction
+	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "locateSasircuDisk", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v", command, err)
+		return fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+
+	// SAS2IRCU: LOCATE Command completed successfully.
+	if !strings.Contains(strings.ToLower(outputStdout.String()), "completed successfully") {
+		color.Red("++ ERROR: Command %s failed.", command)
+		return fmt.Errorf("Error: Command %s failed.", command)
+	}
+	return nil
+}
+
+// Also used for sas3ircu(SAS3008/3108 IR/IT firmware) controllers: manufacturer sas3ircu
 var ProcessHWSas2ircuRaid = func(manufacturer string) ([]utils.ControllerStruct, []utils.RaidStruct, []utils.NoRaidDiskStruct, error) {
 	//fmt.Println("-- processHWSas2ircuRaid --")
 	var controllers = []utils.ControllerStruct{}
 	var raids = []utils.RaidStruct{}
 	var noRaidDisks = []utils.NoRaidDiskStruct{}
+	toolName := strings.ToUpper(manufacturer)
 
 	// Execute sas2ircu
-	fmt.Println("> Getting current SAS2IRCU-RAID configuration.")
+	fmt.Printf("> Getting current %s-RAID configuration.\n", toolName)
 	command := "LIST"
 	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "processHWSas2ircuRaid", command)
 	if err != nil {
@@ -101,7 +158,7 @@ var ProcessHWSas2ircuRaid = func(manufacturer string) ([]utils.ControllerStruct,
 			continue
 		}
 		//fmt.Println("line: ", line)
-		if line == "SAS2IRCU: Utility Completed Successfully." {
+		if line == toolName+": Utility Completed Successfully." {
 			totalSas2ircuControllers = strings.Fields(previousLine)[0]
 			totalSas2ircuControllers = utils.ClearString(totalSas2ircuControllers)
 		}
@@ -134,6 +191,11 @@ var ProcessHWSas2ircuRaid = func(manufacturer string) ([]utils.ControllerStruct,
 
 	insideVolumeData := false
 	insideDriveData := false
+
+	// Controller PCI address parts
+	pciSegment := ""
+	pciBus := ""
+	pciDevice := ""
 	tableBarSeparator := ""
 
 	// Parse sas2ircu controller data
@@ -141,7 +203,7 @@ var ProcessHWSas2ircuRaid = func(manufacturer string) ([]utils.ControllerStruct,
 		controllerId := strconv.Itoa(i)
 		//fmt.Printf("> Getting sas2ircu controller %s data.\n", controllerId)
 
-		fmt.Println("> Parsing SAS2IRCU data.")
+		fmt.Printf("> Parsing %s data.\n", toolName)
 		command := strconv.Itoa(i) + " DISPLAY"
 		outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "processHWSas2ircuRaid", command)
 		if err != nil {
@@ -176,17 +238,61 @@ var ProcessHWSas2ircuRaid = func(manufacturer string) ([]utils.ControllerStruct,
 				controllerModel := controllerModelData[1]
 				controllerModel = utils.ClearString(controllerModel)
 				//fmt.Println("Controller Model: ", controllerModel)
+				// sas2ircu doesnt report driver info, SAS2 controllers are managed by mpt2sas, merged into mpt3sas in newer kernels
+				driverName, driverVersion := utils.GetKernelModuleVersion("mpt2sas", "mpt3sas")
+				if manufacturer == "sas3ircu" {
+					driverName, driverVersion = utils.GetKernelModuleVersion("mpt3sas")
+				}
 				controller := utils.ControllerStruct{
-					Id:           manufacturer + "-" + controllerId,
-					Manufacturer: manufacturer,
-					Model:        controllerModel,
-					Status:       controllerStatus,
+					Id:              manufacturer + "-" + controllerId,
+					Manufacturer:    manufacturer,
+					Model:           controllerModel,
+					Status:          controllerStatus,
+					FirmwareVersion: "Unknown",
+					BiosVersion:     "Unknown",
+					DriverName:      driverName,
+					DriverVersion:   driverVersion,
+					PciAddress:      "Unknown",
+					SerialNumber:    "Unknown",
+					RocTemperature:  "Unknown",
 				}
 				controllers = append(controllers, controller)
 				//fmt.Println("sas2ircu controller appended.")
+				pciSegment = ""
+				pciBus = ""
+				pciDevice = ""
 				continue
 			}
 
+			// Controller inventory data, it follows Controller type line
+			if len(controllers) > 0 && !insideVolumeData && !insideDriveData && strings.Contains(line, ":") {
+				controllerInfoData := strings.SplitN(line, ":", 2)
+				controllerInfoKey := utils.ClearString(controllerInfoData[0])
+				controllerInfoValue := utils.ClearString(controllerInfoData[1])
+				controller := &controllers[len(controllers)-1]
+				switch controllerInfoKey {
+				case "BIOSversion":
+					controller.BiosVersion = controllerInfoValue
+				case "Firmwareversion":
+					controller.FirmwareVersion = controllerInfoValue
+				case "Segment":
+					pciSegment = controllerInfoValue
+				case "Bus":
+					pciBus = controllerInfoValue
+				case "Device":
+					pciDevice = controllerInfoValue
+				// PCI address in sysfs format: segment:bus:device.function
+				case "Function":
+					segment, errSegment := strconv.Atoi(pciSegment)
+					bus, errBus := strconv.Atoi(pciBus)
+					device, errDevice := strconv.Atoi(pciDevice)
+					function, errFunction := strconv.Atoi(controllerInfoValue)
+					if errSegment == nil && errBus == nil && errDevice == nil && errFunction == nil {
+						controller.PciAddress = fmt.Sprintf("%04x:%02x:%02x.%x", segment, bus, device, function)
+					}
+				}
+			}
+
 			// Data section indicators
 			if strings.Contains(line, "IR volume") {
 				insideVolumeData = true
@@ -222,7 +328,7 @@ var ProcessHWSas2ircuRaid = func(manufacturer string) ([]utils.ControllerStruct,
 					wwidData := strings.Split(line, ":")
 					wwid := wwidData[1]
 					wwid = utils.ClearString(wwid)
-					osDevice, err = hardwarecontrollerscommon.GetRaidOSDevice("sas2ircu", controllerId, wwid)
+					osDevice, err = hardwarecontrollerscommon.GetRaidOSDevice(manufacturer, controllerId, wwid)
 					if err != nil {
 						color.Red("++ ERROR Getting OS device: %s", err)
 						return controllers, raids, noRaidDisks, err
@@ -448,5 +554,10 @@ var ProcessHWSas2ircuRaid = func(manufacturer string) ([]utils.ControllerStruct,
 			}
 		}
 	}
+
+	// sas2ircu doesnt report enclosure inventory, only disks bay mapping
+	for i := range controllers {
+		utils.SetControllerBays(&controllers[i], raids, noRaidDisks)
+	}
 	return controllers, raids, noRaidDisks, nil
 }
@@ -140,6 +140,13 @@ func TestProcessHWSas2ircuRaid(t *testing.T) {
 		} else if command == "0 DISPLAY" {
 			outputStdout.WriteString(`
 				Controller type                         : SAS2008
+				BIOS version                            : 7.11.10.00
+				Firmware version                        : 7.15.08.00
+				Channel description                     : 1 Serial Attached SCSI
+				Segment                                 : 0
+				Bus                                     : 3
+				Device                                  : 0
+				Function                                : 0
 				------------------------------------------------------------------------
 				IR volume 1
 				Volume ID                               : 79
@@ -229,6 +236,21 @@ func TestProcessHWSas2ircuRaid(t *testing.T) {
 		if newController.Id != newControllerIdWanted {
 			t.Fatalf(`TestProcessHWSas2ircuRaid newController.Id: %v should match: %v`, newController.Id, newControllerIdWanted)
 		}
+
+		newControllerBiosVersionWanted := "7.11.10.00"
+		if newController.BiosVersion != newControllerBiosVersionWanted {
+			t.Fatalf(`TestProcessHWSas2ircuRaid newController.BiosVersion: %v should match: %v`, newController.BiosVersion, newControllerBiosVersionWanted)
+		}
+
+		newControllerFirmwareVersionWanted := "7.15.08.00"
+		if newController.FirmwareVersion != newControllerFirmwareVersionWanted {
+			t.Fatalf(`TestProcessHWSas2ircuRaid newController.FirmwareVersion: %v should match: %v`, newController.FirmwareVersion, newControllerFirmwareVersionWanted)
+		}
+
+		newControllerPciAddressWanted := "0000:03:00.0"
+		if newController.PciAddress != newControllerPciAddressWanted {
+			t.Fatalf(`TestProcessHWSas2ircuRaid newController.PciAddress: %v should match: %v`, newController.PciAddress, newControllerPciAddressWanted)
+		}
 	}
 
 	//fmt.Println("------------ newRaids -------------")
@@ -464,3 +486,179 @@ func TestProcessHWSas2ircuRaidGetJbodOsDeviceError(t *testing.T) {
 		t.Fatalf(`TestProcessHWSas2ircuRaidGetJbodOsDeviceError should return err != nil`)
 	}
 }
+
+// Test LocateSas2ircuDisk
+func TestLocateSas2ircuDisk(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "0 LOCATE 1:2 ON" {
+			outputStdout.WriteString("SAS2IRCU: Invalid Enclosure/Slot pair.")
+			return &outputStdout, &outputStderr, nil
+		}
+		// sas2ircu 0 LOCATE 1:2 ON
+		outputStdout.WriteString(`
+			LSI Corporation SAS2 IR Configuration Utility.
+			Version 20.00.00.00 (2014.09.18)
+			Copyright (c) 2008-2014 LSI Corporation. All rights reserved.
+
+			SAS2IRCU: LOCATE Command completed successfully.
+			SAS2IRCU: Command LOCATE Completed Successfully.
+			SAS2IRCU: Utility Completed Successfully.
+		`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	err := LocateSas2ircuDisk("0", "1:2", true)
+	if err != nil {
+		t.Fatalf(`TestLocateSas2ircuDisk returned error: %s`, err)
+	}
+	err = LocateSas2ircuDisk("0", "1:2", false)
+	if err == nil {
+		t.Fatalf(`TestLocateSas2ircuDisk should return error on command failure`)
+	}
+}
+
+// Test CheckSas3ircuRaid
+func TestCheckSas3ircuRaid(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if manufacturer != "sas3ircu" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unknown manufacturer.")
+		}
+		outputStdout.WriteString("SAS3IRCU: Utility Completed Successfully.")
+		return &outputStdout, &outputStderr, nil
+	}
+
+	sas3ircuRaidCheck, err := CheckSas3ircuRaid()
+	if err != nil {
+		t.Fatalf(`TestCheckSas3ircuRaid returned error: %s`, err)
+	}
+
+	if !sas3ircuRaidCheck {
+		t.Fatalf(`TestCheckSas3ircuRaid sas3ircuRaidCheck: %v should match TRUE`, sas3ircuRaidCheck)
+	}
+}
+
+// Test CheckSas3ircuRaid without sas3ircu installed in the system
+func TestCheckSas3ircuRaidNotInstalled(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		return &outputStdout, &outputStderr, fmt.Errorf("Cant find sas3ircu system binary.")
+	}
+
+	sas3ircuRaidCheck, err := CheckSas3ircuRaid()
+	if err != nil {
+		t.Fatalf(`TestCheckSas3ircuRaidNotInstalled returned error: %s`, err)
+	}
+
+	if sas3ircuRaidCheck {
+		t.Fatalf(`TestCheckSas3ircuRaidNotInstalled sas3ircuRaidCheck: %v should match FALSE`, sas3ircuRaidCheck)
+	}
+}
+
+// Test ProcessHWSas2ircuRaid with sas3ircu IT firmware: no IR volumes, only JBOD disks
+func TestProcessHWSas3ircuRaid(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	getJbodOsDeviceOri := hardwarecontrollerscommon.GetJbodOsDevice
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		hardwarecontrollerscommon.GetJbodOsDevice = getJbodOsDeviceOri
+	}()
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command == "LIST" {
+			outputStdout.WriteString(`
+				0     SAS3008     1000h    97h   00h:3bh:00h:00h      1000h   30e0h
+				SAS3IRCU: Utility Completed Successfully.
+			`)
+		} else if command == "0 DISPLAY" {
+			outputStdout.WriteString(`
+				Controller type                         : SAS3008
+				BIOS version                            : 8.37.00.00
+				Firmware version                        : 16.00.10.00
+				Channel description                     : 1 Serial Attached SCSI
+				Segment                                 : 0
+				Bus                                     : 59
+				Device                                  : 0
+				Function                                : 0
+				------------------------------------------------------------------------
+				IR Volume information
+				------------------------------------------------------------------------
+				Physical device information
+				------------------------------------------------------------------------
+				Initiator at ID #0
+
+				Device is a Hard disk
+				Enclosure #                             : 2
+				Slot #                                  : 5
+				SAS Address                             : 5000c50-0-a1b2-c3d5
+				State                                   : Ready (RDY)
+				Size (in MB)/(in sectors)               : 3815447/7814037167
+				Manufacturer                            : SEAGATE
+				Model Number                            : ST4000NM0025
+				Firmware Revision                       : E004
+				Serial No                               : ZC1ABCDE
+				GUID                                    : 5000c500a1b2c3d7
+				Protocol                                : SAS
+				Drive Type                              : SAS_HDD
+				------------------------------------------------------------------------
+			`)
+		}
+		return &outputStdout, &outputStderr, nil
+	}
+
+	// Mocked function, this way we can run unit tests in servers without hardware raid controller installed.
+	hardwarecontrollerscommon.GetJbodOsDevice = func(manufacturer, controllerId, eidslot string) (string, error) {
+		return "sdd", nil
+	}
+
+	newControllers, newRaids, newNoRaidDisks, err := ProcessHWSas2ircuRaid("sas3ircu")
+	if err != nil {
+		t.Fatalf(`TestProcessHWSas3ircuRaid returned error: %s`, err)
+	}
+
+	if len(newControllers) != 1 || len(newRaids) != 0 || len(newNoRaidDisks) != 1 {
+		t.Fatalf(`TestProcessHWSas3ircuRaid controllers/raids/noRaidDisks: %v/%v/%v should match: 1/0/1`, len(newControllers), len(newRaids), len(newNoRaidDisks))
+	}
+
+	if newControllers[0].Id != "sas3ircu-0" || newControllers[0].Manufacturer != "sas3ircu" || newControllers[0].Model != "SAS3008" {
+		t.Fatalf(`TestProcessHWSas3ircuRaid newController: %v/%v/%v should match: sas3ircu-0/sas3ircu/SAS3008`, newControllers[0].Id, newControllers[0].Manufacturer, newControllers[0].Model)
+	}
+
+	if newControllers[0].FirmwareVersion != "16.00.10.00" {
+		t.Fatalf(`TestProcessHWSas3ircuRaid newController.FirmwareVersion: %v should match: 16.00.10.00`, newControllers[0].FirmwareVersion)
+	}
+
+	noRaidDisk := newNoRaidDisks[0]
+	if noRaidDisk.ControllerId != "sas3ircu-0" || noRaidDisk.EidSlot != "2:5" || noRaidDisk.SerialNumber != "ZC1ABCDE" || noRaidDisk.Intf != "SAS" {
+		t.Fatalf(`TestProcessHWSas3ircuRaid noRaidDisk: %v/%v/%v/%v should match: sas3ircu-0/2:5/ZC1ABCDE/SAS`, noRaidDisk.ControllerId, noRaidDisk.EidSlot, noRaidDisk.SerialNumber, noRaidDisk.Intf)
+	}
+}
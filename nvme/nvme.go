@@ -0,0 +1,212 @@
+package nvme
+
+import (
+	"fmt"
+	"hardwareAnalyzer/smart"
+	"hardwareAnalyzer/utils"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	human "github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+)
+
+// NVMe controllers and namespaces from /sys/class/nvme and /sys/block
+// Namespace head devices: nvme0n1, multipath paths: nvme0c0n1
+
+var namespaceRegexp = regexp.MustCompile(`^nvme\d+n\d+$`)
+var pathRegexp = regexp.MustCompile(`^nvme\d+c(\d+)n\d+$`)
+
+// Get namespace controllers and paths: nvme0(optimized), nvme1(non-optimized)
+// Without native multipath namespace is only accessible through its parent controller
+func getNamespacePaths(namespace string) ([]string, []string) {
+	controllers := []string{}
+	paths := []string{}
+	multipathPaths, err := utils.ReadSysfsDir("/sys/block/" + namespace + "/multipath")
+	if err == nil && len(multipathPaths) > 0 {
+		for _, multipathPath := range multipathPaths {
+			pathData := pathRegexp.FindStringSubmatch(multipathPath)
+			if len(pathData) != 2 {
+				continue
+			}
+			controller := "nvme" + pathData[1]
+			anaState, err := utils.ReadSysfsFile("/sys/block/" + namespace + "/multipath/" + multipathPath + "/ana_state")
+			if err != nil {
+				anaState = "Unknown"
+			}
+			controllers = append(controllers, controller)
+			paths = append(paths, controller+"("+anaState+")")
+		}
+		return controllers, paths
+	}
+
+	// ../../nvme0
+	link, err := utils.ReadSysfsLink("/sys/block/" + namespace + "/device")
+	if err != nil {
+		return controllers, paths
+	}
+	controller := filepath.Base(link)
+	return []string{controller}, []string{controller + "(direct)"}
+}
+
+// Namespace size and LBA format: 4096+0 (data bytes + metadata bytes)
+func getNamespaceFormat(namespace string) (string, string) {
+	namespaceSize := "Unknown"
+	lbaFormat := "Unknown"
+	// Size is always reported in 512 bytes sectors
+	sectors, err := utils.ReadSysfsFile("/sys/block/" + namespace + "/size")
+	if err == nil {
+		sectorsInt, err := strconv.ParseUint(sectors, 10, 64)
+		if err == nil {
+			namespaceSize = human.Bytes(sectorsInt * 512)
+		}
+	}
+	logicalBlockSize, err := utils.ReadSysfsFile("/sys/block/" + namespace + "/queue/logical_block_size")
+	if err == nil {
+		metadataBytes, err := utils.ReadSysfsFile("/sys/block/" + namespace + "/metadata_bytes")
+		if err != nil {
+			metadataBytes = "0"
+		}
+		lbaFormat = logicalBlockSize + "+" + metadataBytes
+	}
+	return namespaceSize, lbaFormat
+}
+
+var CheckNvme = func() (bool, error) {
+	fmt.Println("> Checking NVMe controllers.")
+	nvmeControllers, err := utils.ReadSysfsDir("/sys/class/nvme")
+	if err != nil || len(nvmeControllers) == 0 {
+		fmt.Println("> No NVMe controllers detected.")
+		return false, nil
+	}
+	color.Magenta("> NVMe controllers detected.")
+	return true, nil
+}
+
+// Each NVMe controller is shown as a controller with its namespaces as disks
+var ProcessNvme = func(manufacturer string) ([]utils.ControllerStruct, []utils.RaidStruct, error) {
+	fmt.Println("> Getting current NVMe configuration.")
+	controllers := []utils.ControllerStruct{}
+	raids := []utils.RaidStruct{}
+
+	nvmeControllers, err := utils.ReadSysfsDir("/sys/class/nvme")
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong reading /sys/class/nvme: %v", err)
+		return controllers, raids, fmt.Errorf("Error: Something went wrong reading /sys/class/nvme: %v.", err)
+	}
+	driverName, driverVersion := utils.GetKernelModuleVersion("nvme")
+
+	for _, nvmeController := range nvmeControllers {
+		controllerPath := "/sys/class/nvme/" + nvmeController
+		controllerId := manufacturer + "-" + strings.TrimPrefix(nvmeController, "nvme")
+		readField := func(field string) string {
+			value, err := utils.ReadSysfsFile(controllerPath + "/" + field)
+			if err != nil || len(value) == 0 {
+				return "Unknown"
+			}
+			return value
+		}
+
+		controller := utils.ControllerStruct{
+			Id:              controllerId,
+			Manufacturer:    manufacturer,
+			Model:           readField("model"),
+			Status:          "Good",
+			FirmwareVersion: readField("firmware_rev"),
+			BiosVersion:     "N/A",
+			DriverName:      driverName,
+			DriverVersion:   driverVersion,
+			SerialNumber:    readField("serial"),
+			RocTemperature:  "N/A",
+			Transport:       readField("transport"),
+		}
+		// PCIe controllers address: 0000:01:00.0, fabrics: traddr=10.0.0.1,trsvcid=4420
+		controller.PciAddress = readField("address")
+
+		state := readField("state")
+		if state != "live" {
+			controller.Status = "Bad: controller " + state
+		}
+
+		// Health log is read from controller character device
+		smartLog, err := smart.ReadNvmeSmartLog(nvmeController)
+		if err != nil {
+			controller.Health = smart.NewSmart("nvme")
+		} else {
+			controller.Health = smart.SmartFromNvmeSmartLog(smartLog)
+			utils.EvaluateSmart(&controller.Health)
+			if controller.Status == "Good" && len(controller.Health.CriticalWarnings) > 0 {
+				controller.Status = "Bad: " + strings.Join(controller.Health.CriticalWarnings, ", ")
+			}
+		}
+		if controller.Health.Temperature >= 0 {
+			controller.RocTemperature = strconv.FormatInt(controller.Health.Temperature, 10) + " C"
+		}
+		controllers = append(controllers, controller)
+	}
+
+	// Namespaces are assigned to its first controller
+	blockDevices, err := utils.ReadSysfsDir("/sys/block")
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong reading /sys/block: %v", err)
+		return controllers, raids, fmt.Errorf("Error: Something went wrong reading /sys/block: %v.", err)
+	}
+	for _, blockDevice := range blockDevices {
+		if !namespaceRegexp.MatchString(blockDevice) {
+			continue
+		}
+		namespaceControllers, paths := getNamespacePaths(blockDevice)
+		if len(namespaceControllers) == 0 {
+			continue
+		}
+		namespaceId, err := utils.ReadSysfsFile("/sys/block/" + blockDevice + "/nsid")
+		if err != nil {
+			namespaceId = blockDevice[strings.LastIndex(blockDevice, "n")+1:]
+		}
+		namespaceSize, lbaFormat := getNamespaceFormat(blockDevice)
+
+		for i := range controllers {
+			controller := &controllers[i]
+			if controller.Id != manufacturer+"-"+strings.TrimPrefix(namespaceControllers[0], "nvme") {
+				continue
+			}
+			controller.Namespaces = append(controller.Namespaces, utils.NamespaceStruct{
+				ControllerId: controller.Id,
+				Id:           namespaceId,
+				OsDevice:     blockDevice,
+				Size:         namespaceSize,
+				LbaFormat:    lbaFormat,
+				Paths:        paths,
+			})
+
+			diskState := "ONLINE"
+			if !strings.HasPrefix(controller.Status, "Good") {
+				diskState = "FAILED"
+			}
+			disk := utils.DiskStruct{
+				ControllerId: controller.Id,
+				State:        diskState,
+				Size:         namespaceSize,
+				Intf:         "nvme",
+				Medium:       "NVME",
+				Model:        controller.Model,
+				SerialNumber: controller.SerialNumber,
+				OsDevice:     blockDevice,
+			}
+			raidIndex := slices.IndexFunc(raids, func(raid utils.RaidStruct) bool { return raid.ControllerId == controller.Id })
+			if raidIndex < 0 {
+				raids = append(raids, utils.RaidStruct{
+					ControllerId: controller.Id,
+					State:        "Good",
+				})
+				raidIndex = len(raids) - 1
+			}
+			raids[raidIndex].AddDisk(disk)
+			break
+		}
+	}
+	return controllers, raids, nil
+}
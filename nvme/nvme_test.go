@@ -0,0 +1,146 @@
+package nvme
+
+import (
+	"encoding/binary"
+	"errors"
+	"hardwareAnalyzer/smart"
+	"hardwareAnalyzer/utils"
+	"reflect"
+	"testing"
+)
+
+// Test ProcessNvme
+func TestProcessNvme(t *testing.T) {
+	// Copy original functions content
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsDirOri := utils.ReadSysfsDir
+	readSysfsLinkOri := utils.ReadSysfsLink
+	readNvmeSmartLogOri := smart.ReadNvmeSmartLog
+	// unmock functions content
+	defer func() {
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsDir = readSysfsDirOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+		smart.ReadNvmeSmartLog = readNvmeSmartLogOri
+	}()
+
+	// Mocked functions: nvme0 local PCIe drive, nvme1/nvme2 fabrics controllers sharing multipath namespace nvme1n1
+	sysfsFiles := map[string]string{
+		"/sys/class/nvme/nvme0/model":                      "Samsung SSD 980 PRO 1TB",
+		"/sys/class/nvme/nvme0/serial":                     "S5GXNF0R123456",
+		"/sys/class/nvme/nvme0/firmware_rev":               "5B2QGXA7",
+		"/sys/class/nvme/nvme0/transport":                  "pcie",
+		"/sys/class/nvme/nvme0/address":                    "0000:01:00.0",
+		"/sys/class/nvme/nvme0/state":                      "live",
+		"/sys/class/nvme/nvme1/model":                      "NetApp ONTAP Controller",
+		"/sys/class/nvme/nvme1/serial":                     "81Abc123",
+		"/sys/class/nvme/nvme1/firmware_rev":               "FFFFFFFF",
+		"/sys/class/nvme/nvme1/transport":                  "tcp",
+		"/sys/class/nvme/nvme1/address":                    "traddr=10.0.0.1,trsvcid=4420",
+		"/sys/class/nvme/nvme1/state":                      "live",
+		"/sys/class/nvme/nvme2/model":                      "NetApp ONTAP Controller",
+		"/sys/class/nvme/nvme2/serial":                     "81Abc123",
+		"/sys/class/nvme/nvme2/firmware_rev":               "FFFFFFFF",
+		"/sys/class/nvme/nvme2/transport":                  "tcp",
+		"/sys/class/nvme/nvme2/address":                    "traddr=10.0.0.2,trsvcid=4420",
+		"/sys/class/nvme/nvme2/state":                      "connecting",
+		"/sys/block/nvme0n1/nsid":                          "1",
+		"/sys/block/nvme0n1/size":                          "1953525168",
+		"/sys/block/nvme0n1/queue/logical_block_size":      "512",
+		"/sys/block/nvme1n1/nsid":                          "1",
+		"/sys/block/nvme1n1/size":                          "209715200",
+		"/sys/block/nvme1n1/queue/logical_block_size":      "4096",
+		"/sys/block/nvme1n1/metadata_bytes":                "0",
+		"/sys/block/nvme1n1/multipath/nvme1c1n1/ana_state": "optimized",
+		"/sys/block/nvme1n1/multipath/nvme1c2n1/ana_state": "inaccessible",
+		"/sys/module/nvme/version":                         "1.0",
+	}
+	sysfsDirs := map[string][]string{
+		"/sys/class/nvme":              {"nvme0", "nvme1", "nvme2"},
+		"/sys/block":                   {"sda", "nvme0n1", "nvme0n1p1", "nvme1c1n1", "nvme1c2n1", "nvme1n1"},
+		"/sys/block/nvme1n1/multipath": {"nvme1c1n1", "nvme1c2n1"},
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		if value, ok := sysfsFiles[path]; ok {
+			return value, nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		if entries, ok := sysfsDirs[path]; ok {
+			return entries, nil
+		}
+		return nil, errors.New("No such file or directory")
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		if path == "/sys/block/nvme0n1/device" {
+			return "../../nvme0", nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	smart.ReadNvmeSmartLog = func(device string) ([]byte, error) {
+		if device != "nvme0" {
+			return nil, errors.New("Inappropriate ioctl for device")
+		}
+		// 40C, 100% spare, 2% used
+		data := make([]byte, 512)
+		binary.LittleEndian.PutUint16(data[1:3], 313)
+		data[3] = 100
+		data[5] = 2
+		return data, nil
+	}
+
+	controllers, raids, err := ProcessNvme("nvme")
+	if err != nil {
+		t.Fatalf(`TestProcessNvme returned error: %s`, err)
+	}
+	if len(controllers) != 3 {
+		t.Fatalf(`TestProcessNvme len(controllers): %v should be: 3`, len(controllers))
+	}
+	if controllers[0].Id != "nvme-0" || controllers[0].Status != "Good" || controllers[0].Transport != "pcie" || controllers[0].PciAddress != "0000:01:00.0" || controllers[0].FirmwareVersion != "5B2QGXA7" || controllers[0].RocTemperature != "40 C" {
+		t.Fatalf(`TestProcessNvme controller: %v muts match nvme-0 Good pcie 0000:01:00.0 5B2QGXA7 40 C`, controllers[0])
+	}
+	if controllers[0].Health.AvailableSpare != 100 || controllers[0].Health.WearLevel != 2 || controllers[0].Health.Status != "OK" {
+		t.Fatalf(`TestProcessNvme controller health: %v muts match spare 100 used 2 OK`, controllers[0].Health)
+	}
+	if controllers[2].Status != "Bad: controller connecting" {
+		t.Fatalf(`TestProcessNvme controller status: %v should be: Bad: controller connecting`, controllers[2].Status)
+	}
+
+	namespacesWanted := []utils.NamespaceStruct{
+		{
+			ControllerId: "nvme-1",
+			Id:           "1",
+			OsDevice:     "nvme1n1",
+			Size:         "107 GB",
+			LbaFormat:    "4096+0",
+			Paths:        []string{"nvme1(optimized)", "nvme2(inaccessible)"},
+		},
+	}
+	if !reflect.DeepEqual(controllers[1].Namespaces, namespacesWanted) {
+		t.Fatalf(`TestProcessNvme namespaces: %v should be: %v`, controllers[1].Namespaces, namespacesWanted)
+	}
+	if len(controllers[0].Namespaces) != 1 || controllers[0].Namespaces[0].LbaFormat != "512+0" {
+		t.Fatalf(`TestProcessNvme nvme-0 namespaces: %v muts match 1 namespace with 512+0 LBA format`, controllers[0].Namespaces)
+	}
+
+	raidsWanted := []utils.RaidStruct{
+		{
+			ControllerId: "nvme-0",
+			State:        "Good",
+			Disks: []utils.DiskStruct{
+				{ControllerId: "nvme-0", State: "ONLINE", Size: "1.0 TB", Intf: "nvme", Medium: "NVME", Model: "Samsung SSD 980 PRO 1TB", SerialNumber: "S5GXNF0R123456", OsDevice: "nvme0n1"},
+			},
+		},
+		{
+			ControllerId: "nvme-1",
+			State:        "Good",
+			Disks: []utils.DiskStruct{
+				{ControllerId: "nvme-1", State: "ONLINE", Size: "107 GB", Intf: "nvme", Medium: "NVME", Model: "NetApp ONTAP Controller", SerialNumber: "81Abc123", OsDevice: "nvme1n1"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(raids, raidsWanted) {
+		t.Fatalf(`TestProcessNvme raids: %v should be: %v`, raids, raidsWanted)
+	}
+}
@@ -0,0 +1,603 @@
+package partitions
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hardwareAnalyzer/utils"
+	"hash/crc32"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/fatih/color"
+)
+
+// Partition tables and filesystem signatures are read directly from disks, blkid/sgdisk are not required
+
+const (
+	mbrDiskSignatureOffset = 440
+	mbrEntriesOffset       = 446
+	mbrEntrySize           = 16
+	mbrSignatureOffset     = 510
+	gptEntriesMax          = 1024
+	gptEntryMinSize        = 128
+	gptEntryMaxSize        = 4096
+	// First 132KiB cover every probed superblock located at disk start, ZFS uberblocks start at 128KiB
+	probeSize = 0x21000
+	mdMagic   = 0xa92b4efc
+)
+
+// Well known GPT partition types
+var gptTypeNames = map[string]string{
+	"C12A7328-F81F-11D2-BA4B-00A0C93EC93B": "EFI System",
+	"21686148-6449-6E6F-744E-656564454649": "BIOS boot",
+	"0FC63DAF-8483-4772-8E79-3D69D8477DE4": "Linux filesystem",
+	"4F68BCE3-E8CD-4DB1-96E7-FBCAF984B709": "Linux root (x86-64)",
+	"BC13C2FF-59E6-4262-A352-B275FD6F7172": "Linux extended boot",
+	"A19D880F-05FC-4D3B-A006-743F0F84911E": "Linux RAID",
+	"0657FD6D-A4AB-43C4-84E5-0933C84B4F4F": "Linux swap",
+	"E6D6D379-F507-44C2-A23C-238F2A3DF928": "Linux LVM",
+	"CA7D7CCB-63ED-4C53-861C-1742536059CC": "Linux LUKS",
+	"6A898CC3-1DD2-11B2-99A6-080020736631": "Solaris /usr & Apple ZFS",
+	"6A945A3B-1DD2-11B2-99A6-080020736631": "Solaris reserved",
+	"516E7CBA-6ECF-11D6-8FF8-00022D09712B": "FreeBSD ZFS",
+	"EBD0A0A2-B9E5-4433-87C0-68B6B72699C7": "Microsoft basic data",
+	"E3C9E316-0B5C-4DB8-817D-F92DF00215AE": "Microsoft reserved",
+	"DE94BBA4-06D1-4D40-A16A-BFD50179D6AC": "Windows recovery",
+}
+
+// Well known MBR partition types
+var mbrTypeNames = map[byte]string{
+	0x05: "Extended",
+	0x07: "HPFS/NTFS/exFAT",
+	0x0b: "W95 FAT32",
+	0x0c: "W95 FAT32 (LBA)",
+	0x0f: "W95 Extended (LBA)",
+	0x82: "Linux swap",
+	0x83: "Linux",
+	0x85: "Linux extended",
+	0x8e: "Linux LVM",
+	0xbf: "Solaris",
+	0xee: "GPT protective",
+	0xef: "EFI System",
+	0xfd: "Linux RAID",
+}
+
+// Function as variable in order to be able to mock it from unit tests
+// Read size bytes from block device at offset
+var ReadDeviceBytes = func(device string, offset int64, size int) ([]byte, error) {
+	file, err := os.Open("/dev/" + device)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data := make([]byte, size)
+	_, err = file.ReadAt(data, offset)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func readSysfsUint(path string) (uint64, bool) {
+	value, err := utils.ReadSysfsFile(path)
+	if err != nil {
+		return 0, false
+	}
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// GPT GUIDs are mixed endian: C12A7328-F81F-11D2-BA4B-00A0C93EC93B
+func formatGuid(data []byte) string {
+	return fmt.Sprintf("%08X-%04X-%04X-%X-%X", binary.LittleEndian.Uint32(data[0:4]), binary.LittleEndian.Uint16(data[4:6]), binary.LittleEndian.Uint16(data[6:8]), data[8:10], data[10:16])
+}
+
+// Filesystems UUIDs are stored as big endian bytes
+func formatUuid(data []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", data[0:4], data[4:6], data[6:8], data[8:10], data[10:16])
+}
+
+func cString(data []byte) string {
+	if index := bytes.IndexByte(data, 0); index >= 0 {
+		data = data[:index]
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Partition device names: sda1, nvme0n1p1, md0p1
+func getPartitionDevice(disk string, number int) string {
+	if len(disk) > 0 && disk[len(disk)-1] >= '0' && disk[len(disk)-1] <= '9' {
+		return disk + "p" + strconv.Itoa(number)
+	}
+	return disk + strconv.Itoa(number)
+}
+
+func newPartition(disk string, number int, start uint64, size uint64, alignment uint64) utils.PartitionStruct {
+	return utils.PartitionStruct{
+		Number:   number,
+		OsDevice: getPartitionDevice(disk, number),
+		Start:    start,
+		Size:     size,
+		Aligned:  start%alignment == 0,
+	}
+}
+
+// GPT header at LBA 1, partition entries location is read from header
+func getGptPartitions(disk string, sectorSize uint64, alignment uint64) (string, []utils.PartitionStruct, error) {
+	partitions := []utils.PartitionStruct{}
+	header, err := ReadDeviceBytes(disk, int64(sectorSize), 512)
+	if err != nil {
+		return "", partitions, err
+	}
+	if string(header[0:8]) != "EFI PART" {
+		return "", partitions, fmt.Errorf("Error: %s has no GPT header.", disk)
+	}
+	headerSize := binary.LittleEndian.Uint32(header[12:16])
+	if headerSize < 92 || headerSize > 512 {
+		return "", partitions, fmt.Errorf("Error: %s GPT header size %d.", disk, headerSize)
+	}
+	// Header CRC32 is computed with its own field zeroed
+	headerCopy := make([]byte, headerSize)
+	copy(headerCopy, header[:headerSize])
+	binary.LittleEndian.PutUint32(headerCopy[16:20], 0)
+	if crc32.ChecksumIEEE(headerCopy) != binary.LittleEndian.Uint32(header[16:20]) {
+		return "", partitions, fmt.Errorf("Error: %s GPT header checksum mismatch.", disk)
+	}
+
+	diskGuid := formatGuid(header[56:72])
+	entriesLba := binary.LittleEndian.Uint64(header[72:80])
+	entriesCount := binary.LittleEndian.Uint32(header[80:84])
+	entrySize := binary.LittleEndian.Uint32(header[84:88])
+	// Corrupted headers with a valid checksum must not overflow entries size
+	if entriesCount == 0 || entriesCount > gptEntriesMax || entrySize < gptEntryMinSize || entrySize > gptEntryMaxSize {
+		return diskGuid, partitions, fmt.Errorf("Error: %s GPT %d entries of %d bytes.", disk, entriesCount, entrySize)
+	}
+	entriesSize := int64(entriesCount) * int64(entrySize)
+	entries, err := ReadDeviceBytes(disk, int64(entriesLba*sectorSize), int(entriesSize))
+	if err != nil {
+		return diskGuid, partitions, err
+	}
+	if int64(len(entries)) < entriesSize {
+		return diskGuid, partitions, fmt.Errorf("Error: %s GPT entries truncated: %d of %d bytes.", disk, len(entries), entriesSize)
+	}
+
+	for i := 0; i < int(entriesCount); i++ {
+		entry := entries[i*int(entrySize) : i*int(entrySize)+gptEntryMinSize]
+		if isZero(entry[0:16]) {
+			continue
+		}
+		firstLba := binary.LittleEndian.Uint64(entry[32:40])
+		lastLba := binary.LittleEndian.Uint64(entry[40:48])
+		if lastLba < firstLba {
+			continue
+		}
+		partition := newPartition(disk, i+1, firstLba*sectorSize, (lastLba-firstLba+1)*sectorSize, alignment)
+		partition.Type = formatGuid(entry[0:16])
+		partition.TypeName = gptTypeNames[partition.Type]
+		partition.Guid = formatGuid(entry[16:32])
+		// Partition name: 36 UTF-16LE code units
+		name := []uint16{}
+		for j := 56; j < gptEntryMinSize; j = j + 2 {
+			codeUnit := binary.LittleEndian.Uint16(entry[j : j+2])
+			if codeUnit == 0 {
+				break
+			}
+			name = append(name, codeUnit)
+		}
+		partition.Name = string(utf16.Decode(name))
+		partitions = append(partitions, partition)
+	}
+	return diskGuid, partitions, nil
+}
+
+type mbrEntry struct {
+	typeCode byte
+	start    uint64
+	sectors  uint64
+}
+
+func hasMbrSignature(sector []byte) bool {
+	return len(sector) >= 512 && sector[mbrSignatureOffset] == 0x55 && sector[mbrSignatureOffset+1] == 0xAA
+}
+
+func isExtendedPartition(typeCode byte) bool {
+	return typeCode == 0x05 || typeCode == 0x0f || typeCode == 0x85
+}
+
+func parseMbrEntries(sector []byte) []mbrEntry {
+	entries := []mbrEntry{}
+	for i := 0; i < 4; i++ {
+		entry := sector[mbrEntriesOffset+i*mbrEntrySize : mbrEntriesOffset+(i+1)*mbrEntrySize]
+		entries = append(entries, mbrEntry{
+			typeCode: entry[4],
+			start:    uint64(binary.LittleEndian.Uint32(entry[8:12])),
+			sectors:  uint64(binary.LittleEndian.Uint32(entry[12:16])),
+		})
+	}
+	return entries
+}
+
+func newMbrPartition(disk string, number int, entry mbrEntry, sectorSize uint64, alignment uint64) utils.PartitionStruct {
+	partition := newPartition(disk, number, entry.start*sectorSize, entry.sectors*sectorSize, alignment)
+	partition.Type = fmt.Sprintf("0x%02x", entry.typeCode)
+	partition.TypeName = mbrTypeNames[entry.typeCode]
+	return partition
+}
+
+// MBR primary partitions are numbered 1-4 by its slot, logical ones from 5 following the EBR chain
+// EBR first entry is relative to its own EBR, second entry points to next EBR relative to extended partition start
+func getMbrPartitions(disk string, sector []byte, sectorSize uint64, alignment uint64) []utils.PartitionStruct {
+	partitions := []utils.PartitionStruct{}
+	for i, entry := range parseMbrEntries(sector) {
+		if entry.typeCode == 0 || entry.sectors == 0 {
+			continue
+		}
+		partitions = append(partitions, newMbrPartition(disk, i+1, entry, sectorSize, alignment))
+		if !isExtendedPartition(entry.typeCode) {
+			continue
+		}
+		number := 5
+		next := uint64(0)
+		// Chain length limit protects against EBR loops
+		for j := 0; j < 128; j++ {
+			ebr, err := ReadDeviceBytes(disk, int64((entry.start+next)*sectorSize), 512)
+			if err != nil || !hasMbrSignature(ebr) {
+				break
+			}
+			ebrEntries := parseMbrEntries(ebr)
+			logical := ebrEntries[0]
+			if logical.typeCode != 0 && logical.sectors > 0 {
+				logical.start = entry.start + next + logical.start
+				partitions = append(partitions, newMbrPartition(disk, number, logical, sectorSize, alignment))
+				number++
+			}
+			if !isExtendedPartition(ebrEntries[1].typeCode) || ebrEntries[1].start == 0 {
+				break
+			}
+			next = ebrEntries[1].start
+		}
+	}
+	return partitions
+}
+
+// ZFS labels store its config as XDR encoded nvlist at 16KiB, nvpair: name length, name, type, elements, value
+func findXdrPair(data []byte, name string, dataType uint32) []byte {
+	pattern := make([]byte, 4, 4+len(name)+11)
+	binary.BigEndian.PutUint32(pattern, uint32(len(name)))
+	pattern = append(pattern, name...)
+	for len(pattern)%4 != 0 {
+		pattern = append(pattern, 0)
+	}
+	pattern = binary.BigEndian.AppendUint32(pattern, dataType)
+	pattern = binary.BigEndian.AppendUint32(pattern, 1)
+	index := bytes.Index(data, pattern)
+	if index < 0 {
+		return nil
+	}
+	return data[index+len(pattern):]
+}
+
+func probeZfsLabel(data []byte, filesystem *utils.FilesystemStruct) bool {
+	if len(data) < probeSize {
+		return false
+	}
+	config := data[0x4000:0x20000]
+	// DATA_TYPE_STRING: 9, DATA_TYPE_UINT64: 8
+	if value := findXdrPair(config, "name", 9); len(value) >= 4 {
+		length := int(binary.BigEndian.Uint32(value[0:4]))
+		if length <= len(value)-4 {
+			filesystem.Type = "zfs_member"
+			filesystem.Label = string(value[4 : 4+length])
+		}
+	}
+	if value := findXdrPair(config, "pool_guid", 8); len(value) >= 8 {
+		filesystem.Type = "zfs_member"
+		filesystem.Uuid = strconv.FormatUint(binary.BigEndian.Uint64(value[0:8]), 10)
+	}
+	if len(filesystem.Type) > 0 {
+		return true
+	}
+	// Spare/cache devices labels have no pool config, uberblock magic is checked instead
+	for offset := 0x20000; offset+8 <= probeSize; offset = offset + 1024 {
+		magic := data[offset : offset+8]
+		if binary.LittleEndian.Uint64(magic) == 0x00bab10c || binary.BigEndian.Uint64(magic) == 0x00bab10c {
+			filesystem.Type = "zfs_member"
+			return true
+		}
+	}
+	return false
+}
+
+// md superblocks 1.0 and 0.90 are located at device end
+func probeMdEnd(device string, size uint64, filesystem *utils.FilesystemStruct) bool {
+	if size < 128*1024 {
+		return false
+	}
+	// 1.0: 8KiB from end aligned to 4KiB
+	superblock, err := ReadDeviceBytes(device, int64((size-8192)&^4095), 256)
+	if err == nil && binary.LittleEndian.Uint32(superblock[0:4]) == mdMagic {
+		filesystem.Type = "linux_raid_member"
+		filesystem.Uuid = formatUuid(superblock[16:32])
+		filesystem.Label = cString(superblock[32:64])
+		return true
+	}
+	// 0.90: last 64KiB aligned block
+	superblock, err = ReadDeviceBytes(device, int64((size&^65535)-65536), 256)
+	if err == nil && binary.LittleEndian.Uint32(superblock[0:4]) == mdMagic {
+		filesystem.Type = "linux_raid_member"
+		uuid := append([]byte{}, superblock[20:24]...)
+		uuid = append(uuid, superblock[52:64]...)
+		filesystem.Uuid = formatUuid(uuid)
+		return true
+	}
+	return false
+}
+
+// Filesystem and volume signatures, raid/volume manager signatures are checked first as them can contain a filesystem
+func ProbeFilesystem(device string, size uint64) utils.FilesystemStruct {
+	filesystem := utils.FilesystemStruct{}
+	readSize := uint64(probeSize)
+	if size > 0 && size < readSize {
+		readSize = size
+	}
+	data, err := ReadDeviceBytes(device, 0, int(readSize))
+	if err != nil || len(data) < 4096 {
+		return filesystem
+	}
+
+	// md 1.1 at start, 1.2 at 4KiB
+	for _, offset := range []int{0, 4096} {
+		if offset+64 <= len(data) && binary.LittleEndian.Uint32(data[offset:offset+4]) == mdMagic {
+			filesystem.Type = "linux_raid_member"
+			filesystem.Uuid = formatUuid(data[offset+16 : offset+32])
+			filesystem.Label = cString(data[offset+32 : offset+64])
+			return filesystem
+		}
+	}
+	if probeMdEnd(device, size, &filesystem) {
+		return filesystem
+	}
+	if probeZfsLabel(data, &filesystem) {
+		return filesystem
+	}
+
+	if bytes.Equal(data[0:6], []byte("LUKS\xba\xbe")) {
+		filesystem.Type = "crypto_LUKS"
+		filesystem.Uuid = cString(data[168:208])
+		// LUKS2 header has a label
+		if binary.BigEndian.Uint16(data[6:8]) == 2 {
+			filesystem.Label = cString(data[24:72])
+		}
+		return filesystem
+	}
+
+	// LVM label can be in any of the first 4 sectors
+	for sector := 0; sector < 4; sector++ {
+		label := data[sector*512 : (sector+1)*512]
+		if string(label[0:8]) == "LABELONE" && string(label[24:32]) == "LVM2 001" {
+			uuid := string(label[32:64])
+			filesystem.Type = "LVM2_member"
+			filesystem.Uuid = uuid[0:6] + "-" + uuid[6:10] + "-" + uuid[10:14] + "-" + uuid[14:18] + "-" + uuid[18:22] + "-" + uuid[22:26] + "-" + uuid[26:32]
+			return filesystem
+		}
+	}
+
+	switch {
+	case len(data) >= 0x10000+0x12b+256 && string(data[0x10040:0x10048]) == "_BHRfS_M":
+		filesystem.Type = "btrfs"
+		filesystem.Uuid = formatUuid(data[0x10020:0x10030])
+		filesystem.Label = cString(data[0x1012b : 0x1012b+256])
+	case string(data[0:4]) == "XFSB":
+		filesystem.Type = "xfs"
+		filesystem.Uuid = formatUuid(data[32:48])
+		filesystem.Label = cString(data[108:120])
+	case binary.LittleEndian.Uint16(data[1024+56:1024+58]) == 0xEF53:
+		compat := binary.LittleEndian.Uint32(data[1024+0x5C : 1024+0x60])
+		incompat := binary.LittleEndian.Uint32(data[1024+0x60 : 1024+0x64])
+		switch {
+		// extents, 64bit, flex_bg
+		case incompat&(0x40|0x80|0x200) != 0:
+			filesystem.Type = "ext4"
+		// has_journal
+		case compat&0x4 != 0:
+			filesystem.Type = "ext3"
+		default:
+			filesystem.Type = "ext2"
+		}
+		filesystem.Uuid = formatUuid(data[1024+0x68 : 1024+0x78])
+		filesystem.Label = cString(data[1024+0x78 : 1024+0x88])
+	// Swap signature is at the end of the first page: 4KiB or 64KiB pages
+	case string(data[4086:4096]) == "SWAPSPACE2" || (len(data) >= 65536 && string(data[65526:65536]) == "SWAPSPACE2"):
+		filesystem.Type = "swap"
+		filesystem.Uuid = formatUuid(data[1024+12 : 1024+28])
+		filesystem.Label = cString(data[1024+28 : 1024+44])
+	case hasMbrSignature(data) && string(data[82:87]) == "FAT32":
+		filesystem.Type = "vfat"
+		filesystem.Uuid = fmt.Sprintf("%04X-%04X", binary.LittleEndian.Uint16(data[69:71]), binary.LittleEndian.Uint16(data[67:69]))
+		filesystem.Label = cString(data[71:82])
+	case hasMbrSignature(data) && string(data[54:58]) == "FAT1":
+		filesystem.Type = "vfat"
+		filesystem.Uuid = fmt.Sprintf("%04X-%04X", binary.LittleEndian.Uint16(data[41:43]), binary.LittleEndian.Uint16(data[39:41]))
+		filesystem.Label = cString(data[43:54])
+	case string(data[3:11]) == "NTFS    ":
+		filesystem.Type = "ntfs"
+		filesystem.Uuid = fmt.Sprintf("%016X", binary.LittleEndian.Uint64(data[72:80]))
+	}
+	// FAT labels are space padded, NO NAME means no label
+	if filesystem.Label == "NO NAME" {
+		filesystem.Label = ""
+	}
+	return filesystem
+}
+
+// Mounted filesystems by device major:minor and by mount source, Btrfs mounts use an anonymous major:minor
+// 36 25 8:1 / /boot rw,relatime shared:7 - ext4 /dev/sda1 rw
+func getMountpoints() map[string][]string {
+	mountpoints := make(map[string][]string)
+	addMountpoint := func(key string, mountpoint string) {
+		if !slices.Contains(mountpoints[key], mountpoint) {
+			mountpoints[key] = append(mountpoints[key], mountpoint)
+		}
+	}
+	unescape := strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+
+	mountinfo, err := utils.ReadSysfsFile("/proc/self/mountinfo")
+	if err == nil {
+		for _, line := range strings.Split(mountinfo, "\n") {
+			fields := strings.Fields(line)
+			separator := -1
+			for i, field := range fields {
+				if field == "-" {
+					separator = i
+					break
+				}
+			}
+			if len(fields) < 5 || separator < 0 || separator+2 >= len(fields) {
+				continue
+			}
+			mountpoint := unescape.Replace(fields[4])
+			addMountpoint(fields[2], mountpoint)
+			addMountpoint(unescape.Replace(fields[separator+2]), mountpoint)
+		}
+	}
+
+	// /dev/sda2 partition 8388604 0 -2
+	swaps, err := utils.ReadSysfsFile("/proc/swaps")
+	if err == nil {
+		for _, line := range strings.Split(swaps, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || fields[0] == "Filename" {
+				continue
+			}
+			addMountpoint(unescape.Replace(fields[0]), "[SWAP]")
+		}
+	}
+	return mountpoints
+}
+
+// Mountpoints and stacked devices of a disk or partition
+func setFilesystemUsers(device string, filesystem *utils.FilesystemStruct, mountpoints map[string][]string) {
+	majorMinor, err := utils.ReadSysfsFile("/sys/class/block/" + device + "/dev")
+	if err == nil {
+		filesystem.Mountpoints = append(filesystem.Mountpoints, mountpoints[majorMinor]...)
+	}
+	for _, mountpoint := range mountpoints["/dev/"+device] {
+		if !slices.Contains(filesystem.Mountpoints, mountpoint) {
+			filesystem.Mountpoints = append(filesystem.Mountpoints, mountpoint)
+		}
+	}
+
+	holders, err := utils.ReadSysfsDir("/sys/class/block/" + device + "/holders")
+	if err != nil {
+		return
+	}
+	for _, holder := range holders {
+		// Device mapper holders are shown by its name: luks-data, vg0-root
+		if strings.HasPrefix(holder, "dm-") {
+			name, err := utils.ReadSysfsFile("/sys/block/" + holder + "/dm/name")
+			if err == nil {
+				holder = name
+			}
+		}
+		filesystem.Holders = append(filesystem.Holders, holder)
+	}
+}
+
+// Disk partition table, partitions and filesystem signatures
+func GetDiskLayout(disk string, mountpoints map[string][]string) (utils.DiskLayoutStruct, error) {
+	layout := utils.DiskLayoutStruct{
+		OsDevice:   disk,
+		SectorSize: 512,
+		Table:      "none",
+	}
+	// Size is always reported in 512 bytes sectors
+	sectors, ok := readSysfsUint("/sys/block/" + disk + "/size")
+	if !ok {
+		return layout, fmt.Errorf("Error: Cant read %s size.", disk)
+	}
+	layout.Size = sectors * 512
+	if sectorSize, ok := readSysfsUint("/sys/block/" + disk + "/queue/logical_block_size"); ok && sectorSize > 0 {
+		layout.SectorSize = sectorSize
+	}
+	// Partitions must start at physical block boundaries, 4KiB at least for SSDs pages
+	alignment := uint64(4096)
+	if physicalBlockSize, ok := readSysfsUint("/sys/block/" + disk + "/queue/physical_block_size"); ok && physicalBlockSize > alignment {
+		alignment = physicalBlockSize
+	}
+
+	sector, err := ReadDeviceBytes(disk, 0, 512)
+	if err != nil {
+		return layout, fmt.Errorf("Error: Cant read %s first sector: %v.", disk, err)
+	}
+
+	// Protective MBR or hybrid MBR, GPT is always preferred
+	diskGuid, partitions, gptErr := getGptPartitions(disk, layout.SectorSize, alignment)
+	filesystem := ProbeFilesystem(disk, layout.Size)
+	switch {
+	case gptErr == nil:
+		layout.Table = "gpt"
+		layout.TableId = diskGuid
+		layout.Partitions = partitions
+	case len(diskGuid) > 0:
+		color.Red("++ ERROR: %s", gptErr)
+		layout.Table = "gpt"
+		layout.TableId = diskGuid
+	// FAT/NTFS boot sectors have MBR signature too
+	case hasMbrSignature(sector) && filesystem.Type != "vfat" && filesystem.Type != "ntfs":
+		partitions = getMbrPartitions(disk, sector, layout.SectorSize, alignment)
+		if len(partitions) == 0 {
+			break
+		}
+		layout.Table = "dos"
+		layout.TableId = fmt.Sprintf("0x%08x", binary.LittleEndian.Uint32(sector[mbrDiskSignatureOffset:mbrDiskSignatureOffset+4]))
+		layout.Partitions = partitions
+	}
+
+	if layout.Table == "none" {
+		layout.Filesystem = filesystem
+		setFilesystemUsers(disk, &layout.Filesystem, mountpoints)
+		return layout, nil
+	}
+	for i := range layout.Partitions {
+		partition := &layout.Partitions[i]
+		// Extended partitions only contain logical partitions EBRs
+		if partition.Type == "0x05" || partition.Type == "0x0f" || partition.Type == "0x85" {
+			continue
+		}
+		partition.Filesystem = ProbeFilesystem(partition.OsDevice, partition.Size)
+		setFilesystemUsers(partition.OsDevice, &partition.Filesystem, mountpoints)
+	}
+	return layout, nil
+}
+
+// Layout of every given whole disk
+func GetDiskLayouts(disks []string) []utils.DiskLayoutStruct {
+	fmt.Println("> Reading disks partition tables.")
+	layouts := []utils.DiskLayoutStruct{}
+	mountpoints := getMountpoints()
+	for _, disk := range disks {
+		layout, err := GetDiskLayout(disk, mountpoints)
+		if err != nil {
+			color.Red("++ ERROR: %s", err)
+			continue
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts
+}
@@ -0,0 +1,387 @@
+package partitions
+
+import (
+	"encoding/binary"
+	"errors"
+	"hardwareAnalyzer/utils"
+	"hash/crc32"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// GUID bytes as stored on GPT: first three fields little endian
+func putGuid(data []byte, guid [16]byte) {
+	copy(data, guid[:])
+	data[0], data[1], data[2], data[3] = guid[3], guid[2], guid[1], guid[0]
+	data[4], data[5] = guid[5], guid[4]
+	data[6], data[7] = guid[7], guid[6]
+}
+
+// Build GPT disk first sectors: protective MBR, header and 128 entries at LBA 2
+func buildGptDisk(entries [][]byte) []byte {
+	disk := make([]byte, 34*512)
+	disk[mbrEntriesOffset+4] = 0xee
+	disk[mbrSignatureOffset] = 0x55
+	disk[mbrSignatureOffset+1] = 0xAA
+
+	entriesData := disk[2*512 : 34*512]
+	for i, entry := range entries {
+		copy(entriesData[i*128:], entry)
+	}
+	header := disk[512:1024]
+	copy(header[0:8], "EFI PART")
+	binary.LittleEndian.PutUint32(header[12:16], 92)
+	putGuid(header[56:72], [16]byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF, 0x00})
+	binary.LittleEndian.PutUint64(header[72:80], 2)
+	binary.LittleEndian.PutUint32(header[80:84], 128)
+	binary.LittleEndian.PutUint32(header[84:88], 128)
+	binary.LittleEndian.PutUint32(header[88:92], crc32.ChecksumIEEE(entriesData))
+	binary.LittleEndian.PutUint32(header[16:20], crc32.ChecksumIEEE(header[:92]))
+	return disk
+}
+
+func buildGptEntry(typeGuid [16]byte, uniqueGuid [16]byte, firstLba uint64, lastLba uint64, name string) []byte {
+	entry := make([]byte, 128)
+	putGuid(entry[0:16], typeGuid)
+	putGuid(entry[16:32], uniqueGuid)
+	binary.LittleEndian.PutUint64(entry[32:40], firstLba)
+	binary.LittleEndian.PutUint64(entry[40:48], lastLba)
+	for i, codeUnit := range utf16.Encode([]rune(name)) {
+		binary.LittleEndian.PutUint16(entry[56+i*2:], codeUnit)
+	}
+	return entry
+}
+
+func putMbrEntry(sector []byte, slot int, typeCode byte, start uint32, sectors uint32) {
+	entry := sector[mbrEntriesOffset+slot*mbrEntrySize:]
+	entry[4] = typeCode
+	binary.LittleEndian.PutUint32(entry[8:12], start)
+	binary.LittleEndian.PutUint32(entry[12:16], sectors)
+	sector[mbrSignatureOffset] = 0x55
+	sector[mbrSignatureOffset+1] = 0xAA
+}
+
+// Mock devices content, unwritten areas are read as zeros
+func mockDevices(images map[string][]byte) {
+	ReadDeviceBytes = func(device string, offset int64, size int) ([]byte, error) {
+		image, ok := images[device]
+		if !ok {
+			return nil, errors.New("No such device")
+		}
+		data := make([]byte, size)
+		if offset < int64(len(image)) {
+			copy(data, image[offset:])
+		}
+		return data, nil
+	}
+}
+
+// Test ProbeFilesystem signatures
+func TestProbeFilesystem(t *testing.T) {
+	// Copy original functions content
+	readDeviceBytesOri := ReadDeviceBytes
+	// unmock functions content
+	defer func() {
+		ReadDeviceBytes = readDeviceBytesOri
+	}()
+
+	uuid := []byte{0x3f, 0x1b, 0x6c, 0x2e, 0x8a, 0x4d, 0x4e, 0x3b, 0x9c, 0x1a, 0x2d, 0x5e, 0x6f, 0x70, 0x81, 0x92}
+	uuidString := "3f1b6c2e-8a4d-4e3b-9c1a-2d5e6f708192"
+	images := map[string][]byte{}
+
+	ext4 := make([]byte, probeSize)
+	binary.LittleEndian.PutUint16(ext4[1024+56:], 0xEF53)
+	binary.LittleEndian.PutUint32(ext4[1024+0x60:], 0x2C2)
+	copy(ext4[1024+0x68:], uuid)
+	copy(ext4[1024+0x78:], "root")
+	images["ext4"] = ext4
+
+	xfs := make([]byte, probeSize)
+	copy(xfs[0:], "XFSB")
+	copy(xfs[32:], uuid)
+	copy(xfs[108:], "data")
+	images["xfs"] = xfs
+
+	btrfs := make([]byte, probeSize)
+	copy(btrfs[0x10040:], "_BHRfS_M")
+	copy(btrfs[0x10020:], uuid)
+	copy(btrfs[0x1012b:], "pool")
+	images["btrfs"] = btrfs
+
+	swap := make([]byte, probeSize)
+	copy(swap[4086:], "SWAPSPACE2")
+	copy(swap[1024+12:], uuid)
+	images["swap"] = swap
+
+	luks := make([]byte, probeSize)
+	copy(luks[0:], "LUKS\xba\xbe")
+	binary.BigEndian.PutUint16(luks[6:], 2)
+	copy(luks[24:], "secure")
+	copy(luks[168:], uuidString)
+	images["luks"] = luks
+
+	lvm := make([]byte, probeSize)
+	copy(lvm[512:], "LABELONE")
+	copy(lvm[512+24:], "LVM2 001")
+	copy(lvm[512+32:], "AbCdEf12345678901234567890123456")
+	images["lvm"] = lvm
+
+	md := make([]byte, probeSize)
+	binary.LittleEndian.PutUint32(md[4096:], mdMagic)
+	copy(md[4096+16:], uuid)
+	copy(md[4096+32:], "server:0")
+	images["md"] = md
+
+	// ZFS label nvlist: name and pool_guid pairs
+	zfs := make([]byte, probeSize)
+	nvlist := []byte{0, 0, 0, 4, 'n', 'a', 'm', 'e', 0, 0, 0, 9, 0, 0, 0, 1, 0, 0, 0, 5, 't', 'a', 'n', 'k', '0', 0, 0, 0}
+	nvlist = append(nvlist, 0, 0, 0, 9, 'p', 'o', 'o', 'l', '_', 'g', 'u', 'i', 'd', 0, 0, 0, 0, 0, 0, 8, 0, 0, 0, 1)
+	nvlist = binary.BigEndian.AppendUint64(nvlist, 1234567890123)
+	copy(zfs[0x4000+24:], nvlist)
+	images["zfs"] = zfs
+
+	fat := make([]byte, probeSize)
+	fat[510], fat[511] = 0x55, 0xAA
+	copy(fat[82:], "FAT32   ")
+	binary.LittleEndian.PutUint32(fat[67:], 0xABCD1234)
+	copy(fat[71:], "NO NAME    ")
+	images["fat"] = fat
+
+	mockDevices(images)
+	filesystemsWanted := map[string]utils.FilesystemStruct{
+		"ext4":  {Type: "ext4", Label: "root", Uuid: uuidString},
+		"xfs":   {Type: "xfs", Label: "data", Uuid: uuidString},
+		"btrfs": {Type: "btrfs", Label: "pool", Uuid: uuidString},
+		"swap":  {Type: "swap", Uuid: uuidString},
+		"luks":  {Type: "crypto_LUKS", Label: "secure", Uuid: uuidString},
+		"lvm":   {Type: "LVM2_member", Uuid: "AbCdEf-1234-5678-9012-3456-7890-123456"},
+		"md":    {Type: "linux_raid_member", Label: "server:0", Uuid: uuidString},
+		"zfs":   {Type: "zfs_member", Label: "tank0", Uuid: "1234567890123"},
+		"fat":   {Type: "vfat", Uuid: "ABCD-1234"},
+	}
+	for device, filesystemWanted := range filesystemsWanted {
+		filesystem := ProbeFilesystem(device, 1024*1024*1024)
+		if !reflect.DeepEqual(filesystem, filesystemWanted) {
+			t.Fatalf(`TestProbeFilesystem %s: %v should be: %v`, device, filesystem, filesystemWanted)
+		}
+	}
+
+	// md 1.0 superblock at device end
+	size := uint64(1024 * 1024 * 1024)
+	mdEnd := make([]byte, size-((size-8192)&^4095)+0)
+	binary.LittleEndian.PutUint32(mdEnd[0:], mdMagic)
+	copy(mdEnd[16:], uuid)
+	ReadDeviceBytes = func(device string, offset int64, readSize int) ([]byte, error) {
+		data := make([]byte, readSize)
+		if uint64(offset) == (size-8192)&^4095 {
+			copy(data, mdEnd)
+		}
+		return data, nil
+	}
+	filesystem := ProbeFilesystem("mdend", size)
+	if filesystem.Type != "linux_raid_member" || filesystem.Uuid != uuidString {
+		t.Fatalf(`TestProbeFilesystem md 1.0: %v should be linux_raid_member %s`, filesystem, uuidString)
+	}
+}
+
+// Test GetDiskLayout GPT disk with mounted, stacked and misaligned partitions
+func TestGetDiskLayoutGpt(t *testing.T) {
+	// Copy original functions content
+	readDeviceBytesOri := ReadDeviceBytes
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsDirOri := utils.ReadSysfsDir
+	// unmock functions content
+	defer func() {
+		ReadDeviceBytes = readDeviceBytesOri
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsDir = readSysfsDirOri
+	}()
+
+	efiType := [16]byte{0xC1, 0x2A, 0x73, 0x28, 0xF8, 0x1F, 0x11, 0xD2, 0xBA, 0x4B, 0x00, 0xA0, 0xC9, 0x3E, 0xC9, 0x3B}
+	raidType := [16]byte{0xA1, 0x9D, 0x88, 0x0F, 0x05, 0xFC, 0x4D, 0x3B, 0xA0, 0x06, 0x74, 0x3F, 0x0F, 0x84, 0x91, 0x1E}
+	luksType := [16]byte{0xCA, 0x7D, 0x7C, 0xCB, 0x63, 0xED, 0x4C, 0x53, 0x86, 0x1C, 0x17, 0x42, 0x53, 0x60, 0x59, 0xCC}
+	disk := buildGptDisk([][]byte{
+		buildGptEntry(efiType, [16]byte{1}, 2048, 1050623, "EFI System Partition"),
+		buildGptEntry(raidType, [16]byte{2}, 1050624, 3147775, ""),
+		{},
+		buildGptEntry(luksType, [16]byte{4}, 3147777, 5244928, "data"),
+	})
+
+	fat := make([]byte, 4096)
+	fat[510], fat[511] = 0x55, 0xAA
+	copy(fat[82:], "FAT32   ")
+	binary.LittleEndian.PutUint32(fat[67:], 0x0000BEEF)
+	md := make([]byte, 8192)
+	binary.LittleEndian.PutUint32(md[4096:], mdMagic)
+	copy(md[4096+32:], "server:1")
+	luks := make([]byte, 4096)
+	copy(luks[0:], "LUKS\xba\xbe")
+	binary.BigEndian.PutUint16(luks[6:], 1)
+	copy(luks[168:], "c0ffee00-0000-4000-8000-000000000000")
+	mockDevices(map[string][]byte{"sda": disk, "sda1": fat, "sda2": md, "sda4": luks})
+
+	sysfsFiles := map[string]string{
+		"/sys/block/sda/size":                      "7814037168",
+		"/sys/block/sda/queue/logical_block_size":  "512",
+		"/sys/block/sda/queue/physical_block_size": "4096",
+		"/sys/class/block/sda1/dev":                "8:1",
+		"/sys/class/block/sda2/dev":                "8:2",
+		"/sys/class/block/sda4/dev":                "8:4",
+		"/sys/block/dm-0/dm/name":                  "luks-data",
+		"/proc/self/mountinfo":                     "22 1 9:1 / / rw,relatime shared:1 - ext4 /dev/md1 rw\n36 22 8:1 / /boot/efi rw,relatime shared:7 - vfat /dev/sda1 rw\n37 22 0:45 / /mnt/my\\040data rw,relatime shared:8 - btrfs /dev/mapper/luks-data rw",
+		"/proc/swaps":                              "Filename\tType\tSize\tUsed\tPriority\n/dev/sdb2\tpartition\t8388604\t0\t-2",
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		value, ok := sysfsFiles[path]
+		if !ok {
+			return "", errors.New("No such file or directory")
+		}
+		return value, nil
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		switch path {
+		case "/sys/class/block/sda2/holders":
+			return []string{"md1"}, nil
+		case "/sys/class/block/sda4/holders":
+			return []string{"dm-0"}, nil
+		}
+		return []string{}, errors.New("No such file or directory")
+	}
+
+	layouts := GetDiskLayouts([]string{"sda", "sdz"})
+	if len(layouts) != 1 {
+		t.Fatalf(`TestGetDiskLayoutGpt: len(layouts): %v should be: 1`, len(layouts))
+	}
+	layout := layouts[0]
+	if layout.Table != "gpt" || layout.TableId != "11223344-5566-7788-99AA-BBCCDDEEFF00" || layout.Size != 4000787030016 {
+		t.Fatalf(`TestGetDiskLayoutGpt: layout: %v %v %v should be: gpt 11223344-5566-7788-99AA-BBCCDDEEFF00 4000787030016`, layout.Table, layout.TableId, layout.Size)
+	}
+	partitionsWanted := []utils.PartitionStruct{
+		{Number: 1, OsDevice: "sda1", Type: "C12A7328-F81F-11D2-BA4B-00A0C93EC93B", TypeName: "EFI System", Guid: "01000000-0000-0000-0000-000000000000", Name: "EFI System Partition", Start: 1048576, Size: 536870912, Aligned: true,
+			Filesystem: utils.FilesystemStruct{Type: "vfat", Uuid: "0000-BEEF", Mountpoints: []string{"/boot/efi"}}},
+		{Number: 2, OsDevice: "sda2", Type: "A19D880F-05FC-4D3B-A006-743F0F84911E", TypeName: "Linux RAID", Guid: "02000000-0000-0000-0000-000000000000", Start: 537919488, Size: 1073741824, Aligned: true,
+			Filesystem: utils.FilesystemStruct{Type: "linux_raid_member", Label: "server:1", Uuid: "00000000-0000-0000-0000-000000000000", Holders: []string{"md1"}}},
+		{Number: 4, OsDevice: "sda4", Type: "CA7D7CCB-63ED-4C53-861C-1742536059CC", TypeName: "Linux LUKS", Guid: "04000000-0000-0000-0000-000000000000", Name: "data", Start: 1611661824, Size: 1073741824, Aligned: false,
+			Filesystem: utils.FilesystemStruct{Type: "crypto_LUKS", Uuid: "c0ffee00-0000-4000-8000-000000000000", Holders: []string{"luks-data"}}},
+	}
+	if !reflect.DeepEqual(layout.Partitions, partitionsWanted) {
+		t.Fatalf(`TestGetDiskLayoutGpt: partitions: %v should be: %v`, layout.Partitions, partitionsWanted)
+	}
+}
+
+// Test getGptPartitions corrupted headers with valid checksum
+func TestGetGptPartitionsCorruptHeader(t *testing.T) {
+	// Copy original functions content
+	readDeviceBytesOri := ReadDeviceBytes
+	// unmock functions content
+	defer func() {
+		ReadDeviceBytes = readDeviceBytesOri
+	}()
+
+	// Entry size that would overflow entries size
+	disk := buildGptDisk([][]byte{})
+	header := disk[512:1024]
+	binary.LittleEndian.PutUint32(header[84:88], 0x80000000)
+	binary.LittleEndian.PutUint32(header[16:20], 0)
+	binary.LittleEndian.PutUint32(header[16:20], crc32.ChecksumIEEE(header[:92]))
+	mockDevices(map[string][]byte{"sda": disk})
+	_, partitions, err := getGptPartitions("sda", 512, 1048576)
+	if err == nil || len(partitions) != 0 {
+		t.Fatalf(`TestGetGptPartitionsCorruptHeader: entry size 0x80000000: %v %v should return an error`, partitions, err)
+	}
+
+	// Device returning less entries data than requested
+	disk = buildGptDisk([][]byte{})
+	ReadDeviceBytes = func(device string, offset int64, size int) ([]byte, error) {
+		data := make([]byte, size)
+		copy(data, disk[offset:])
+		if offset != 512 {
+			return data[:size/2], nil
+		}
+		return data, nil
+	}
+	_, partitions, err = getGptPartitions("sda", 512, 1048576)
+	if err == nil || len(partitions) != 0 {
+		t.Fatalf(`TestGetGptPartitionsCorruptHeader: truncated entries: %v %v should return an error`, partitions, err)
+	}
+}
+
+// Test GetDiskLayout MBR disk with logical partitions and whole disk filesystem
+func TestGetDiskLayoutMbr(t *testing.T) {
+	// Copy original functions content
+	readDeviceBytesOri := ReadDeviceBytes
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsDirOri := utils.ReadSysfsDir
+	// unmock functions content
+	defer func() {
+		ReadDeviceBytes = readDeviceBytesOri
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsDir = readSysfsDirOri
+	}()
+
+	// sdb1 at sector 63, extended sdb2 with logical sdb5 and sdb6
+	disk := make([]byte, 5*1024*1024)
+	putMbrEntry(disk, 0, 0x83, 63, 2000)
+	putMbrEntry(disk, 1, 0x05, 4096, 8192)
+	binary.LittleEndian.PutUint32(disk[mbrDiskSignatureOffset:], 0x0004a3c1)
+	ebr := disk[4096*512:]
+	putMbrEntry(ebr, 0, 0x82, 2048, 1024)
+	putMbrEntry(ebr, 1, 0x05, 4096, 4096)
+	ebr = disk[8192*512:]
+	putMbrEntry(ebr, 0, 0x83, 2048, 2048)
+
+	swap := make([]byte, 4096)
+	copy(swap[4086:], "SWAPSPACE2")
+	// sdc: btrfs on whole disk
+	btrfs := make([]byte, probeSize)
+	copy(btrfs[0x10040:], "_BHRfS_M")
+	mockDevices(map[string][]byte{"sdb": disk, "sdb5": swap, "sdc": btrfs})
+
+	sysfsFiles := map[string]string{
+		"/sys/block/sdb/size":      "4194304",
+		"/sys/block/sdc/size":      "4194304",
+		"/sys/class/block/sdc/dev": "8:32",
+		"/proc/self/mountinfo":     "40 22 0:52 / /backup rw,relatime shared:9 - btrfs /dev/sdc rw",
+		"/proc/swaps":              "Filename\tType\tSize\tUsed\tPriority\n/dev/sdb5\tpartition\t524284\t0\t-2",
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		value, ok := sysfsFiles[path]
+		if !ok {
+			return "", errors.New("No such file or directory")
+		}
+		return value, nil
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		return []string{}, errors.New("No such file or directory")
+	}
+
+	layouts := GetDiskLayouts([]string{"sdb", "sdc"})
+	if len(layouts) != 2 {
+		t.Fatalf(`TestGetDiskLayoutMbr: len(layouts): %v should be: 2`, len(layouts))
+	}
+	if layouts[0].Table != "dos" || layouts[0].TableId != "0x0004a3c1" {
+		t.Fatalf(`TestGetDiskLayoutMbr: sdb table: %v %v should be: dos 0x0004a3c1`, layouts[0].Table, layouts[0].TableId)
+	}
+	partitions := []string{}
+	for _, partition := range layouts[0].Partitions {
+		partitions = append(partitions, partition.OsDevice+" "+partition.Type+" "+partition.Filesystem.Type)
+		if partition.Number == 1 && partition.Aligned {
+			t.Fatalf(`TestGetDiskLayoutMbr: sdb1 starting at sector 63 should be misaligned`)
+		}
+		if partition.Number == 6 && (partition.Start != (8192+2048)*512 || partition.Size != 2048*512) {
+			t.Fatalf(`TestGetDiskLayoutMbr: sdb6 start/size: %v/%v should be: %v/%v`, partition.Start, partition.Size, (8192+2048)*512, 2048*512)
+		}
+	}
+	partitionsWanted := []string{"sdb1 0x83 ", "sdb2 0x05 ", "sdb5 0x82 swap", "sdb6 0x83 "}
+	if !reflect.DeepEqual(partitions, partitionsWanted) {
+		t.Fatalf(`TestGetDiskLayoutMbr: partitions: %v should be: %v`, partitions, partitionsWanted)
+	}
+	if !reflect.DeepEqual(layouts[0].Partitions[2].Filesystem.Mountpoints, []string{"[SWAP]"}) {
+		t.Fatalf(`TestGetDiskLayoutMbr: sdb5 mountpoints: %v should be: [[SWAP]]`, layouts[0].Partitions[2].Filesystem.Mountpoints)
+	}
+
+	if layouts[1].Table != "none" || layouts[1].Filesystem.Type != "btrfs" || !reflect.DeepEqual(layouts[1].Filesystem.Mountpoints, []string{"/backup"}) {
+		t.Fatalf(`TestGetDiskLayoutMbr: sdc: %v %v should be: none btrfs mounted on /backup`, layouts[1].Table, layouts[1].Filesystem)
+	}
+}
@@ -2,9 +2,13 @@ package regulardisks
 
 import (
 	"fmt"
+	"hardwareAnalyzer/devicemapper"
 	"hardwareAnalyzer/utils"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
@@ -87,7 +91,7 @@ var GetSystemDisks = func() ([]string, error) {
 			//fmt.Printf("%s discarded.\n", regularDisk)
 			continue
 		}
-		// ZFS virtualization dataset devices
+		// ZFS zvols devices, shown as pool datasets
 		matched, err = regexp.MatchString(`zd\d+`, regularDisk)
 		if err != nil {
 			color.Red("++ ERROR: processRegularDisks Regexp errror %s", err)
@@ -107,6 +111,234 @@ var GetSystemDisks = func() ([]string, error) {
 	}
 }
 
+// Get dm-multipath maps: device mapper devices with mpath- uuid prefix and its slaves as paths
+// Function as a variable in order to be mocked from unitary tests
+var GetMultipathMaps = func() ([]utils.MultipathMapStruct, error) {
+	multipathMaps := []utils.MultipathMapStruct{}
+	blockDevices, err := utils.ReadSysfsDir("/sys/block")
+	if err != nil {
+		color.Red("++ ERROR: GetMultipathMaps, error reading /sys/block/: %s", err)
+		return multipathMaps, err
+	}
+	for _, blockDevice := range blockDevices {
+		if !strings.HasPrefix(blockDevice, "dm-") {
+			continue
+		}
+		uuid, err := utils.ReadSysfsFile("/sys/block/" + blockDevice + "/dm/uuid")
+		if err != nil || !strings.HasPrefix(uuid, "mpath-") {
+			continue
+		}
+		name, err := utils.ReadSysfsFile("/sys/block/" + blockDevice + "/dm/name")
+		if err != nil {
+			name = blockDevice
+		}
+		slaves, err := utils.ReadSysfsDir("/sys/block/" + blockDevice + "/slaves")
+		if err != nil {
+			color.Red("++ ERROR: GetMultipathMaps, error reading %s slaves: %s", blockDevice, err)
+			continue
+		}
+		multipathMaps = append(multipathMaps, utils.MultipathMapStruct{
+			Device: blockDevice,
+			Name:   name,
+			Uuid:   strings.TrimPrefix(uuid, "mpath-"),
+			Paths:  slaves,
+		})
+	}
+	return multipathMaps, nil
+}
+
+// Multipath path status from multipath target status line
+type multipathPathStatus struct {
+	state     string
+	failCount string
+}
+
+// Parse multipath target status, paths are indexed by its major:minor:
+// <#features> <features> <#handler args> <handler args> <#groups> <next group>
+// per group: <A|E|D> <#group args> <group args> <#paths> <#selector args>
+// per path: <major:minor> <A|F> <fail count> <selector args>
+// 2 0 0 0 1 1 A 0 2 1 8:16 A 0 0 8:32 F 3 0
+func parseMultipathStatus(params string) map[string]multipathPathStatus {
+	pathsStatus := make(map[string]multipathPathStatus)
+	fields := strings.Fields(params)
+	index := 0
+	// Read a counter field and advance, -1 when status line is truncated or malformed
+	next := func() int {
+		if index >= len(fields) {
+			return -1
+		}
+		value, err := strconv.Atoi(fields[index])
+		index++
+		if err != nil {
+			return -1
+		}
+		return value
+	}
+
+	// Features and hardware handler args
+	for i := 0; i < 2; i++ {
+		count := next()
+		if count < 0 {
+			return pathsStatus
+		}
+		index += count
+	}
+	groups := next()
+	if groups < 0 || next() < 0 {
+		return pathsStatus
+	}
+	for group := 0; group < groups; group++ {
+		// Group state
+		index++
+		groupArgs := next()
+		if groupArgs < 0 {
+			return pathsStatus
+		}
+		index += groupArgs
+		paths := next()
+		selectorArgs := next()
+		if paths < 0 || selectorArgs < 0 {
+			return pathsStatus
+		}
+		for path := 0; path < paths; path++ {
+			if index+3 > len(fields) {
+				return pathsStatus
+			}
+			state := "failed"
+			if fields[index+1] == "A" {
+				state = "active"
+			}
+			pathsStatus[fields[index]] = multipathPathStatus{state: state, failCount: fields[index+2]}
+			index += 3 + selectorArgs
+		}
+	}
+	return pathsStatus
+}
+
+// Multipath paths status read from device-mapper, multipathd checker fails paths there while SCSI devices can keep running state
+// Function as a variable in order to be mocked from unitary tests
+var GetMultipathPathsStatus = func(name string) (map[string]multipathPathStatus, error) {
+	targets, err := devicemapper.ReadDmTable(name, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range targets {
+		if target.Type == "multipath" {
+			return parseMultipathStatus(target.Params), nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no multipath target", name)
+}
+
+// Multipath path state from its SCSI device state, only used when multipath target status cant be read: running -> active, offline/blocked/transport-offline -> failed
+func getMultipathPathState(path string) string {
+	state, err := utils.ReadSysfsFile("/sys/block/" + path + "/device/state")
+	if err != nil {
+		return "unknown"
+	}
+	if state == "running" {
+		return "active"
+	}
+	return "failed"
+}
+
+// Build multipath logical disk: ONLINE, DEGRADED when some path is failed, FAILED when all paths are failed
+// Paths missing from multipath target status are unknown and counted as not active
+func getMultipathDisk(multipathMap utils.MultipathMapStruct) utils.DiskStruct {
+	diskSize, err := utils.GetDiskPartitionSize(multipathMap.Device)
+	if err != nil {
+		color.Red("++ ERROR: GetDiskPartitionSize: %s", err)
+	}
+
+	diskSerialNumber := "Unknown"
+	diskIntf := "Unknown"
+	diskMedium := "Unknown"
+	diskModel := "Unknown"
+	pathsStatus, err := GetMultipathPathsStatus(multipathMap.Name)
+	if err != nil {
+		color.Red("++ ERROR: GetMultipathPathsStatus: %s, using SCSI devices state", err)
+	}
+	failedPaths := 0
+	paths := []string{}
+	for _, path := range multipathMap.Paths {
+		pathState := "unknown"
+		pathFailCount := "0"
+		majorMinor, err := utils.ReadSysfsFile("/sys/block/" + path + "/dev")
+		pathStatus, found := pathsStatus[majorMinor]
+		if pathsStatus == nil {
+			pathState = getMultipathPathState(path)
+		} else if err == nil && found {
+			pathState = pathStatus.state
+			pathFailCount = pathStatus.failCount
+		}
+		if pathState != "active" {
+			failedPaths++
+		}
+		// Path failures since map was loaded: sdc(failed, failures: 3)
+		if pathFailCount != "0" {
+			pathState = pathState + ", failures: " + pathFailCount
+		}
+		paths = append(paths, path+"("+pathState+")")
+	}
+	// All paths are the same LUN, its data is read from first one
+	if len(multipathMap.Paths) > 0 {
+		diskSerialNumber, diskModel, diskIntf, diskMedium, err = utils.GetDiskData(multipathMap.Paths[0])
+		if err != nil {
+			color.Red("++ ERROR: GetDiskData: %s", err)
+		}
+	}
+
+	diskState := "ONLINE"
+	if failedPaths == len(multipathMap.Paths) {
+		diskState = "FAILED"
+	} else if failedPaths > 0 {
+		diskState = "DEGRADED"
+	}
+	return utils.DiskStruct{
+		ControllerId: "motherBoard-0",
+		State:        diskState,
+		Size:         diskSize,
+		Intf:         diskIntf,
+		Medium:       diskMedium,
+		Model:        diskModel,
+		SerialNumber: diskSerialNumber,
+		OsDevice:     multipathMap.Device,
+		Paths:        paths,
+	}
+}
+
+// Kernel device of raid member names: mapper/mpatha, /dev/mapper/mpatha-part1, mpatha1 -> dm-N
+// Names without /dev entry are looked up as device-mapper names, not device-mapper devices are returned unmodified
+func getKernelDevice(device string) string {
+	device = strings.TrimPrefix(device, "/dev/")
+	if strings.HasPrefix(device, "dm-") {
+		return device
+	}
+	for _, path := range []string{"/dev/" + device, "/dev/mapper/" + device} {
+		link, err := utils.ReadSysfsLink(path)
+		if err == nil {
+			return filepath.Base(link)
+		}
+	}
+	return device
+}
+
+// Multipath map kernel devices: map itself and its partitions, kpartx partitions are holders with part uuid prefix: dm-1, dm-5
+func getMultipathDevices(multipathMap utils.MultipathMapStruct) []string {
+	devices := []string{multipathMap.Device}
+	holders, err := utils.ReadSysfsDir("/sys/block/" + multipathMap.Device + "/holders")
+	if err != nil {
+		return devices
+	}
+	for _, holder := range holders {
+		uuid, err := utils.ReadSysfsFile("/sys/block/" + holder + "/dm/uuid")
+		if err == nil && strings.HasPrefix(uuid, "part") {
+			devices = append(devices, holder)
+		}
+	}
+	return devices
+}
+
 var ProcessRegularDisks = func(raids []utils.RaidStruct, noRaidDisks []utils.NoRaidDiskStruct) ([]utils.ControllerStruct, []utils.RaidStruct, error) {
 	fmt.Println("> Getting current regular disks configuration.")
 
@@ -124,10 +356,22 @@ var ProcessRegularDisks = func(raids []utils.RaidStruct, noRaidDisks []utils.NoR
 		return regularDiskControllers, regularDiskRaids, err
 	}
 
+	// Multipath paths are shown grouped under its dm device instead of as independent disks
+	multipathMaps, err := GetMultipathMaps()
+	if err != nil {
+		color.Red("++ ERROR: ProcessRegularDisks, error getting multipath maps: %v.", err)
+	}
+	multipathPaths := make(map[string]bool)
+	for _, multipathMap := range multipathMaps {
+		for _, path := range multipathMap.Paths {
+			multipathPaths[path] = true
+		}
+	}
+
 	fmt.Println("> Parsing disks.")
 	for _, regularDisk := range diskArray {
 		// Compare disks with already saved Raid disks
-		diskAlreadyFound := false
+		diskAlreadyFound := multipathPaths[regularDisk]
 		// We can label loops in order to break it from other nested loop
 	RaidLoop:
 		// Check agains raid disks
@@ -196,6 +440,22 @@ var ProcessRegularDisks = func(raids []utils.RaidStruct, noRaidDisks []utils.NoR
 		}
 	}
 
+MultipathLoop:
+	for _, multipathMap := range multipathMaps {
+		// Multipath devices or its partitions already used by software raids: ZFS, LVM, mdadm, btrfs
+		// Both sides are compared as kernel devices: LVM uses mapper/mpatha, ZFS mpatha-part1, md dm-N
+		multipathDevices := getMultipathDevices(multipathMap)
+		for _, raid := range raids {
+			for _, disk := range raid.Disks {
+				if slices.Contains(multipathDevices, getKernelDevice(disk.OsDevice)) {
+					continue MultipathLoop
+				}
+			}
+		}
+		//fmt.Printf("Multipath disk detected: %s(%s) paths: %v\n", multipathMap.Name, multipathMap.Device, multipathMap.Paths)
+		regularDiskRaid.AddDisk(getMultipathDisk(multipathMap))
+	}
+
 	if len(regularDiskRaid.Disks) > 0 {
 		// At the start of the function we created empty regularDiskController and regularDiskRaid structures
 		// Now that we know it has disks, fill the other structure fields and append to returned function arrays
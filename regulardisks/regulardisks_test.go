@@ -1,8 +1,11 @@
 package regulardisks
 
 import (
+	"errors"
 	"fmt"
+	"hardwareAnalyzer/devicemapper"
 	"hardwareAnalyzer/utils"
+	"reflect"
 	"testing"
 )
 
@@ -12,13 +15,20 @@ func TestProcessRegularDisks(t *testing.T) {
 	getSystemDisksOri := GetSystemDisks
 	getDiskPartitionSizeOri := utils.GetDiskPartitionSize
 	getDiskDataOri := utils.GetDiskData
+	getMultipathMapsOri := GetMultipathMaps
 	// unmock functions content
 	defer func() {
+		GetMultipathMaps = getMultipathMapsOri
 		GetSystemDisks = getSystemDisksOri
 		utils.GetDiskPartitionSize = getDiskPartitionSizeOri
 		utils.GetDiskData = getDiskDataOri
 	}()
 
+	// Mocked function
+	GetMultipathMaps = func() ([]utils.MultipathMapStruct, error) {
+		return []utils.MultipathMapStruct{}, nil
+	}
+
 	// Mocked function
 	GetSystemDisks = func() ([]string, error) {
 		//fmt.Println("-- Executing mocked GetSystemDisks")
@@ -236,13 +246,20 @@ func TestProcessRegularDisksMatchWithRaidDisk(t *testing.T) {
 	getSystemDisksOri := GetSystemDisks
 	getDiskPartitionSizeOri := utils.GetDiskPartitionSize
 	getDiskDataOri := utils.GetDiskData
+	getMultipathMapsOri := GetMultipathMaps
 	// unmock functions content
 	defer func() {
+		GetMultipathMaps = getMultipathMapsOri
 		GetSystemDisks = getSystemDisksOri
 		utils.GetDiskPartitionSize = getDiskPartitionSizeOri
 		utils.GetDiskData = getDiskDataOri
 	}()
 
+	// Mocked function
+	GetMultipathMaps = func() ([]utils.MultipathMapStruct, error) {
+		return []utils.MultipathMapStruct{}, nil
+	}
+
 	// Mocked function
 	GetSystemDisks = func() ([]string, error) {
 		//fmt.Println("-- Executing mocked GetSystemDisks")
@@ -491,3 +508,196 @@ func TestGetSystemDisks(t *testing.T) {
 		t.Fatalf(`TestGetSystemDisks: Abnormal disks detected on system, disks found: %v`, len(diskArray))
 	}
 }
+
+// Test GetMultipathMaps
+func TestGetMultipathMaps(t *testing.T) {
+	// Copy original functions content
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsDirOri := utils.ReadSysfsDir
+	// unmock functions content
+	defer func() {
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsDir = readSysfsDirOri
+	}()
+
+	// Mocked functions: dm-0 LVM volume, dm-1 multipath map
+	sysfsFiles := map[string]string{
+		"/sys/block/dm-0/dm/uuid": "LVM-Qx2Vd1cE0Ii3aLbfd9KczXsrRr5x2ZkI",
+		"/sys/block/dm-0/dm/name": "vg0-root",
+		"/sys/block/dm-1/dm/uuid": "mpath-3600a098038303053453f463045727a46",
+		"/sys/block/dm-1/dm/name": "mpatha",
+	}
+	sysfsDirs := map[string][]string{
+		"/sys/block":             {"sda", "sdb", "sdc", "dm-0", "dm-1"},
+		"/sys/block/dm-0/slaves": {"sda2"},
+		"/sys/block/dm-1/slaves": {"sdb", "sdc"},
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		if value, ok := sysfsFiles[path]; ok {
+			return value, nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		if entries, ok := sysfsDirs[path]; ok {
+			return entries, nil
+		}
+		return nil, errors.New("No such file or directory")
+	}
+
+	multipathMaps, err := GetMultipathMaps()
+	if err != nil {
+		t.Fatalf(`TestGetMultipathMaps: err: %v`, err)
+	}
+	multipathMapsWanted := []utils.MultipathMapStruct{
+		{Device: "dm-1", Name: "mpatha", Uuid: "3600a098038303053453f463045727a46", Paths: []string{"sdb", "sdc"}},
+	}
+	if !reflect.DeepEqual(multipathMaps, multipathMapsWanted) {
+		t.Fatalf(`TestGetMultipathMaps: multipathMaps: %v should be: %v`, multipathMaps, multipathMapsWanted)
+	}
+}
+
+// Test ProcessRegularDisks, multipath paths grouped under its dm device
+func TestProcessRegularDisksMultipath(t *testing.T) {
+	// Copy original functions content
+	getSystemDisksOri := GetSystemDisks
+	getMultipathMapsOri := GetMultipathMaps
+	getDiskPartitionSizeOri := utils.GetDiskPartitionSize
+	getDiskDataOri := utils.GetDiskData
+	readSysfsFileOri := utils.ReadSysfsFile
+	readDmTableOri := devicemapper.ReadDmTable
+	readSysfsLinkOri := utils.ReadSysfsLink
+	readSysfsDirOri := utils.ReadSysfsDir
+	// unmock functions content
+	defer func() {
+		GetSystemDisks = getSystemDisksOri
+		GetMultipathMaps = getMultipathMapsOri
+		utils.GetDiskPartitionSize = getDiskPartitionSizeOri
+		utils.GetDiskData = getDiskDataOri
+		utils.ReadSysfsFile = readSysfsFileOri
+		devicemapper.ReadDmTable = readDmTableOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+		utils.ReadSysfsDir = readSysfsDirOri
+	}()
+
+	// Mocked functions: sda local disk, mpatha(dm-1) with sdb active and sdc failed by multipathd checker while its SCSI device is running
+	// mpathb(dm-2) with all paths active, mpathc(dm-3) without readable status uses SCSI devices state
+	GetSystemDisks = func() ([]string, error) {
+		return []string{"sda", "sdb", "sdc", "sdd", "sde", "sdf", "sdg"}, nil
+	}
+	GetMultipathMaps = func() ([]utils.MultipathMapStruct, error) {
+		return []utils.MultipathMapStruct{
+			{Device: "dm-1", Name: "mpatha", Uuid: "3600a098038303053453f463045727a46", Paths: []string{"sdb", "sdc"}},
+			{Device: "dm-2", Name: "mpathb", Uuid: "3600a098038303053453f463045727a47", Paths: []string{"sdd", "sde"}},
+			{Device: "dm-3", Name: "mpathc", Uuid: "3600a098038303053453f463045727a48", Paths: []string{"sdf", "sdg"}},
+		}, nil
+	}
+	utils.GetDiskPartitionSize = func(diskDrive string) (string, error) {
+		return "100 GB", nil
+	}
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "SERIAL-" + diskDrive, "LUN C-Mode", "FC", "SSD", nil
+	}
+	sysfsFiles := map[string]string{
+		"/sys/block/sdb/dev":      "8:16",
+		"/sys/block/sdc/dev":      "8:32",
+		"/sys/block/sdd/dev":      "8:48",
+		"/sys/block/sde/dev":      "8:64",
+		"/sys/block/dm-7/dm/uuid": "part1-mpath-3600a098038303053453f463045727a48",
+		"/sys/block/dm-8/dm/uuid": "LVM-Qx2Vd1cE0Ii3aLbfd9KczXsrRr5x2ZkI",
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		if content, found := sysfsFiles[path]; found {
+			return content, nil
+		}
+		if path == "/sys/block/sdg/device/state" {
+			return "transport-offline", nil
+		}
+		return "running", nil
+	}
+	devicemapper.ReadDmTable = func(name string, table bool) ([]devicemapper.DmTarget, error) {
+		switch name {
+		case "mpatha":
+			return []devicemapper.DmTarget{{Type: "multipath", Params: "2 0 0 0 2 1 A 0 1 2 8:16 A 0 0 1 E 0 1 2 8:32 F 3 0 1"}}, nil
+		case "mpathb":
+			return []devicemapper.DmTarget{{Type: "multipath", Params: "2 0 0 0 1 1 A 0 2 0 8:48 A 0 8:64 A 1"}}, nil
+		}
+		return nil, fmt.Errorf("No such device or address")
+	}
+
+	_, newRaids, err := ProcessRegularDisks([]utils.RaidStruct{}, []utils.NoRaidDiskStruct{})
+	if err != nil {
+		t.Fatalf(`TestProcessRegularDisksMultipath: error: %v`, err)
+	}
+	if len(newRaids) != 1 || len(newRaids[0].Disks) != 4 {
+		t.Fatalf(`TestProcessRegularDisksMultipath: newRaids: %v muts match 1 raid with 4 disks`, newRaids)
+	}
+	disksWanted := []utils.DiskStruct{
+		{ControllerId: "motherBoard-0", State: "ONLINE", Size: "100 GB", Intf: "FC", Medium: "SSD", Model: "LUN C-Mode", SerialNumber: "SERIAL-sda", OsDevice: "sda"},
+		{ControllerId: "motherBoard-0", State: "DEGRADED", Size: "100 GB", Intf: "FC", Medium: "SSD", Model: "LUN C-Mode", SerialNumber: "SERIAL-sdb", OsDevice: "dm-1", Paths: []string{"sdb(active)", "sdc(failed, failures: 3)"}},
+		{ControllerId: "motherBoard-0", State: "ONLINE", Size: "100 GB", Intf: "FC", Medium: "SSD", Model: "LUN C-Mode", SerialNumber: "SERIAL-sdd", OsDevice: "dm-2", Paths: []string{"sdd(active)", "sde(active, failures: 1)"}},
+		{ControllerId: "motherBoard-0", State: "DEGRADED", Size: "100 GB", Intf: "FC", Medium: "SSD", Model: "LUN C-Mode", SerialNumber: "SERIAL-sdf", OsDevice: "dm-3", Paths: []string{"sdf(active)", "sdg(failed)"}},
+	}
+	if !reflect.DeepEqual(newRaids[0].Disks, disksWanted) {
+		t.Fatalf(`TestProcessRegularDisksMultipath: disks: %v should be: %v`, newRaids[0].Disks, disksWanted)
+	}
+
+	// Multipath devices used by software raids: mpatha by LVM, mpathb by ZFS pool, mpathc partition by md
+	// mpathc has an LVM volume holder too, only part holders are its partitions
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		switch path {
+		case "/dev/mapper/mpatha":
+			return "../dm-1", nil
+		case "/dev/mapper/mpathb":
+			return "../dm-2", nil
+		case "/dev/mapper/mpathc-part1":
+			return "../dm-7", nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		if path == "/sys/block/dm-3/holders" {
+			return []string{"dm-7", "dm-8"}, nil
+		}
+		return []string{}, nil
+	}
+	raidsCases := []struct {
+		raid          utils.RaidStruct
+		disksOsDevice []string
+	}{
+		{utils.RaidStruct{ControllerId: "lvm-0", Disks: []utils.DiskStruct{{ControllerId: "lvm-0", OsDevice: "mapper/mpatha"}}}, []string{"sda", "dm-2", "dm-3"}},
+		{utils.RaidStruct{ControllerId: "zfs-0", Disks: []utils.DiskStruct{{ControllerId: "zfs-0", OsDevice: "mpathb"}}}, []string{"sda", "dm-1", "dm-3"}},
+		{utils.RaidStruct{ControllerId: "softraid-0", Disks: []utils.DiskStruct{{ControllerId: "softraid-0", OsDevice: "dm-7"}}}, []string{"sda", "dm-1", "dm-2"}},
+		{utils.RaidStruct{ControllerId: "zfs-0", Disks: []utils.DiskStruct{{ControllerId: "zfs-0", OsDevice: "/dev/mapper/mpathc-part1"}}}, []string{"sda", "dm-1", "dm-2"}},
+		{utils.RaidStruct{ControllerId: "lvm-0", Disks: []utils.DiskStruct{{ControllerId: "lvm-0", OsDevice: "dm-8"}}}, []string{"sda", "dm-1", "dm-2", "dm-3"}},
+	}
+	for _, raidCase := range raidsCases {
+		_, newRaids, _ = ProcessRegularDisks([]utils.RaidStruct{raidCase.raid}, []utils.NoRaidDiskStruct{})
+		disksOsDevice := []string{}
+		for _, disk := range newRaids[0].Disks {
+			disksOsDevice = append(disksOsDevice, disk.OsDevice)
+		}
+		if !reflect.DeepEqual(disksOsDevice, raidCase.disksOsDevice) {
+			t.Fatalf(`TestProcessRegularDisksMultipath: %v used by %v disks: %v should be: %v`, raidCase.raid.Disks[0].OsDevice, raidCase.raid.ControllerId, disksOsDevice, raidCase.disksOsDevice)
+		}
+	}
+}
+
+// Test parseMultipathStatus, all paths failed and truncated status lines
+func TestParseMultipathStatus(t *testing.T) {
+	pathsStatus := parseMultipathStatus("1 queue_if_no_path 1 alua 1 1 E 0 2 1 8:16 F 2 0 8:32 F 5 0")
+	pathsStatusWanted := map[string]multipathPathStatus{
+		"8:16": {state: "failed", failCount: "2"},
+		"8:32": {state: "failed", failCount: "5"},
+	}
+	if !reflect.DeepEqual(pathsStatus, pathsStatusWanted) {
+		t.Fatalf(`TestParseMultipathStatus: pathsStatus: %v should be: %v`, pathsStatus, pathsStatusWanted)
+	}
+
+	for _, params := range []string{"", "2 0 0 0 1 1 A 0 2 1 8:16 A", "2 0 0 x"} {
+		pathsStatus = parseMultipathStatus(params)
+		if len(pathsStatus) != 0 {
+			t.Fatalf(`TestParseMultipathStatus: %v pathsStatus: %v should be empty`, params, pathsStatus)
+		}
+	}
+}
@@ -0,0 +1,462 @@
+package smart
+
+// Native SMART data collection, no smartctl binary needed:
+// ATA: SMART READ DATA/THRESHOLDS through SCSI ATA PASS-THROUGH(16) SG_IO ioctl
+// SCSI: LOG SENSE pages and READ DEFECT DATA through SG_IO ioctl
+// NVMe: SMART/Health log page through NVMe admin ioctl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hardwareAnalyzer/utils"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/fatih/color"
+)
+
+const (
+	sgIo              = 0x2285
+	sgDxferFromDev    = -3
+	nvmeIoctlAdminCmd = 0xC0484E41
+	// SCSI log pages
+	logPageTemperature         = 0x0D
+	logPageSolidStateMedia     = 0x11
+	logPageBackgroundScan      = 0x15
+	logPageInformationalExcept = 0x2F
+)
+
+// Linux sg_io_hdr
+type sgIoHdr struct {
+	interfaceId    int32
+	dxferDirection int32
+	cmdLen         uint8
+	mxSbLen        uint8
+	iovecCount     uint16
+	dxferLen       uint32
+	dxferp         uintptr
+	cmdp           uintptr
+	sbp            uintptr
+	timeout        uint32
+	flags          uint32
+	packId         int32
+	usrPtr         uintptr
+	status         uint8
+	maskedStatus   uint8
+	msgStatus      uint8
+	sbLenWr        uint8
+	hostStatus     uint16
+	driverStatus   uint16
+	resid          int32
+	duration       uint32
+	info           uint32
+}
+
+// Linux nvme_admin_cmd
+type nvmeAdminCmd struct {
+	opcode      uint8
+	flags       uint8
+	rsvd1       uint16
+	nsid        uint32
+	cdw2        uint32
+	cdw3        uint32
+	metadata    uint64
+	addr        uint64
+	metadataLen uint32
+	dataLen     uint32
+	cdw10       uint32
+	cdw11       uint32
+	cdw12       uint32
+	cdw13       uint32
+	cdw14       uint32
+	cdw15       uint32
+	timeoutMs   uint32
+	result      uint32
+}
+
+// ATA SMART attribute, from SMART READ DATA or controller tools
+type AtaAttribute struct {
+	Id        int
+	Prefail   bool
+	Value     int
+	Threshold int
+	Raw       uint64
+}
+
+// New SMART struct with all numeric fields unknown
+func NewSmart(source string) utils.SmartStruct {
+	return utils.SmartStruct{
+		Source:             source,
+		Verdict:            "Unknown",
+		ReallocatedSectors: -1,
+		PendingSectors:     -1,
+		MediaErrors:        -1,
+		WearLevel:          -1,
+		PowerOnHours:       -1,
+		Temperature:        -1,
+		AvailableSpare:     -1,
+		Status:             "Unknown",
+	}
+}
+
+// Execute SCSI command reading dataLength bytes from device
+func sgIoRead(device string, cdb []byte, dataLength int) ([]byte, error) {
+	file, err := os.OpenFile("/dev/"+device, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data := make([]byte, dataLength)
+	sense := make([]byte, 32)
+	hdr := sgIoHdr{
+		interfaceId:    'S',
+		dxferDirection: sgDxferFromDev,
+		cmdLen:         uint8(len(cdb)),
+		mxSbLen:        uint8(len(sense)),
+		dxferLen:       uint32(len(data)),
+		dxferp:         uintptr(unsafe.Pointer(&data[0])),
+		cmdp:           uintptr(unsafe.Pointer(&cdb[0])),
+		sbp:            uintptr(unsafe.Pointer(&sense[0])),
+		timeout:        20000,
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), sgIo, uintptr(unsafe.Pointer(&hdr)))
+	runtime.KeepAlive(data)
+	runtime.KeepAlive(cdb)
+	runtime.KeepAlive(sense)
+	if errno != 0 {
+		return nil, errno
+	}
+	if hdr.status != 0 || hdr.hostStatus != 0 || (hdr.driverStatus&0x0F) != 0 {
+		return nil, fmt.Errorf("SCSI status: 0x%x host status: 0x%x driver status: 0x%x", hdr.status, hdr.hostStatus, hdr.driverStatus)
+	}
+	return data, nil
+}
+
+// ATA SMART READ DATA(0xD0) or READ THRESHOLDS(0xD1) using ATA PASS-THROUGH(16)
+// Function as variable in order to be possible to mock it from unitary tests
+var ReadAtaSmart = func(device string, feature byte) ([]byte, error) {
+	// PIO Data-In protocol, data from device, transfer length in sector count field
+	cdb := []byte{0x85, 0x08, 0x0E, 0x00, feature, 0x00, 0x01, 0x00, 0x00, 0x00, 0x4F, 0x00, 0xC2, 0x00, 0xB0, 0x00}
+	return sgIoRead(device, cdb, 512)
+}
+
+// SCSI LOG SENSE cumulative values page
+// Function as variable in order to be possible to mock it from unitary tests
+var ReadScsiLogPage = func(device string, page byte) ([]byte, error) {
+	cdb := []byte{0x4D, 0x00, 0x40 | page, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00}
+	return sgIoRead(device, cdb, 4096)
+}
+
+// SCSI READ DEFECT DATA(10) grown defect list header
+// Function as variable in order to be possible to mock it from unitary tests
+var ReadScsiGrownDefects = func(device string) ([]byte, error) {
+	cdb := []byte{0x37, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00}
+	return sgIoRead(device, cdb, 4)
+}
+
+// NVMe Get Log Page: SMART/Health Information(0x02)
+// Function as variable in order to be possible to mock it from unitary tests
+var ReadNvmeSmartLog = func(device string) ([]byte, error) {
+	file, err := os.OpenFile("/dev/"+device, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data := make([]byte, 512)
+	cmd := nvmeAdminCmd{
+		opcode:    0x02,
+		nsid:      0xFFFFFFFF,
+		addr:      uint64(uintptr(unsafe.Pointer(&data[0]))),
+		dataLen:   uint32(len(data)),
+		cdw10:     uint32((len(data)/4-1)<<16) | 0x02,
+		timeoutMs: 20000,
+	}
+	// ioctl return value is the NVMe completion status, result is only command specific dword0
+	status, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), nvmeIoctlAdminCmd, uintptr(unsafe.Pointer(&cmd)))
+	runtime.KeepAlive(data)
+	if errno != 0 {
+		return nil, errno
+	}
+	if status != 0 {
+		return nil, fmt.Errorf("NVMe status: 0x%x", status)
+	}
+	return data, nil
+}
+
+// Parse SMART READ DATA and READ THRESHOLDS sectors, thresholds can be nil
+func ParseAtaSmartData(data, thresholds []byte) []AtaAttribute {
+	attributes := []AtaAttribute{}
+	if len(data) < 362 {
+		return attributes
+	}
+	// 30 attributes of 12 bytes starting at offset 2
+	for i := 0; i < 30; i++ {
+		offset := 2 + i*12
+		id := int(data[offset])
+		if id == 0 {
+			continue
+		}
+		raw := uint64(0)
+		for j := 5; j >= 0; j-- {
+			raw = raw<<8 | uint64(data[offset+5+j])
+		}
+		attribute := AtaAttribute{
+			Id:      id,
+			Prefail: data[offset+1]&0x01 != 0,
+			Value:   int(data[offset+3]),
+			Raw:     raw,
+		}
+		// Thresholds sector uses same layout: id, threshold
+		if len(thresholds) >= offset+2 && int(thresholds[offset]) == id {
+			attribute.Threshold = int(thresholds[offset+1])
+		}
+		attributes = append(attributes, attribute)
+	}
+	return attributes
+}
+
+// Fill SMART data from ATA attributes
+// Verdict is FAILED when a pre-failure attribute reaches its threshold
+func SmartFromAtaAttributes(source string, attributes []AtaAttribute) utils.SmartStruct {
+	smart := NewSmart(source)
+	if len(attributes) == 0 {
+		return smart
+	}
+	smart.Verdict = "PASSED"
+	for _, attribute := range attributes {
+		if attribute.Prefail && attribute.Threshold > 0 && attribute.Value <= attribute.Threshold {
+			smart.Verdict = "FAILED"
+		}
+		switch attribute.Id {
+		// Reallocated_Sector_Ct
+		case 5:
+			smart.ReallocatedSectors = int64(attribute.Raw & 0xFFFFFFFF)
+		// Power_On_Hours
+		case 9:
+			smart.PowerOnHours = int64(attribute.Raw & 0xFFFFFFFF)
+		// Current_Pending_Sector
+		case 197:
+			smart.PendingSectors = int64(attribute.Raw & 0xFFFFFFFF)
+		// Temperature_Celsius preferred over Airflow_Temperature_Cel
+		case 194:
+			smart.Temperature = int64(attribute.Raw & 0xFF)
+		case 190:
+			if smart.Temperature < 0 {
+				smart.Temperature = int64(attribute.Raw & 0xFF)
+			}
+		// Remaining_Lifetime_Perc, Wear_Leveling_Count, Percent_Lifetime_Remain, SSD_Life_Left, Media_Wearout_Indicator
+		// Normalized value is remaining life
+		case 169, 177, 202, 231, 233:
+			if smart.WearLevel < 0 && attribute.Value <= 100 {
+				smart.WearLevel = int64(100 - attribute.Value)
+			}
+		}
+	}
+	return smart
+}
+
+// Get SCSI log page parameter value
+func scsiLogParameter(page []byte, parameterCode uint16) []byte {
+	if len(page) < 4 {
+		return nil
+	}
+	pageLength := int(binary.BigEndian.Uint16(page[2:4]))
+	end := 4 + pageLength
+	if end > len(page) {
+		end = len(page)
+	}
+	for offset := 4; offset+4 <= end; {
+		code := binary.BigEndian.Uint16(page[offset : offset+2])
+		length := int(page[offset+3])
+		if offset+4+length > end {
+			break
+		}
+		if code == parameterCode {
+			return page[offset+4 : offset+4+length]
+		}
+		offset = offset + 4 + length
+	}
+	return nil
+}
+
+// Fill SMART data from SCSI log pages and grown defect list header
+func SmartFromScsiLogPages(pages map[byte][]byte, grownDefects []byte) utils.SmartStruct {
+	smart := NewSmart("scsi")
+	// Informational exceptions: ASC, ASCQ, most recent temperature
+	if value := scsiLogParameter(pages[logPageInformationalExcept], 0x0000); len(value) >= 3 {
+		smart.Verdict = "PASSED"
+		if value[0] != 0 {
+			smart.Verdict = "FAILED"
+		}
+		if value[2] != 0 && value[2] != 0xFF {
+			smart.Temperature = int64(value[2])
+		}
+	}
+	// Temperature page: reserved, temperature
+	if value := scsiLogParameter(pages[logPageTemperature], 0x0000); len(value) >= 2 && value[1] != 0xFF {
+		smart.Temperature = int64(value[1])
+	}
+	// Background scan results: accumulated power on minutes
+	if value := scsiLogParameter(pages[logPageBackgroundScan], 0x0000); len(value) >= 4 {
+		smart.PowerOnHours = int64(binary.BigEndian.Uint32(value[0:4]) / 60)
+	}
+	// Solid state media: percentage used endurance indicator
+	if value := scsiLogParameter(pages[logPageSolidStateMedia], 0x0001); len(value) >= 4 {
+		smart.WearLevel = int64(value[3])
+	}
+	// Grown defects are SCSI reallocated sectors, descriptor size depends on defect list format
+	if len(grownDefects) >= 4 {
+		descriptorLength := int64(8)
+		if grownDefects[1]&0x07 == 0 {
+			descriptorLength = 4
+		}
+		smart.ReallocatedSectors = int64(binary.BigEndian.Uint16(grownDefects[2:4])) / descriptorLength
+	}
+	return smart
+}
+
+// Fill SMART data from NVMe SMART/Health log page
+func SmartFromNvmeSmartLog(data []byte) utils.SmartStruct {
+	smart := NewSmart("nvme")
+	if len(data) < 176 {
+		return smart
+	}
+	smart.Verdict = "PASSED"
+	// Critical warning bits
+	criticalWarnings := []string{"spare below threshold", "temperature", "reliability degraded", "read only", "volatile backup failed", "persistent memory read only"}
+	for bit, criticalWarning := range criticalWarnings {
+		if data[0]&(1<<bit) != 0 {
+			smart.CriticalWarnings = append(smart.CriticalWarnings, criticalWarning)
+		}
+	}
+	if data[0] != 0 {
+		smart.Verdict = "FAILED"
+	}
+	smart.AvailableSpare = int64(data[3])
+	// Composite temperature in Kelvin
+	temperature := int64(binary.LittleEndian.Uint16(data[1:3]))
+	if temperature > 0 {
+		smart.Temperature = temperature - 273
+	}
+	smart.WearLevel = int64(data[5])
+	// 128 bits counters, low 64 bits are enough
+	smart.PowerOnHours = int64(binary.LittleEndian.Uint64(data[128:136]))
+	smart.MediaErrors = int64(binary.LittleEndian.Uint64(data[160:168]))
+	return smart
+}
+
+// Get SMART data from an OS block device: nvme0n1, sda
+// ATA pass-through is tried first, SCSI log pages otherwise
+func GetDeviceSmart(device string) (utils.SmartStruct, error) {
+	//fmt.Println("-- GetDeviceSmart --")
+	if strings.HasPrefix(device, "nvme") {
+		data, err := ReadNvmeSmartLog(device)
+		if err != nil {
+			return NewSmart("nvme"), fmt.Errorf("Error: Something went wrong reading NVMe SMART log of %s: %v.", device, err)
+		}
+		return SmartFromNvmeSmartLog(data), nil
+	}
+
+	data, err := ReadAtaSmart(device, 0xD0)
+	if err == nil {
+		thresholds, err := ReadAtaSmart(device, 0xD1)
+		if err != nil {
+			thresholds = nil
+		}
+		attributes := ParseAtaSmartData(data, thresholds)
+		if len(attributes) > 0 {
+			return SmartFromAtaAttributes("ata", attributes), nil
+		}
+	}
+
+	pages := make(map[byte][]byte)
+	for _, page := range []byte{logPageInformationalExcept, logPageTemperature, logPageBackgroundScan, logPageSolidStateMedia} {
+		pageData, err := ReadScsiLogPage(device, page)
+		if err == nil {
+			pages[page] = pageData
+		}
+	}
+	if len(pages) == 0 {
+		return NewSmart("scsi"), fmt.Errorf("Error: No SMART data available for %s.", device)
+	}
+	grownDefects, err := ReadScsiGrownDefects(device)
+	if err != nil {
+		grownDefects = nil
+	}
+	return SmartFromScsiLogPages(pages, grownDefects), nil
+}
+
+// Get OS block device of a disk, partitions are resolved to its disk: JBOD-sdb -> sdb, sda3 -> sda
+// Empty string is returned when disk has no OS block device
+func GetSmartDevice(osDevice string) string {
+	device := strings.TrimPrefix(strings.TrimPrefix(osDevice, "JBOD-"), "/dev/")
+	if len(device) == 0 || strings.Contains(device, " ") || strings.Contains(device, "/") {
+		return ""
+	}
+	// Virtual devices(md, dm, loop) dont have SMART data
+	link, err := utils.ReadSysfsLink("/sys/class/block/" + device)
+	if err != nil || strings.Contains(link, "/virtual/") {
+		return ""
+	}
+	return utils.GetParentBlockDevice(device)
+}
+
+// Disk state including SMART health when thresholds are exceeded: ONLINE/SMART-Warning
+func SmartState(state string, smart utils.SmartStruct) string {
+	if smart.Status == "Warning" || smart.Status == "Failed" {
+		return state + "/SMART-" + smart.Status
+	}
+	return state
+}
+
+// Collect SMART data of OS visible disks, disks already having SMART data are skipped
+// Hardware raid members are skipped too, its OS device is the logical drive
+func CollectDeviceSmart(raids []utils.RaidStruct, noRaidDisks []utils.NoRaidDiskStruct) {
+	// Same disk can be member of several raids: partitions
+	smartCache := make(map[string]utils.SmartStruct)
+	getSmart := func(osDevice string) (utils.SmartStruct, bool) {
+		device := GetSmartDevice(osDevice)
+		if len(device) == 0 {
+			return utils.SmartStruct{}, false
+		}
+		if smart, ok := smartCache[device]; ok {
+			return smart, true
+		}
+		smart, err := GetDeviceSmart(device)
+		if err != nil {
+			color.Red("++ ERROR: %s", err)
+			return utils.SmartStruct{}, false
+		}
+		utils.EvaluateSmart(&smart)
+		smartCache[device] = smart
+		return smart, true
+	}
+
+	for i := range raids {
+		for j := range raids[i].Disks {
+			disk := &raids[i].Disks[j]
+			if len(disk.Smart.Source) > 0 || (len(disk.EidSlot) > 0 && !strings.HasPrefix(disk.OsDevice, "JBOD-")) {
+				continue
+			}
+			if smart, ok := getSmart(disk.OsDevice); ok {
+				disk.Smart = smart
+				disk.State = SmartState(disk.State, smart)
+			}
+		}
+	}
+	for i := range noRaidDisks {
+		noRaidDisk := &noRaidDisks[i]
+		if len(noRaidDisk.Smart.Source) > 0 || (len(noRaidDisk.EidSlot) > 0 && !strings.HasPrefix(noRaidDisk.OsDevice, "JBOD-")) {
+			continue
+		}
+		if smart, ok := getSmart(noRaidDisk.OsDevice); ok {
+			noRaidDisk.Smart = smart
+			noRaidDisk.State = SmartState(noRaidDisk.State, smart)
+		}
+	}
+}
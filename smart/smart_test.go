@@ -0,0 +1,245 @@
+package smart
+
+import (
+	"encoding/binary"
+	"errors"
+	"hardwareAnalyzer/utils"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Build ATA SMART READ DATA/THRESHOLDS sectors from attributes
+func buildAtaSmartSectors(attributes []AtaAttribute) ([]byte, []byte) {
+	data := make([]byte, 512)
+	thresholds := make([]byte, 512)
+	for i, attribute := range attributes {
+		offset := 2 + i*12
+		data[offset] = byte(attribute.Id)
+		if attribute.Prefail {
+			data[offset+1] = 0x01
+		}
+		data[offset+3] = byte(attribute.Value)
+		data[offset+4] = byte(attribute.Value)
+		raw := attribute.Raw
+		for j := 0; j < 6; j++ {
+			data[offset+5+j] = byte(raw)
+			raw = raw >> 8
+		}
+		thresholds[offset] = byte(attribute.Id)
+		thresholds[offset+1] = byte(attribute.Threshold)
+	}
+	return data, thresholds
+}
+
+// Build SCSI log page with one parameter
+func buildScsiLogPage(page byte, parameterCode uint16, value []byte) []byte {
+	data := []byte{page, 0x00, 0x00, byte(4 + len(value)), byte(parameterCode >> 8), byte(parameterCode), 0x03, byte(len(value))}
+	return append(data, value...)
+}
+
+// Test ParseAtaSmartData and SmartFromAtaAttributes
+func TestSmartFromAtaAttributes(t *testing.T) {
+	attributesWanted := []AtaAttribute{
+		{Id: 5, Prefail: true, Value: 100, Threshold: 10, Raw: 12},
+		{Id: 9, Prefail: false, Value: 95, Threshold: 0, Raw: 23456},
+		{Id: 177, Prefail: true, Value: 97, Threshold: 5, Raw: 41},
+		{Id: 190, Prefail: false, Value: 66, Threshold: 0, Raw: 0x1E0A0022},
+		{Id: 197, Prefail: false, Value: 100, Threshold: 0, Raw: 2},
+	}
+	data, thresholds := buildAtaSmartSectors(attributesWanted)
+	attributes := ParseAtaSmartData(data, thresholds)
+	if !reflect.DeepEqual(attributes, attributesWanted) {
+		t.Fatalf(`TestSmartFromAtaAttributes attributes: %v should be: %v`, attributes, attributesWanted)
+	}
+
+	smart := SmartFromAtaAttributes("ata", attributes)
+	smartWanted := utils.SmartStruct{
+		Source:             "ata",
+		Verdict:            "PASSED",
+		ReallocatedSectors: 12,
+		PendingSectors:     2,
+		MediaErrors:        -1,
+		WearLevel:          3,
+		PowerOnHours:       23456,
+		Temperature:        34,
+		AvailableSpare:     -1,
+		Status:             "Unknown",
+	}
+	if !reflect.DeepEqual(smart, smartWanted) {
+		t.Fatalf(`TestSmartFromAtaAttributes smart: %v should be: %v`, smart, smartWanted)
+	}
+
+	// Pre-failure attribute under threshold
+	attributes[0].Value = 9
+	smart = SmartFromAtaAttributes("ata", attributes)
+	if smart.Verdict != "FAILED" {
+		t.Fatalf(`TestSmartFromAtaAttributes verdict: %v should be: FAILED`, smart.Verdict)
+	}
+}
+
+// Test SmartFromScsiLogPages
+func TestSmartFromScsiLogPages(t *testing.T) {
+	pages := map[byte][]byte{
+		logPageInformationalExcept: buildScsiLogPage(logPageInformationalExcept, 0x0000, []byte{0x00, 0x00, 0x25}),
+		logPageTemperature:         buildScsiLogPage(logPageTemperature, 0x0000, []byte{0x00, 0x24}),
+		logPageBackgroundScan:      buildScsiLogPage(logPageBackgroundScan, 0x0000, []byte{0x00, 0x0F, 0x42, 0x40, 0x08, 0x00, 0x00, 0x00}),
+		logPageSolidStateMedia:     buildScsiLogPage(logPageSolidStateMedia, 0x0001, []byte{0x00, 0x00, 0x00, 0x07}),
+	}
+	// Long block format: 8 bytes descriptors
+	grownDefects := []byte{0x00, 0x0C, 0x00, 0x18}
+
+	smart := SmartFromScsiLogPages(pages, grownDefects)
+	smartWanted := utils.SmartStruct{
+		Source:             "scsi",
+		Verdict:            "PASSED",
+		ReallocatedSectors: 3,
+		PendingSectors:     -1,
+		MediaErrors:        -1,
+		WearLevel:          7,
+		PowerOnHours:       16666,
+		Temperature:        36,
+		AvailableSpare:     -1,
+		Status:             "Unknown",
+	}
+	if !reflect.DeepEqual(smart, smartWanted) {
+		t.Fatalf(`TestSmartFromScsiLogPages smart: %v should be: %v`, smart, smartWanted)
+	}
+
+	// Informational exception reported: HARDWARE IMPENDING FAILURE
+	pages[logPageInformationalExcept] = buildScsiLogPage(logPageInformationalExcept, 0x0000, []byte{0x5D, 0x10, 0x25})
+	smart = SmartFromScsiLogPages(pages, grownDefects)
+	if smart.Verdict != "FAILED" {
+		t.Fatalf(`TestSmartFromScsiLogPages verdict: %v should be: FAILED`, smart.Verdict)
+	}
+}
+
+// Test SmartFromNvmeSmartLog
+func TestSmartFromNvmeSmartLog(t *testing.T) {
+	data := make([]byte, 512)
+	// 35C composite temperature, 12% used, 9876 power on hours, 1 media error
+	binary.LittleEndian.PutUint16(data[1:3], 308)
+	data[3] = 100
+	data[5] = 12
+	binary.LittleEndian.PutUint64(data[128:136], 9876)
+	binary.LittleEndian.PutUint64(data[160:168], 1)
+
+	smart := SmartFromNvmeSmartLog(data)
+	smartWanted := utils.SmartStruct{
+		Source:             "nvme",
+		Verdict:            "PASSED",
+		ReallocatedSectors: -1,
+		PendingSectors:     -1,
+		MediaErrors:        1,
+		WearLevel:          12,
+		PowerOnHours:       9876,
+		Temperature:        35,
+		AvailableSpare:     100,
+		Status:             "Unknown",
+	}
+	if !reflect.DeepEqual(smart, smartWanted) {
+		t.Fatalf(`TestSmartFromNvmeSmartLog smart: %v should be: %v`, smart, smartWanted)
+	}
+
+	// Available spare below threshold critical warning
+	data[0] = 0x01
+	smart = SmartFromNvmeSmartLog(data)
+	if smart.Verdict != "FAILED" || !reflect.DeepEqual(smart.CriticalWarnings, []string{"spare below threshold"}) {
+		t.Fatalf(`TestSmartFromNvmeSmartLog verdict: %v %v should be: FAILED [spare below threshold]`, smart.Verdict, smart.CriticalWarnings)
+	}
+}
+
+// Test CollectDeviceSmart
+func TestCollectDeviceSmart(t *testing.T) {
+	// Copy original functions content
+	readAtaSmartOri := ReadAtaSmart
+	readScsiLogPageOri := ReadScsiLogPage
+	readScsiGrownDefectsOri := ReadScsiGrownDefects
+	readNvmeSmartLogOri := ReadNvmeSmartLog
+	readSysfsLinkOri := utils.ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		ReadAtaSmart = readAtaSmartOri
+		ReadScsiLogPage = readScsiLogPageOri
+		ReadScsiGrownDefects = readScsiGrownDefectsOri
+		ReadNvmeSmartLog = readNvmeSmartLogOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked functions: sda SATA disk with pending sectors, sdb SAS disk, nvme0n1 NVMe disk
+	ataData, ataThresholds := buildAtaSmartSectors([]AtaAttribute{
+		{Id: 5, Prefail: true, Value: 100, Threshold: 10, Raw: 0},
+		{Id: 197, Prefail: false, Value: 100, Threshold: 0, Raw: 8},
+	})
+	ReadAtaSmart = func(device string, feature byte) ([]byte, error) {
+		if device != "sda" {
+			return nil, errors.New("SCSI status: 0x2")
+		}
+		if feature == 0xD1 {
+			return ataThresholds, nil
+		}
+		return ataData, nil
+	}
+	ReadScsiLogPage = func(device string, page byte) ([]byte, error) {
+		if device != "sdb" || page != logPageInformationalExcept {
+			return nil, errors.New("SCSI status: 0x2")
+		}
+		return buildScsiLogPage(logPageInformationalExcept, 0x0000, []byte{0x00, 0x00, 0x20}), nil
+	}
+	ReadScsiGrownDefects = func(device string) ([]byte, error) {
+		return []byte{0x00, 0x00, 0x00, 0x00}, nil
+	}
+	ReadNvmeSmartLog = func(device string) ([]byte, error) {
+		data := make([]byte, 512)
+		binary.LittleEndian.PutUint16(data[1:3], 303)
+		return data, nil
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		device := strings.TrimPrefix(path, "/sys/class/block/")
+		switch device {
+		case "sda3":
+			return "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda/sda3", nil
+		case "sda", "sdb", "nvme0n1":
+			return "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/" + device, nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+
+	raids := []utils.RaidStruct{
+		{
+			ControllerId: "softraid-0",
+			Disks: []utils.DiskStruct{
+				{ControllerId: "softraid-0", State: "ONLINE", OsDevice: "sda3"},
+				{ControllerId: "softraid-0", State: "ONLINE", OsDevice: "nvme0n1"},
+			},
+		},
+		{
+			// Hardware raid members OS device is logical drive
+			ControllerId: "mega-0",
+			Disks: []utils.DiskStruct{
+				{ControllerId: "mega-0", EidSlot: "252:0", State: "Onln", OsDevice: "sda"},
+			},
+		},
+	}
+	noRaidDisks := []utils.NoRaidDiskStruct{
+		{ControllerId: "sas2ircu-0", EidSlot: "1:2", State: "Ready(RDY)", OsDevice: "JBOD-sdb"},
+	}
+
+	CollectDeviceSmart(raids, noRaidDisks)
+
+	if raids[0].Disks[0].Smart.Source != "ata" || raids[0].Disks[0].Smart.PendingSectors != 8 || raids[0].Disks[0].Smart.Status != "Warning" {
+		t.Fatalf(`TestCollectDeviceSmart sda3 smart: %v should be ata Warning with 8 pending sectors`, raids[0].Disks[0].Smart)
+	}
+	if raids[0].Disks[0].State != "ONLINE/SMART-Warning" {
+		t.Fatalf(`TestCollectDeviceSmart sda3 state: %v should be: ONLINE/SMART-Warning`, raids[0].Disks[0].State)
+	}
+	if raids[0].Disks[1].Smart.Source != "nvme" || raids[0].Disks[1].Smart.Temperature != 30 || raids[0].Disks[1].Smart.Status != "OK" {
+		t.Fatalf(`TestCollectDeviceSmart nvme0n1 smart: %v should be nvme OK 30C`, raids[0].Disks[1].Smart)
+	}
+	if raids[1].Disks[0].Smart.Source != "" {
+		t.Fatalf(`TestCollectDeviceSmart hardware raid member smart: %v should be empty`, raids[1].Disks[0].Smart)
+	}
+	if noRaidDisks[0].Smart.Source != "scsi" || noRaidDisks[0].Smart.Verdict != "PASSED" || noRaidDisks[0].Smart.ReallocatedSectors != 0 {
+		t.Fatalf(`TestCollectDeviceSmart sdb smart: %v should be scsi PASSED`, noRaidDisks[0].Smart)
+	}
+}
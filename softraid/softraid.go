@@ -2,10 +2,15 @@ package softraid
 
 import (
 	"bufio"
+	"cmp"
 	"fmt"
 	"hardwareAnalyzer/utils"
+	"math"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
@@ -52,6 +57,236 @@ var CheckSoftRaid = func() (bool, error) {
 	return false, nil
 }
 
+var mdstatMemberRegexp = regexp.MustCompile(`^(.+)\[\d+\]((?:\([A-Z]\))*)$`)
+
+// md array sysfs attribute: /sys/block/md0/md/array_state
+func readMdAttribute(raidName string, attribute string) string {
+	value, err := utils.ReadSysfsFile("/sys/block/" + raidName + "/md/" + attribute)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// Sync progress from sync_completed: 1048576 / 104857600 sectors
+func getMdSyncProgress(syncCompleted string) string {
+	fields := strings.Fields(syncCompleted)
+	if len(fields) != 3 || fields[1] != "/" {
+		return ""
+	}
+	done, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return ""
+	}
+	total, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil || total == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %.1f%%", done*100/total)
+}
+
+// Member role and state from dev-*/state flags and dev-*/slot, as mdadm --detail does:
+//
+//	in_sync                -> active, ONLINE
+//	in_sync,write_mostly   -> write-mostly, ONLINE
+//	spare + slot none      -> spare
+//	spare + slot assigned  -> active, Rebuilding
+//	replacement            -> replacement, Rebuilding until in_sync
+//	journal                -> journal
+//	faulty                 -> Failed
+func getMdMemberRole(stateFlags []string, slot string) (string, string) {
+	role := "active"
+	state := "ONLINE"
+	switch {
+	case slices.Contains(stateFlags, "journal"):
+		role = "journal"
+	case slices.Contains(stateFlags, "replacement"):
+		role = "replacement"
+	case slices.Contains(stateFlags, "spare") && (slot == "none" || len(slot) == 0) && !slices.Contains(stateFlags, "faulty"):
+		return "spare", "Available"
+	case slices.Contains(stateFlags, "write_mostly"):
+		role = "write-mostly"
+	}
+
+	switch {
+	case slices.Contains(stateFlags, "faulty"):
+		state = "Failed"
+	case !slices.Contains(stateFlags, "in_sync") && role != "journal":
+		state = "Rebuilding"
+	}
+	if slices.Contains(stateFlags, "write_error") {
+		state = state + "/Write-Errors"
+	}
+	if slices.Contains(stateFlags, "want_replacement") {
+		state = state + "/Want-Replacement"
+	}
+	if slices.Contains(stateFlags, "blocked") {
+		state = state + "/Blocked"
+	}
+	return role, state
+}
+
+// Array state from array_state and degraded attributes
+// clean/active/active-idle/write-pending are normal running states
+func getMdArrayState(arrayState string, degraded string) string {
+	switch arrayState {
+	case "clean", "active", "active-idle", "write-pending":
+	case "readonly", "read-auto":
+		return "Read-only"
+	case "inactive":
+		return "Inactive"
+	case "broken":
+		return "Broken"
+	case "suspended":
+		return "Suspended"
+	case "clear":
+		return "Stopped"
+	default:
+		return "Unknown: " + arrayState
+	}
+	if len(degraded) > 0 && degraded != "0" {
+		return "Degraded"
+	}
+	return "Okay"
+}
+
+// Array details: metadata, chunk size, consistency policy, bitmap, mismatches and running sync action
+func getMdArrayDetails(raidName string) []string {
+	details := []string{}
+	if metadataVersion := readMdAttribute(raidName, "metadata_version"); len(metadataVersion) > 0 && metadataVersion != "none" {
+		details = append(details, "metadata "+metadataVersion)
+	}
+	if uuid := readMdAttribute(raidName, "uuid"); len(uuid) > 0 {
+		details = append(details, "uuid "+uuid)
+	}
+	chunkSize, err := strconv.ParseUint(readMdAttribute(raidName, "chunk_size"), 10, 64)
+	if err == nil && chunkSize > 0 {
+		details = append(details, "chunk "+strconv.FormatUint(chunkSize/1024, 10)+"K")
+	}
+	if consistencyPolicy := readMdAttribute(raidName, "consistency_policy"); len(consistencyPolicy) > 0 {
+		details = append(details, "consistency-policy "+consistencyPolicy)
+	}
+	// Internal bitmaps location is an offset from superblock: +8
+	switch bitmapLocation := readMdAttribute(raidName, "bitmap/location"); {
+	case strings.HasPrefix(bitmapLocation, "+") || strings.HasPrefix(bitmapLocation, "-"):
+		details = append(details, "bitmap internal")
+	case bitmapLocation == "file":
+		details = append(details, "bitmap file")
+	}
+	if mismatchCount := readMdAttribute(raidName, "mismatch_cnt"); len(mismatchCount) > 0 && mismatchCount != "0" {
+		details = append(details, mismatchCount+" mismatches")
+	}
+	if syncAction := readMdAttribute(raidName, "sync_action"); len(syncAction) > 0 && syncAction != "idle" {
+		details = append(details, syncAction+getMdSyncProgress(readMdAttribute(raidName, "sync_completed")))
+	}
+	return details
+}
+
+// md array from /sys/block/mdX/md, members are dev-* directories
+func getMdSysfsRaid(controller *utils.ControllerStruct, raidName string) (utils.RaidStruct, error) {
+	arrayState := readMdAttribute(raidName, "array_state")
+	if len(arrayState) == 0 {
+		return utils.RaidStruct{}, fmt.Errorf("Error: Cant read %s sysfs array_state.", raidName)
+	}
+	entries, err := utils.ReadSysfsDir("/sys/block/" + raidName + "/md")
+	if err != nil {
+		return utils.RaidStruct{}, fmt.Errorf("Error: Cant read %s sysfs members: %v.", raidName, err)
+	}
+
+	raidType := readMdAttribute(raidName, "level")
+	if len(raidType) == 0 {
+		raidType = "Unknown"
+	}
+	raid := utils.RaidStruct{
+		ControllerId: "softraid-0",
+		RaidLevel:    0,
+		Dg:           raidName,
+		State:        getMdArrayState(arrayState, readMdAttribute(raidName, "degraded")),
+		RaidType:     raidType,
+		OsDevice:     raidName,
+		Details:      getMdArrayDetails(raidName),
+	}
+	if raid.State != "Okay" {
+		controller.Status = "Bad"
+	}
+
+	// Members are shown in slot order, spares at the end
+	type mdMember struct {
+		device string
+		slot   int
+		role   string
+		state  string
+	}
+	members := []mdMember{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry, "dev-") {
+			continue
+		}
+		device := strings.TrimPrefix(entry, "dev-")
+		// dev-sda1/block -> ../../../../../../devices/.../block/sda/sda1
+		link, err := utils.ReadSysfsLink("/sys/block/" + raidName + "/md/" + entry + "/block")
+		if err == nil {
+			device = filepath.Base(link)
+		}
+		slotString := readMdAttribute(raidName, entry+"/slot")
+		role, state := getMdMemberRole(strings.Split(readMdAttribute(raidName, entry+"/state"), ","), slotString)
+		slot, err := strconv.Atoi(slotString)
+		if err != nil {
+			slot = math.MaxInt
+		}
+		members = append(members, mdMember{device: device, slot: slot, role: role, state: state})
+	}
+	slices.SortStableFunc(members, func(a, b mdMember) int { return cmp.Compare(a.slot, b.slot) })
+
+	for _, member := range members {
+		diskSize, err := utils.GetDiskPartitionSize(member.device)
+		if err != nil {
+			color.Red("++ ERROR: utils.GetDiskPartitionSize: %s", err)
+		}
+		diskSerialNumber, diskModel, diskIntf, diskMedium, err := utils.GetDiskData(member.device)
+		if err != nil {
+			color.Red("++ ERROR: utils.GetDiskData: %s", err)
+		}
+		if member.role == "spare" {
+			controller.AddSpare(utils.SpareStruct{
+				ControllerId: "softraid-0",
+				Type:         "Dedicated",
+				Arrays:       []string{raidName},
+				State:        member.state,
+				Size:         diskSize,
+				Intf:         diskIntf,
+				Medium:       diskMedium,
+				Model:        diskModel,
+				SerialNumber: diskSerialNumber,
+				OsDevice:     member.device,
+			})
+			continue
+		}
+		if member.state != "ONLINE" {
+			controller.Status = "Bad"
+		}
+		raid.AddDisk(utils.DiskStruct{
+			ControllerId: "softraid-0",
+			Dg:           raidName,
+			State:        member.state,
+			Size:         diskSize,
+			Intf:         diskIntf,
+			Medium:       diskMedium,
+			Model:        diskModel,
+			SerialNumber: diskSerialNumber,
+			OsDevice:     member.device,
+			Role:         member.role,
+		})
+	}
+
+	raidSize, err := utils.GetDiskPartitionSize(raidName)
+	if err != nil {
+		color.Red("++ ERROR: utils.GetDiskPartitionSize: %s", err)
+	}
+	raid.Size = strings.TrimSpace(raidSize)
+	return raid, nil
+}
+
 var ProcessSoftRaid = func(manufacturer string) ([]utils.ControllerStruct, []utils.RaidStruct, error) {
 	var controllers = []utils.ControllerStruct{}
 	var raids = []utils.RaidStruct{}
@@ -78,7 +313,6 @@ var ProcessSoftRaid = func(manufacturer string) ([]utils.ControllerStruct, []uti
 
 	var raid = utils.RaidStruct{}
 	var diskState string
-	var tempVar []string
 	var raidName string
 	var raidState string
 	var raidType string
@@ -100,6 +334,13 @@ var ProcessSoftRaid = func(manufacturer string) ([]utils.ControllerStruct, []uti
 		}
 		if matched {
 			raidName = strings.Fields(line)[0]
+			// Arrays are read from sysfs when available, mdstat line is only parsed on old kernels
+			sysfsRaid, err := getMdSysfsRaid(&controllers[0], raidName)
+			if err == nil {
+				raids = append(raids, sysfsRaid)
+				driveStateLine = false
+				continue
+			}
 			raidState = strings.Fields(line)[2]
 			var start int
 			switch raidState {
@@ -116,7 +357,7 @@ var ProcessSoftRaid = func(manufacturer string) ([]utils.ControllerStruct, []uti
 					start = 4
 				}
 			default:
-				raidState = strings.ToTitle(raidState)
+				raidState = strings.ToUpper(raidState[:1]) + raidState[1:]
 				raidType = "Unknown"
 				controllers[0].Status = "Bad"
 				start = 3
@@ -137,26 +378,31 @@ var ProcessSoftRaid = func(manufacturer string) ([]utils.ControllerStruct, []uti
 			for i := start; i <= n-1; i++ {
 				diskDriveData := strings.Fields(line)[i]
 
-				// Check for Failed drives
-				matched, err := regexp.MatchString(`^.*\[\d+\]\(F\).*$`, diskDriveData)
-				if err != nil {
-					color.Red("++ ERROR: Regexp errror %s", err)
+				// Member flags: sda1[0](F) failed, (S) spare, (W) write-mostly, (R) replacement, (J) journal
+				memberData := mdstatMemberRegexp.FindStringSubmatch(diskDriveData)
+				if len(memberData) != 3 {
+					continue
 				}
-				if matched {
+				diskDrive := utils.ClearString(memberData[1])
+				memberFlags := memberData[2]
+				diskState = "ONLINE"
+				diskRole := "active"
+				if strings.Contains(memberFlags, "(F)") {
 					//fmt.Println("FAILED drive detected")
-					tempVar = strings.Split(diskDriveData, "(F)")
-					diskDriveData = tempVar[0]
 					diskState = "Failed"
 					raid.State = "Degraded"
 					controllers[0].Status = "Bad"
-				} else {
-					//fmt.Println("ONLINE drive detected")
-					diskState = "ONLINE"
 				}
-
-				tempVar = strings.Split(diskDriveData, "[")
-				diskDrive := tempVar[0]
-				diskDrive = utils.ClearString(diskDrive)
+				// Spare drives are dedicated to current md device
+				isSpare := strings.Contains(memberFlags, "(S)") && diskState != "Failed"
+				switch {
+				case strings.Contains(memberFlags, "(J)"):
+					diskRole = "journal"
+				case strings.Contains(memberFlags, "(R)"):
+					diskRole = "replacement"
+				case strings.Contains(memberFlags, "(W)"):
+					diskRole = "write-mostly"
+				}
 				//fmt.Println("diskDrive: ", diskDrive)
 				diskSize, err := utils.GetDiskPartitionSize(diskDrive)
 				if err != nil {
@@ -172,6 +418,23 @@ var ProcessSoftRaid = func(manufacturer string) ([]utils.ControllerStruct, []uti
 				if err != nil {
 					color.Red("++ ERROR: utils.GetDiskData: %s", err)
 				}
+				if isSpare {
+					spare := utils.SpareStruct{
+						ControllerId: "softraid-0",
+						Type:         "Dedicated",
+						Arrays:       []string{raidName},
+						State:        "Available",
+						Size:         diskSize,
+						Intf:         diskIntf,
+						Medium:       diskMedium,
+						Model:        diskModel,
+						SerialNumber: diskSerialNumber,
+						OsDevice:     diskDrive,
+					}
+					controllers[0].AddSpare(spare)
+					continue
+				}
+
 				disk := utils.DiskStruct{
 					ControllerId: "softraid-0",
 					Dg:           raidName,
@@ -182,6 +445,7 @@ var ProcessSoftRaid = func(manufacturer string) ([]utils.ControllerStruct, []uti
 					Model:        diskModel,
 					SerialNumber: diskSerialNumber,
 					OsDevice:     diskDrive,
+					Role:         diskRole,
 				}
 				//fmt.Println("disk: ", disk)
 				raid.AddDisk(disk)
@@ -3,9 +3,12 @@ package softraid
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"hardwareAnalyzer/utils"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -107,13 +110,21 @@ func TestProcessSoftRaid(t *testing.T) {
 	// Copy original functions content
 	getSoftraidsOri := GetSoftraids
 	getDiskData := utils.GetDiskData
-	getDiskPartitionSize := utils.GetDiskPartitionSize // unmock functions content
+	getDiskPartitionSize := utils.GetDiskPartitionSize
+	readSysfsFileOri := utils.ReadSysfsFile
+	// unmock functions content
 	defer func() {
 		GetSoftraids = getSoftraidsOri
 		utils.GetDiskData = getDiskData
 		utils.GetDiskPartitionSize = getDiskPartitionSize
+		utils.ReadSysfsFile = readSysfsFileOri
 	}()
 
+	// Mocked function, kernel without md sysfs attributes
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		return "", errors.New("No such file or directory")
+	}
+
 	// Mocked function
 	GetSoftraids = func() (*bufio.Scanner, *os.File, error) {
 		//fmt.Println("-- Executing mocked GetSoftraids function")
@@ -537,3 +548,287 @@ func TestCheckSoftRaidDisksNoMatch(t *testing.T) {
 		}
 	}
 }
+
+// Test ProcessSoftRaid spare drives
+func TestProcessSoftRaidSpare(t *testing.T) {
+	// Copy original functions content
+	getSoftraidsOri := GetSoftraids
+	getDiskData := utils.GetDiskData
+	getDiskPartitionSize := utils.GetDiskPartitionSize
+	readSysfsFileOri := utils.ReadSysfsFile
+	// unmock functions content
+	defer func() {
+		GetSoftraids = getSoftraidsOri
+		utils.GetDiskData = getDiskData
+		utils.GetDiskPartitionSize = getDiskPartitionSize
+		utils.ReadSysfsFile = readSysfsFileOri
+	}()
+
+	// Mocked function, kernel without md sysfs attributes
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		return "", errors.New("No such file or directory")
+	}
+
+	// Mocked function
+	GetSoftraids = func() (*bufio.Scanner, *os.File, error) {
+		//fmt.Println("-- Executing mocked GetSoftraids function")
+		// Dont open any file, just fill a buffer to pass it to bufio.NewScanner
+		var buffer bytes.Buffer
+		buffer.WriteString(`
+			Personalities : [raid0] [raid1] [raid6] [raid5] [raid4] [linear] [multipath] [raid10]
+			md0 : active raid1 sdc1[2](S) sda1[0] sdb1[1]
+				51198912 blocks [2/2] [UU]
+
+			unused devices: <none>
+		`)
+
+		scanner := bufio.NewScanner(&buffer)
+		return scanner, nil, nil
+	}
+
+	// Mocked function
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "SERIALNUMBER-" + diskDrive, "MODEL-" + diskDrive, "SATA", "HDD", nil
+	}
+
+	// Mocked function
+	utils.GetDiskPartitionSize = func(diskDrive string) (string, error) {
+		return "100GB", nil
+	}
+
+	newControllers, newRaids, err := ProcessSoftRaid("softraid")
+	if err != nil {
+		t.Fatalf(`TestProcessSoftRaidSpare: error: %s`, err)
+	}
+
+	if len(newRaids) != 1 {
+		t.Fatalf(`TestProcessSoftRaidSpare: len(newRaids): %v must match 1`, len(newRaids))
+	}
+
+	// Spare drive must not be listed as raid drive
+	for _, disk := range newRaids[0].Disks {
+		if disk.OsDevice == "sdc1" {
+			t.Fatalf(`TestProcessSoftRaidSpare: spare drive sdc1 listed as raid drive`)
+		}
+	}
+	if len(newRaids[0].Disks) != 2 {
+		t.Fatalf(`TestProcessSoftRaidSpare: len(newRaids[0].Disks): %v must match 2`, len(newRaids[0].Disks))
+	}
+
+	if len(newControllers[0].Spares) != 1 {
+		t.Fatalf(`TestProcessSoftRaidSpare: len(newControllers[0].Spares): %v must match 1`, len(newControllers[0].Spares))
+	}
+	spare := newControllers[0].Spares[0]
+	if spare.OsDevice != "sdc1" {
+		t.Fatalf(`TestProcessSoftRaidSpare: spare.OsDevice: %v must match sdc1`, spare.OsDevice)
+	}
+	if spare.Type != "Dedicated" {
+		t.Fatalf(`TestProcessSoftRaidSpare: spare.Type: %v must match Dedicated`, spare.Type)
+	}
+	if len(spare.Arrays) != 1 || spare.Arrays[0] != "md0" {
+		t.Fatalf(`TestProcessSoftRaidSpare: spare.Arrays: %v must match [md0]`, spare.Arrays)
+	}
+	if spare.State != "Available" {
+		t.Fatalf(`TestProcessSoftRaidSpare: spare.State: %v must match Available`, spare.State)
+	}
+	if spare.SerialNumber != "SERIALNUMBER-sdc1" {
+		t.Fatalf(`TestProcessSoftRaidSpare: spare.SerialNumber: %v must match SERIALNUMBER-sdc1`, spare.SerialNumber)
+	}
+}
+
+// Test ProcessSoftRaid mdstat write-mostly, replacement and failed members
+func TestProcessSoftRaidMdstatRoles(t *testing.T) {
+	// Copy original functions content
+	getSoftraidsOri := GetSoftraids
+	getDiskData := utils.GetDiskData
+	getDiskPartitionSize := utils.GetDiskPartitionSize
+	readSysfsFileOri := utils.ReadSysfsFile
+	// unmock functions content
+	defer func() {
+		GetSoftraids = getSoftraidsOri
+		utils.GetDiskData = getDiskData
+		utils.GetDiskPartitionSize = getDiskPartitionSize
+		utils.ReadSysfsFile = readSysfsFileOri
+	}()
+
+	// Mocked function
+	GetSoftraids = func() (*bufio.Scanner, *os.File, error) {
+		var buffer bytes.Buffer
+		buffer.WriteString(`
+			Personalities : [raid1]
+			md0 : active raid1 sdd1[3](R) sdc1[2](F) sdb1[1](W) sda1[0]
+				51198912 blocks [2/2] [UU]
+
+			unused devices: <none>
+		`)
+		scanner := bufio.NewScanner(&buffer)
+		return scanner, nil, nil
+	}
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "SERIALNUMBER-" + diskDrive, "MODEL-" + diskDrive, "SATA", "HDD", nil
+	}
+	utils.GetDiskPartitionSize = func(diskDrive string) (string, error) {
+		return "100GB", nil
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		return "", errors.New("No such file or directory")
+	}
+
+	_, newRaids, err := ProcessSoftRaid("softraid")
+	if err != nil {
+		t.Fatalf(`TestProcessSoftRaidMdstatRoles: error: %s`, err)
+	}
+	disks := map[string]string{}
+	for _, disk := range newRaids[0].Disks {
+		disks[disk.OsDevice] = disk.State + " " + disk.Role
+	}
+	disksWanted := map[string]string{"sdd1": "ONLINE replacement", "sdc1": "Failed active", "sdb1": "ONLINE write-mostly", "sda1": "ONLINE active"}
+	if !reflect.DeepEqual(disks, disksWanted) {
+		t.Fatalf(`TestProcessSoftRaidMdstatRoles: disks: %v should be: %v`, disks, disksWanted)
+	}
+	if newRaids[0].State != "Degraded" {
+		t.Fatalf(`TestProcessSoftRaidMdstatRoles: raid.State: %v should be: Degraded`, newRaids[0].State)
+	}
+}
+
+// Test getMdMemberRole
+func TestGetMdMemberRole(t *testing.T) {
+	members := []struct {
+		state string
+		slot  string
+		role  string
+		disk  string
+	}{
+		{"in_sync", "0", "active", "ONLINE"},
+		{"in_sync,write_mostly", "1", "write-mostly", "ONLINE"},
+		{"spare", "none", "spare", "Available"},
+		{"spare", "1", "active", "Rebuilding"},
+		{"replacement", "1", "replacement", "Rebuilding"},
+		{"in_sync,replacement", "1", "replacement", "ONLINE"},
+		{"journal", "none", "journal", "ONLINE"},
+		{"faulty", "none", "active", "Failed"},
+		{"faulty,spare", "none", "active", "Failed"},
+		{"in_sync,write_error,want_replacement", "0", "active", "ONLINE/Write-Errors/Want-Replacement"},
+	}
+	for _, member := range members {
+		role, state := getMdMemberRole(strings.Split(member.state, ","), member.slot)
+		if role != member.role || state != member.disk {
+			t.Fatalf(`TestGetMdMemberRole: %v slot %v: %v %v should be: %v %v`, member.state, member.slot, role, state, member.role, member.disk)
+		}
+	}
+}
+
+// Test ProcessSoftRaid from md sysfs attributes
+func TestProcessSoftRaidSysfs(t *testing.T) {
+	// Copy original functions content
+	getSoftraidsOri := GetSoftraids
+	getDiskData := utils.GetDiskData
+	getDiskPartitionSize := utils.GetDiskPartitionSize
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsDirOri := utils.ReadSysfsDir
+	readSysfsLinkOri := utils.ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		GetSoftraids = getSoftraidsOri
+		utils.GetDiskData = getDiskData
+		utils.GetDiskPartitionSize = getDiskPartitionSize
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsDir = readSysfsDirOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked functions: raid5 recovering sdd1 after sdc1 failure, sde1 spare
+	GetSoftraids = func() (*bufio.Scanner, *os.File, error) {
+		var buffer bytes.Buffer
+		buffer.WriteString(`
+			Personalities : [raid6] [raid5] [raid4]
+			md1 : active raid5 sde1[4](S) sdd1[3] sdc1[2](F) sdb1[1] sda1[0]
+				1953260544 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+				[=====>...............]  recovery = 25.0% (244157568/976630272) finish=80.1min speed=152345K/sec
+				bitmap: 1/8 pages [4KB], 65536KB chunk
+
+			unused devices: <none>
+		`)
+		scanner := bufio.NewScanner(&buffer)
+		return scanner, nil, nil
+	}
+	sysfsFiles := map[string]string{
+		"/sys/block/md1/md/array_state":        "active",
+		"/sys/block/md1/md/level":              "raid5",
+		"/sys/block/md1/md/raid_disks":         "3",
+		"/sys/block/md1/md/degraded":           "1",
+		"/sys/block/md1/md/sync_action":        "recover",
+		"/sys/block/md1/md/sync_completed":     "488315136 / 1953260544",
+		"/sys/block/md1/md/mismatch_cnt":       "0",
+		"/sys/block/md1/md/chunk_size":         "524288",
+		"/sys/block/md1/md/consistency_policy": "bitmap",
+		"/sys/block/md1/md/metadata_version":   "1.2",
+		"/sys/block/md1/md/uuid":               "3f1b6c2e-8a4d-4e3b-9c1a-2d5e6f708192",
+		"/sys/block/md1/md/bitmap/location":    "+8",
+		"/sys/block/md1/md/dev-sda1/state":     "in_sync",
+		"/sys/block/md1/md/dev-sda1/slot":      "0",
+		"/sys/block/md1/md/dev-sdb1/state":     "in_sync,write_mostly",
+		"/sys/block/md1/md/dev-sdb1/slot":      "1",
+		"/sys/block/md1/md/dev-sdc1/state":     "faulty",
+		"/sys/block/md1/md/dev-sdc1/slot":      "none",
+		"/sys/block/md1/md/dev-sdd1/state":     "spare",
+		"/sys/block/md1/md/dev-sdd1/slot":      "2",
+		"/sys/block/md1/md/dev-sde1/state":     "spare",
+		"/sys/block/md1/md/dev-sde1/slot":      "none",
+	}
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		value, ok := sysfsFiles[path]
+		if !ok {
+			return "", errors.New("No such file or directory")
+		}
+		return value, nil
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		if path == "/sys/block/md1/md" {
+			return []string{"array_state", "bitmap", "dev-sda1", "dev-sdb1", "dev-sdc1", "dev-sdd1", "dev-sde1", "level", "rd0", "rd1", "rd2"}, nil
+		}
+		return []string{}, errors.New("No such file or directory")
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		return "", errors.New("No such file or directory")
+	}
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "SERIALNUMBER-" + diskDrive, "MODEL-" + diskDrive, "SATA", "HDD", nil
+	}
+	utils.GetDiskPartitionSize = func(diskDrive string) (string, error) {
+		return "1.0 TB", nil
+	}
+
+	newControllers, newRaids, err := ProcessSoftRaid("softraid")
+	if err != nil {
+		t.Fatalf(`TestProcessSoftRaidSysfs: error: %s`, err)
+	}
+	if newControllers[0].Status != "Bad" {
+		t.Fatalf(`TestProcessSoftRaidSysfs: controller.Status: %v should be: Bad`, newControllers[0].Status)
+	}
+	if len(newRaids) != 1 {
+		t.Fatalf(`TestProcessSoftRaidSysfs: len(newRaids): %v should be: 1`, len(newRaids))
+	}
+	raid := newRaids[0]
+	if raid.State != "Degraded" || raid.RaidType != "raid5" || raid.Size != "1.0 TB" {
+		t.Fatalf(`TestProcessSoftRaidSysfs: raid: %v %v %v should be: Degraded raid5 1.0 TB`, raid.State, raid.RaidType, raid.Size)
+	}
+	detailsWanted := []string{"metadata 1.2", "uuid 3f1b6c2e-8a4d-4e3b-9c1a-2d5e6f708192", "chunk 512K", "consistency-policy bitmap", "bitmap internal", "recover 25.0%"}
+	if !reflect.DeepEqual(raid.Details, detailsWanted) {
+		t.Fatalf(`TestProcessSoftRaidSysfs: raid.Details: %v should be: %v`, raid.Details, detailsWanted)
+	}
+
+	// Slot order, failed members without slot at the end
+	disks := []string{}
+	for _, disk := range raid.Disks {
+		disks = append(disks, disk.OsDevice+" "+disk.State+" "+disk.Role)
+	}
+	disksWanted := []string{"sda1 ONLINE active", "sdb1 ONLINE write-mostly", "sdd1 Rebuilding active", "sdc1 Failed active"}
+	if !reflect.DeepEqual(disks, disksWanted) {
+		t.Fatalf(`TestProcessSoftRaidSysfs: disks: %v should be: %v`, disks, disksWanted)
+	}
+
+	if len(newControllers[0].Spares) != 1 || newControllers[0].Spares[0].OsDevice != "sde1" || newControllers[0].Spares[0].State != "Available" {
+		t.Fatalf(`TestProcessSoftRaidSysfs: spares: %v should be sde1 Available`, newControllers[0].Spares)
+	}
+}
@@ -6,6 +6,75 @@ type ControllerStruct struct {
 	Manufacturer string
 	Model        string
 	Status       string
+	Spares       []SpareStruct
+	// Pending to import foreign configurations
+	ForeignConfigs int
+	// Foreign and unconfigured bad disks, they are not part of any raid neither usable as JBOD
+	ForeignBadDisks []NoRaidDiskStruct
+	// Hardware controllers inventory data, empty for software raids
+	FirmwareVersion string
+	BiosVersion     string
+	DriverName      string
+	DriverVersion   string
+	PciAddress      string
+	SerialNumber    string
+	RocTemperature  string
+	// Write cache module and its battery/capacitor status, empty when controller doesnt report it
+	CacheStatus   string
+	BatteryStatus string
+	Enclosures    []EnclosureStruct
+	// NVMe controllers data
+	Transport  string
+	Namespaces []NamespaceStruct
+	Health     SmartStruct
+	// Kernel log adapter resets and its LDs IO errors
+	KernelEvents []KernelEventStruct
+}
+
+// Every controllerStruct object will be binded to AddSpare function
+func (c *ControllerStruct) AddSpare(spare SpareStruct) {
+	c.Spares = append(c.Spares, spare)
+}
+
+// Every controllerStruct object will be binded to AddForeignBadDisk function
+func (c *ControllerStruct) AddForeignBadDisk(disk NoRaidDiskStruct) {
+	c.ForeignBadDisks = append(c.ForeignBadDisks, disk)
+}
+
+// Every controllerStruct object will be binded to AddEnclosure function
+func (c *ControllerStruct) AddEnclosure(enclosure EnclosureStruct) {
+	c.Enclosures = append(c.Enclosures, enclosure)
+}
+
+// Enclosure/backplane struct, Fans/PowerSupplies/TemperatureSensors contains the status of every enclosure element
+// OccupiedSlots contains the slot number of every detected disk
+type EnclosureStruct struct {
+	ControllerId       string
+	Id                 string
+	Vendor             string
+	Model              string
+	State              string
+	Slots              string
+	OccupiedSlots      []string
+	Fans               []string
+	PowerSupplies      []string
+	TemperatureSensors []string
+}
+
+// Hot spare struct, Type: Global/Dedicated, State: Available/InUse
+// Arrays contains the Dg of every covered raid, global spares cover all controller raids so Arrays will be empty
+type SpareStruct struct {
+	ControllerId string
+	Type         string
+	Arrays       []string
+	State        string
+	EidSlot      string
+	Size         string
+	Intf         string
+	Medium       string
+	Model        string
+	SerialNumber string
+	OsDevice     string
 }
 
 // ZFS pool struct
@@ -15,6 +84,29 @@ type PoolStruct struct {
 	State        string
 	Size         string
 	OsDevice     string
+	Details      []string
+	// zpool status message of unhealthy pools
+	Status   string
+	Datasets []DatasetStruct
+}
+
+// ZFS filesystem or volume, sizes as shown by zfs list
+// Volumes OsDevice is its zdN block device, Consumers are partitions, holders and mounts using it
+type DatasetStruct struct {
+	Pool          string
+	Name          string
+	Type          string
+	Used          string
+	Available     string
+	Referenced    string
+	Quota         string
+	Reservation   string
+	Size          string
+	CompressRatio string
+	Encryption    string
+	Mountpoint    string
+	OsDevice      string
+	Consumers     []string
 }
 
 // LVM volumeGroup struct
@@ -37,6 +129,17 @@ type DiskStruct struct {
 	Model        string
 	SerialNumber string
 	OsDevice     string
+	Bay          string
+	Smart        SmartStruct
+	Identity     DiskIdentityStruct
+	Transport    string
+	LinkRate     string
+	MaxLinkRate  string
+	Paths        []string
+	IoErrors     IoErrorsStruct
+	// md members role: active, write-mostly, replacement, journal
+	Role         string
+	KernelEvents []KernelEventStruct
 }
 
 // Raid struct, all storcli parsed data as string
@@ -49,6 +152,7 @@ type RaidStruct struct {
 	Size         string
 	Disks        []DiskStruct
 	OsDevice     string
+	Details      []string
 }
 
 // Every raidStruct object will be binded to AddDisk function
@@ -68,4 +172,163 @@ type NoRaidDiskStruct struct {
 	Model        string
 	SerialNumber string
 	OsDevice     string
+	Bay          string
+	Smart        SmartStruct
+	Identity     DiskIdentityStruct
+	Transport    string
+	LinkRate     string
+	MaxLinkRate  string
+	KernelEvents []KernelEventStruct
+}
+
+// Disk selected by locate command
+type LocateDiskStruct struct {
+	ControllerId string
+	EidSlot      string
+	SerialNumber string
+	OsDevice     string
+}
+
+// Disk SMART data, numeric fields are -1 when unknown
+// Source: ata, scsi, nvme, megaraid, adaptec
+// Verdict: PASSED, FAILED, Unknown
+// Status: OK, Warning, Failed after applying Thresholds
+type SmartStruct struct {
+	Source             string
+	Verdict            string
+	ReallocatedSectors int64
+	PendingSectors     int64
+	MediaErrors        int64
+	WearLevel          int64
+	PowerOnHours       int64
+	Temperature        int64
+	AvailableSpare     int64
+	CriticalWarnings   []string
+	Status             string
+	Warnings           []string
+}
+
+// Device error counters reported by software raid layers
+// Source: zfs, btrfs(Checksum are corruption errors, only btrfs reports Flush and Generation errors)
+type IoErrorsStruct struct {
+	Source     string
+	Read       int64
+	Write      int64
+	Checksum   int64
+	Flush      int64
+	Generation int64
+}
+
+// Health thresholds, configurable from command line flags
+type ThresholdsStruct struct {
+	SmartReallocatedSectors int64
+	SmartPendingSectors     int64
+	SmartMediaErrors        int64
+	SmartWearLevel          int64
+	SmartTemperature        int64
+	ThinPoolData            int64
+	ThinPoolMetadata        int64
+	ZfsScrubDays            int64
+	BtrfsUnallocatedGiB     int64
+	BtrfsMetadata           int64
+	FilesystemUsage         int64
+	FilesystemInodes        int64
+}
+
+// NVMe namespace, Paths are the controllers giving access to it with its ANA state: nvme0(optimized)
+type NamespaceStruct struct {
+	ControllerId string
+	Id           string
+	OsDevice     string
+	Size         string
+	LbaFormat    string
+	Paths        []string
+}
+
+// Disk identity, Sources maps each field to where it was read from: sysfs, udev, heuristic
+type DiskIdentityStruct struct {
+	Vendor       string
+	Model        string
+	Revision     string
+	SerialNumber string
+	Wwn          string
+	Intf         string
+	Medium       string
+	Sources      map[string]string
+}
+
+// dm-multipath map: mpatha -> dm-3, Paths are its slave devices
+type MultipathMapStruct struct {
+	Device string
+	Name   string
+	Uuid   string
+	Paths  []string
+}
+
+// Filesystem or volume signature found on a disk or partition: ext4, xfs, btrfs, swap, crypto_LUKS, zfs_member, linux_raid_member, LVM2_member
+// Mountpoints come from /proc/self/mountinfo and /proc/swaps, Holders are the devices stacked over it: md0, luks-data
+type FilesystemStruct struct {
+	Type        string
+	Label       string
+	Uuid        string
+	Mountpoints []string
+	Holders     []string
+}
+
+// Partition table entry, Start/Size in bytes
+// Type: GPT partition type GUID or MBR type code(0x83), Guid/Name only exist on GPT tables
+type PartitionStruct struct {
+	Number     int
+	OsDevice   string
+	Type       string
+	TypeName   string
+	Guid       string
+	Name       string
+	Start      uint64
+	Size       uint64
+	Aligned    bool
+	Filesystem FilesystemStruct
+}
+
+// Whole disk layout, Table: gpt, dos or none, TableId: GPT disk GUID or MBR disk signature
+// Disks without partition table can hold a filesystem directly
+type DiskLayoutStruct struct {
+	OsDevice   string
+	Size       uint64
+	SectorSize uint64
+	Table      string
+	TableId    string
+	Filesystem FilesystemStruct
+	Partitions []PartitionStruct
+}
+
+// Mounted filesystem usage in bytes, first mountpoint is the main one, others are bind mounts or subvolumes
+// Layer is the device kind it sits on: disk, md, lvm, dm-crypt, multipath, dm, zvol, btrfs, zfs
+// State: OK or its flags: Usage-High/Inodes-High/Read-only/FS-Errors
+type MountedFilesystemStruct struct {
+	Mountpoints []string
+	Source      string
+	Type        string
+	Options     string
+	Layer       string
+	Device      string
+	Size        uint64
+	Used        uint64
+	Available   uint64
+	Inodes      uint64
+	InodesFree  uint64
+	ReadOnly    bool
+	State       string
+}
+
+// Kernel log storage event occurrences grouped by device and type
+// Source: block, scsi, ata, md, zfs, btrfs, nvme, megaraid_sas, mpt2sas, mpt3sas, aacraid
+// Device: kernel disk name(sda), ata port(ata3), nvme controller(nvme0), PCI address or mpt ioc(cm0)
+// LastSeen: 2006-01-02 15:04:05 local time or Unknown
+type KernelEventStruct struct {
+	Source   string
+	Device   string
+	Type     string
+	Count    int64
+	LastSeen string
 }
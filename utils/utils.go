@@ -292,8 +292,529 @@ func GetDiskPartitionInterface(diskDataRaw string) (string, error) {
 	return intf, nil
 }
 
+// Read sysfs/procfs file content without trailing new line
+// Function as variable in order to be possible to be mocked from unit tests
+var ReadSysfsFile = func(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// Health thresholds, values over them are reported as warnings
+var Thresholds = ThresholdsStruct{
+	SmartReallocatedSectors: 10,
+	SmartPendingSectors:     0,
+	SmartMediaErrors:        0,
+	SmartWearLevel:          90,
+	SmartTemperature:        60,
+	ThinPoolData:            80,
+	ThinPoolMetadata:        80,
+	ZfsScrubDays:            35,
+	BtrfsUnallocatedGiB:     5,
+	BtrfsMetadata:           90,
+	FilesystemUsage:         90,
+	FilesystemInodes:        90,
+}
+
+// Read sysfs directory entry names
+// Function as variable in order to be possible to be mocked from unit tests
+var ReadSysfsDir = func(path string) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// Read sysfs symlink destination
+// Function as variable in order to be possible to be mocked from unit tests
+var ReadSysfsLink = func(path string) (string, error) {
+	return os.Readlink(path)
+}
+
+// Write sysfs file content
+// Function as variable in order to be possible to be mocked from unit tests
+var WriteSysfsFile = func(path, value string) error {
+	return os.WriteFile(path, []byte(value), 0644)
+}
+
+// Get whole disk device of a partition using /sys/class/block symlinks: sda3 -> sda, nvme0n1p2 -> nvme0n1
+// Whole disk devices are returned unmodified
+func GetParentBlockDevice(device string) string {
+	link, err := ReadSysfsLink("/sys/class/block/" + device)
+	if err != nil {
+		return device
+	}
+	// ../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda/sda3
+	// NVMe namespaces parent is its controller: ../../devices/pci0000:00/0000:00:1d.0/0000:3d:00.0/nvme/nvme0/nvme0n1
+	linkData := strings.Split(link, "/")
+	if len(linkData) < 2 {
+		return device
+	}
+	parent := linkData[len(linkData)-2]
+	if !strings.HasPrefix(device, parent) || !partitionSuffixRegexp.MatchString(strings.TrimPrefix(device, parent)) {
+		return device
+	}
+	return parent
+}
+
+var partitionSuffixRegexp = regexp.MustCompile(`^p?\d+$`)
+
+// PCI bus, device and function numbers, domain is ignored: 0000:03:00.0 and storcli 00:03:00:00 -> 3:0:0
+// Empty string is returned for unknown or non PCI addresses
+func PciFunction(address string) string {
+	fields := strings.FieldsFunc(address, func(r rune) bool { return r == ':' || r == '.' })
+	if len(fields) < 3 {
+		return ""
+	}
+	numbers := []string{}
+	for _, field := range fields[len(fields)-3:] {
+		number, err := strconv.ParseUint(field, 16, 32)
+		if err != nil {
+			return ""
+		}
+		numbers = append(numbers, strconv.FormatUint(number, 10))
+	}
+	return strings.Join(numbers, ":")
+}
+
+// Kernel driver bound to a PCI device: /sys/bus/pci/devices/0000:03:00.0/driver -> ../../../bus/pci/drivers/aacraid
+// Address formats accepted by PciFunction are valid, domain is only taken from four fields addresses: arcconf 0:3:0:0
+// Unknown is returned when no driver is bound or address cant be parsed
+func GetPciDriver(address string) string {
+	pciFunction := PciFunction(address)
+	if len(pciFunction) == 0 {
+		return "Unknown"
+	}
+	domain := uint64(0)
+	fields := strings.FieldsFunc(address, func(r rune) bool { return r == ':' || r == '.' })
+	if len(fields) == 4 {
+		domain, _ = strconv.ParseUint(fields[0], 16, 32)
+	}
+	numbers := []uint64{}
+	for _, field := range strings.Split(pciFunction, ":") {
+		number, _ := strconv.ParseUint(field, 10, 32)
+		numbers = append(numbers, number)
+	}
+	sysfsAddress := fmt.Sprintf("%04x:%02x:%02x.%x", domain, numbers[0], numbers[1], numbers[2])
+	link, err := ReadSysfsLink("/sys/bus/pci/devices/" + sysfsAddress + "/driver")
+	if err != nil {
+		return "Unknown"
+	}
+	return filepath.Base(link)
+}
+
+// Get first loaded kernel module from modules list and its version using /sys/module info
+// Built-in modules or modules without version info are returned with Unknown version
+func GetKernelModuleVersion(modules ...string) (string, string) {
+	for _, module := range modules {
+		version, err := ReadSysfsFile("/sys/module/" + module + "/version")
+		if err == nil {
+			return module, version
+		}
+		_, err = ReadSysfsFile("/sys/module/" + module + "/initstate")
+		if err == nil {
+			return module, "Unknown"
+		}
+	}
+	return "Unknown", "Unknown"
+}
+
+// Parse /run/udev/data/bMAJOR:MINOR properties: E:ID_SERIAL_SHORT=Z4D0ABCD
+func getUdevProperties(device string) map[string]string {
+	properties := make(map[string]string)
+	majorMinor, err := ReadSysfsFile("/sys/class/block/" + device + "/dev")
+	if err != nil {
+		return properties
+	}
+	udevData, err := ReadSysfsFile("/run/udev/data/b" + majorMinor)
+	if err != nil {
+		return properties
+	}
+	for _, line := range strings.Split(udevData, "\n") {
+		if !strings.HasPrefix(line, "E:") {
+			continue
+		}
+		property, value, found := strings.Cut(strings.TrimPrefix(line, "E:"), "=")
+		if found {
+			properties[property] = strings.TrimSpace(value)
+		}
+	}
+	return properties
+}
+
+// Get disk identity from sysfs device attributes and udev database
+// NVMe namespaces device link points to its controller: model, serial, firmware_rev
+// Function as variable in order to be possible to mock it from unitary tests
+var GetDiskIdentity = func(diskDrive string) DiskIdentityStruct {
+	identity := DiskIdentityStruct{
+		Vendor:       "Unknown",
+		Model:        "Unknown",
+		Revision:     "Unknown",
+		SerialNumber: "Unknown",
+		Wwn:          "Unknown",
+		Intf:         "Unknown",
+		Medium:       "Unknown",
+		Sources:      make(map[string]string),
+	}
+	// Partitions identity is its whole disk identity
+	device := GetParentBlockDevice(diskDrive)
+	devicePath := "/sys/block/" + device
+	nvmeDevice := strings.HasPrefix(device, "nvme")
+
+	setField := func(field *string, fieldName, value, source string) {
+		value = strings.TrimSpace(value)
+		if *field != "Unknown" || len(value) == 0 {
+			return
+		}
+		*field = value
+		identity.Sources[fieldName] = source
+	}
+	readAttribute := func(attributes ...string) string {
+		for _, attribute := range attributes {
+			value, err := ReadSysfsFile(devicePath + "/" + attribute)
+			if err == nil && len(value) > 0 {
+				return value
+			}
+		}
+		return ""
+	}
+
+	// sysfs
+	vendor := readAttribute("device/vendor")
+	// libata disks are reported with ATA vendor
+	if vendor != "ATA" {
+		setField(&identity.Vendor, "vendor", vendor, "sysfs")
+	}
+	addVendor := func(model string) string {
+		if len(model) > 0 && identity.Vendor != "Unknown" && !strings.HasPrefix(model, identity.Vendor) {
+			return identity.Vendor + " " + model
+		}
+		return model
+	}
+	// libata sysfs model is SCSI INQUIRY model truncated to 16 characters, udev model comes from ATA IDENTIFY
+	udevProperties := getUdevProperties(device)
+	setField(&identity.Model, "model", addVendor(strings.ReplaceAll(udevProperties["ID_MODEL"], "_", " ")), "udev")
+	setField(&identity.Model, "model", addVendor(readAttribute("device/model")), "sysfs")
+	setField(&identity.Revision, "revision", readAttribute("device/rev", "device/firmware_rev"), "sysfs")
+	setField(&identity.SerialNumber, "serial", readAttribute("device/serial"), "sysfs")
+	setField(&identity.Wwn, "wwn", readAttribute("wwid", "device/wwid"), "sysfs")
+	switch readAttribute("queue/rotational") {
+	case "1":
+		setField(&identity.Medium, "medium", "HDD", "sysfs")
+	case "0":
+		if nvmeDevice {
+			setField(&identity.Medium, "medium", "NVME", "sysfs")
+		} else {
+			setField(&identity.Medium, "medium", "SSD", "sysfs")
+		}
+	}
+
+	transport, _, _ := GetDiskTransport(device)
+	if transport != "Unknown" {
+		setField(&identity.Intf, "intf", transport, "sysfs")
+	}
+
+	// udev
+	setField(&identity.SerialNumber, "serial", udevProperties["ID_SERIAL_SHORT"], "udev")
+	setField(&identity.Revision, "revision", udevProperties["ID_REVISION"], "udev")
+	setField(&identity.Wwn, "wwn", udevProperties["ID_WWN"], "udev")
+	setField(&identity.Intf, "intf", udevProperties["ID_BUS"], "udev")
+
+	//fmt.Printf("identity: %v\n", identity)
+	return identity
+}
+
+// Disk identity from sysfs/udev, gopsutil serial number heuristic is only used for fields not found there
+// Function as variable in order to be possible to mock it from unitary tests
+var ResolveDiskIdentity = func(diskDrive string) DiskIdentityStruct {
+	identity := GetDiskIdentity(diskDrive)
+	if identity.SerialNumber == "Unknown" || identity.Model == "Unknown" || identity.Intf == "Unknown" || identity.Medium == "Unknown" {
+		diskSerialNumber, diskModel, diskIntf, diskMedium := getDiskDataHeuristic(diskDrive)
+		setHeuristicField := func(field *string, fieldName, value string) {
+			if *field != "Unknown" || value == "Unknown" || len(value) == 0 {
+				return
+			}
+			*field = value
+			identity.Sources[fieldName] = "heuristic"
+		}
+		setHeuristicField(&identity.SerialNumber, "serial", diskSerialNumber)
+		setHeuristicField(&identity.Model, "model", diskModel)
+		setHeuristicField(&identity.Intf, "intf", diskIntf)
+		setHeuristicField(&identity.Medium, "medium", diskMedium)
+	}
+	return identity
+}
+
 // Function as variable in order to be possible to mock it from unitary tests
 var GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+	identity := ResolveDiskIdentity(diskDrive)
+	//fmt.Printf("diskSerialNumber: %v diskModel: %v diskIntf: %v diskMedium: %v\n", identity.SerialNumber, identity.Model, identity.Intf, identity.Medium)
+	return identity.SerialNumber, identity.Model, identity.Intf, identity.Medium, nil
+}
+
+// Fill OS visible disks identity, hardware raid disks identity comes from its controller
+func SetDiskIdentities(raids []RaidStruct, noRaidDisks []NoRaidDiskStruct) {
+	getIdentity := func(osDevice string) (DiskIdentityStruct, bool) {
+		if len(osDevice) == 0 || osDevice == "Unknown" {
+			return DiskIdentityStruct{}, false
+		}
+		identity := ResolveDiskIdentity(osDevice)
+		return identity, len(identity.Sources) > 0
+	}
+	for i := range raids {
+		for j := range raids[i].Disks {
+			disk := &raids[i].Disks[j]
+			if len(disk.EidSlot) != 0 {
+				continue
+			}
+			if identity, ok := getIdentity(disk.OsDevice); ok {
+				disk.Identity = identity
+			}
+		}
+	}
+	for i := range noRaidDisks {
+		noRaidDisk := &noRaidDisks[i]
+		if len(noRaidDisk.EidSlot) != 0 {
+			continue
+		}
+		if identity, ok := getIdentity(noRaidDisk.OsDevice); ok {
+			noRaidDisk.Identity = identity
+		}
+	}
+}
+
+var nvmeControllerRegexp = regexp.MustCompile(`/(nvme\d+)/nvme\d+n\d+$`)
+var usbDeviceRegexp = regexp.MustCompile(`^\d+-[\d.]+$`)
+var ataPortRegexp = regexp.MustCompile(`^ata(\d+)$`)
+
+// Get disk transport from its sysfs device path: SATA, SAS, NVMe, USB, virtio, iSCSI, FC
+// Negotiated and maximum link rates are read from transport classes: sas_phy, ata_link, fc_host, NVMe PCIe link
+// Function as variable in order to be possible to mock it from unitary tests
+var GetDiskTransport = func(diskDrive string) (string, string, string) {
+	transport := "Unknown"
+	linkRate := "N/A"
+	maxLinkRate := "N/A"
+	device := GetParentBlockDevice(strings.TrimPrefix(diskDrive, "JBOD-"))
+	// ../../devices/pci0000:00/0000:00:1f.2/ata1/host0/target0:0:0/0:0:0:0/block/sda
+	link, err := ReadSysfsLink("/sys/class/block/" + device)
+	if err != nil {
+		return transport, linkRate, maxLinkRate
+	}
+	linkData := strings.Split(link, "/")
+	// Index of first path element with given prefix
+	findElement := func(prefix string) int {
+		return slices.IndexFunc(linkData, func(element string) bool { return strings.HasPrefix(element, prefix) })
+	}
+	readAttribute := func(path string) string {
+		value, err := ReadSysfsFile(path)
+		if err != nil || len(value) == 0 {
+			return "N/A"
+		}
+		return value
+	}
+
+	switch {
+	case strings.HasPrefix(device, "nvme"):
+		// Multipath namespace heads are virtual devices, its controller is guessed from its name
+		controller := "nvme" + strings.Split(strings.TrimPrefix(device, "nvme"), "n")[0]
+		controllerData := nvmeControllerRegexp.FindStringSubmatch(link)
+		if len(controllerData) == 2 {
+			controller = controllerData[1]
+		}
+		controllerPath := "/sys/class/nvme/" + controller
+		transport = "NVMe"
+		nvmeTransport := readAttribute(controllerPath + "/transport")
+		if nvmeTransport != "N/A" && nvmeTransport != "pcie" {
+			transport = "NVMe/" + strings.ToUpper(nvmeTransport)
+			break
+		}
+		// 8.0 GT/s PCIe x4
+		linkRate = readAttribute(controllerPath + "/device/current_link_speed")
+		if linkRate != "N/A" {
+			linkRate = linkRate + " x" + readAttribute(controllerPath+"/device/current_link_width")
+		}
+		maxLinkRate = readAttribute(controllerPath + "/device/max_link_speed")
+		if maxLinkRate != "N/A" {
+			maxLinkRate = maxLinkRate + " x" + readAttribute(controllerPath+"/device/max_link_width")
+		}
+	case strings.Contains(link, "/virtual/"):
+		// md, dm, loop, zram: no transport
+	case findElement("usb") >= 0:
+		transport = "USB"
+		// Last USB device before interface: 2-1
+		usbDevice := ""
+		for _, element := range linkData {
+			if usbDeviceRegexp.MatchString(element) {
+				usbDevice = element
+			}
+		}
+		if len(usbDevice) > 0 {
+			linkRate = readAttribute("/sys/bus/usb/devices/" + usbDevice + "/speed")
+			if linkRate != "N/A" {
+				linkRate = linkRate + " Mbps"
+			}
+		}
+	case findElement("virtio") >= 0:
+		transport = "virtio"
+	case findElement("session") >= 0:
+		transport = "iSCSI"
+	case findElement("rport-") >= 0:
+		transport = "FC"
+		host := linkData[findElement("host")]
+		linkRate = readAttribute("/sys/class/fc_host/" + host + "/speed")
+		// 4 Gbit, 8 Gbit, 16 Gbit
+		supportedSpeeds := strings.Split(readAttribute("/sys/class/fc_host/"+host+"/supported_speeds"), ",")
+		maxLinkRate = strings.TrimSpace(supportedSpeeds[len(supportedSpeeds)-1])
+	case findElement("end_device-") > 0:
+		endDeviceIndex := findElement("end_device-")
+		endDevice := linkData[endDeviceIndex]
+		transport = "SAS"
+		if readAttribute("/sys/class/sas_device/"+endDevice+"/target_port_protocols") == "sata" {
+			transport = "SATA"
+		}
+		// Port containing end device phys: host0/port-0:0/end_device-0:0, expander-0:0/port-0:0:1/end_device-0:0:1
+		port := linkData[endDeviceIndex-1]
+		portEntries, err := ReadSysfsDir("/sys/class/sas_port/" + port + "/device")
+		if err != nil {
+			break
+		}
+		portMaxLinkRate := "N/A"
+		for _, portEntry := range portEntries {
+			if !strings.HasPrefix(portEntry, "phy-") {
+				continue
+			}
+			linkRate = readAttribute("/sys/class/sas_phy/" + portEntry + "/negotiated_linkrate")
+			portMaxLinkRate = readAttribute("/sys/class/sas_phy/" + portEntry + "/maximum_linkrate")
+			break
+		}
+		// HBA/expander phy maximum rate is not the disk maximum rate, SAS disks phy capabilities are not exposed in sysfs
+		if transport == "SATA" {
+			maxLinkRate = getSataMaxLinkRate(device, "", portMaxLinkRate, "Gbit")
+		}
+	case findElement("ata") >= 0:
+		transport = "SATA"
+		ataPort := ataPortRegexp.FindStringSubmatch(linkData[findElement("ata")])
+		if len(ataPort) == 2 {
+			linkRate = readAttribute("/sys/class/ata_link/link" + ataPort[1] + "/sata_spd")
+			portMaxLinkRate := readAttribute("/sys/class/ata_link/link" + ataPort[1] + "/hw_sata_spd_limit")
+			maxLinkRate = getSataMaxLinkRate(device, ataPort[1], portMaxLinkRate, "Gbps")
+		}
+	case findElement("target") >= 0:
+		transport = "SCSI"
+	}
+	//fmt.Printf("transport: %v linkRate: %v maxLinkRate: %v\n", transport, linkRate, maxLinkRate)
+	return transport, linkRate, maxLinkRate
+}
+
+// SATA disk maximum link rate from its ATA IDENTIFY word 76: bit 1 1.5 Gbps, bit 2 3.0 Gbps, bit 3 6.0 Gbps
+// IDENTIFY data is read from ATA Information VPD page(0x89) or libata ata_device id, it is limited by port maximum rate
+func getSataMaxLinkRate(device, ataPort, portMaxLinkRate, unit string) string {
+	word76 := -1
+	// 60 bytes page header followed by 512 bytes IDENTIFY data, little endian words
+	vpdPage, err := ReadSysfsFile("/sys/class/block/" + device + "/device/vpd_pg89")
+	if err == nil && len(vpdPage) >= 60+2*76+2 {
+		word76 = int(vpdPage[60+2*76]) | int(vpdPage[60+2*76+1])<<8
+	}
+	// 256 hexadecimal words, 8 per line
+	if word76 < 0 && len(ataPort) > 0 {
+		identify, err := ReadSysfsFile("/sys/class/ata_device/dev" + ataPort + ".0/id")
+		identifyWords := strings.Fields(identify)
+		if err == nil && len(identifyWords) > 76 {
+			value, err := strconv.ParseUint(identifyWords[76], 16, 16)
+			if err == nil {
+				word76 = int(value)
+			}
+		}
+	}
+	// 0x0000 and 0xFFFF: SATA capabilities not reported
+	if word76 <= 0 || word76 == 0xFFFF {
+		return "N/A"
+	}
+	diskMaxSpeed := -1.0
+	for bit, speed := range []float64{1.5, 3.0, 6.0} {
+		if word76&(1<<(bit+1)) != 0 {
+			diskMaxSpeed = speed
+		}
+	}
+	if diskMaxSpeed < 0 {
+		return "N/A"
+	}
+	portMaxSpeed := parseLinkRate(portMaxLinkRate)
+	if portMaxSpeed > 0 && portMaxSpeed < diskMaxSpeed {
+		return portMaxLinkRate
+	}
+	return fmt.Sprintf("%.1f %s", diskMaxSpeed, unit)
+}
+
+// Parse link rate speed: 12.0 Gbit -> 12, 8.0 GT/s PCIe x4 -> 8, unknown rates are returned as -1
+func parseLinkRate(linkRate string) float64 {
+	linkRateData := strings.Fields(linkRate)
+	if len(linkRateData) == 0 {
+		return -1
+	}
+	speed, err := strconv.ParseFloat(linkRateData[0], 64)
+	if err != nil {
+		return -1
+	}
+	return speed
+}
+
+// Link is degraded when negotiated speed or PCIe width is lower than disk maximum
+func IsLinkDegraded(linkRate, maxLinkRate string) bool {
+	speed := parseLinkRate(linkRate)
+	maxSpeed := parseLinkRate(maxLinkRate)
+	if speed > 0 && maxSpeed > 0 && speed < maxSpeed {
+		return true
+	}
+	_, width, found := strings.Cut(linkRate, " x")
+	_, maxWidth, maxFound := strings.Cut(maxLinkRate, " x")
+	if found && maxFound {
+		widthInt, err := strconv.Atoi(width)
+		maxWidthInt, maxErr := strconv.Atoi(maxWidth)
+		return err == nil && maxErr == nil && widthInt < maxWidthInt
+	}
+	return false
+}
+
+// Collect transport and link rates of OS visible disks, hardware raid members OS device is the logical drive so they are skipped
+// OS visible disks interface is replaced by its detected transport
+func CollectDiskTransports(raids []RaidStruct, noRaidDisks []NoRaidDiskStruct) {
+	setTransport := func(eidSlot, osDevice string, intf, state, transport, linkRate, maxLinkRate *string) {
+		if len(eidSlot) > 0 && !strings.HasPrefix(osDevice, "JBOD-") {
+			return
+		}
+		*transport, *linkRate, *maxLinkRate = GetDiskTransport(osDevice)
+		if *transport == "Unknown" {
+			return
+		}
+		if len(eidSlot) == 0 {
+			*intf = *transport
+		}
+		if IsLinkDegraded(*linkRate, *maxLinkRate) {
+			*state = *state + "/Link-Degraded"
+		}
+	}
+	for i := range raids {
+		for j := range raids[i].Disks {
+			disk := &raids[i].Disks[j]
+			setTransport(disk.EidSlot, disk.OsDevice, &disk.Intf, &disk.State, &disk.Transport, &disk.LinkRate, &disk.MaxLinkRate)
+		}
+	}
+	for i := range noRaidDisks {
+		noRaidDisk := &noRaidDisks[i]
+		setTransport(noRaidDisk.EidSlot, noRaidDisk.OsDevice, &noRaidDisk.Intf, &noRaidDisk.State, &noRaidDisk.Transport, &noRaidDisk.LinkRate, &noRaidDisk.MaxLinkRate)
+	}
+}
+
+// Guess disk data from gopsutil serial number string: MODEL_WORDS_SERIAL
+func getDiskDataHeuristic(diskDrive string) (string, string, string, string) {
 	diskSerialNumber := "Unknown"
 	diskIntf := "Unknown"
 	diskMedium := "Unknown"
@@ -349,7 +870,28 @@ var GetDiskData = func(diskDrive string) (string, string, string, string, error)
 		}
 	}
 	//fmt.Printf("diskSerialNumber: %v diskModel: %v diskIntf: %v diskMedium: %v\n", diskSerialNumber, diskModel, diskIntf, diskMedium)
-	return diskSerialNumber, diskModel, diskIntf, diskMedium, nil
+	return diskSerialNumber, diskModel, diskIntf, diskMedium
+}
+
+// Search tool in system common binary paths
+func findSystemBinary(raidBinaryName string) (string, bool) {
+	var commonPaths = []string{
+		"/bin",
+		"/usr/bin",
+		"/sbin",
+		"/usr/sbin",
+		"/usr/local/bin",
+	}
+
+	for _, dir := range commonPaths {
+		fullPath := filepath.Join(dir, raidBinaryName)
+		//fmt.Println("Checking tool path: ", fullPath)
+		if _, err := os.Stat(fullPath); err == nil {
+			//fmt.Println("Tool found: ", fullPath)
+			return fullPath, true
+		}
+	}
+	return "", false
 }
 
 // Get binary executor depending of the manufacturer
@@ -388,6 +930,14 @@ func GetBinaryExecutor(manufacturer string, callingFunction string) (string, *me
 		raidBinaryName = "lvm"
 		raidBinary = Lvm
 		checkCommand = []string{"lvs"}
+	// Not embedded, zfs tool depends on system libzfs version
+	case "zfsdataset":
+		raidBinaryName = "zfs"
+	// Not embedded, only executed when installed in the system
+	case "sas3ircu":
+		raidBinaryName = "sas3ircu"
+	case "hpe":
+		raidBinaryName = "ssacli"
 	default:
 		return raidBinaryName, nil, fmt.Errorf("Unknown manufacturer.")
 	}
@@ -400,6 +950,18 @@ func GetBinaryExecutor(manufacturer string, callingFunction string) (string, *me
 
 	raidBinaryFile := "/tmp/hardwareAnalyzerBin"
 
+	// Tools without embedded version can only be executed from system
+	if raidBinary == nil {
+		fullPath, found := findSystemBinary(raidBinaryName)
+		if !found {
+			return raidBinaryFile, nil, fmt.Errorf("Cant find %s system binary.", raidBinaryName)
+		}
+		if err := CopySystemBinary(fullPath); err != nil {
+			return raidBinaryFile, nil, err
+		}
+		return raidBinaryFile, nil, nil
+	}
+
 	//fmt.Println("Trying memory execution")
 	// memexec requires Kernel >= 3.17 and glibc >= 2.27: syscall_319 (errno 38)
 	// If we detect previous versions, copy binary to temp directory and execute it
@@ -492,25 +1054,12 @@ func GetBinaryExecutor(manufacturer string, callingFunction string) (string, *me
 		// lvm cant be renamed, so if we copy it to /tmp/hardwareAnalyzerBin, we will get an execution error
 		if raidBinaryName != "lvm" {
 			//fmt.Println("Trying system binary execution")
-			var commonPaths = []string{
-				"/bin",
-				"/usr/bin",
-				"/sbin",
-				"/usr/sbin",
-				"/usr/local/bin",
-			}
-
-			fullPath := ""
-			for _, dir := range commonPaths {
-				fullPath = filepath.Join(dir, raidBinaryName)
-				//fmt.Println("Checking tool path: ", fullPath)
-				if _, err := os.Stat(fullPath); err == nil {
-					//fmt.Println("Tool found: ", fullPath)
-					if err := CopySystemBinary(fullPath); err != nil {
-						return raidBinaryFile, nil, err
-					}
-					return raidBinaryFile, nil, nil
+			fullPath, found := findSystemBinary(raidBinaryName)
+			if found {
+				if err := CopySystemBinary(fullPath); err != nil {
+					return raidBinaryFile, nil, err
 				}
+				return raidBinaryFile, nil, nil
 			}
 		}
 		//fmt.Println("Tool not found")
@@ -617,6 +1166,593 @@ func CheckPreviousDiskData(newRaids []RaidStruct, raids []RaidStruct) error {
 	return nil
 }
 
+// Determine if raid type provides any kind of redundancy, raid type naming depends on each controller/software
+func IsRedundantRaid(raidType string) bool {
+	raidType = strings.ToLower(raidType)
+	if strings.Contains(raidType, "mirror") || strings.Contains(raidType, "raidz") || strings.Contains(raidType, "draid") {
+		return true
+	}
+	if !strings.HasPrefix(raidType, "raid") {
+		return false
+	}
+	raidLevel := strings.TrimPrefix(raidType, "raid")
+	// LVM raid0 segments can be reported as raid0_meta
+	if len(raidLevel) == 0 || raidLevel == "0" || strings.HasPrefix(raidLevel, "0_") {
+		return false
+	}
+	return true
+}
+
+// Check if raid is covered by any available controller hot spare, global spares cover all controller raids
+func IsRaidCoveredBySpare(controller ControllerStruct, raid RaidStruct) bool {
+	for _, spare := range controller.Spares {
+		if spare.State != "Available" {
+			continue
+		}
+		if spare.Type == "Global" || slices.Contains(spare.Arrays, raid.Dg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get enclosure bay description of an EID:Slot disk
+func GetEnclosureBay(enclosures []EnclosureStruct, eidSlot string) string {
+	eidSlotData := strings.Split(eidSlot, ":")
+	if len(eidSlotData) != 2 {
+		return "Unknown"
+	}
+	eid := eidSlotData[0]
+	slot := eidSlotData[1]
+	for _, enclosure := range enclosures {
+		if enclosure.Id == eid && len(enclosure.Vendor) > 0 {
+			return "Enclosure " + eid + "(" + strings.TrimSpace(enclosure.Vendor+" "+enclosure.Model) + ") Slot " + slot
+		}
+	}
+	return "Enclosure " + eid + " Slot " + slot
+}
+
+// Map hardware controller disks to its physical bay and fill enclosures occupied slots
+func SetControllerBays(controller *ControllerStruct, raids []RaidStruct, noRaidDisks []NoRaidDiskStruct) {
+	eidSlots := []string{}
+	for i := range raids {
+		for j := range raids[i].Disks {
+			disk := &raids[i].Disks[j]
+			if disk.ControllerId == controller.Id && len(disk.EidSlot) > 0 {
+				disk.Bay = GetEnclosureBay(controller.Enclosures, disk.EidSlot)
+				eidSlots = append(eidSlots, disk.EidSlot)
+			}
+		}
+	}
+	for i := range noRaidDisks {
+		noRaidDisk := &noRaidDisks[i]
+		if noRaidDisk.ControllerId == controller.Id && len(noRaidDisk.EidSlot) > 0 {
+			noRaidDisk.Bay = GetEnclosureBay(controller.Enclosures, noRaidDisk.EidSlot)
+			eidSlots = append(eidSlots, noRaidDisk.EidSlot)
+		}
+	}
+	for i := range controller.ForeignBadDisks {
+		foreignBadDisk := &controller.ForeignBadDisks[i]
+		foreignBadDisk.Bay = GetEnclosureBay(controller.Enclosures, foreignBadDisk.EidSlot)
+		eidSlots = append(eidSlots, foreignBadDisk.EidSlot)
+	}
+	for _, spare := range controller.Spares {
+		if len(spare.EidSlot) > 0 {
+			eidSlots = append(eidSlots, spare.EidSlot)
+		}
+	}
+
+	for i := range controller.Enclosures {
+		enclosure := &controller.Enclosures[i]
+		for _, eidSlot := range eidSlots {
+			eidSlotData := strings.Split(eidSlot, ":")
+			if len(eidSlotData) == 2 && eidSlotData[0] == enclosure.Id && !slices.Contains(enclosure.OccupiedSlots, eidSlotData[1]) {
+				enclosure.OccupiedSlots = append(enclosure.OccupiedSlots, eidSlotData[1])
+			}
+		}
+	}
+}
+
+// Get OS visible enclosures from /sys/class/enclosure and map OS disks to its physical bay
+// Hardware controller disks already have its bay assigned from controller enclosure info
+func GetSysfsEnclosures(raids []RaidStruct, noRaidDisks []NoRaidDiskStruct) []EnclosureStruct {
+	enclosures := []EnclosureStruct{}
+	// Whole disk device -> bay
+	bays := make(map[string]string)
+
+	enclosureNames, err := ReadSysfsDir("/sys/class/enclosure")
+	if err != nil {
+		return enclosures
+	}
+	for _, enclosureName := range enclosureNames {
+		enclosurePath := "/sys/class/enclosure/" + enclosureName
+		enclosure := EnclosureStruct{
+			ControllerId: "os",
+			Id:           enclosureName,
+			State:        "Unknown",
+		}
+		enclosure.Vendor, _ = ReadSysfsFile(enclosurePath + "/device/vendor")
+		enclosure.Model, _ = ReadSysfsFile(enclosurePath + "/device/model")
+		enclosureDescription := strings.TrimSpace(enclosure.Vendor + " " + enclosure.Model)
+
+		componentNames, err := ReadSysfsDir(enclosurePath)
+		if err != nil {
+			continue
+		}
+		slots := 0
+		for _, componentName := range componentNames {
+			componentPath := enclosurePath + "/" + componentName
+			componentType, err := ReadSysfsFile(componentPath + "/type")
+			if err != nil {
+				continue
+			}
+			componentStatus, err := ReadSysfsFile(componentPath + "/status")
+			if err != nil {
+				componentStatus = "Unknown"
+			}
+			switch componentType {
+			case "array device", "device":
+				slots++
+				slot, err := ReadSysfsFile(componentPath + "/slot")
+				if err != nil {
+					slot = componentName
+				}
+				blockDevices, err := ReadSysfsDir(componentPath + "/device/block")
+				if err != nil || len(blockDevices) == 0 {
+					continue
+				}
+				enclosure.OccupiedSlots = append(enclosure.OccupiedSlots, slot)
+				for _, blockDevice := range blockDevices {
+					bays[blockDevice] = "Enclosure " + enclosureName + "(" + enclosureDescription + ") Slot " + slot
+				}
+			case "cooling":
+				enclosure.Fans = append(enclosure.Fans, componentStatus)
+			case "power supply":
+				enclosure.PowerSupplies = append(enclosure.PowerSupplies, componentStatus)
+			case "temperature":
+				enclosure.TemperatureSensors = append(enclosure.TemperatureSensors, componentStatus)
+			case "enclosure":
+				enclosure.State = componentStatus
+			}
+		}
+		enclosure.Slots = strconv.Itoa(slots)
+		enclosures = append(enclosures, enclosure)
+	}
+
+	// Map OS disks to its bay
+	for i := range raids {
+		for j := range raids[i].Disks {
+			disk := &raids[i].Disks[j]
+			if len(disk.Bay) == 0 && len(disk.EidSlot) == 0 && len(disk.OsDevice) > 0 {
+				if bay, ok := bays[GetParentBlockDevice(disk.OsDevice)]; ok {
+					disk.Bay = bay
+				}
+			}
+		}
+	}
+	for i := range noRaidDisks {
+		noRaidDisk := &noRaidDisks[i]
+		if len(noRaidDisk.Bay) == 0 && len(noRaidDisk.EidSlot) == 0 && len(noRaidDisk.OsDevice) > 0 {
+			if bay, ok := bays[GetParentBlockDevice(noRaidDisk.OsDevice)]; ok {
+				noRaidDisk.Bay = bay
+			}
+		}
+	}
+	return enclosures
+}
+
+// Find disk by serial number, OS device or controllerId/EID:Slot: S3Z8NB0K123456, sdb, mega-0/252:1
+// Only one disk must match, otherwise an error is returned
+func FindLocateDisk(diskQuery string, controllers []ControllerStruct, raids []RaidStruct, noRaidDisks []NoRaidDiskStruct) (LocateDiskStruct, error) {
+	candidates := []LocateDiskStruct{}
+	for _, raid := range raids {
+		for _, disk := range raid.Disks {
+			candidates = append(candidates, LocateDiskStruct{ControllerId: disk.ControllerId, EidSlot: disk.EidSlot, SerialNumber: disk.SerialNumber, OsDevice: disk.OsDevice})
+		}
+	}
+	for _, noRaidDisk := range noRaidDisks {
+		candidates = append(candidates, LocateDiskStruct{ControllerId: noRaidDisk.ControllerId, EidSlot: noRaidDisk.EidSlot, SerialNumber: noRaidDisk.SerialNumber, OsDevice: noRaidDisk.OsDevice})
+	}
+	for _, controller := range controllers {
+		for _, spare := range controller.Spares {
+			candidates = append(candidates, LocateDiskStruct{ControllerId: spare.ControllerId, EidSlot: spare.EidSlot, SerialNumber: spare.SerialNumber, OsDevice: spare.OsDevice})
+		}
+		for _, foreignBadDisk := range controller.ForeignBadDisks {
+			candidates = append(candidates, LocateDiskStruct{ControllerId: foreignBadDisk.ControllerId, EidSlot: foreignBadDisk.EidSlot, SerialNumber: foreignBadDisk.SerialNumber, OsDevice: foreignBadDisk.OsDevice})
+		}
+	}
+
+	query := strings.TrimPrefix(strings.TrimSpace(diskQuery), "/dev/")
+	matches := []LocateDiskStruct{}
+	for _, candidate := range candidates {
+		// Same disk can be reported several times: spares and noRaidDisks
+		if slices.Contains(matches, candidate) {
+			continue
+		}
+		osDevice := strings.TrimPrefix(candidate.OsDevice, "JBOD-")
+		switch {
+		case len(candidate.EidSlot) > 0 && strings.EqualFold(query, candidate.ControllerId+"/"+candidate.EidSlot):
+			matches = append(matches, candidate)
+		case len(candidate.SerialNumber) > 0 && candidate.SerialNumber != "Unknown" && strings.EqualFold(query, candidate.SerialNumber):
+			matches = append(matches, candidate)
+		case len(osDevice) > 0 && (strings.EqualFold(query, osDevice) || strings.EqualFold(query, GetParentBlockDevice(osDevice))):
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return LocateDiskStruct{}, fmt.Errorf("Error: No disk found matching: %s.", diskQuery)
+	}
+	if len(matches) > 1 {
+		return LocateDiskStruct{}, fmt.Errorf("Error: %d disks found matching: %s, use serial number or controllerId/EID:Slot.", len(matches), diskQuery)
+	}
+	return matches[0], nil
+}
+
+// Turn on/off identify LED of an OS disk using /sys/class/enclosure/*/*/locate
+func LocateSysfsDisk(osDevice string, on bool) error {
+	device := GetParentBlockDevice(strings.TrimPrefix(strings.TrimPrefix(osDevice, "JBOD-"), "/dev/"))
+	locateValue := "0"
+	if on {
+		locateValue = "1"
+	}
+
+	enclosureNames, err := ReadSysfsDir("/sys/class/enclosure")
+	if err != nil {
+		color.Red("++ ERROR: No enclosures found in /sys/class/enclosure: %v", err)
+		return fmt.Errorf("Error: No enclosures found in /sys/class/enclosure: %v.", err)
+	}
+	for _, enclosureName := range enclosureNames {
+		enclosurePath := "/sys/class/enclosure/" + enclosureName
+		componentNames, err := ReadSysfsDir(enclosurePath)
+		if err != nil {
+			continue
+		}
+		for _, componentName := range componentNames {
+			componentPath := enclosurePath + "/" + componentName
+			blockDevices, err := ReadSysfsDir(componentPath + "/device/block")
+			if err != nil || !slices.Contains(blockDevices, device) {
+				continue
+			}
+			err = WriteSysfsFile(componentPath+"/locate", locateValue)
+			if err != nil {
+				color.Red("++ ERROR: Something went wrong writing %s: %v", componentPath+"/locate", err)
+				return fmt.Errorf("Error: Something went wrong writing %s: %v.", componentPath+"/locate", err)
+			}
+			return nil
+		}
+	}
+	color.Red("++ ERROR: Disk %s not found in any enclosure.", device)
+	return fmt.Errorf("Error: Disk %s not found in any enclosure.", device)
+}
+
+// Summarize enclosure elements status: OK(2) or Critical,OK(1)
+func summarizeElementStatus(elements []string) string {
+	if len(elements) == 0 {
+		return "N/A"
+	}
+	okCounter := 0
+	badElements := []string{}
+	for _, element := range elements {
+		if element == "OK" || element == "Optimal" || element == "Normal" {
+			okCounter++
+		} else {
+			badElements = append(badElements, element)
+		}
+	}
+	if len(badElements) == 0 {
+		return "OK(" + strconv.Itoa(okCounter) + ")"
+	}
+	return strings.Join(badElements, ",") + ",OK(" + strconv.Itoa(okCounter) + ")"
+}
+
+// Show enclosure info line, degraded enclosures or elements are shown in red
+func showEnclosure(enclosure EnclosureStruct, indentation string) {
+	fans := summarizeElementStatus(enclosure.Fans)
+	powerSupplies := summarizeElementStatus(enclosure.PowerSupplies)
+	temperatureSensors := summarizeElementStatus(enclosure.TemperatureSensors)
+	enclosureDescription := strings.TrimSpace(enclosure.Vendor + " " + enclosure.Model)
+	occupiedSlots := strings.Join(enclosure.OccupiedSlots, ",")
+	if len(occupiedSlots) == 0 {
+		occupiedSlots = "None"
+	}
+	if (enclosure.State == "OK" || enclosure.State == "Optimal" || enclosure.State == "Unknown") && !strings.Contains(fans+powerSupplies+temperatureSensors, ",") {
+		color.Blue("%sEnclosure %s(%s): %s   Slots: %s   Occupied: %s   Fans: %s   PSUs: %s   Temp sensors: %s", indentation, enclosure.Id, enclosureDescription, enclosure.State, enclosure.Slots, occupiedSlots, fans, powerSupplies, temperatureSensors)
+	} else {
+		color.Red("%sEnclosure %s(%s): %s   Slots: %s   Occupied: %s   Fans: %s   PSUs: %s   Temp sensors: %s", indentation, enclosure.Id, enclosureDescription, enclosure.State, enclosure.Slots, occupiedSlots, fans, powerSupplies, temperatureSensors)
+	}
+}
+
+// Show NVMe controller transport, health log and namespaces
+func showNvmeController(controller ControllerStruct) {
+	criticalWarnings := "None"
+	if len(controller.Health.CriticalWarnings) > 0 {
+		criticalWarnings = strings.Join(controller.Health.CriticalWarnings, ", ")
+	}
+	if controller.Health.Status == "OK" {
+		color.Yellow("   Transport: %s   Critical warnings: %s   Used: %s   Spare: %s   Temperature: %s", controller.Transport, criticalWarnings, smartValue(controller.Health.WearLevel, "%"), smartValue(controller.Health.AvailableSpare, "%"), smartValue(controller.Health.Temperature, "C"))
+	} else {
+		color.Red("   Transport: %s   Critical warnings: %s   Used: %s   Spare: %s   Temperature: %s", controller.Transport, criticalWarnings, smartValue(controller.Health.WearLevel, "%"), smartValue(controller.Health.AvailableSpare, "%"), smartValue(controller.Health.Temperature, "C"))
+	}
+	for _, namespace := range controller.Namespaces {
+		color.Blue("   Namespace %s: %s   Size: %s   LBA format: %s   Paths: %s", namespace.Id, strings.ToUpper(namespace.OsDevice), namespace.Size, namespace.LbaFormat, strings.Join(namespace.Paths, ", "))
+	}
+}
+
+// Show OS visible enclosures
+func ShowEnclosures(enclosures []EnclosureStruct) {
+	if len(enclosures) == 0 {
+		return
+	}
+	fmt.Println("")
+	color.Yellow("-- OS enclosures:")
+	for _, enclosure := range enclosures {
+		showEnclosure(enclosure, "   ")
+	}
+}
+
+// Filesystem signature and its users appended to layout lines
+func filesystemInfo(filesystem FilesystemStruct) string {
+	if len(filesystem.Type) == 0 {
+		return ""
+	}
+	info := "   " + filesystem.Type
+	if len(filesystem.Label) > 0 {
+		info = info + " '" + filesystem.Label + "'"
+	}
+	if len(filesystem.Uuid) > 0 {
+		info = info + " UUID: " + filesystem.Uuid
+	}
+	if len(filesystem.Mountpoints) > 0 {
+		info = info + " => " + strings.Join(filesystem.Mountpoints, ", ")
+	}
+	if len(filesystem.Holders) > 0 {
+		info = info + "   Holders: " + strings.Join(filesystem.Holders, ", ")
+	}
+	return info
+}
+
+// Show OS disks partition tables and filesystems
+func ShowDiskLayouts(layouts []DiskLayoutStruct) {
+	if len(layouts) == 0 {
+		return
+	}
+	fmt.Println("")
+	color.Yellow("-- OS disks layout:")
+	for _, layout := range layouts {
+		table := layout.Table
+		if len(layout.TableId) > 0 {
+			table = table + " " + layout.TableId
+		}
+		color.Blue("   %s: %s   Size: %s%s", strings.ToUpper(layout.OsDevice), table, human.Bytes(layout.Size), filesystemInfo(layout.Filesystem))
+		for _, partition := range layout.Partitions {
+			partitionType := partition.TypeName
+			if len(partitionType) == 0 {
+				partitionType = partition.Type
+			}
+			if len(partition.Name) > 0 {
+				partitionType = partitionType + " '" + partition.Name + "'"
+			}
+			if partition.Aligned {
+				color.Green("      %s: %s   Start: %s   Size: %s%s", strings.ToUpper(partition.OsDevice), partitionType, human.IBytes(partition.Start), human.Bytes(partition.Size), filesystemInfo(partition.Filesystem))
+			} else {
+				color.Red("      %s: %s   Start: %s   Size: %s%s   [misaligned]", strings.ToUpper(partition.OsDevice), partitionType, human.IBytes(partition.Start), human.Bytes(partition.Size), filesystemInfo(partition.Filesystem))
+			}
+		}
+	}
+}
+
+// Used percentage as shown by df: used/(used+available), root reserved blocks are not available
+func filesystemPercent(used uint64, total uint64) string {
+	if total == 0 {
+		return "N/A"
+	}
+	return strconv.FormatUint(used*100/total, 10) + "%"
+}
+
+// Kernel log events summary: IO-Error x3 (last 2026-10-19 10:00:00), devices are shown when they are not implicit
+func kernelEventsInfo(events []KernelEventStruct, showDevice bool) string {
+	eventsInfo := []string{}
+	for _, event := range events {
+		eventInfo := event.Type
+		if (showDevice || event.Source == "ata" || strings.Contains(event.Device, ":")) && len(event.Device) > 0 {
+			eventInfo = eventInfo + "(" + event.Device + ")"
+		}
+		eventsInfo = append(eventsInfo, eventInfo+" x"+strconv.FormatInt(event.Count, 10)+" (last "+event.LastSeen+")")
+	}
+	return strings.Join(eventsInfo, ", ")
+}
+
+// Show kernel log storage events not attached to any disk or controller
+func ShowKernelEvents(events []KernelEventStruct) {
+	if len(events) == 0 {
+		return
+	}
+	fmt.Println("")
+	color.Yellow("-- Kernel log storage events of not inventoried devices:")
+	for _, event := range events {
+		color.Red("   %s %s: %s x%d (last %s)", event.Source, event.Device, event.Type, event.Count, event.LastSeen)
+	}
+}
+
+// Show mounted filesystems usage and the device they sit on
+func ShowMountedFilesystems(filesystems []MountedFilesystemStruct) {
+	if len(filesystems) == 0 {
+		return
+	}
+	fmt.Println("")
+	color.Yellow("-- Mounted filesystems:")
+	for _, filesystem := range filesystems {
+		mountpoints := filesystem.Mountpoints[0]
+		if len(filesystem.Mountpoints) > 1 {
+			mountpoints = mountpoints + " (+" + strings.Join(filesystem.Mountpoints[1:], ", ") + ")"
+		}
+		inodes := "N/A"
+		if filesystem.Inodes > 0 {
+			inodes = filesystemPercent(filesystem.Inodes-filesystem.InodesFree, filesystem.Inodes)
+		}
+		if filesystem.State == "OK" {
+			color.Green("   %s: %s   %s on %s %s   Size: %s   Used: %s (%s)   Inodes: %s   Options: %s", mountpoints, filesystem.State, filesystem.Type, strings.ToUpper(filesystem.Layer), filesystem.Device, human.Bytes(filesystem.Size), human.Bytes(filesystem.Used), filesystemPercent(filesystem.Used, filesystem.Used+filesystem.Available), inodes, filesystem.Options)
+		} else {
+			color.Red("   %s: %s   %s on %s %s   Size: %s   Used: %s (%s)   Inodes: %s   Options: %s", mountpoints, filesystem.State, filesystem.Type, strings.ToUpper(filesystem.Layer), filesystem.Device, human.Bytes(filesystem.Size), human.Bytes(filesystem.Used), filesystemPercent(filesystem.Used, filesystem.Used+filesystem.Available), inodes, filesystem.Options)
+		}
+	}
+}
+
+// SMART numeric value or N/A when unknown
+func smartValue(value int64, unit string) string {
+	if value < 0 {
+		return "N/A"
+	}
+	return strconv.FormatInt(value, 10) + unit
+}
+
+// Extra disk information appended to disk lines
+func diskExtraInfo(disk DiskStruct) string {
+	extraInfo := ""
+	if len(disk.Smart.Source) > 0 {
+		extraInfo = extraInfo + "   SMART(" + disk.Smart.Source + "): " + disk.Smart.Verdict
+		extraInfo = extraInfo + " Realloc: " + smartValue(disk.Smart.ReallocatedSectors, "")
+		extraInfo = extraInfo + " Pending: " + smartValue(disk.Smart.PendingSectors, "")
+		if disk.Smart.MediaErrors >= 0 {
+			extraInfo = extraInfo + " MediaErr: " + smartValue(disk.Smart.MediaErrors, "")
+		}
+		extraInfo = extraInfo + " Wear: " + smartValue(disk.Smart.WearLevel, "%")
+		extraInfo = extraInfo + " POH: " + smartValue(disk.Smart.PowerOnHours, "h")
+		extraInfo = extraInfo + " Temp: " + smartValue(disk.Smart.Temperature, "C")
+		if disk.Smart.AvailableSpare >= 0 {
+			extraInfo = extraInfo + " Spare: " + smartValue(disk.Smart.AvailableSpare, "%")
+		}
+		if len(disk.Smart.Warnings) > 0 {
+			extraInfo = extraInfo + " [" + strings.Join(disk.Smart.Warnings, ", ") + "]"
+		}
+	}
+	if len(disk.Bay) > 0 {
+		extraInfo = extraInfo + "   Bay: " + disk.Bay
+	}
+	if len(disk.LinkRate) > 0 && disk.LinkRate != "N/A" {
+		extraInfo = extraInfo + "   Link: " + disk.Transport + " " + disk.LinkRate + "/" + disk.MaxLinkRate
+		if IsLinkDegraded(disk.LinkRate, disk.MaxLinkRate) {
+			extraInfo = extraInfo + " [link degraded]"
+		}
+	}
+	if len(disk.IoErrors.Source) > 0 {
+		extraInfo = extraInfo + "   Errors(" + disk.IoErrors.Source + "): R:" + strconv.FormatInt(disk.IoErrors.Read, 10) + " W:" + strconv.FormatInt(disk.IoErrors.Write, 10) + " C:" + strconv.FormatInt(disk.IoErrors.Checksum, 10)
+		if disk.IoErrors.Source == "btrfs" {
+			extraInfo = extraInfo + " F:" + strconv.FormatInt(disk.IoErrors.Flush, 10) + " G:" + strconv.FormatInt(disk.IoErrors.Generation, 10)
+		}
+	}
+	if len(disk.Role) > 0 && disk.Role != "active" {
+		extraInfo = extraInfo + "   Role: " + disk.Role
+	}
+	if len(disk.KernelEvents) > 0 {
+		extraInfo = extraInfo + "   Kernel: " + kernelEventsInfo(disk.KernelEvents, false)
+	}
+	if len(disk.Paths) > 0 {
+		extraInfo = extraInfo + "   Paths(" + strconv.Itoa(len(disk.Paths)) + "): " + strings.Join(disk.Paths, " ")
+	}
+	if len(disk.Identity.Sources) > 0 {
+		if disk.Identity.Wwn != "Unknown" {
+			extraInfo = extraInfo + "   WWN: " + disk.Identity.Wwn
+		}
+		sources := []string{}
+		for _, field := range []string{"serial", "model", "intf", "medium", "revision", "wwn"} {
+			if source, ok := disk.Identity.Sources[field]; ok {
+				sources = append(sources, field+"="+source)
+			}
+		}
+		extraInfo = extraInfo + "   Identity: " + strings.Join(sources, " ")
+	}
+	return extraInfo
+}
+
+// Apply Thresholds to SMART data: Status and Warnings are filled
+func EvaluateSmart(smart *SmartStruct) {
+	smart.Warnings = nil
+	if smart.ReallocatedSectors > Thresholds.SmartReallocatedSectors {
+		smart.Warnings = append(smart.Warnings, "reallocated sectors "+strconv.FormatInt(smart.ReallocatedSectors, 10))
+	}
+	if smart.PendingSectors > Thresholds.SmartPendingSectors {
+		smart.Warnings = append(smart.Warnings, "pending sectors "+strconv.FormatInt(smart.PendingSectors, 10))
+	}
+	if smart.MediaErrors > Thresholds.SmartMediaErrors {
+		smart.Warnings = append(smart.Warnings, "media errors "+strconv.FormatInt(smart.MediaErrors, 10))
+	}
+	if smart.WearLevel >= Thresholds.SmartWearLevel {
+		smart.Warnings = append(smart.Warnings, "wear level "+strconv.FormatInt(smart.WearLevel, 10)+"%")
+	}
+	if smart.Temperature >= Thresholds.SmartTemperature {
+		smart.Warnings = append(smart.Warnings, "temperature "+strconv.FormatInt(smart.Temperature, 10)+"C")
+	}
+	switch {
+	case smart.Verdict == "FAILED":
+		smart.Status = "Failed"
+	case len(smart.Warnings) > 0:
+		smart.Status = "Warning"
+	default:
+		smart.Status = "OK"
+	}
+}
+
+// Extra raid health information appended to raid lines: sync progress, cache and thin pool usage
+func raidDetails(raid RaidStruct) string {
+	if len(raid.Details) == 0 {
+		return ""
+	}
+	return "   [" + strings.Join(raid.Details, ", ") + "]"
+}
+
+func poolDetails(pool PoolStruct) string {
+	if len(pool.Details) == 0 {
+		return ""
+	}
+	return "   [" + strings.Join(pool.Details, ", ") + "]"
+}
+
+// Pool state can be flagged even when its vdevs are healthy: ONLINE/Scrub-Overdue
+func showZfsPool(pool PoolStruct) {
+	if pool.State == "ONLINE" {
+		color.Blue("   Pool: %s  %s - %s  => %s%s", pool.Name, pool.State, pool.Size, pool.OsDevice, poolDetails(pool))
+	} else {
+		color.Red("   Pool: %s  %s - %s  => %s%s", pool.Name, pool.State, pool.Size, pool.OsDevice, poolDetails(pool))
+	}
+	if len(pool.Status) > 0 {
+		color.Yellow("     Status: %s", pool.Status)
+	}
+	for _, dataset := range pool.Datasets {
+		if dataset.Type == "volume" {
+			consumers := ""
+			if len(dataset.Consumers) > 0 {
+				consumers = "   Consumers: " + strings.Join(dataset.Consumers, ", ")
+			}
+			color.Blue("     Zvol: %s   Size: %s   Used: %s   Refer: %s   Reserv: %s   Compress: %s   Encryption: %s   => %s%s", dataset.Name, dataset.Size, dataset.Used, dataset.Referenced, dataset.Reservation, dataset.CompressRatio, dataset.Encryption, strings.ToUpper(dataset.OsDevice), consumers)
+		} else {
+			color.Blue("     Dataset: %s   Used: %s   Avail: %s   Refer: %s   Quota: %s   Reserv: %s   Compress: %s   Encryption: %s   => %s", dataset.Name, dataset.Used, dataset.Available, dataset.Referenced, dataset.Quota, dataset.Reservation, dataset.CompressRatio, dataset.Encryption, dataset.Mountpoint)
+		}
+	}
+}
+
+func noRaidDiskExtraInfo(noRaidDisk NoRaidDiskStruct) string {
+	disk := DiskStruct{
+		ControllerId: noRaidDisk.ControllerId,
+		EidSlot:      noRaidDisk.EidSlot,
+		State:        noRaidDisk.State,
+		Size:         noRaidDisk.Size,
+		Intf:         noRaidDisk.Intf,
+		Medium:       noRaidDisk.Medium,
+		Model:        noRaidDisk.Model,
+		SerialNumber: noRaidDisk.SerialNumber,
+		OsDevice:     noRaidDisk.OsDevice,
+		Bay:          noRaidDisk.Bay,
+		Smart:        noRaidDisk.Smart,
+		Identity:     noRaidDisk.Identity,
+		Transport:    noRaidDisk.Transport,
+		LinkRate:     noRaidDisk.LinkRate,
+		MaxLinkRate:  noRaidDisk.MaxLinkRate,
+		KernelEvents: noRaidDisk.KernelEvents,
+	}
+	return diskExtraInfo(disk)
+}
+
 func ShowGatheredData(controllers []ControllerStruct, pools []PoolStruct, volumeGroups []VolumeGroupStruct, raids []RaidStruct, noRaidDisks []NoRaidDiskStruct) error {
 	//Show gathered data
 	// fmt.Println("-- showGatheredData --")
@@ -663,6 +1799,30 @@ func ShowGatheredData(controllers []ControllerStruct, pools []PoolStruct, volume
 			} else {
 				color.Red("-- ControllerID: %s - %s: %s", controller.Id, controller.Model, controller.Status)
 			}
+			// Hardware controllers inventory data
+			if len(controller.FirmwareVersion) > 0 || len(controller.DriverName) > 0 {
+				color.Yellow("   Firmware: %s   BIOS: %s   Driver: %s %s   PCI: %s   SN: %s   ROC temperature: %s", controller.FirmwareVersion, controller.BiosVersion, controller.DriverName, controller.DriverVersion, controller.PciAddress, controller.SerialNumber, controller.RocTemperature)
+			}
+			if len(controller.CacheStatus) > 0 {
+				batteryStatus := controller.BatteryStatus
+				if len(batteryStatus) == 0 {
+					batteryStatus = "None"
+				}
+				if controller.CacheStatus == "OK" && (batteryStatus == "OK" || batteryStatus == "None") {
+					color.Yellow("   Cache: %s   Battery: %s", controller.CacheStatus, batteryStatus)
+				} else {
+					color.Red("   Cache: %s   Battery: %s", controller.CacheStatus, batteryStatus)
+				}
+			}
+			if len(controller.KernelEvents) > 0 {
+				color.Red("   Kernel events: %s", kernelEventsInfo(controller.KernelEvents, true))
+			}
+			for _, enclosure := range controller.Enclosures {
+				showEnclosure(enclosure, "   ")
+			}
+			if controller.Manufacturer == "nvme" {
+				showNvmeController(controller)
+			}
 
 			// Show raids and disks
 			zfsPoolListOfShownPools := []string{}
@@ -682,22 +1842,22 @@ func ShowGatheredData(controllers []ControllerStruct, pools []PoolStruct, volume
 					if raid.State == "Okay(OKY)" || raid.State == "Okay" || raid.State == "Optl" || raid.State == "Optimal" || raid.State == "Good" || raid.State == "ONLINE" || raid.State == "available" {
 						switch controller.Manufacturer {
 						case "mdadm":
-							color.Blue("   %s%s: %s   Size: %s   => %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.OsDevice))
+							color.Blue("   %s%s: %s   Size: %s   => %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.OsDevice), raidDetails(raid))
 						case "zfs":
 							// Show pool info
 							for _, pool := range pools {
 								if !slices.Contains(zfsPoolListOfShownPools, pool.Name) {
 									if raid.Dg == pool.Name {
-										color.Blue("   Pool: %s  %s - %s  => %s", pool.Name, pool.State, pool.Size, pool.OsDevice)
+										showZfsPool(pool)
 										zfsPoolListOfShownPools = append(zfsPoolListOfShownPools, pool.Name)
 										break
 									}
 								}
 							}
 							// Show vdev info
-							color.Blue("     %s%s: %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State)
+							color.Blue("     %s%s: %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raidDetails(raid))
 						case "btrfs":
-							color.Blue("   %s%s: %s   Size: %s   => %s - %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.Dg), strings.ToUpper(raid.OsDevice))
+							color.Blue("   %s%s: %s   Size: %s   => %s - %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.Dg), strings.ToUpper(raid.OsDevice), raidDetails(raid))
 						case "lvm":
 							// Show volumeGroup info
 							for _, volumeGroup := range volumeGroups {
@@ -709,9 +1869,9 @@ func ShowGatheredData(controllers []ControllerStruct, pools []PoolStruct, volume
 										// LVM disks are part of the VG not RAID as usually, so we show disks when VG is shown
 										for _, disk := range raid.Disks {
 											if (disk.State == "Optimal(OPT)" || disk.State == "Onln" || disk.State == "Online" || disk.State == "Good" || disk.State == "ONLINE") && disk.OsDevice != "[UNKNOWN]" {
-												color.Green("       %s%s   Size: %s   Model: %s - %s/%s -> SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+												color.Green("       %s%s   Size: %s   Model: %s - %s/%s -> SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 											} else {
-												color.Red("       %s%s   Size: %s   Model: %s - %s/%s -> SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+												color.Red("       %s%s   Size: %s   Model: %s - %s/%s -> SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 											}
 										}
 										shownLvmsHeader = false
@@ -723,39 +1883,41 @@ func ShowGatheredData(controllers []ControllerStruct, pools []PoolStruct, volume
 								shownLvmsHeader = true
 							}
 							if raid.OsDevice == "NONE" {
-								color.Blue("        %s%s: %s   Size: %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), strings.ToUpper(raid.State), raid.Size)
+								color.Blue("        %s%s: %s   Size: %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), strings.ToUpper(raid.State), raid.Size, raidDetails(raid))
 							} else {
-								color.Blue("        %s%s: %s   Size: %s   => %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), strings.ToUpper(raid.State), raid.Size, strings.ToUpper(raid.OsDevice))
+								color.Blue("        %s%s: %s   Size: %s   => %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), strings.ToUpper(raid.State), raid.Size, strings.ToUpper(raid.OsDevice), raidDetails(raid))
 							}
-						case "motherboard":
+						case "devicemapper":
+							color.Blue("   %s%s: %s   Size: %s   => %s(%s)\n", raidLevelTabs, raid.RaidType, raid.State, raid.Size, raid.Dg, raid.OsDevice)
+						case "motherboard", "nvme":
 							// NOOP
 						// HW Raid
 						default:
 							if raid.RaidLevel > 0 {
 								color.Blue("   %s%s: %s   Size: %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size)
 							} else {
-								color.Blue("   %s%s: %s   Size: %s   => %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.OsDevice))
+								color.Blue("   %s%s: %s   Size: %s   => %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.OsDevice), raidDetails(raid))
 							}
 						}
 					} else {
 						switch controller.Manufacturer {
 						case "mdadm":
-							color.Red("   %s%s: %s   Size: %s   => %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.OsDevice))
+							color.Red("   %s%s: %s   Size: %s   => %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.OsDevice), raidDetails(raid))
 						case "zfs":
 							// Show pool info
 							for _, pool := range pools {
 								if !slices.Contains(zfsPoolListOfShownPools, pool.Name) {
 									if raid.Dg == pool.Name {
-										color.Red("   Pool: %s  %s - %s  => %s", pool.Name, pool.State, pool.Size, pool.OsDevice)
+										showZfsPool(pool)
 										zfsPoolListOfShownPools = append(zfsPoolListOfShownPools, pool.Name)
 										break
 									}
 								}
 							}
 							// Show vdev info
-							color.Red("     %s%s: %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State)
+							color.Red("     %s%s: %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raidDetails(raid))
 						case "btrfs":
-							color.Red("   %s%s: %s   Size: %s   => %s - %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.Dg), strings.ToUpper(raid.OsDevice))
+							color.Red("   %s%s: %s   Size: %s   => %s - %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.Dg), strings.ToUpper(raid.OsDevice), raidDetails(raid))
 						case "lvm":
 							// Show volumeGroup info
 							for _, volumeGroup := range volumeGroups {
@@ -767,9 +1929,9 @@ func ShowGatheredData(controllers []ControllerStruct, pools []PoolStruct, volume
 										// LVM disks are part of the VG not RAID as usually, so we show disks when VG is shown
 										for _, disk := range raid.Disks {
 											if (disk.State == "Optimal(OPT)" || disk.State == "Onln" || disk.State == "Online" || disk.State == "Good" || disk.State == "ONLINE") && disk.OsDevice != "[unknown]" {
-												color.Green("       %s%s   Size: %s   Model: %s - %s/%s -> SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+												color.Green("       %s%s   Size: %s   Model: %s - %s/%s -> SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 											} else {
-												color.Red("       %s%s   Size: %s   Model: %s - %s/%s -> SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+												color.Red("       %s%s   Size: %s   Model: %s - %s/%s -> SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 											}
 										}
 										shownLvmsHeader = false
@@ -781,18 +1943,20 @@ func ShowGatheredData(controllers []ControllerStruct, pools []PoolStruct, volume
 								shownLvmsHeader = true
 							}
 							if raid.OsDevice == "NONE" {
-								color.Red("        %s%s: %s   Size: %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), strings.ToUpper(raid.State), raid.Size)
+								color.Red("        %s%s: %s   Size: %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), strings.ToUpper(raid.State), raid.Size, raidDetails(raid))
 							} else {
-								color.Red("        %s%s: %s   Size: %s   => %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), strings.ToUpper(raid.State), raid.Size, strings.ToUpper(raid.OsDevice))
+								color.Red("        %s%s: %s   Size: %s   => %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), strings.ToUpper(raid.State), raid.Size, strings.ToUpper(raid.OsDevice), raidDetails(raid))
 							}
-						case "motherboard":
+						case "devicemapper":
+							color.Red("   %s%s: %s   Size: %s   => %s(%s)\n", raidLevelTabs, raid.RaidType, raid.State, raid.Size, raid.Dg, raid.OsDevice)
+						case "motherboard", "nvme":
 							// NOOP
 						// HW Raid
 						default:
 							if raid.RaidLevel > 0 {
 								color.Red("   %s%s: %s   Size: %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size)
 							} else {
-								color.Red("   %s%s: %s   Size: %s   => %s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.OsDevice))
+								color.Red("   %s%s: %s   Size: %s   => %s%s\n", raidLevelTabs, strings.ToUpper(raid.RaidType), raid.State, raid.Size, strings.ToUpper(raid.OsDevice), raidDetails(raid))
 							}
 						}
 					}
@@ -801,60 +1965,65 @@ func ShowGatheredData(controllers []ControllerStruct, pools []PoolStruct, volume
 						if disk.State == "Optimal(OPT)" || disk.State == "Onln" || disk.State == "Online" || disk.State == "Good" || disk.State == "ONLINE" {
 							switch controller.Manufacturer {
 							case "mega":
-								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber)
+								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, diskExtraInfo(disk))
 							case "perc":
-								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber)
-							case "sas2ircu":
-								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber)
+								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, diskExtraInfo(disk))
+							case "sas2ircu", "sas3ircu", "mpt3sas", "hpe":
+								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, diskExtraInfo(disk))
 							case "adaptec":
-								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber)
+								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, diskExtraInfo(disk))
 							case "mdadm":
 								// Bogus disk
 								if disk.Size == "Unknown" && disk.Model == "Unknown" && disk.Intf == "Unknown" && disk.Medium == "Unknown" && disk.SerialNumber == "Unknown" {
-									color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s Disk seems to be bogus.\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+									color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s Disk seems to be bogus.%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 								} else {
-									color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+									color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 								}
 							case "zfs":
-								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 							case "btrfs":
-								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 							case "lvm":
 								// Disks show in raid check due to disks owning to VG not LVMs
 								//color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
 								break
 							case "motherboard":
-								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 							default:
-								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+								color.Green("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 							}
 						} else {
 							switch controller.Manufacturer {
 							case "mega":
-								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber)
+								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, diskExtraInfo(disk))
 							case "perc":
-								color.Red("       %s%s   Size: %s   Model: %s - %s/%s  - SN: %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber)
-							case "sas2ircu":
-								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber)
+								color.Red("       %s%s   Size: %s   Model: %s - %s/%s  - SN: %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, diskExtraInfo(disk))
+							case "sas2ircu", "sas3ircu", "mpt3sas", "hpe":
+								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, diskExtraInfo(disk))
 							case "adaptec":
-								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber)
+								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, diskExtraInfo(disk))
 							case "mdadm":
-								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 							case "zfs":
-								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 							case "btrfs":
-								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 							case "lvm":
 								// Disks show in raid check due to disks owning to VG not LVMs
 								//color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
 								break
 							case "motherboard":
-								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 							default:
-								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice))
+								color.Red("       %s%s   Size: %s   Model: %s - %s/%s - SN: %s => %s%s\n", raidLevelTabs, disk.State, disk.Size, disk.Model, disk.Intf, disk.Medium, disk.SerialNumber, strings.ToUpper(disk.OsDevice), diskExtraInfo(disk))
 							}
 						}
 					}
+
+					// Redundant arrays without any available hot spare covering them
+					if raid.RaidLevel == 0 && controller.Manufacturer != "btrfs" && controller.Manufacturer != "motherboard" && IsRedundantRaid(raid.RaidType) && !IsRaidCoveredBySpare(controller, raid) {
+						color.Yellow("       %sNo hot spare coverage.\n", raidLevelTabs)
+					}
 				}
 			}
 
@@ -872,10 +2041,41 @@ func ShowGatheredData(controllers []ControllerStruct, pools []PoolStruct, volume
 			if noRaidDisksFound {
 				color.Blue("   NO-RAID disks:")
 				for _, noRaidDisk := range noRaidDisks {
-					if noRaidDisk.State == "Optimal (OPT)" || noRaidDisk.State == "Ready(RDY)" || noRaidDisk.State == "UGood" || noRaidDisk.State == "JBOD" {
-						color.Green("       %s   Size: %s   Model: %s - %s/%s -> SN: %s => %s\n", noRaidDisk.State, noRaidDisk.Size, noRaidDisk.Model, noRaidDisk.Intf, noRaidDisk.Medium, noRaidDisk.SerialNumber, strings.ToUpper(noRaidDisk.OsDevice))
+					if noRaidDisk.State == "Optimal (OPT)" || noRaidDisk.State == "Ready(RDY)" || noRaidDisk.State == "UGood" || noRaidDisk.State == "JBOD" || noRaidDisk.State == "IR-Member" {
+						color.Green("       %s   Size: %s   Model: %s - %s/%s -> SN: %s => %s%s\n", noRaidDisk.State, noRaidDisk.Size, noRaidDisk.Model, noRaidDisk.Intf, noRaidDisk.Medium, noRaidDisk.SerialNumber, strings.ToUpper(noRaidDisk.OsDevice), noRaidDiskExtraInfo(noRaidDisk))
 					} else {
-						color.Red("       %s   Size: %s   Model: %s - %s/%s -> SN: %s => %s\n", noRaidDisk.State, noRaidDisk.Size, noRaidDisk.Model, noRaidDisk.Intf, noRaidDisk.Medium, noRaidDisk.SerialNumber, strings.ToUpper(noRaidDisk.OsDevice))
+						color.Red("       %s   Size: %s   Model: %s - %s/%s -> SN: %s => %s%s\n", noRaidDisk.State, noRaidDisk.Size, noRaidDisk.Model, noRaidDisk.Intf, noRaidDisk.Medium, noRaidDisk.SerialNumber, strings.ToUpper(noRaidDisk.OsDevice), noRaidDiskExtraInfo(noRaidDisk))
+					}
+				}
+			}
+
+			// Show foreign and unconfigured bad disks
+			if len(controller.ForeignBadDisks) > 0 {
+				color.Red("   Foreign/Unconfigured bad disks:")
+				for _, foreignBadDisk := range controller.ForeignBadDisks {
+					color.Red("       %s   Size: %s   Model: %s - %s/%s -> SN: %s => %s%s\n", foreignBadDisk.State, foreignBadDisk.Size, foreignBadDisk.Model, foreignBadDisk.Intf, foreignBadDisk.Medium, foreignBadDisk.SerialNumber, foreignBadDisk.EidSlot, noRaidDiskExtraInfo(foreignBadDisk))
+				}
+			}
+
+			// Show hot spares
+			if len(controller.Spares) > 0 {
+				color.Blue("   Hot spares:")
+				for _, spare := range controller.Spares {
+					spareType := spare.Type
+					if len(spare.Arrays) > 0 {
+						spareType = spareType + "(" + strings.Join(spare.Arrays, ",") + ")"
+					}
+					spareLocation := strings.ToUpper(spare.OsDevice)
+					if len(spare.EidSlot) > 0 {
+						spareLocation = spare.EidSlot
+					}
+					switch spare.State {
+					case "Available":
+						color.Green("       %s %s   Size: %s   Model: %s - %s/%s -> SN: %s => %s\n", spareType, spare.State, spare.Size, spare.Model, spare.Intf, spare.Medium, spare.SerialNumber, spareLocation)
+					case "InUse":
+						color.Yellow("       %s %s   Size: %s   Model: %s - %s/%s -> SN: %s => %s\n", spareType, spare.State, spare.Size, spare.Model, spare.Intf, spare.Medium, spare.SerialNumber, spareLocation)
+					default:
+						color.Red("       %s %s   Size: %s   Model: %s - %s/%s -> SN: %s => %s\n", spareType, spare.State, spare.Size, spare.Model, spare.Intf, spare.Medium, spare.SerialNumber, spareLocation)
 					}
 				}
 			}
@@ -4,9 +4,11 @@ import (
 	"bufio"
 	"bytes"
 	_ "embed"
+	"errors"
 	"io"
 	"os"
 	"os/user"
+	"reflect"
 	"regexp"
 	"runtime"
 	"strings"
@@ -1358,3 +1360,667 @@ func TestShowGatheredDataNoRaidDisk(t *testing.T) {
 		}
 	}
 }
+
+// Test IsRedundantRaid
+func TestIsRedundantRaid(t *testing.T) {
+	raidTypes := map[string]bool{
+		"RAID1":           true,
+		"RAID10":          true,
+		"raid5_ls":        true,
+		"raidz2":          true,
+		"mirror":          true,
+		"draid2:4d:1s:8c": true,
+		"RAID0":           false,
+		"raid0_meta":      false,
+		"linear":          false,
+		"STRIPE":          false,
+	}
+	for raidType, redundantWanted := range raidTypes {
+		if IsRedundantRaid(raidType) != redundantWanted {
+			t.Fatalf(`TestIsRedundantRaid %v: %v != redundantWanted: %v`, raidType, !redundantWanted, redundantWanted)
+		}
+	}
+}
+
+// Test IsRaidCoveredBySpare
+func TestIsRaidCoveredBySpare(t *testing.T) {
+	raid := RaidStruct{
+		ControllerId: "mega-0",
+		Dg:           "1",
+		RaidType:     "RAID1",
+	}
+
+	controller := ControllerStruct{
+		Id: "mega-0",
+	}
+	if IsRaidCoveredBySpare(controller, raid) {
+		t.Fatalf(`TestIsRaidCoveredBySpare controller without spares must not cover raid`)
+	}
+
+	controller.AddSpare(SpareStruct{ControllerId: "mega-0", Type: "Dedicated", Arrays: []string{"0"}, State: "Available"})
+	if IsRaidCoveredBySpare(controller, raid) {
+		t.Fatalf(`TestIsRaidCoveredBySpare spare dedicated to other DG must not cover raid`)
+	}
+
+	controller.AddSpare(SpareStruct{ControllerId: "mega-0", Type: "Global", State: "InUse"})
+	if IsRaidCoveredBySpare(controller, raid) {
+		t.Fatalf(`TestIsRaidCoveredBySpare in use spare must not cover raid`)
+	}
+
+	controller.AddSpare(SpareStruct{ControllerId: "mega-0", Type: "Dedicated", Arrays: []string{"0", "1"}, State: "Available"})
+	if !IsRaidCoveredBySpare(controller, raid) {
+		t.Fatalf(`TestIsRaidCoveredBySpare spare dedicated to raid DG must cover raid`)
+	}
+}
+
+// Test GetKernelModuleVersion
+func TestGetKernelModuleVersion(t *testing.T) {
+	// Copy original functions content
+	readSysfsFileOri := ReadSysfsFile
+	// unmock functions content
+	defer func() {
+		ReadSysfsFile = readSysfsFileOri
+	}()
+
+	// Mocked function: mpt3sas loaded without version file, smartpqi loaded with version file
+	ReadSysfsFile = func(path string) (string, error) {
+		switch path {
+		case "/sys/module/mpt3sas/initstate":
+			return "live", nil
+		case "/sys/module/smartpqi/version":
+			return "2.1.22-040", nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+
+	driverName, driverVersion := GetKernelModuleVersion("mpt2sas", "mpt3sas")
+	if driverName != "mpt3sas" || driverVersion != "Unknown" {
+		t.Fatalf(`TestGetKernelModuleVersion: %v %v != mpt3sas Unknown`, driverName, driverVersion)
+	}
+
+	driverName, driverVersion = GetKernelModuleVersion("smartpqi", "aacraid")
+	if driverName != "smartpqi" || driverVersion != "2.1.22-040" {
+		t.Fatalf(`TestGetKernelModuleVersion: %v %v != smartpqi 2.1.22-040`, driverName, driverVersion)
+	}
+
+	driverName, driverVersion = GetKernelModuleVersion("megaraid_sas")
+	if driverName != "Unknown" || driverVersion != "Unknown" {
+		t.Fatalf(`TestGetKernelModuleVersion: %v %v != Unknown Unknown`, driverName, driverVersion)
+	}
+}
+
+// Test GetPciDriver
+func TestGetPciDriver(t *testing.T) {
+	// Copy original functions content
+	readSysfsLinkOri := ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked function: aacraid bound to 0000:03:00.0, smartpqi bound to 0001:5c:00.0
+	ReadSysfsLink = func(path string) (string, error) {
+		switch path {
+		case "/sys/bus/pci/devices/0000:03:00.0/driver":
+			return "../../../../bus/pci/drivers/aacraid", nil
+		case "/sys/bus/pci/devices/0001:5c:00.0/driver":
+			return "../../../../bus/pci/drivers/smartpqi", nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+
+	pciDrivers := map[string]string{
+		"0:3:0:0":      "aacraid",
+		"0000:03:00.0": "aacraid",
+		"1:5c:0:0":     "smartpqi",
+		"0000:04:00.0": "Unknown",
+		"Unknown":      "Unknown",
+	}
+	for address, driverWanted := range pciDrivers {
+		driver := GetPciDriver(address)
+		if driver != driverWanted {
+			t.Fatalf(`TestGetPciDriver: %v driver: %v should be: %v`, address, driver, driverWanted)
+		}
+	}
+}
+
+// Test PciFunction
+func TestPciFunction(t *testing.T) {
+	pciFunctions := map[string]string{
+		"0000:3b:00.0": "59:0:0",
+		"00:3b:00:00":  "59:0:0",
+		"0000:af:1f.7": "175:31:7",
+		"Unknown":      "",
+		"":             "",
+	}
+	for address, pciFunctionWanted := range pciFunctions {
+		pciFunction := PciFunction(address)
+		if pciFunction != pciFunctionWanted {
+			t.Fatalf(`TestPciFunction %s: %v should be: %v`, address, pciFunction, pciFunctionWanted)
+		}
+	}
+}
+
+// Test SetControllerBays
+func TestSetControllerBays(t *testing.T) {
+	controller := ControllerStruct{
+		Id:           "mega-0",
+		Manufacturer: "mega",
+		Enclosures: []EnclosureStruct{
+			{ControllerId: "mega-0", Id: "252", Vendor: "LSI", Model: "SGPIO", State: "OK", Slots: "8"},
+		},
+		Spares: []SpareStruct{
+			{ControllerId: "mega-0", Type: "Global", State: "Available", EidSlot: "252:3"},
+		},
+	}
+	raids := []RaidStruct{
+		{
+			ControllerId: "mega-0",
+			Disks: []DiskStruct{
+				{ControllerId: "mega-0", EidSlot: "252:0"},
+				{ControllerId: "mega-0", EidSlot: "252:1"},
+			},
+		},
+	}
+	noRaidDisks := []NoRaidDiskStruct{
+		{ControllerId: "mega-0", EidSlot: "8:2"},
+		{ControllerId: "mega-1", EidSlot: "252:4"},
+	}
+
+	SetControllerBays(&controller, raids, noRaidDisks)
+
+	bayWanted := "Enclosure 252(LSI SGPIO) Slot 1"
+	if raids[0].Disks[1].Bay != bayWanted {
+		t.Fatalf(`TestSetControllerBays disk bay: %v should be: %v`, raids[0].Disks[1].Bay, bayWanted)
+	}
+	// Enclosure without inventory data
+	bayWanted = "Enclosure 8 Slot 2"
+	if noRaidDisks[0].Bay != bayWanted {
+		t.Fatalf(`TestSetControllerBays noRaidDisk bay: %v should be: %v`, noRaidDisks[0].Bay, bayWanted)
+	}
+	// Other controller disk
+	if noRaidDisks[1].Bay != "" {
+		t.Fatalf(`TestSetControllerBays other controller noRaidDisk bay: %v should be empty`, noRaidDisks[1].Bay)
+	}
+	occupiedSlotsWanted := []string{"0", "1", "3"}
+	if !reflect.DeepEqual(controller.Enclosures[0].OccupiedSlots, occupiedSlotsWanted) {
+		t.Fatalf(`TestSetControllerBays occupiedSlots: %v should be: %v`, controller.Enclosures[0].OccupiedSlots, occupiedSlotsWanted)
+	}
+}
+
+// Test GetSysfsEnclosures
+func TestGetSysfsEnclosures(t *testing.T) {
+	// Copy original functions content
+	readSysfsFileOri := ReadSysfsFile
+	readSysfsDirOri := ReadSysfsDir
+	readSysfsLinkOri := ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		ReadSysfsFile = readSysfsFileOri
+		ReadSysfsDir = readSysfsDirOri
+		ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked functions: One enclosure with two slots, one fan, one failed PSU
+	sysfsFiles := map[string]string{
+		"/sys/class/enclosure/0:0:4:0/device/vendor": "SMC",
+		"/sys/class/enclosure/0:0:4:0/device/model":  "SC846-P",
+		"/sys/class/enclosure/0:0:4:0/Slot00/type":   "array device",
+		"/sys/class/enclosure/0:0:4:0/Slot00/slot":   "0",
+		"/sys/class/enclosure/0:0:4:0/Slot00/status": "OK",
+		"/sys/class/enclosure/0:0:4:0/Slot01/type":   "array device",
+		"/sys/class/enclosure/0:0:4:0/Slot01/slot":   "1",
+		"/sys/class/enclosure/0:0:4:0/Slot01/status": "Not Installed",
+		"/sys/class/enclosure/0:0:4:0/Fan0/type":     "cooling",
+		"/sys/class/enclosure/0:0:4:0/Fan0/status":   "OK",
+		"/sys/class/enclosure/0:0:4:0/PSU0/type":     "power supply",
+		"/sys/class/enclosure/0:0:4:0/PSU0/status":   "Critical",
+	}
+	sysfsDirs := map[string][]string{
+		"/sys/class/enclosure":                             {"0:0:4:0"},
+		"/sys/class/enclosure/0:0:4:0":                     {"Slot00", "Slot01", "Fan0", "PSU0", "device"},
+		"/sys/class/enclosure/0:0:4:0/Slot00/device/block": {"sdb"},
+	}
+	ReadSysfsFile = func(path string) (string, error) {
+		if value, ok := sysfsFiles[path]; ok {
+			return value, nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	ReadSysfsDir = func(path string) ([]string, error) {
+		if entries, ok := sysfsDirs[path]; ok {
+			return entries, nil
+		}
+		return nil, errors.New("No such file or directory")
+	}
+	ReadSysfsLink = func(path string) (string, error) {
+		if path == "/sys/class/block/sdb1" {
+			return "../../devices/pci0000:00/0000:00:17.0/ata2/host1/target1:0:0/1:0:0:0/block/sdb/sdb1", nil
+		}
+		return "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda", nil
+	}
+
+	raids := []RaidStruct{
+		{
+			ControllerId: "softraid-0",
+			Disks: []DiskStruct{
+				{ControllerId: "softraid-0", OsDevice: "sdb1"},
+				{ControllerId: "softraid-0", OsDevice: "sda1"},
+			},
+		},
+	}
+	noRaidDisks := []NoRaidDiskStruct{}
+
+	enclosures := GetSysfsEnclosures(raids, noRaidDisks)
+	enclosuresWanted := []EnclosureStruct{
+		{
+			ControllerId:  "os",
+			Id:            "0:0:4:0",
+			Vendor:        "SMC",
+			Model:         "SC846-P",
+			State:         "Unknown",
+			Slots:         "2",
+			OccupiedSlots: []string{"0"},
+			Fans:          []string{"OK"},
+			PowerSupplies: []string{"Critical"},
+		},
+	}
+	if !reflect.DeepEqual(enclosures, enclosuresWanted) {
+		t.Fatalf(`TestGetSysfsEnclosures enclosures: %v should be: %v`, enclosures, enclosuresWanted)
+	}
+	bayWanted := "Enclosure 0:0:4:0(SMC SC846-P) Slot 0"
+	if raids[0].Disks[0].Bay != bayWanted {
+		t.Fatalf(`TestGetSysfsEnclosures disk bay: %v should be: %v`, raids[0].Disks[0].Bay, bayWanted)
+	}
+	if raids[0].Disks[1].Bay != "" {
+		t.Fatalf(`TestGetSysfsEnclosures disk bay: %v should be empty`, raids[0].Disks[1].Bay)
+	}
+}
+
+// Test FindLocateDisk
+func TestFindLocateDisk(t *testing.T) {
+	// Copy original functions content
+	readSysfsLinkOri := ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked function: whole disk devices
+	ReadSysfsLink = func(path string) (string, error) {
+		device := strings.TrimPrefix(path, "/sys/class/block/")
+		return "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/" + device, nil
+	}
+
+	controllers := []ControllerStruct{
+		{
+			Id:           "mega-0",
+			Manufacturer: "mega",
+			Spares: []SpareStruct{
+				{ControllerId: "mega-0", EidSlot: "252:3", SerialNumber: "SPARE01", OsDevice: "JBOD-sdd"},
+			},
+		},
+	}
+	raids := []RaidStruct{
+		{
+			ControllerId: "mega-0",
+			Disks: []DiskStruct{
+				{ControllerId: "mega-0", EidSlot: "252:0", SerialNumber: "DISK00", OsDevice: "sda"},
+				{ControllerId: "mega-0", EidSlot: "252:1", SerialNumber: "DISK01", OsDevice: "sda"},
+			},
+		},
+	}
+	noRaidDisks := []NoRaidDiskStruct{
+		{ControllerId: "motherBoard-0", SerialNumber: "JBOD02", OsDevice: "sdc"},
+	}
+
+	disk, err := FindLocateDisk("disk01", controllers, raids, noRaidDisks)
+	if err != nil || disk.EidSlot != "252:1" {
+		t.Fatalf(`TestFindLocateDisk serial number: %v %v should be: 252:1`, disk.EidSlot, err)
+	}
+	disk, err = FindLocateDisk("mega-0/252:3", controllers, raids, noRaidDisks)
+	if err != nil || disk.SerialNumber != "SPARE01" {
+		t.Fatalf(`TestFindLocateDisk controllerId/EID:Slot: %v %v should be: SPARE01`, disk.SerialNumber, err)
+	}
+	disk, err = FindLocateDisk("/dev/sdc", controllers, raids, noRaidDisks)
+	if err != nil || disk.SerialNumber != "JBOD02" {
+		t.Fatalf(`TestFindLocateDisk OS device: %v %v should be: JBOD02`, disk.SerialNumber, err)
+	}
+	// Hardware raid members share raid OS device
+	_, err = FindLocateDisk("sda", controllers, raids, noRaidDisks)
+	if err == nil {
+		t.Fatalf(`TestFindLocateDisk should return error when several disks match`)
+	}
+	_, err = FindLocateDisk("sdz", controllers, raids, noRaidDisks)
+	if err == nil {
+		t.Fatalf(`TestFindLocateDisk should return error when no disk matches`)
+	}
+}
+
+// Test LocateSysfsDisk
+func TestLocateSysfsDisk(t *testing.T) {
+	// Copy original functions content
+	readSysfsDirOri := ReadSysfsDir
+	readSysfsLinkOri := ReadSysfsLink
+	writeSysfsFileOri := WriteSysfsFile
+	// unmock functions content
+	defer func() {
+		ReadSysfsDir = readSysfsDirOri
+		ReadSysfsLink = readSysfsLinkOri
+		WriteSysfsFile = writeSysfsFileOri
+	}()
+
+	// Mocked functions: sdb located in enclosure slot 1
+	sysfsDirs := map[string][]string{
+		"/sys/class/enclosure":                             {"0:0:4:0"},
+		"/sys/class/enclosure/0:0:4:0":                     {"Slot00", "Slot01"},
+		"/sys/class/enclosure/0:0:4:0/Slot00/device/block": {"sda"},
+		"/sys/class/enclosure/0:0:4:0/Slot01/device/block": {"sdb"},
+	}
+	ReadSysfsDir = func(path string) ([]string, error) {
+		if entries, ok := sysfsDirs[path]; ok {
+			return entries, nil
+		}
+		return nil, errors.New("No such file or directory")
+	}
+	ReadSysfsLink = func(path string) (string, error) {
+		device := strings.TrimPrefix(path, "/sys/class/block/")
+		return "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/" + device, nil
+	}
+	writtenFiles := make(map[string]string)
+	WriteSysfsFile = func(path, value string) error {
+		writtenFiles[path] = value
+		return nil
+	}
+
+	err := LocateSysfsDisk("JBOD-sdb", true)
+	if err != nil {
+		t.Fatalf(`TestLocateSysfsDisk returned error: %s`, err)
+	}
+	writtenFilesWanted := map[string]string{"/sys/class/enclosure/0:0:4:0/Slot01/locate": "1"}
+	if !reflect.DeepEqual(writtenFiles, writtenFilesWanted) {
+		t.Fatalf(`TestLocateSysfsDisk writtenFiles: %v should be: %v`, writtenFiles, writtenFilesWanted)
+	}
+
+	err = LocateSysfsDisk("sdc", false)
+	if err == nil {
+		t.Fatalf(`TestLocateSysfsDisk should return error when disk is not in any enclosure`)
+	}
+}
+
+// Test EvaluateSmart
+func TestEvaluateSmart(t *testing.T) {
+	smart := SmartStruct{
+		Source:             "ata",
+		Verdict:            "PASSED",
+		ReallocatedSectors: 0,
+		PendingSectors:     -1,
+		MediaErrors:        -1,
+		WearLevel:          3,
+		PowerOnHours:       1000,
+		Temperature:        35,
+	}
+	EvaluateSmart(&smart)
+	if smart.Status != "OK" || len(smart.Warnings) != 0 {
+		t.Fatalf(`TestEvaluateSmart status: %v %v should be: OK []`, smart.Status, smart.Warnings)
+	}
+
+	smart.ReallocatedSectors = Thresholds.SmartReallocatedSectors + 1
+	smart.WearLevel = Thresholds.SmartWearLevel
+	EvaluateSmart(&smart)
+	if smart.Status != "Warning" || len(smart.Warnings) != 2 {
+		t.Fatalf(`TestEvaluateSmart status: %v %v should be: Warning with 2 warnings`, smart.Status, smart.Warnings)
+	}
+
+	smart.Verdict = "FAILED"
+	EvaluateSmart(&smart)
+	if smart.Status != "Failed" {
+		t.Fatalf(`TestEvaluateSmart status: %v should be: Failed`, smart.Status)
+	}
+}
+
+// Test GetDiskIdentity and SetDiskIdentities
+func TestGetDiskIdentity(t *testing.T) {
+	// Copy original functions content
+	readSysfsFileOri := ReadSysfsFile
+	readSysfsLinkOri := ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		ReadSysfsFile = readSysfsFileOri
+		ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked functions: sda SATA SSD with udev data, nvme0n1 NVMe namespace without udev data
+	sysfsFiles := map[string]string{
+		"/sys/block/sda/device/vendor":           "ATA",
+		"/sys/block/sda/device/model":            "Samsung SSD 870",
+		"/sys/block/sda/device/rev":              "2B6Q",
+		"/sys/block/sda/device/wwid":             "t10.ATA     Samsung SSD 870 EVO 1TB                 S6PUNX0R123456",
+		"/sys/block/sda/queue/rotational":        "0",
+		"/sys/class/block/sda/dev":               "8:0",
+		"/run/udev/data/b8:0":                    "S:disk/by-id/ata-Samsung_SSD_870_EVO_1TB_S6PUNX0R123456\nE:ID_BUS=ata\nE:ID_MODEL=Samsung_SSD_870_EVO_1TB\nE:ID_SERIAL_SHORT=S6PUNX0R123456\nE:ID_WWN=0x5002538f4123abcd",
+		"/sys/block/nvme0n1/device/model":        "Samsung SSD 980 PRO 1TB",
+		"/sys/block/nvme0n1/device/serial":       "S5GXNF0R123456",
+		"/sys/block/nvme0n1/device/firmware_rev": "5B2QGXA7",
+		"/sys/block/nvme0n1/wwid":                "eui.002538b111b2c3d4",
+		"/sys/block/nvme0n1/queue/rotational":    "0",
+	}
+	ReadSysfsFile = func(path string) (string, error) {
+		if value, ok := sysfsFiles[path]; ok {
+			return value, nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	ReadSysfsLink = func(path string) (string, error) {
+		device := strings.TrimPrefix(path, "/sys/class/block/")
+		if device == "sda3" {
+			return "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda/sda3", nil
+		}
+		return "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/" + device, nil
+	}
+
+	// udev ATA IDENTIFY model is preferred over sysfs truncated INQUIRY model
+	identityWanted := DiskIdentityStruct{
+		Vendor:       "Unknown",
+		Model:        "Samsung SSD 870 EVO 1TB",
+		Revision:     "2B6Q",
+		SerialNumber: "S6PUNX0R123456",
+		Wwn:          "t10.ATA     Samsung SSD 870 EVO 1TB                 S6PUNX0R123456",
+		Intf:         "SATA",
+		Medium:       "SSD",
+		Sources: map[string]string{
+			"model":    "udev",
+			"revision": "sysfs",
+			"serial":   "udev",
+			"wwn":      "sysfs",
+			"intf":     "sysfs",
+			"medium":   "sysfs",
+		},
+	}
+	identity := GetDiskIdentity("sda3")
+	if !reflect.DeepEqual(identity, identityWanted) {
+		t.Fatalf(`TestGetDiskIdentity sda3 identity: %v should be: %v`, identity, identityWanted)
+	}
+
+	identityWanted = DiskIdentityStruct{
+		Vendor:       "Unknown",
+		Model:        "Samsung SSD 980 PRO 1TB",
+		Revision:     "5B2QGXA7",
+		SerialNumber: "S5GXNF0R123456",
+		Wwn:          "eui.002538b111b2c3d4",
+		Intf:         "NVMe",
+		Medium:       "NVME",
+		Sources: map[string]string{
+			"model":    "sysfs",
+			"revision": "sysfs",
+			"serial":   "sysfs",
+			"wwn":      "sysfs",
+			"intf":     "sysfs",
+			"medium":   "sysfs",
+		},
+	}
+	identity = GetDiskIdentity("nvme0n1")
+	if !reflect.DeepEqual(identity, identityWanted) {
+		t.Fatalf(`TestGetDiskIdentity nvme0n1 identity: %v should be: %v`, identity, identityWanted)
+	}
+
+	// GetDiskData doesnt need heuristic when all fields are available
+	diskSerialNumber, diskModel, diskIntf, diskMedium, err := GetDiskData("nvme0n1")
+	if err != nil {
+		t.Fatalf(`TestGetDiskIdentity GetDiskData returned error: %s`, err)
+	}
+	if diskSerialNumber != "S5GXNF0R123456" || diskModel != "Samsung SSD 980 PRO 1TB" || diskIntf != "NVMe" || diskMedium != "NVME" {
+		t.Fatalf(`TestGetDiskIdentity GetDiskData: %v %v %v %v should be: S5GXNF0R123456 Samsung SSD 980 PRO 1TB NVMe NVME`, diskSerialNumber, diskModel, diskIntf, diskMedium)
+	}
+
+	raids := []RaidStruct{
+		{
+			ControllerId: "softraid-0",
+			Disks: []DiskStruct{
+				{ControllerId: "softraid-0", OsDevice: "nvme0n1"},
+				{ControllerId: "mega-0", EidSlot: "252:0", OsDevice: "nvme0n1"},
+			},
+		},
+	}
+	SetDiskIdentities(raids, []NoRaidDiskStruct{})
+	if !reflect.DeepEqual(raids[0].Disks[0].Identity, identityWanted) {
+		t.Fatalf(`TestGetDiskIdentity SetDiskIdentities identity: %v should be: %v`, raids[0].Disks[0].Identity, identityWanted)
+	}
+	if len(raids[0].Disks[1].Identity.Sources) != 0 {
+		t.Fatalf(`TestGetDiskIdentity SetDiskIdentities hardware raid disk identity: %v should be empty`, raids[0].Disks[1].Identity)
+	}
+}
+
+// Test GetDiskTransport and CollectDiskTransports
+func TestGetDiskTransport(t *testing.T) {
+	// Copy original functions content
+	readSysfsFileOri := ReadSysfsFile
+	readSysfsDirOri := ReadSysfsDir
+	readSysfsLinkOri := ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		ReadSysfsFile = readSysfsFileOri
+		ReadSysfsDir = readSysfsDirOri
+		ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked functions: sda 6G SATA at 1.5G, sdb SAS SSD behind expander at 3G, sdc 3G SATA behind 12G HBA, sdd FC, sde USB, nvme0n1 PCIe x2, vda virtio, md0
+	// ATA IDENTIFY word 76: sda supports 1.5/3.0/6.0 Gbps, sdc supports 1.5/3.0 Gbps
+	ataIdentifyWords := make([]string, 256)
+	for i := range ataIdentifyWords {
+		ataIdentifyWords[i] = "0000"
+	}
+	ataIdentifyWords[76] = "000e"
+	ataIdentify := ""
+	for i := 0; i < 256; i += 8 {
+		ataIdentify += strings.Join(ataIdentifyWords[i:i+8], " ") + "\n"
+	}
+	vpdPage89Bytes := make([]byte, 572)
+	vpdPage89Bytes[1] = 0x89
+	vpdPage89Bytes[60+2*76] = 0x06
+	vpdPage89Bytes[571] = 0xA5
+	vpdPage89 := string(vpdPage89Bytes)
+	sysfsLinks := map[string]string{
+		"/sys/class/block/sda":     "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda",
+		"/sys/class/block/sda1":    "../../devices/pci0000:00/0000:00:17.0/ata1/host0/target0:0:0/0:0:0:0/block/sda/sda1",
+		"/sys/class/block/sdb":     "../../devices/pci0000:00/0000:00:01.0/0000:01:00.0/host1/port-1:0/expander-1:0/port-1:0:1/end_device-1:0:1/target1:0:1/1:0:1:0/block/sdb",
+		"/sys/class/block/sdc":     "../../devices/pci0000:00/0000:00:01.0/0000:01:00.0/host1/port-1:1/end_device-1:1/target1:0:2/1:0:2:0/block/sdc",
+		"/sys/class/block/sdd":     "../../devices/pci0000:00/0000:00:02.0/0000:02:00.0/host5/rport-5:0-0/target5:0:0/5:0:0:0/block/sdd",
+		"/sys/class/block/sde":     "../../devices/pci0000:00/0000:00:14.0/usb2/2-1/2-1:1.0/host6/target6:0:0/6:0:0:0/block/sde",
+		"/sys/class/block/nvme0n1": "../../devices/pci0000:00/0000:00:1d.0/0000:3d:00.0/nvme/nvme0/nvme0n1",
+		"/sys/class/block/vda":     "../../devices/pci0000:00/0000:00:04.0/virtio2/block/vda",
+		"/sys/class/block/md0":     "../../devices/virtual/block/md0",
+	}
+	sysfsFiles := map[string]string{
+		"/sys/class/ata_link/link1/sata_spd":                           "1.5 Gbps",
+		"/sys/class/ata_link/link1/hw_sata_spd_limit":                  "6.0 Gbps",
+		"/sys/class/ata_device/dev1.0/id":                              ataIdentify,
+		"/sys/class/block/sdc/device/vpd_pg89":                         vpdPage89,
+		"/sys/class/sas_device/end_device-1:0:1/target_port_protocols": "ssp",
+		"/sys/class/sas_phy/phy-1:0:5/negotiated_linkrate":             "3.0 Gbit",
+		"/sys/class/sas_phy/phy-1:0:5/maximum_linkrate":                "12.0 Gbit",
+		"/sys/class/sas_device/end_device-1:1/target_port_protocols":   "sata",
+		"/sys/class/sas_phy/phy-1:1/negotiated_linkrate":               "3.0 Gbit",
+		"/sys/class/sas_phy/phy-1:1/maximum_linkrate":                  "12.0 Gbit",
+		"/sys/class/fc_host/host5/speed":                               "16 Gbit",
+		"/sys/class/fc_host/host5/supported_speeds":                    "4 Gbit, 8 Gbit, 16 Gbit",
+		"/sys/bus/usb/devices/2-1/speed":                               "5000",
+		"/sys/class/nvme/nvme0/transport":                              "pcie",
+		"/sys/class/nvme/nvme0/device/current_link_speed":              "8.0 GT/s PCIe",
+		"/sys/class/nvme/nvme0/device/current_link_width":              "2",
+		"/sys/class/nvme/nvme0/device/max_link_speed":                  "8.0 GT/s PCIe",
+		"/sys/class/nvme/nvme0/device/max_link_width":                  "4",
+	}
+	sysfsDirs := map[string][]string{
+		"/sys/class/sas_port/port-1:0:1/device": {"end_device-1:0:1", "phy-1:0:5", "sas_port", "uevent"},
+		"/sys/class/sas_port/port-1:1/device":   {"end_device-1:1", "phy-1:1", "sas_port", "uevent"},
+	}
+	ReadSysfsLink = func(path string) (string, error) {
+		if link, ok := sysfsLinks[path]; ok {
+			return link, nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	ReadSysfsFile = func(path string) (string, error) {
+		if value, ok := sysfsFiles[path]; ok {
+			return value, nil
+		}
+		return "", errors.New("No such file or directory")
+	}
+	ReadSysfsDir = func(path string) ([]string, error) {
+		if entries, ok := sysfsDirs[path]; ok {
+			return entries, nil
+		}
+		return nil, errors.New("No such file or directory")
+	}
+
+	transportsWanted := map[string][]string{
+		"sda1":    {"SATA", "1.5 Gbps", "6.0 Gbps"},
+		"sdb":     {"SAS", "3.0 Gbit", "N/A"},
+		"sdc":     {"SATA", "3.0 Gbit", "3.0 Gbit"},
+		"sdd":     {"FC", "16 Gbit", "16 Gbit"},
+		"sde":     {"USB", "5000 Mbps", "N/A"},
+		"nvme0n1": {"NVMe", "8.0 GT/s PCIe x2", "8.0 GT/s PCIe x4"},
+		"vda":     {"virtio", "N/A", "N/A"},
+		"md0":     {"Unknown", "N/A", "N/A"},
+	}
+	for device, transportWanted := range transportsWanted {
+		transport, linkRate, maxLinkRate := GetDiskTransport(device)
+		if !reflect.DeepEqual([]string{transport, linkRate, maxLinkRate}, transportWanted) {
+			t.Fatalf(`TestGetDiskTransport %v: %v %v %v should be: %v`, device, transport, linkRate, maxLinkRate, transportWanted)
+		}
+	}
+
+	raids := []RaidStruct{
+		{
+			ControllerId: "softraid-0",
+			Disks: []DiskStruct{
+				{ControllerId: "softraid-0", State: "ONLINE", Intf: "scsi", OsDevice: "sdb"},
+				{ControllerId: "softraid-0", State: "ONLINE", Intf: "scsi", OsDevice: "sda"},
+				{ControllerId: "softraid-0", State: "ONLINE", Intf: "nvme", OsDevice: "nvme0n1"},
+				{ControllerId: "mega-0", EidSlot: "252:0", State: "Onln", Intf: "SAS", OsDevice: "sda"},
+			},
+		},
+	}
+	noRaidDisks := []NoRaidDiskStruct{
+		{ControllerId: "sas2ircu-0", EidSlot: "1:1", State: "Ready(RDY)", Intf: "SATA", OsDevice: "JBOD-sdc"},
+	}
+	CollectDiskTransports(raids, noRaidDisks)
+	// SAS disk maximum rate is unknown, HBA/expander phy maximum rate is not used
+	if raids[0].Disks[0].State != "ONLINE" || raids[0].Disks[0].Intf != "SAS" {
+		t.Fatalf(`TestGetDiskTransport sdb state: %v intf: %v should be: ONLINE SAS`, raids[0].Disks[0].State, raids[0].Disks[0].Intf)
+	}
+	if raids[0].Disks[1].State != "ONLINE/Link-Degraded" || raids[0].Disks[1].Intf != "SATA" {
+		t.Fatalf(`TestGetDiskTransport sda state: %v intf: %v should be: ONLINE/Link-Degraded SATA`, raids[0].Disks[1].State, raids[0].Disks[1].Intf)
+	}
+	if raids[0].Disks[2].State != "ONLINE/Link-Degraded" || raids[0].Disks[2].Intf != "NVMe" {
+		t.Fatalf(`TestGetDiskTransport nvme0n1 state: %v intf: %v should be: ONLINE/Link-Degraded NVMe`, raids[0].Disks[2].State, raids[0].Disks[2].Intf)
+	}
+	if raids[0].Disks[3].State != "Onln" || len(raids[0].Disks[3].Transport) != 0 {
+		t.Fatalf(`TestGetDiskTransport hardware raid member state: %v transport: %v should be: Onln and empty`, raids[0].Disks[3].State, raids[0].Disks[3].Transport)
+	}
+	// 3G SATA disk behind 12G HBA is not degraded
+	if noRaidDisks[0].State != "Ready(RDY)" || noRaidDisks[0].LinkRate != "3.0 Gbit" {
+		t.Fatalf(`TestGetDiskTransport JBOD-sdc state: %v link rate: %v should be: Ready(RDY) 3.0 Gbit`, noRaidDisks[0].State, noRaidDisks[0].LinkRate)
+	}
+}
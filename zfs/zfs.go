@@ -6,9 +6,14 @@ import (
 	"hardwareAnalyzer/utils"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	human "github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 )
 
@@ -86,7 +91,432 @@ var CheckZFSRaid = func() (bool, error) {
 	return false, nil
 }
 
+// Top level vdevs: mirror-0, raidz2-1, draid2:4d:1s:8c-0
+// Grouping vdevs: spare-1 (hot spare replacing a drive), replacing-0, indirect-0 (removed top level device)
+var vdevRegexp = regexp.MustCompile(`^(mirror|raidz[1-3]?|draid[1-3]?(:\w+)*|spare|replacing|indirect)-\d+$`)
+
+// dRAID distributed spares are not real drives: draid2-0-0
+var distributedSpareRegexp = regexp.MustCompile(`^draid\d-\d+-\d+$`)
+
+// Allocation classes and spares headers in zpool status config section
+var vdevClasses = []string{"logs", "cache", "special", "dedup", "spares"}
+
+// zpool status sections, its text can continue in following lines
+var zpoolStatusKeys = []string{"pool:", "state:", "status:", "action:", "see:", "scan:", "remove:", "checkpoint:", "config:", "errors:"}
+
+// Scan line dates: Sun Oct 13 00:25:24 2024
+const zpoolTimeLayout = "Mon Jan _2 15:04:05 2006"
+
+var scanDateRegexp = regexp.MustCompile(`(?:on|since) (\w{3} \w{3} +\d+ \d{2}:\d{2}:\d{2} \d{4})`)
+
+// scrub repaired 0B in 00:01:23 with 0 errors on ..., resilvered 1.2G in 0 days 01:10:00 with 0 errors on ...
+var scanResultRegexp = regexp.MustCompile(`^(scrub repaired|resilvered) (\S+) in .+? with (\d+) errors`)
+
+var scanProgressRegexp = regexp.MustCompile(`([\d.]+%) done`)
+
+// errors: 3 data errors, use '-v' for a list
+var dataErrorsRegexp = regexp.MustCompile(`^(\d+) data errors`)
+
+// Function as variable in order to be able to mock it from unit tests
+var GetCurrentTime = func() time.Time {
+	return time.Now()
+}
+
+// Function as variable in order to be able to mock it from unit tests
+// zpool get -H -p all: one tab separated "pool property value source" line per property with exact values
+var GetZpoolProperties = func(poolName string) (map[string]string, error) {
+	properties := map[string]string{}
+	command := "get -H -p all " + poolName
+	outputStdout, outputStderr, err := utils.GetCommandOutput("zfs", "getZpoolProperties", command)
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return properties, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return properties, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		lineData := strings.Split(strings.TrimSpace(scanner.Text()), "\t")
+		if len(lineData) < 3 || lineData[0] != poolName {
+			continue
+		}
+		properties[lineData[1]] = lineData[2]
+	}
+	return properties, nil
+}
+
+// zfs list columns, encryption properties are not supported by zfs < 0.8
+var datasetProperties = []string{"name", "type", "used", "avail", "refer", "quota", "reservation", "volsize", "compressratio", "mountpoint", "encryption", "keystatus"}
+
+// zfs list -p sizes are bytes, quota/reservation 0 means none
+func formatZfsBytes(value string, zeroValue string) string {
+	if value == "-" || len(value) == 0 {
+		return "-"
+	}
+	valueInt, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return value
+	}
+	if valueInt == 0 && len(zeroValue) > 0 {
+		return zeroValue
+	}
+	return human.Bytes(valueInt)
+}
+
+// Devices using a zvol: zd0p1(mounted on /mnt/vm), dm-3(vg0-lv0)
+func getZvolConsumers(zvol string, mounts string) []string {
+	consumers := []string{}
+	devices := []string{zvol}
+	entries, err := utils.ReadSysfsDir("/sys/class/block/" + zvol)
+	if err == nil {
+		for _, entry := range entries {
+			if strings.HasPrefix(entry, zvol+"p") {
+				devices = append(devices, entry)
+			}
+		}
+	}
+
+	for _, device := range devices {
+		for _, mountLine := range strings.Split(mounts, "\n") {
+			mountData := strings.Fields(mountLine)
+			if len(mountData) > 1 && mountData[0] == "/dev/"+device {
+				consumers = append(consumers, device+"(mounted on "+mountData[1]+")")
+			}
+		}
+		holders, err := utils.ReadSysfsDir("/sys/class/block/" + device + "/holders")
+		if err != nil {
+			continue
+		}
+		for _, holder := range holders {
+			holderName, err := utils.ReadSysfsFile("/sys/class/block/" + holder + "/dm/name")
+			if err == nil && len(holderName) > 0 {
+				holder = holder + "(" + holderName + ")"
+			}
+			consumers = append(consumers, holder)
+		}
+	}
+	return consumers
+}
+
+// Function as variable in order to be able to mock it from unit tests
+// zfs list -H -p: one tab separated line per filesystem/volume with exact values
+var GetZFSDatasets = func() ([]utils.DatasetStruct, error) {
+	datasets := []utils.DatasetStruct{}
+	properties := datasetProperties
+	command := "list -H -p -t filesystem,volume -o " + strings.Join(properties, ",")
+	outputStdout, outputStderr, err := utils.GetCommandOutput("zfsdataset", "getZFSDatasets", command)
+	if outputStderr != nil && strings.Contains(outputStderr.String(), "invalid property") {
+		// Retry without encryption properties
+		properties = datasetProperties[:len(datasetProperties)-2]
+		command = "list -H -p -t filesystem,volume -o " + strings.Join(properties, ",")
+		outputStdout, outputStderr, err = utils.GetCommandOutput("zfsdataset", "getZFSDatasets", command)
+	}
+	if err != nil {
+		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
+		return datasets, fmt.Errorf("Error: Something went wrong executing command %s: %v.", command, err)
+	}
+	if len(outputStderr.String()) != 0 {
+		color.Red("++ ERROR: Something went wrong executing command: %s.", command)
+		return datasets, fmt.Errorf("Error: Something went wrong executing command: %s.", command)
+	}
+	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
+
+	mounts, err := utils.ReadSysfsFile("/proc/mounts")
+	if err != nil {
+		mounts = ""
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
+	for scanner.Scan() {
+		lineData := strings.Split(strings.TrimSpace(scanner.Text()), "\t")
+		if len(lineData) != len(properties) {
+			continue
+		}
+		values := map[string]string{}
+		for i, property := range properties {
+			values[property] = lineData[i]
+		}
+
+		dataset := utils.DatasetStruct{
+			Pool:          strings.Split(values["name"], "/")[0],
+			Name:          values["name"],
+			Type:          values["type"],
+			Used:          formatZfsBytes(values["used"], ""),
+			Available:     formatZfsBytes(values["avail"], ""),
+			Referenced:    formatZfsBytes(values["refer"], ""),
+			Quota:         formatZfsBytes(values["quota"], "none"),
+			Reservation:   formatZfsBytes(values["reservation"], "none"),
+			Size:          formatZfsBytes(values["volsize"], ""),
+			CompressRatio: values["compressratio"] + "x",
+			Encryption:    "Unknown",
+			Mountpoint:    values["mountpoint"],
+			OsDevice:      "N/A",
+		}
+		if encryption, ok := values["encryption"]; ok {
+			dataset.Encryption = encryption
+			if encryption != "off" && values["keystatus"] != "-" {
+				dataset.Encryption = encryption + "(key " + values["keystatus"] + ")"
+			}
+		}
+
+		// /dev/zvol/tank/vm-100-disk-0 -> ../../zd0
+		if dataset.Type == "volume" {
+			dataset.OsDevice = "Unknown"
+			link, err := utils.ReadSysfsLink("/dev/zvol/" + dataset.Name)
+			if err == nil {
+				dataset.OsDevice = filepath.Base(link)
+				dataset.Consumers = getZvolConsumers(dataset.OsDevice, mounts)
+			}
+		}
+		datasets = append(datasets, dataset)
+	}
+	return datasets, nil
+}
+
+// Pool usage details from pool properties
+func getPoolDetails(properties map[string]string) []string {
+	details := []string{}
+	if capacity, ok := properties["capacity"]; ok && capacity != "-" {
+		details = append(details, "used "+capacity+"%")
+	}
+	if fragmentation, ok := properties["fragmentation"]; ok && fragmentation != "-" {
+		details = append(details, "frag "+fragmentation+"%")
+	}
+	if ashift, ok := properties["ashift"]; ok {
+		details = append(details, "ashift "+ashift)
+	}
+	if autoreplace, ok := properties["autoreplace"]; ok {
+		details = append(details, "autoreplace "+autoreplace)
+	}
+	// Feature flags: active, enabled(not used yet), disabled(pool can be upgraded)
+	features := map[string]int{}
+	for property, value := range properties {
+		if strings.HasPrefix(property, "feature@") {
+			features[value]++
+		}
+	}
+	if len(features) > 0 {
+		details = append(details, fmt.Sprintf("features %d active/%d enabled/%d disabled", features["active"], features["enabled"], features["disabled"]))
+	}
+	if properties["readonly"] == "on" {
+		details = append(details, "read-only")
+	}
+	return details
+}
+
+// Last scan result from zpool status scan section:
+//
+//	scrub repaired 0B in 00:01:23 with 0 errors on Sun Oct 13 00:25:24 2024
+//	scrub in progress since Sun Oct 13 00:25:24 2024 1.2T scanned at 1G/s, 900G issued at 800M/s, 2T total 0B repaired, 45.60% done, 00:10:00 to go
+//	none requested
+//
+// Pools not scrubbed within Thresholds.ZfsScrubDays are flagged, a resilver does not check all pool data
+func getScanHealth(scan string, now time.Time) ([]string, []string) {
+	details := []string{}
+	flags := []string{}
+	scanDate := "unknown date"
+	scanTime := time.Time{}
+	dateData := scanDateRegexp.FindStringSubmatch(scan)
+	if len(dateData) == 2 {
+		parsedTime, err := time.ParseInLocation(zpoolTimeLayout, dateData[1], time.Local)
+		if err == nil {
+			scanTime = parsedTime
+			scanDate = scanTime.Format("2006-01-02")
+		}
+	}
+
+	scrubbed := false
+	resultData := scanResultRegexp.FindStringSubmatch(scan)
+	switch {
+	case len(scan) == 0 || strings.HasPrefix(scan, "none requested"):
+		details = append(details, "never scrubbed")
+	case strings.Contains(scan, "in progress"):
+		progress := "Unknown"
+		progressData := scanProgressRegexp.FindStringSubmatch(scan)
+		if len(progressData) == 2 {
+			progress = progressData[1]
+		}
+		details = append(details, strings.Fields(scan)[0]+" in progress "+progress)
+		// Pool is being checked
+		scrubbed = true
+	case len(resultData) == 4:
+		operation := "scrub"
+		if resultData[1] == "resilvered" {
+			operation = "resilver"
+		}
+		details = append(details, "last "+operation+" "+scanDate+": repaired "+resultData[2]+", "+resultData[3]+" errors")
+		if resultData[3] != "0" {
+			flags = append(flags, "Scrub-Errors")
+		}
+		scrubbed = operation == "scrub" && !scanTime.IsZero() && now.Sub(scanTime) <= time.Duration(utils.Thresholds.ZfsScrubDays)*24*time.Hour
+	default:
+		// scrub canceled/paused
+		details = append(details, "last scan: "+scan)
+	}
+	if !scrubbed {
+		flags = append(flags, "Scrub-Overdue")
+	}
+	return details, flags
+}
+
+// Sum of pool drives checksum errors
+func getPoolChecksumErrors(poolName string, vdevs []utils.RaidStruct) int64 {
+	checksumErrors := int64(0)
+	for _, vdev := range vdevs {
+		if vdev.Dg != poolName {
+			continue
+		}
+		for _, disk := range vdev.Disks {
+			if disk.IoErrors.Checksum > 0 {
+				checksumErrors = checksumErrors + disk.IoErrors.Checksum
+			}
+		}
+	}
+	return checksumErrors
+}
+
+// zpool status -p prints exact counters, older versions show big values as 1.2K
+func parseZfsCounter(value string) int64 {
+	counter, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		return counter
+	}
+	counterBytes, err := human.ParseBytes(value)
+	if err != nil {
+		return -1
+	}
+	return int64(counterBytes)
+}
+
+// READ WRITE CKSUM columns
+func getZfsErrors(fields []string) utils.IoErrorsStruct {
+	if len(fields) < 5 {
+		return utils.IoErrorsStruct{}
+	}
+	return utils.IoErrorsStruct{
+		Source:   "zfs",
+		Read:     parseZfsCounter(fields[2]),
+		Write:    parseZfsCounter(fields[3]),
+		Checksum: parseZfsCounter(fields[4]),
+	}
+}
+
+func hasZfsErrors(ioErrors utils.IoErrorsStruct) bool {
+	return ioErrors.Read > 0 || ioErrors.Write > 0 || ioErrors.Checksum > 0
+}
+
+// -P shows full device paths and -L resolves /dev/disk/by-id links: /dev/sdb1
+// Missing devices are shown by guid: 1234567890  UNAVAIL  0  0  0  was /dev/sdc1
+func getZfsDevice(fields []string) string {
+	device := fields[0]
+	wasIndex := slices.Index(fields, "was")
+	if wasIndex >= 0 && wasIndex+1 < len(fields) {
+		device = fields[wasIndex+1]
+	}
+	return strings.TrimPrefix(device, "/dev/")
+}
+
+func getZfsDisk(poolName string, fields []string) utils.DiskStruct {
+	drive := getZfsDevice(fields)
+	//fmt.Println("Drive: ", drive)
+	driveState := "Unknown"
+	if len(fields) > 1 {
+		driveState = fields[1]
+	}
+	driveSerialNumber, driveModel, driveIntf, driveMedium, err := utils.GetDiskData(drive)
+	if err != nil {
+		color.Red("++ ERROR: utils.GetDiskData: %s", err)
+	}
+	driveSize, _ := utils.GetDiskPartitionSize(drive)
+	diskDrive := utils.DiskStruct{
+		ControllerId: "zfs-0",
+		Dg:           poolName,
+		State:        driveState,
+		Size:         driveSize,
+		Intf:         driveIntf,
+		Medium:       driveMedium,
+		Model:        driveModel,
+		SerialNumber: driveSerialNumber,
+		OsDevice:     drive,
+		IoErrors:     getZfsErrors(fields),
+	}
+	if hasZfsErrors(diskDrive.IoErrors) {
+		diskDrive.State = diskDrive.State + "/IO-Errors"
+	}
+	return diskDrive
+}
+
+func getZfsSpare(poolName string, fields []string) utils.SpareStruct {
+	drive := getZfsDevice(fields)
+	//fmt.Println("Spare drive: ", drive)
+	spareState := "Unknown"
+	if len(fields) > 1 {
+		spareState = fields[1]
+	}
+	switch spareState {
+	case "AVAIL":
+		spareState = "Available"
+	case "INUSE":
+		spareState = "InUse"
+	}
+
+	spare := utils.SpareStruct{
+		ControllerId: "zfs-0",
+		Type:         "Dedicated",
+		Arrays:       []string{poolName},
+		State:        spareState,
+		Size:         "Unknown",
+		Intf:         "Unknown",
+		Medium:       "Unknown",
+		Model:        "dRAID distributed spare",
+		SerialNumber: "Unknown",
+		OsDevice:     drive,
+	}
+	if distributedSpareRegexp.MatchString(drive) {
+		return spare
+	}
+
+	driveSerialNumber, driveModel, driveIntf, driveMedium, err := utils.GetDiskData(drive)
+	if err != nil {
+		color.Red("++ ERROR: utils.GetDiskData: %s", err)
+	}
+	spare.Size, _ = utils.GetDiskPartitionSize(drive)
+	spare.Intf = driveIntf
+	spare.Medium = driveMedium
+	spare.Model = driveModel
+	spare.SerialNumber = driveSerialNumber
+	return spare
+}
+
+// Vdev type shown with its allocation class: raidz2, special mirror, logs
+// Top level single drives of a class are grouped in one vdev: STRIPE, cache
+func getVdevType(vdevClass string, vdevType string) string {
+	if vdevClass == "data" {
+		if len(vdevType) == 0 {
+			return "STRIPE"
+		}
+		return vdevType
+	}
+	if len(vdevType) == 0 {
+		return vdevClass
+	}
+	return vdevClass + " " + vdevType
+}
+
 // ZFS: Drives are in VDEVs with determine the RAID level.
+// Config section hierarchy is determined by indentation relative to the pool line:
+//
+//	tank                ONLINE       0     0     0
+//	  mirror-0          ONLINE       0     0     0
+//	    /dev/sdb1       ONLINE       0     0     0
+//	logs
+//	  /dev/nvme0n1p1    ONLINE       0     0     0
+//	spares
+//	  /dev/sdd1         AVAIL
 var ProcessZFSRaid = func(manufacturer string) ([]utils.ControllerStruct, []utils.PoolStruct, []utils.RaidStruct, error) {
 	var controllers = []utils.ControllerStruct{}
 	// pools and vdevs are ralated using: pool.name <-> vdev.dg
@@ -102,7 +532,7 @@ var ProcessZFSRaid = func(manufacturer string) ([]utils.ControllerStruct, []util
 	controllers = append(controllers, controller)
 
 	fmt.Println("> Getting current zpool configuration.")
-	command := "status"
+	command := "status -P -L -p"
 	outputStdout, outputStderr, err := utils.GetCommandOutput(manufacturer, "processZFSRaid", command)
 	if err != nil {
 		color.Red("++ ERROR: Something went wrong executing command %s: %v.", command, err)
@@ -115,140 +545,237 @@ var ProcessZFSRaid = func(manufacturer string) ([]utils.ControllerStruct, []util
 	//fmt.Println("out:", outputStdout.String(), "err:", outputStderr.String())
 
 	fmt.Println("> Parsing zpool data.")
-	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
 	poolName := "Unknown"
-	poolState := "Unknown"
-	vdevType := "Unknown"
-	vdevState := "Unknown"
-	insideVdevList := false
+	statusKey := ""
+	// Scan and errors sections of each pool, evaluated once its drives are known
+	poolScans := []string{}
+	poolErrors := []string{}
+	insideConfig := false
+	vdevClass := "data"
+	rootIndent := 0
 	var vdev utils.RaidStruct
-	var pool utils.PoolStruct
-	previousLineWasDrive := false
+	insideVdev := false
+	var stripe utils.RaidStruct
+	insideStripe := false
+	// Top level spare-N/replacing-N: replaced drive belongs to class stripe vdev
+	childrenToStripe := false
+
+	closeVdev := func() {
+		if insideVdev {
+			vdevs = append(vdevs, vdev)
+			insideVdev = false
+		}
+	}
+	closeClass := func() {
+		closeVdev()
+		if insideStripe {
+			vdevs = append(vdevs, stripe)
+			insideStripe = false
+		}
+	}
+	addStripeDisk := func(disk utils.DiskStruct) {
+		if !insideStripe {
+			stripe = utils.RaidStruct{
+				ControllerId: "zfs-0",
+				RaidLevel:    0,
+				Dg:           poolName,
+				RaidType:     getVdevType(vdevClass, ""),
+				State:        "ONLINE",
+			}
+			insideStripe = true
+		}
+		// Stripe vdev has no status line, it gets the state of its failed drives
+		driveState := strings.Split(disk.State, "/")[0]
+		if driveState != "ONLINE" {
+			stripe.State = driveState
+		}
+		stripe.AddDisk(disk)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(outputStdout.String()))
 	for scanner.Scan() {
 		line := scanner.Text()
-		// DONT trim line, \n lines are useful for determining end sections
 		//fmt.Println("-- LINE: ", line)
+		fields := strings.Fields(line)
 
-		// Last pool line detected
-		if insideVdevList && len(line) == 0 {
-			insideVdevList = false
-			//fmt.Println("End of vdev list detected.")
-			vdevs = append(vdevs, vdev)
-			// fmt.Println("-----------")
-			// fmt.Println("VDEVS-FINAL:")
-			// for _, vdev := range vdevs {
-			// 	spew.Dump(vdev)
-			// }
-			// fmt.Println("-----------")
-			previousLineWasDrive = false
+		// Empty line ends config section
+		if len(fields) == 0 {
+			if insideConfig {
+				closeClass()
+				insideConfig = false
+			}
+			continue
+		}
+
+		if !insideConfig {
+			if fields[0] == "NAME" {
+				insideConfig = true
+				vdevClass = "data"
+				continue
+			}
+			value := strings.Join(fields, " ")
+			if slices.Contains(zpoolStatusKeys, fields[0]) {
+				statusKey = fields[0]
+				value = strings.Join(fields[1:], " ")
+			}
+			switch statusKey {
+			case "pool:":
+				poolName = value
+				//fmt.Println("poolName: ", poolName)
+			case "state:":
+				poolState := value
+				if poolState != "ONLINE" {
+					controllers[0].Status = "Bad"
+				}
+				//fmt.Println("poolState: ", poolState)
+
+				properties, err := GetZpoolProperties(poolName)
+				if err != nil {
+					color.Red("++ ERROR getting pool properties: %s: %s", poolName, err)
+				}
+				poolSize := "Unknown"
+				poolSizeBytes, err := strconv.ParseUint(properties["size"], 10, 64)
+				if err == nil {
+					poolSize = human.Bytes(poolSizeBytes)
+				} else {
+					poolSize, err = GetZFSPoolSize(poolName)
+					if err != nil {
+						color.Red("++ ERROR getting poolSize: %s: %s", poolName, err)
+					}
+				}
+				//fmt.Println("poolSize: ", poolSize)
+
+				pool := utils.PoolStruct{
+					ControllerId: "zfs-0",
+					Name:         poolName,
+					State:        poolState,
+					Size:         poolSize,
+					OsDevice:     "/" + poolName,
+					Details:      getPoolDetails(properties),
+				}
+				pools = append(pools, pool)
+				poolScans = append(poolScans, "")
+				poolErrors = append(poolErrors, "")
+			case "status:":
+				if len(pools) > 0 {
+					pools[len(pools)-1].Status = strings.TrimSpace(pools[len(pools)-1].Status + " " + value)
+				}
+			case "scan:":
+				if len(poolScans) > 0 {
+					poolScans[len(poolScans)-1] = strings.TrimSpace(poolScans[len(poolScans)-1] + " " + value)
+				}
+			case "errors:":
+				if len(poolErrors) > 0 {
+					poolErrors[len(poolErrors)-1] = strings.TrimSpace(poolErrors[len(poolErrors)-1] + " " + value)
+				}
+			}
 			continue
 		}
 
-		if strings.Contains(line, "pool:") {
-			poolName = strings.Fields(line)[1]
-			//fmt.Println("poolName: ", poolName)
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if fields[0] == poolName {
+			rootIndent = indent
 			continue
 		}
 
-		if strings.Contains(line, "state:") {
-			poolState = strings.Fields(line)[1]
-			if poolState != "ONLINE" {
+		if len(fields) == 1 && slices.Contains(vdevClasses, fields[0]) {
+			closeClass()
+			vdevClass = fields[0]
+			//fmt.Println("vdevClass: ", vdevClass)
+			continue
+		}
+
+		// Spares are bound to the pool
+		if vdevClass == "spares" {
+			spare := getZfsSpare(poolName, fields)
+			if spare.State != "Available" && spare.State != "InUse" {
 				controllers[0].Status = "Bad"
 			}
-			//fmt.Println("poolState: ", poolState)
+			controllers[0].AddSpare(spare)
+			continue
+		}
 
-			poolSize, err := GetZFSPoolSize(poolName)
-			if err != nil {
-				color.Red("++ ERROR getting poolSize: %s: %s", poolName, err)
+		vdevData := vdevRegexp.FindStringSubmatch(fields[0])
+		if indent <= rootIndent+2 {
+			closeVdev()
+			childrenToStripe = false
+			if len(vdevData) == 0 {
+				disk := getZfsDisk(poolName, fields)
+				if disk.State != "ONLINE" {
+					controllers[0].Status = "Bad"
+				}
+				addStripeDisk(disk)
+				continue
 			}
-			//fmt.Println("poolSize: ", poolSize)
-
-			pool = utils.PoolStruct{
-				ControllerId: "zfs-0",
-				Name:         poolName,
-				State:        poolState,
-				Size:         poolSize,
-				OsDevice:     "/" + poolName,
+			switch vdevData[1] {
+			case "spare", "replacing":
+				childrenToStripe = true
+			case "indirect":
+				// Removed device, data remapped to other vdevs
+			default:
+				vdev = utils.RaidStruct{
+					ControllerId: "zfs-0",
+					RaidLevel:    0,
+					Dg:           poolName,
+					RaidType:     getVdevType(vdevClass, fields[0][:strings.LastIndex(fields[0], "-")]),
+					State:        fields[1],
+				}
+				vdevErrors := getZfsErrors(fields)
+				if hasZfsErrors(vdevErrors) {
+					vdev.Details = []string{fmt.Sprintf("errors R:%d W:%d C:%d", vdevErrors.Read, vdevErrors.Write, vdevErrors.Checksum)}
+				}
+				insideVdev = true
 			}
-			//fmt.Println("pool created: ", poolName)
-			pools = append(pools, pool)
 			continue
 		}
 
-		// When we find poolName it can be a Stripe vdev raid or only the header of the real vdev raid
-		if strings.Contains(line, poolName) {
-			vdevState = strings.Fields(line)[1]
-			insideVdevList = true
-			vdevType = "STRIPE"
-			// Create vdev:
-			vdev = utils.RaidStruct{
-				ControllerId: "zfs-0",
-				RaidLevel:    0,
-				Dg:           poolName,
-				RaidType:     vdevType,
-				State:        vdevState,
-			}
+		// Spare replacing a drive is shown as an spare-N vdev grouping the replaced drive and the spare one, both drives are listed as vdev drives
+		if len(vdevData) > 0 {
 			continue
 		}
 
-		// Dont be concerned about strings.Contains, pool name cant match because raidz and mirror are reserved words, not allowing to create raids starting with these names.
-		// If previous line was header, its overwritten
-		if strings.Contains(line, "mirror") || strings.Contains(line, "raidz") {
-			// If we find another vdev in the same pool, save previous vdev
-			if previousLineWasDrive {
-				vdevs = append(vdevs, vdev)
-				previousLineWasDrive = false
-			}
+		disk := getZfsDisk(poolName, fields)
+		if disk.State != "ONLINE" {
+			controllers[0].Status = "Bad"
+		}
+		if insideVdev {
+			vdev.AddDisk(disk)
+		} else if childrenToStripe {
+			addStripeDisk(disk)
+		}
+	}
+	closeClass()
 
-			lineData := strings.Split(line, "-")
-			vdevType = lineData[0]
-			vdevType = utils.ClearString(vdevType)
-			//fmt.Printf("vdevType: %s\n", vdevType)
-			vdevState = strings.Fields(line)[1]
-			//fmt.Printf("vdevState: %s\n", vdevState)
-			// Create vdev:
-			vdev = utils.RaidStruct{
-				ControllerId: "zfs-0",
-				RaidLevel:    0,
-				Dg:           poolName,
-				RaidType:     vdevType,
-				State:        vdevState,
+	datasets, err := GetZFSDatasets()
+	if err != nil {
+		color.Red("++ ERROR getting datasets: %s", err)
+	}
+	for i := range pools {
+		for _, dataset := range datasets {
+			if dataset.Pool == pools[i].Name {
+				pools[i].Datasets = append(pools[i].Datasets, dataset)
 			}
-			continue
 		}
+	}
 
-		driveSerialNumber := "Unknown"
-		driveModel := "Unknown"
-		driveIntf := "Unknown"
-		driveMedium := "Unknown"
-		if insideVdevList {
-			previousLineWasDrive = true
-			drive := strings.Fields(line)[0]
-			//fmt.Println("Drive: ", drive)
-			driveState := strings.Fields(line)[1]
-			//fmt.Println("driveState: ", driveState)
-			if driveState != "ONLINE" {
+	// Pool flags: ONLINE/Scrub-Overdue, DEGRADED/Data-Errors/Checksum-Errors
+	for i := range pools {
+		pool := &pools[i]
+		scanDetails, flags := getScanHealth(poolScans[i], GetCurrentTime())
+		pool.Details = append(pool.Details, scanDetails...)
+		errorsData := dataErrorsRegexp.FindStringSubmatch(poolErrors[i])
+		if len(errorsData) == 2 {
+			pool.Details = append(pool.Details, errorsData[1]+" files with data errors")
+			flags = append(flags, "Data-Errors")
+		}
+		if getPoolChecksumErrors(pool.Name, vdevs) > 0 {
+			flags = append(flags, "Checksum-Errors")
+		}
+		for _, flag := range flags {
+			pool.State = pool.State + "/" + flag
+			if flag != "Scrub-Overdue" {
 				controllers[0].Status = "Bad"
 			}
-
-			driveSerialNumber, driveModel, driveIntf, driveMedium, err = utils.GetDiskData(drive)
-			if err != nil {
-				color.Red("++ ERROR: utils.GetDiskData: %s", err)
-			}
-
-			driveSize, _ := utils.GetDiskPartitionSize(drive)
-			diskDrive := utils.DiskStruct{
-				ControllerId: "zfs-0",
-				Dg:           poolName,
-				State:        driveState,
-				Size:         driveSize,
-				Intf:         driveIntf,
-				Medium:       driveMedium,
-				Model:        driveModel,
-				SerialNumber: driveSerialNumber,
-				OsDevice:     drive,
-			}
-			vdev.AddDisk(diskDrive)
 		}
 	}
 	return controllers, pools, vdevs, nil
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"hardwareAnalyzer/utils"
 	"io/fs"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestCheckZFSRaid fs.DirEntry interface implementation in order to mock []fs.DirEntry output in TestCheckZFSRaid* unit tests
@@ -209,12 +212,18 @@ func TestProcessZFSRaid(t *testing.T) {
 	// Copy original functions content
 	getCommandOutputOri := utils.GetCommandOutput
 	getZFSPoolSizeOri := GetZFSPoolSize
+	getZpoolPropertiesOri := GetZpoolProperties
+	getZFSDatasetsOri := GetZFSDatasets
+	getCurrentTimeOri := GetCurrentTime
 	getDiskDataOri := utils.GetDiskData
 	getDiskPartitionSizeOri := utils.GetDiskPartitionSize
 	// unmock functions content
 	defer func() {
 		utils.GetCommandOutput = getCommandOutputOri
 		GetZFSPoolSize = getZFSPoolSizeOri
+		GetZpoolProperties = getZpoolPropertiesOri
+		GetZFSDatasets = getZFSDatasetsOri
+		GetCurrentTime = getCurrentTimeOri
 		utils.GetDiskData = getDiskDataOri
 		utils.GetDiskPartitionSize = getDiskPartitionSizeOri
 	}()
@@ -224,26 +233,45 @@ func TestProcessZFSRaid(t *testing.T) {
 		//fmt.Println("-- Executing mocked getCommandOutput function")
 		var outputStdout, outputStderr bytes.Buffer
 
+		if command != "status -P -L -p" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unexpected command: %s", command)
+		}
 		outputStdout.WriteString(`
-		pool: lxd
-		state: ONLINE
-		config:
-
-			NAME        STATE     READ WRITE CKSUM
-			lxd         ONLINE       0     0     0
-			raidz2-0  ONLINE         0     0     0
-				sdb     ONLINE       0     0     0
-				sdc     ONLINE       0     0     0
-				sdd     ONLINE       0     0     0
-				sde     ONLINE       0     0     0
-				sdf     ONLINE       0     0     0
-				sdg     ONLINE       0     0     0
-
-		errors: No known data errors
-		`)
+  pool: lxd
+ state: ONLINE
+  scan: scrub repaired 0B in 00:01:23 with 0 errors on Sun Oct 13 00:25:24 2024
+config:
+
+	NAME            STATE     READ WRITE CKSUM
+	lxd             ONLINE       0     0     0
+	  raidz2-0      ONLINE       0     0     0
+	    /dev/sdb    ONLINE       0     0     0
+	    /dev/sdc    ONLINE       0     0     0
+	    /dev/sdd    ONLINE       0     0     0
+	    /dev/sde    ONLINE       0     0     0
+	    /dev/sdf    ONLINE       0     0     0
+	    /dev/sdg    ONLINE       0     0     0
+
+errors: No known data errors
+`)
 		return &outputStdout, &outputStderr, nil
 	}
 
+	// Mocked function
+	GetZpoolProperties = func(poolName string) (map[string]string, error) {
+		return map[string]string{}, nil
+	}
+
+	// Mocked function
+	GetZFSDatasets = func() ([]utils.DatasetStruct, error) {
+		return []utils.DatasetStruct{}, nil
+	}
+
+	// Mocked function
+	GetCurrentTime = func() time.Time {
+		return time.Date(2024, time.October, 20, 0, 0, 0, 0, time.Local)
+	}
+
 	// Mocked function
 	GetZFSPoolSize = func(poolName string) (string, error) {
 		poolSize := "60 TB"
@@ -455,3 +483,426 @@ func TestProcessZFSRaid(t *testing.T) {
 		}
 	}
 }
+
+// Test ProcessZFSRaid spares section
+func TestProcessZFSRaidSpares(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	getZFSPoolSizeOri := GetZFSPoolSize
+	getZpoolPropertiesOri := GetZpoolProperties
+	getZFSDatasetsOri := GetZFSDatasets
+	getDiskDataOri := utils.GetDiskData
+	getDiskPartitionSizeOri := utils.GetDiskPartitionSize
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		GetZFSPoolSize = getZFSPoolSizeOri
+		GetZpoolProperties = getZpoolPropertiesOri
+		GetZFSDatasets = getZFSDatasetsOri
+		utils.GetDiskData = getDiskDataOri
+		utils.GetDiskPartitionSize = getDiskPartitionSizeOri
+	}()
+
+	// Mocked function
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		//fmt.Println("-- Executing mocked getCommandOutput function")
+		var outputStdout, outputStderr bytes.Buffer
+
+		if command != "status -P -L -p" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unexpected command: %s", command)
+		}
+		outputStdout.WriteString(`
+  pool: tank
+ state: DEGRADED
+config:
+
+	NAME              STATE     READ WRITE CKSUM
+	tank              DEGRADED     0     0     0
+	  mirror-0        DEGRADED     0     0     0
+	    /dev/sdb      ONLINE       0     0     0
+	    spare-1       DEGRADED     0     0     0
+	      /dev/sdc    FAULTED      0     0     0
+	      /dev/sdd    ONLINE       0     0     0
+	spares
+	  /dev/sdd        INUSE     currently in use
+	  /dev/sde        AVAIL
+
+errors: No known data errors
+`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	// Mocked function
+	GetZpoolProperties = func(poolName string) (map[string]string, error) {
+		return map[string]string{}, nil
+	}
+
+	// Mocked function
+	GetZFSDatasets = func() ([]utils.DatasetStruct, error) {
+		return []utils.DatasetStruct{}, nil
+	}
+
+	// Mocked function
+	GetZFSPoolSize = func(poolName string) (string, error) {
+		return "10 TB", nil
+	}
+
+	// Mocked function
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "SERIALNUMBER-" + diskDrive, "MODEL-" + diskDrive, "SATA", "HDD", nil
+	}
+
+	// Mocked function
+	utils.GetDiskPartitionSize = func(diskDrive string) (string, error) {
+		return "10 TB", nil
+	}
+
+	newControllers, _, newRaids, err := ProcessZFSRaid("zfs")
+	if err != nil {
+		t.Fatalf(`TestProcessZFSRaidSpares returned error: %s`, err)
+	}
+
+	if len(newRaids) != 1 {
+		t.Fatalf(`TestProcessZFSRaidSpares: len(newRaids): %v must match 1`, len(newRaids))
+	}
+	// spare-N grouping vdev must not be listed as a drive
+	for _, disk := range newRaids[0].Disks {
+		if disk.OsDevice == "spare-1" {
+			t.Fatalf(`TestProcessZFSRaidSpares: spare-1 listed as vdev drive`)
+		}
+	}
+	if len(newRaids[0].Disks) != 3 {
+		t.Fatalf(`TestProcessZFSRaidSpares: len(newRaids[0].Disks): %v must match 3`, len(newRaids[0].Disks))
+	}
+
+	spares := newControllers[0].Spares
+	if len(spares) != 2 {
+		t.Fatalf(`TestProcessZFSRaidSpares: len(spares): %v must match 2`, len(spares))
+	}
+	sparesWanted := map[string]string{"sdd": "InUse", "sde": "Available"}
+	for _, spare := range spares {
+		if spare.State != sparesWanted[spare.OsDevice] {
+			t.Fatalf(`TestProcessZFSRaidSpares: spare %v State: %v must match %v`, spare.OsDevice, spare.State, sparesWanted[spare.OsDevice])
+		}
+		if len(spare.Arrays) != 1 || spare.Arrays[0] != "tank" {
+			t.Fatalf(`TestProcessZFSRaidSpares: spare.Arrays: %v must match [tank]`, spare.Arrays)
+		}
+	}
+}
+
+// Test GetZpoolProperties
+func TestGetZpoolProperties(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+	}()
+
+	// Mocked function
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "get -H -p all tank" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unexpected command: %s", command)
+		}
+		outputStdout.WriteString("tank\tsize\t47781511168\t-\n")
+		outputStdout.WriteString("tank\tcapacity\t45\t-\n")
+		outputStdout.WriteString("tank\thealth\tONLINE\t-\n")
+		outputStdout.WriteString("tank\tfragmentation\t12\t-\n")
+		outputStdout.WriteString("tank\tcomment\tbackup pool\tlocal\n")
+		outputStdout.WriteString("tank\treadonly\toff\t-\n")
+		outputStdout.WriteString("tank\tashift\t12\tlocal\n")
+		outputStdout.WriteString("tank\tautoreplace\toff\tdefault\n")
+		outputStdout.WriteString("tank\tfeature@async_destroy\tenabled\tlocal\n")
+		outputStdout.WriteString("tank\tfeature@empty_bpobj\tactive\tlocal\n")
+		outputStdout.WriteString("tank\tfeature@lz4_compress\tactive\tlocal\n")
+		outputStdout.WriteString("tank\tfeature@draid\tdisabled\tlocal\n")
+		return &outputStdout, &outputStderr, nil
+	}
+
+	properties, err := GetZpoolProperties("tank")
+	if err != nil {
+		t.Fatalf(`TestGetZpoolProperties returned error: %s`, err)
+	}
+	propertiesWanted := map[string]string{
+		"size":          "47781511168",
+		"capacity":      "45",
+		"health":        "ONLINE",
+		"fragmentation": "12",
+		"comment":       "backup pool",
+		"readonly":      "off",
+		"ashift":        "12",
+		"autoreplace":   "off",
+
+		"feature@async_destroy": "enabled",
+		"feature@empty_bpobj":   "active",
+		"feature@lz4_compress":  "active",
+		"feature@draid":         "disabled",
+	}
+	if !reflect.DeepEqual(properties, propertiesWanted) {
+		t.Fatalf(`TestGetZpoolProperties properties: %v should be: %v`, properties, propertiesWanted)
+	}
+
+	details := getPoolDetails(properties)
+	detailsWanted := []string{"used 45%", "frag 12%", "ashift 12", "autoreplace off", "features 2 active/1 enabled/1 disabled"}
+	if !reflect.DeepEqual(details, detailsWanted) {
+		t.Fatalf(`TestGetZpoolProperties details: %v should be: %v`, details, detailsWanted)
+	}
+}
+
+// Test ProcessZFSRaid allocation classes, dRAID and error counters
+func TestProcessZFSRaidVdevClasses(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	getZpoolPropertiesOri := GetZpoolProperties
+	getZFSDatasetsOri := GetZFSDatasets
+	getDiskDataOri := utils.GetDiskData
+	getDiskPartitionSizeOri := utils.GetDiskPartitionSize
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		GetZpoolProperties = getZpoolPropertiesOri
+		GetZFSDatasets = getZFSDatasetsOri
+		utils.GetDiskData = getDiskDataOri
+		utils.GetDiskPartitionSize = getDiskPartitionSizeOri
+	}()
+
+	// Mocked function
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if command != "status -P -L -p" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unexpected command: %s", command)
+		}
+		outputStdout.WriteString(`
+  pool: tank
+ state: DEGRADED
+status: One or more devices could not be used because the label is missing or
+	invalid.  Sufficient replicas exist for the pool to continue
+	functioning in a degraded state.
+config:
+
+	NAME                      STATE     READ WRITE CKSUM
+	tank                      DEGRADED     0     0     0
+	  draid2:4d:1s:8c-0       DEGRADED     0     0     0
+	    /dev/sda1             ONLINE       0     0     0
+	    /dev/sdb1             ONLINE       0     0     0
+	    /dev/sdc1             ONLINE       3     0    12
+	    /dev/sdd1             ONLINE       0     0     0
+	    /dev/sde1             ONLINE       0     0     0
+	    /dev/sdf1             ONLINE       0     0     0
+	    /dev/sdg1             ONLINE       0     0     0
+	    9876543210123456789   UNAVAIL      0     0     0  was /dev/sdh1
+	dedup
+	  mirror-1                ONLINE       0     0     0
+	    /dev/nvme2n1p1        ONLINE       0     0     0
+	    /dev/nvme3n1p1        ONLINE       0     0     0
+	special
+	  mirror-2                ONLINE       0     0     0
+	    /dev/nvme0n1p2        ONLINE       0     0     0
+	    /dev/nvme1n1p2        ONLINE       0     0     0
+	logs
+	  /dev/nvme0n1p1          ONLINE       0     0     0
+	cache
+	  /dev/nvme1n1p1          ONLINE       0     0     0
+	spares
+	  draid2-0-0              AVAIL
+	  /dev/sdi1               AVAIL
+
+errors: 2 data errors, use '-v' for a list
+`)
+		return &outputStdout, &outputStderr, nil
+	}
+
+	// Mocked function
+	GetZpoolProperties = func(poolName string) (map[string]string, error) {
+		return map[string]string{"size": "8000000000000", "capacity": "45", "fragmentation": "3"}, nil
+	}
+
+	// Mocked function
+	GetZFSDatasets = func() ([]utils.DatasetStruct, error) {
+		return []utils.DatasetStruct{
+			{Pool: "tank", Name: "tank", Type: "filesystem"},
+			{Pool: "tank", Name: "tank/vm-100-disk-0", Type: "volume", OsDevice: "zd0"},
+			{Pool: "backup", Name: "backup", Type: "filesystem"},
+		}, nil
+	}
+
+	// Mocked function
+	utils.GetDiskData = func(diskDrive string) (string, string, string, string, error) {
+		return "SERIALNUMBER-" + diskDrive, "MODEL-" + diskDrive, "SATA", "HDD", nil
+	}
+
+	// Mocked function
+	utils.GetDiskPartitionSize = func(diskDrive string) (string, error) {
+		return "1 TB", nil
+	}
+
+	newControllers, newPools, newRaids, err := ProcessZFSRaid("zfs")
+	if err != nil {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses returned error: %s`, err)
+	}
+
+	poolDetailsWanted := []string{"used 45%", "frag 3%", "never scrubbed", "2 files with data errors"}
+	if len(newPools) != 1 || newPools[0].Size != "8.0 TB" || !reflect.DeepEqual(newPools[0].Details, poolDetailsWanted) {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses pools: %v should be tank 8.0 TB %v`, newPools, poolDetailsWanted)
+	}
+	if len(newPools[0].Datasets) != 2 || newPools[0].Datasets[1].OsDevice != "zd0" {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses pool.Datasets: %v should be tank and tank/vm-100-disk-0`, newPools[0].Datasets)
+	}
+	poolStateWanted := "DEGRADED/Scrub-Overdue/Data-Errors/Checksum-Errors"
+	if newPools[0].State != poolStateWanted {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses pool.State: %v should be: %v`, newPools[0].State, poolStateWanted)
+	}
+	if !strings.HasPrefix(newPools[0].Status, "One or more devices could not be used") || !strings.HasSuffix(newPools[0].Status, "degraded state.") {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses pool.Status: %v should be zpool status message`, newPools[0].Status)
+	}
+	if newControllers[0].Status != "Bad" {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses controller.Status: %v should be: Bad`, newControllers[0].Status)
+	}
+
+	raidsWanted := map[string]int{
+		"draid2:4d:1s:8c": 8,
+		"dedup mirror":    2,
+		"special mirror":  2,
+		"logs":            1,
+		"cache":           1,
+	}
+	if len(newRaids) != len(raidsWanted) {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses len(newRaids): %v should be: %v`, len(newRaids), len(raidsWanted))
+	}
+	for _, raid := range newRaids {
+		disksWanted, ok := raidsWanted[raid.RaidType]
+		if !ok || len(raid.Disks) != disksWanted {
+			t.Fatalf(`TestProcessZFSRaidVdevClasses raid %v disks: %v should be: %v`, raid.RaidType, len(raid.Disks), disksWanted)
+		}
+		if raid.Dg != "tank" {
+			t.Fatalf(`TestProcessZFSRaidVdevClasses raid.Dg: %v should be: tank`, raid.Dg)
+		}
+	}
+
+	draid := newRaids[0]
+	if draid.State != "DEGRADED" {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses draid.State: %v should be: DEGRADED`, draid.State)
+	}
+	ioErrorsWanted := utils.IoErrorsStruct{Source: "zfs", Read: 3, Write: 0, Checksum: 12}
+	if draid.Disks[2].IoErrors != ioErrorsWanted || draid.Disks[2].State != "ONLINE/IO-Errors" {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses sdc1: %v %v should be: ONLINE/IO-Errors %v`, draid.Disks[2].State, draid.Disks[2].IoErrors, ioErrorsWanted)
+	}
+	if draid.Disks[7].OsDevice != "sdh1" || draid.Disks[7].State != "UNAVAIL" {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses missing drive: %v %v should be: sdh1 UNAVAIL`, draid.Disks[7].OsDevice, draid.Disks[7].State)
+	}
+	if newRaids[3].Disks[0].OsDevice != "nvme0n1p1" {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses logs drive: %v should be: nvme0n1p1`, newRaids[3].Disks[0].OsDevice)
+	}
+
+	spares := newControllers[0].Spares
+	if len(spares) != 2 {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses len(spares): %v should be: 2`, len(spares))
+	}
+	if spares[0].OsDevice != "draid2-0-0" || spares[0].Model != "dRAID distributed spare" || spares[0].State != "Available" {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses distributed spare: %v should be draid2-0-0 Available`, spares[0])
+	}
+	if spares[1].OsDevice != "sdi1" || spares[1].SerialNumber != "SERIALNUMBER-sdi1" {
+		t.Fatalf(`TestProcessZFSRaidVdevClasses spare: %v should be sdi1`, spares[1])
+	}
+}
+
+// Test getScanHealth
+func TestGetScanHealth(t *testing.T) {
+	now := time.Date(2024, time.December, 1, 0, 0, 0, 0, time.Local)
+	scans := []struct {
+		scan    string
+		details []string
+		flags   []string
+	}{
+		{"scrub repaired 0B in 00:01:23 with 0 errors on Sun Nov 24 00:25:24 2024", []string{"last scrub 2024-11-24: repaired 0B, 0 errors"}, []string{}},
+		{"scrub repaired 12K in 1 days 02:03:04 with 3 errors on Sun Nov 24 00:25:24 2024", []string{"last scrub 2024-11-24: repaired 12K, 3 errors"}, []string{"Scrub-Errors"}},
+		{"scrub repaired 0B in 00:01:23 with 0 errors on Sun Sep  1 00:25:24 2024", []string{"last scrub 2024-09-01: repaired 0B, 0 errors"}, []string{"Scrub-Overdue"}},
+		{"resilvered 1.2G in 00:10:00 with 0 errors on Sun Nov 24 00:25:24 2024", []string{"last resilver 2024-11-24: repaired 1.2G, 0 errors"}, []string{"Scrub-Overdue"}},
+		{"scrub in progress since Sun Nov 24 00:25:24 2024 1.2T scanned at 1G/s, 900G issued at 800M/s, 2T total 0B repaired, 45.60% done, 00:10:00 to go", []string{"scrub in progress 45.60%"}, []string{}},
+		{"scrub canceled on Sun Nov 24 00:25:24 2024", []string{"last scan: scrub canceled on Sun Nov 24 00:25:24 2024"}, []string{"Scrub-Overdue"}},
+		{"none requested", []string{"never scrubbed"}, []string{"Scrub-Overdue"}},
+	}
+	for _, scan := range scans {
+		details, flags := getScanHealth(scan.scan, now)
+		if !reflect.DeepEqual(details, scan.details) || !reflect.DeepEqual(flags, scan.flags) {
+			t.Fatalf(`TestGetScanHealth %v: %v %v should be: %v %v`, scan.scan, details, flags, scan.details, scan.flags)
+		}
+	}
+}
+
+// Test GetZFSDatasets
+func TestGetZFSDatasets(t *testing.T) {
+	// Copy original functions content
+	getCommandOutputOri := utils.GetCommandOutput
+	readSysfsFileOri := utils.ReadSysfsFile
+	readSysfsDirOri := utils.ReadSysfsDir
+	readSysfsLinkOri := utils.ReadSysfsLink
+	// unmock functions content
+	defer func() {
+		utils.GetCommandOutput = getCommandOutputOri
+		utils.ReadSysfsFile = readSysfsFileOri
+		utils.ReadSysfsDir = readSysfsDirOri
+		utils.ReadSysfsLink = readSysfsLinkOri
+	}()
+
+	// Mocked function: zfs < 0.8 without encryption support
+	utils.GetCommandOutput = func(manufacturer string, callingFunction string, command string) (*bytes.Buffer, *bytes.Buffer, error) {
+		var outputStdout, outputStderr bytes.Buffer
+		if manufacturer != "zfsdataset" {
+			return &outputStdout, &outputStderr, fmt.Errorf("Unexpected manufacturer: %s", manufacturer)
+		}
+		switch command {
+		case "list -H -p -t filesystem,volume -o name,type,used,avail,refer,quota,reservation,volsize,compressratio,mountpoint,encryption,keystatus":
+			outputStderr.WriteString("bad property list: invalid property 'encryption'\n")
+			return &outputStdout, &outputStderr, fmt.Errorf("exit status 2")
+		case "list -H -p -t filesystem,volume -o name,type,used,avail,refer,quota,reservation,volsize,compressratio,mountpoint":
+			outputStdout.WriteString("tank\tfilesystem\t2000000000\t6000000000\t98304\t0\t0\t-\t1.50\t/tank\n")
+			outputStdout.WriteString("tank/home\tfilesystem\t1000000000\t6000000000\t1000000000\t5000000000\t0\t-\t1.00\t/home\n")
+			outputStdout.WriteString("tank/vm-100-disk-0\tvolume\t34000000000\t6000000000\t4000000000\t-\t-\t32000000000\t1.20\t-\n")
+			return &outputStdout, &outputStderr, nil
+		}
+		return &outputStdout, &outputStderr, fmt.Errorf("Unexpected command: %s", command)
+	}
+
+	// Mocked functions: zd0 with a partition mounted and LVM on top of the whole zvol
+	utils.ReadSysfsFile = func(path string) (string, error) {
+		switch path {
+		case "/proc/mounts":
+			return "tank /tank zfs rw,xattr,noacl 0 0\n/dev/zd0p1 /mnt/vm ext4 rw,relatime 0 0", nil
+		case "/sys/class/block/dm-3/dm/name":
+			return "vg0-lv0", nil
+		}
+		return "", fmt.Errorf("No such file or directory")
+	}
+	utils.ReadSysfsDir = func(path string) ([]string, error) {
+		switch path {
+		case "/sys/class/block/zd0":
+			return []string{"alignment_offset", "holders", "queue", "zd0p1"}, nil
+		case "/sys/class/block/zd0/holders":
+			return []string{"dm-3"}, nil
+		case "/sys/class/block/zd0p1/holders":
+			return []string{}, nil
+		}
+		return []string{}, fmt.Errorf("No such file or directory")
+	}
+	utils.ReadSysfsLink = func(path string) (string, error) {
+		if path == "/dev/zvol/tank/vm-100-disk-0" {
+			return "../../zd0", nil
+		}
+		return "", fmt.Errorf("No such file or directory")
+	}
+
+	datasets, err := GetZFSDatasets()
+	if err != nil {
+		t.Fatalf(`TestGetZFSDatasets returned error: %s`, err)
+	}
+	datasetsWanted := []utils.DatasetStruct{
+		{Pool: "tank", Name: "tank", Type: "filesystem", Used: "2.0 GB", Available: "6.0 GB", Referenced: "98 kB", Quota: "none", Reservation: "none", Size: "-", CompressRatio: "1.50x", Encryption: "Unknown", Mountpoint: "/tank", OsDevice: "N/A"},
+		{Pool: "tank", Name: "tank/home", Type: "filesystem", Used: "1.0 GB", Available: "6.0 GB", Referenced: "1.0 GB", Quota: "5.0 GB", Reservation: "none", Size: "-", CompressRatio: "1.00x", Encryption: "Unknown", Mountpoint: "/home", OsDevice: "N/A"},
+		{Pool: "tank", Name: "tank/vm-100-disk-0", Type: "volume", Used: "34 GB", Available: "6.0 GB", Referenced: "4.0 GB", Quota: "-", Reservation: "-", Size: "32 GB", CompressRatio: "1.20x", Encryption: "Unknown", Mountpoint: "-", OsDevice: "zd0", Consumers: []string{"dm-3(vg0-lv0)", "zd0p1(mounted on /mnt/vm)"}},
+	}
+	if !reflect.DeepEqual(datasets, datasetsWanted) {
+		t.Fatalf(`TestGetZFSDatasets datasets: %v should be: %v`, datasets, datasetsWanted)
+	}
+}